@@ -1,12 +1,14 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 	"github.com/rancher/go-rancher/api"
 	"github.com/rancher/go-rancher/client"
+	"github.com/sirupsen/logrus"
 )
 
 func (s *Server) EventList(rw http.ResponseWriter, req *http.Request) error {
@@ -52,6 +54,23 @@ func (s *Server) NodeTagList(rw http.ResponseWriter, req *http.Request) error {
 	return nil
 }
 
+func (s *Server) ReplicaSchedulingDryRun(rw http.ResponseWriter, req *http.Request) error {
+	var input ReplicaSchedulingDryRunInput
+
+	apiContext := api.GetApiContext(req)
+	if err := apiContext.Read(&input); err != nil {
+		return err
+	}
+
+	placements, err := s.m.DryRunReplicaScheduling(input.Settings)
+	if err != nil {
+		return errors.Wrap(err, "failed to dry run the replica scheduling")
+	}
+
+	apiContext.Write(toReplicaPlacementCollection(placements))
+	return nil
+}
+
 func (s *Server) InstanceManagerGet(rw http.ResponseWriter, req *http.Request) error {
 	id := mux.Vars(req)["name"]
 	apiContext := api.GetApiContext(req)
@@ -76,3 +95,20 @@ func (s *Server) InstanceManagerList(rw http.ResponseWriter, req *http.Request)
 	apiContext.Write(toInstanceManagerCollection(instanceManagers))
 	return nil
 }
+
+// Healthz reports the manager is alive as long as the API server is serving.
+func (s *Server) Healthz(rw http.ResponseWriter, req *http.Request) {
+	rw.WriteHeader(http.StatusOK)
+}
+
+// Readyz reports the manager is ready once the datastore caches have synced
+// and the Kubernetes API server is reachable.
+func (s *Server) Readyz(rw http.ResponseWriter, req *http.Request) {
+	if err := s.m.CheckReadiness(); err != nil {
+		logrus.WithError(err).Warn("Longhorn manager is not ready")
+		rw.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(rw, err.Error())
+		return
+	}
+	rw.WriteHeader(http.StatusOK)
+}
@@ -3,17 +3,20 @@ package api
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/rancher/go-rancher/api"
 	"github.com/rancher/go-rancher/client"
 	"github.com/sirupsen/logrus"
 
 	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
 
 	"github.com/longhorn/longhorn-manager/controller"
 	"github.com/longhorn/longhorn-manager/datastore"
 	"github.com/longhorn/longhorn-manager/engineapi"
 	"github.com/longhorn/longhorn-manager/manager"
+	"github.com/longhorn/longhorn-manager/scheduler"
 	"github.com/longhorn/longhorn-manager/types"
 	"github.com/longhorn/longhorn-manager/util"
 
@@ -40,19 +43,25 @@ type Volume struct {
 	Created                   string                                 `json:"created"`
 	LastBackup                string                                 `json:"lastBackup"`
 	LastBackupAt              string                                 `json:"lastBackupAt"`
+	LastDegradedAt            string                                 `json:"lastDegradedAt"`
+	LastHealthyAt             string                                 `json:"lastHealthyAt"`
 	LastAttachedBy            string                                 `json:"lastAttachedBy"`
 	Standby                   bool                                   `json:"standby"`
 	RestoreRequired           bool                                   `json:"restoreRequired"`
 	RevisionCounterDisabled   bool                                   `json:"revisionCounterDisabled"`
 	SnapshotDataIntegrity     longhorn.SnapshotDataIntegrity         `json:"snapshotDataIntegrity"`
 	UnmapMarkSnapChainRemoved longhorn.UnmapMarkSnapChainRemoved     `json:"unmapMarkSnapChainRemoved"`
+	SnapshotMaxCount          int                                    `json:"snapshotMaxCount"`
+	BackupRetention           longhorn.VolumeBackupRetention         `json:"backupRetention"`
 
 	DiskSelector         []string                      `json:"diskSelector"`
 	NodeSelector         []string                      `json:"nodeSelector"`
 	RecurringJobSelector []longhorn.VolumeRecurringJob `json:"recurringJobSelector"`
 
-	NumberOfReplicas   int                         `json:"numberOfReplicas"`
-	ReplicaAutoBalance longhorn.ReplicaAutoBalance `json:"replicaAutoBalance"`
+	NumberOfReplicas       int                             `json:"numberOfReplicas"`
+	ReplicaAutoBalance     longhorn.ReplicaAutoBalance     `json:"replicaAutoBalance"`
+	ReplicaPlacementPolicy longhorn.ReplicaPlacementPolicy `json:"replicaPlacementPolicy"`
+	ReplicaPlacementNodes  []string                        `json:"replicaPlacementNodes"`
 
 	Conditions       map[string]longhorn.Condition `json:"conditions"`
 	KubernetesStatus longhorn.KubernetesStatus     `json:"kubernetesStatus"`
@@ -78,7 +87,8 @@ type Volume struct {
 type Snapshot struct {
 	client.Resource
 	longhorn.SnapshotInfo
-	Checksum string `json:"checksum"`
+	Checksum  string `json:"checksum"`
+	Deletable bool   `json:"deletable"`
 }
 
 type BackupTarget struct {
@@ -99,6 +109,7 @@ type BackupVolume struct {
 	Messages             map[string]string `json:"messages"`
 	BackingImageName     string            `json:"backingImageName"`
 	BackingImageChecksum string            `json:"backingImageChecksum"`
+	BackupCount          int               `json:"backupCount"`
 }
 
 type Backup struct {
@@ -258,6 +269,27 @@ type PVCCreateInput struct {
 	PVCName   string `json:"pvcName"`
 }
 
+type KubernetesManifestExportInput struct {
+	PVName string `json:"pvName"`
+	FSType string `json:"fsType"`
+
+	SecretName      string `json:"secretName"`
+	SecretNamespace string `json:"secretNamespace"`
+
+	Namespace string `json:"namespace"`
+	PVCName   string `json:"pvcName"`
+}
+
+type KubernetesManifest struct {
+	client.Resource
+
+	Volume    string `json:"volume"`
+	PVName    string `json:"pvName"`
+	PVCName   string `json:"pvcName"`
+	Namespace string `json:"namespace"`
+	Manifest  string `json:"manifest"`
+}
+
 type ActivateInput struct {
 	Frontend string `json:"frontend"`
 }
@@ -272,6 +304,7 @@ type Node struct {
 	Address                  string                        `json:"address"`
 	AllowScheduling          bool                          `json:"allowScheduling"`
 	EvictionRequested        bool                          `json:"evictionRequested"`
+	AutoEvicting             bool                          `json:"autoEvicting"`
 	Disks                    map[string]DiskInfo           `json:"disks"`
 	Conditions               map[string]longhorn.Condition `json:"conditions"`
 	Tags                     []string                      `json:"tags"`
@@ -288,6 +321,7 @@ type DiskStatus struct {
 	StorageMaximum   int64                         `json:"storageMaximum"`
 	ScheduledReplica map[string]int64              `json:"scheduledReplica"`
 	DiskUUID         string                        `json:"diskUUID"`
+	Benchmark        *longhorn.DiskBenchmark       `json:"benchmark"`
 }
 
 type DiskInfo struct {
@@ -355,6 +389,32 @@ type Tag struct {
 	TagType string `json:"tagType"`
 }
 
+type ReplicaPlacement struct {
+	client.Resource
+	Volume       string `json:"volume"`
+	Replica      string `json:"replica"`
+	CurrentNode  string `json:"currentNode"`
+	CurrentDisk  string `json:"currentDisk"`
+	ProposedNode string `json:"proposedNode"`
+	ProposedDisk string `json:"proposedDisk"`
+	Reason       string `json:"reason"`
+}
+
+type SettingSnapshot struct {
+	client.Resource
+	Settings map[string]string `json:"settings"`
+}
+
+type SettingSnapshotImportOutput struct {
+	client.Resource
+	Applied []string          `json:"applied"`
+	Skipped map[string]string `json:"skipped"`
+}
+
+type ReplicaSchedulingDryRunInput struct {
+	Settings map[string]string `json:"settings"`
+}
+
 type BackupStatus struct {
 	client.Resource
 	Name      string `json:"id"`
@@ -471,12 +531,15 @@ func NewSchema() *client.Schemas {
 	schemas.AddType("UpdateUnmapMarkSnapChainRemovedInput", UpdateUnmapMarkSnapChainRemovedInput{})
 	schemas.AddType("workloadStatus", longhorn.WorkloadStatus{})
 	schemas.AddType("cloneStatus", longhorn.VolumeCloneStatus{})
+	schemas.AddType("backupRetention", longhorn.VolumeBackupRetention{})
 
 	schemas.AddType("volumeRecurringJob", VolumeRecurringJob{})
 	schemas.AddType("volumeRecurringJobInput", VolumeRecurringJobInput{})
 
 	schemas.AddType("PVCreateInput", PVCreateInput{})
 	schemas.AddType("PVCCreateInput", PVCCreateInput{})
+	schemas.AddType("KubernetesManifestExportInput", KubernetesManifestExportInput{})
+	schemas.AddType("kubernetesManifest", KubernetesManifest{})
 
 	schemas.AddType("settingDefinition", types.SettingDefinition{})
 	// to avoid duplicate name with built-in type condition
@@ -490,6 +553,11 @@ func NewSchema() *client.Schemas {
 
 	schemas.AddType("tag", Tag{})
 
+	schemas.AddType("replicaPlacement", ReplicaPlacement{})
+	schemas.AddType("settingSnapshot", SettingSnapshot{})
+	schemas.AddType("settingSnapshotImportOutput", SettingSnapshotImportOutput{})
+	schemas.AddType("replicaSchedulingDryRunInput", ReplicaSchedulingDryRunInput{})
+
 	schemas.AddType("instanceManager", InstanceManager{})
 	schemas.AddType("instanceProcess", longhorn.InstanceProcess{})
 
@@ -764,6 +832,10 @@ func volumeSchema(volume *client.Schema) {
 			Input:  "snapshotInput",
 			Output: "volume",
 		},
+		"backupCreate": {
+			Input:  "snapshotInput",
+			Output: "backup",
+		},
 
 		"recurringJobAdd": {
 			Input:  "volumeRecurringJobInput",
@@ -813,6 +885,11 @@ func volumeSchema(volume *client.Schema) {
 			Output: "volume",
 		},
 
+		"kubernetesManifestExport": {
+			Input:  "KubernetesManifestExportInput",
+			Output: "kubernetesManifest",
+		},
+
 		"jobList": {},
 
 		"replicaRemove": {
@@ -854,8 +931,6 @@ func volumeSchema(volume *client.Schema) {
 
 	volumeNumberOfReplicas := volume.ResourceFields["numberOfReplicas"]
 	volumeNumberOfReplicas.Create = true
-	volumeNumberOfReplicas.Required = true
-	volumeNumberOfReplicas.Default = 2
 	volume.ResourceFields["numberOfReplicas"] = volumeNumberOfReplicas
 
 	volumeDataLocality := volume.ResourceFields["dataLocality"]
@@ -868,6 +943,22 @@ func volumeSchema(volume *client.Schema) {
 	volumeSnapshotDataIntegrity.Default = longhorn.SnapshotDataIntegrityIgnored
 	volume.ResourceFields["snapshotDataIntegrity"] = volumeSnapshotDataIntegrity
 
+	volumeSnapshotMaxCount := volume.ResourceFields["snapshotMaxCount"]
+	volumeSnapshotMaxCount.Create = true
+	volume.ResourceFields["snapshotMaxCount"] = volumeSnapshotMaxCount
+
+	volumeReplicaPlacementPolicy := volume.ResourceFields["replicaPlacementPolicy"]
+	volumeReplicaPlacementPolicy.Create = true
+	volume.ResourceFields["replicaPlacementPolicy"] = volumeReplicaPlacementPolicy
+
+	volumeReplicaPlacementNodes := volume.ResourceFields["replicaPlacementNodes"]
+	volumeReplicaPlacementNodes.Create = true
+	volume.ResourceFields["replicaPlacementNodes"] = volumeReplicaPlacementNodes
+
+	volumeBackupRetention := volume.ResourceFields["backupRetention"]
+	volumeBackupRetention.Create = true
+	volume.ResourceFields["backupRetention"] = volumeBackupRetention
+
 	volumeAccessMode := volume.ResourceFields["accessMode"]
 	volumeAccessMode.Create = true
 	volumeAccessMode.Default = longhorn.AccessModeReadWriteOnce
@@ -1013,6 +1104,25 @@ func toSettingResource(setting *longhorn.Setting) *Setting {
 	}
 }
 
+func toSettingSnapshotResource(values map[string]string) *SettingSnapshot {
+	return &SettingSnapshot{
+		Resource: client.Resource{
+			Type: "settingSnapshot",
+		},
+		Settings: values,
+	}
+}
+
+func toSettingSnapshotImportOutputResource(applied []string, skipped map[string]string) *SettingSnapshotImportOutput {
+	return &SettingSnapshotImportOutput{
+		Resource: client.Resource{
+			Type: "settingSnapshotImportOutput",
+		},
+		Applied: applied,
+		Skipped: skipped,
+	}
+}
+
 func toSettingCollection(settings []*longhorn.Setting) *client.GenericCollection {
 	data := []interface{}{}
 	for _, setting := range settings {
@@ -1182,8 +1292,12 @@ func toVolumeResource(v *longhorn.Volume, ves []*longhorn.Engine, vrs []*longhor
 		DataSource:                v.Spec.DataSource,
 		NumberOfReplicas:          v.Spec.NumberOfReplicas,
 		ReplicaAutoBalance:        v.Spec.ReplicaAutoBalance,
+		ReplicaPlacementPolicy:    v.Spec.ReplicaPlacementPolicy,
+		ReplicaPlacementNodes:     v.Spec.ReplicaPlacementNodes,
 		DataLocality:              v.Spec.DataLocality,
 		SnapshotDataIntegrity:     v.Spec.SnapshotDataIntegrity,
+		SnapshotMaxCount:          v.Spec.SnapshotMaxCount,
+		BackupRetention:           v.Spec.BackupRetention,
 		StaleReplicaTimeout:       v.Spec.StaleReplicaTimeout,
 		Created:                   v.CreationTimestamp.String(),
 		EngineImage:               v.Spec.EngineImage,
@@ -1198,6 +1312,8 @@ func toVolumeResource(v *longhorn.Volume, ves []*longhorn.Engine, vrs []*longhor
 		CurrentImage:              v.Status.CurrentImage,
 		LastBackup:                v.Status.LastBackup,
 		LastBackupAt:              v.Status.LastBackupAt,
+		LastDegradedAt:            v.Status.LastDegradedAt,
+		LastHealthyAt:             v.Status.LastHealthyAt,
 		RestoreRequired:           v.Status.RestoreRequired,
 		RevisionCounterDisabled:   v.Spec.RevisionCounterDisabled,
 		UnmapMarkSnapChainRemoved: v.Spec.UnmapMarkSnapChainRemoved,
@@ -1226,8 +1342,9 @@ func toVolumeResource(v *longhorn.Volume, ves []*longhorn.Engine, vrs []*longhor
 	// api attach & detach calls are always allowed
 	// the volume manager is responsible for handling them appropriately
 	actions := map[string]struct{}{
-		"attach": {},
-		"detach": {},
+		"attach":                   {},
+		"detach":                   {},
+		"kubernetesManifestExport": {},
 	}
 
 	if v.Status.Robustness == longhorn.VolumeRobustnessFaulted {
@@ -1265,6 +1382,7 @@ func toVolumeResource(v *longhorn.Volume, ves []*longhorn.Engine, vrs []*longhor
 			actions["snapshotDelete"] = struct{}{}
 			actions["snapshotRevert"] = struct{}{}
 			actions["snapshotBackup"] = struct{}{}
+			actions["backupCreate"] = struct{}{}
 			actions["replicaRemove"] = struct{}{}
 			actions["engineUpgrade"] = struct{}{}
 			actions["updateReplicaCount"] = struct{}{}
@@ -1289,7 +1407,7 @@ func toVolumeResource(v *longhorn.Volume, ves []*longhorn.Engine, vrs []*longhor
 	return r
 }
 
-func toSnapshotResource(s *longhorn.SnapshotInfo, checksum string) *Snapshot {
+func toSnapshotResource(s *longhorn.SnapshotInfo, checksum string, backupSources map[string]string) *Snapshot {
 	if s == nil {
 		logrus.Warn("weird: nil snapshot")
 		return nil
@@ -1301,10 +1419,11 @@ func toSnapshotResource(s *longhorn.SnapshotInfo, checksum string) *Snapshot {
 		},
 		SnapshotInfo: *s,
 		Checksum:     checksum,
+		Deletable:    manager.CheckSnapshotDeletable(s, backupSources) == nil,
 	}
 }
 
-func toSnapshotCollection(ssList map[string]*longhorn.SnapshotInfo, ssListRO map[string]*longhorn.Snapshot) *client.GenericCollection {
+func toSnapshotCollection(ssList map[string]*longhorn.SnapshotInfo, ssListRO map[string]*longhorn.Snapshot, backupSources map[string]string) *client.GenericCollection {
 	data := []interface{}{}
 
 	for name, v := range ssList {
@@ -1314,7 +1433,7 @@ func toSnapshotCollection(ssList map[string]*longhorn.SnapshotInfo, ssListRO map
 				checksum = ssRO.Status.Checksum
 			}
 		}
-		data = append(data, toSnapshotResource(v, checksum))
+		data = append(data, toSnapshotResource(v, checksum, backupSources))
 	}
 	return &client.GenericCollection{Data: data, Collection: client.Collection{ResourceType: "snapshot"}}
 }
@@ -1385,6 +1504,7 @@ func toBackupVolumeResource(bv *longhorn.BackupVolume, apiContext *api.ApiContex
 		Messages:             bv.Status.Messages,
 		BackingImageName:     bv.Status.BackingImageName,
 		BackingImageChecksum: bv.Status.BackingImageChecksum,
+		BackupCount:          bv.Status.BackupCount,
 	}
 	b.Actions = map[string]string{
 		"backupList":   apiContext.UrlBuilder.ActionLink(b.Resource, "backupList"),
@@ -1569,6 +1689,7 @@ func toNodeResource(node *longhorn.Node, address string, apiContext *api.ApiCont
 		Address:                  address,
 		AllowScheduling:          node.Spec.AllowScheduling,
 		EvictionRequested:        node.Spec.EvictionRequested,
+		AutoEvicting:             node.Status.AutoEvicting,
 		Conditions:               sliceToMap(node.Status.Conditions),
 		Tags:                     node.Spec.Tags,
 		Region:                   node.Status.Region,
@@ -1590,6 +1711,7 @@ func toNodeResource(node *longhorn.Node, address string, apiContext *api.ApiCont
 				StorageMaximum:   node.Status.DiskStatus[name].StorageMaximum,
 				ScheduledReplica: node.Status.DiskStatus[name].ScheduledReplica,
 				DiskUUID:         node.Status.DiskStatus[name].DiskUUID,
+				Benchmark:        node.Status.DiskStatus[name].Benchmark,
 			}
 		}
 		disks[name] = di
@@ -1743,6 +1865,49 @@ func toTagCollection(tags []string, tagType string, apiContext *api.ApiContext)
 	return &client.GenericCollection{Data: data, Collection: client.Collection{ResourceType: "tag"}}
 }
 
+func toKubernetesManifestResource(volumeName string, pv *v1.PersistentVolume, pvc *v1.PersistentVolumeClaim) (*KubernetesManifest, error) {
+	manifest := []string{}
+	for _, obj := range []interface{}{pv, pvc} {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		manifest = append(manifest, string(data))
+	}
+
+	return &KubernetesManifest{
+		Resource: client.Resource{
+			Id:   volumeName,
+			Type: "kubernetesManifest",
+		},
+		Volume:    volumeName,
+		PVName:    pv.Name,
+		PVCName:   pvc.Name,
+		Namespace: pvc.Namespace,
+		Manifest:  strings.Join(manifest, "---\n"),
+	}, nil
+}
+
+func toReplicaPlacementCollection(placements []*scheduler.ReplicaPlacement) *client.GenericCollection {
+	data := []interface{}{}
+	for _, p := range placements {
+		data = append(data, &ReplicaPlacement{
+			Resource: client.Resource{
+				Id:   p.ReplicaName,
+				Type: "replicaPlacement",
+			},
+			Volume:       p.VolumeName,
+			Replica:      p.ReplicaName,
+			CurrentNode:  p.CurrentNodeID,
+			CurrentDisk:  p.CurrentDiskID,
+			ProposedNode: p.ProposedNodeID,
+			ProposedDisk: p.ProposedDiskID,
+			Reason:       p.Reason,
+		})
+	}
+	return &client.GenericCollection{Data: data, Collection: client.Collection{ResourceType: "replicaPlacement"}}
+}
+
 func toInstanceManagerResource(im *longhorn.InstanceManager) *InstanceManager {
 	return &InstanceManager{
 		Resource: client.Resource{
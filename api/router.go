@@ -23,6 +23,24 @@ func HandleError(s *client.Schemas, t HandleFuncWithError) http.Handler {
 	}))
 }
 
+// writeErrWithStatus writes the error with the given HTTP status code rather
+// than the 500 written by HandleError.
+func writeErrWithStatus(rw http.ResponseWriter, req *http.Request, status int, err error) {
+	logrus.Warnf("HTTP handling error %v", err)
+	rw.WriteHeader(status)
+	apiContext := api.GetApiContext(req)
+	if writeErr := apiContext.WriteResource(&client.ServerApiError{
+		Resource: client.Resource{
+			Type: "error",
+		},
+		Status:  status,
+		Code:    http.StatusText(status),
+		Message: err.Error(),
+	}); writeErr != nil {
+		logrus.WithError(writeErr).Warn("Failed to write error response")
+	}
+}
+
 func NewRouter(s *Server) *mux.Router {
 	schemas := NewSchema()
 	r := mux.NewRouter().StrictSlash(true)
@@ -32,6 +50,8 @@ func NewRouter(s *Server) *mux.Router {
 	versionHandler := api.VersionHandler(schemas, "v1")
 	r.Methods("GET").Path("/").Handler(versionsHandler)
 	r.Methods("GET").Path("/metrics").Handler(registry.Handler())
+	r.Methods("GET").Path("/healthz").HandlerFunc(s.Healthz)
+	r.Methods("GET").Path("/readyz").HandlerFunc(s.Readyz)
 	r.Methods("GET").Path("/v1").Handler(versionHandler)
 	r.Methods("GET").Path("/v1/apiversions").Handler(versionsHandler)
 	r.Methods("GET").Path("/v1/apiversions/v1").Handler(versionHandler)
@@ -41,10 +61,14 @@ func NewRouter(s *Server) *mux.Router {
 	r.Methods("GET").Path("/v1/settings").Handler(f(schemas, s.SettingList))
 	r.Methods("GET").Path("/v1/settings/{name}").Handler(f(schemas, s.SettingGet))
 	r.Methods("PUT").Path("/v1/settings/{name}").Handler(f(schemas, s.SettingSet))
+	r.Methods("GET").Path("/v1/settingsnapshot").Handler(f(schemas, s.SettingSnapshotExport))
+	r.Methods("POST").Path("/v1/settingsnapshot").Handler(f(schemas, s.SettingSnapshotImport))
 
 	r.Methods("GET").Path("/v1/volumes").Handler(f(schemas, s.VolumeList))
 	r.Methods("GET").Path("/v1/volumes/{name}").Handler(f(schemas, s.VolumeGet))
 	r.Methods("DELETE").Path("/v1/volumes/{name}").Handler(f(schemas, s.VolumeDelete))
+	r.Methods("GET").Path("/v1/volumes/{name}/snapshots").Handler(f(schemas, s.fwd.Handler(s.fwd.HandleProxyRequestByNodeID, s.fwd.GetHTTPAddressByNodeID(OwnerIDFromVolume(s.m)), s.SnapshotList)))
+	r.Methods("DELETE").Path("/v1/volumes/{name}/snapshots/{snapshotName}").Handler(f(schemas, s.fwd.Handler(s.fwd.HandleProxyRequestByNodeID, s.fwd.GetHTTPAddressByNodeID(OwnerIDFromVolume(s.m)), s.SnapshotDeleteByName)))
 	r.Methods("POST").Path("/v1/volumes").Handler(f(schemas, s.fwd.Handler(s.fwd.HandleProxyRequestByNodeID, s.fwd.GetHTTPAddressByNodeID(NodeHasDefaultEngineImage(s.m)), s.VolumeCreate)))
 	volumeActions := map[string]func(http.ResponseWriter, *http.Request) error{
 		"attach":                          s.VolumeAttach,
@@ -73,10 +97,13 @@ func NewRouter(s *Server) *mux.Router {
 		"snapshotDelete": s.fwd.Handler(s.fwd.HandleProxyRequestByNodeID, s.fwd.GetHTTPAddressByNodeID(OwnerIDFromVolume(s.m)), s.SnapshotDelete),
 		"snapshotRevert": s.fwd.Handler(s.fwd.HandleProxyRequestByNodeID, s.fwd.GetHTTPAddressByNodeID(OwnerIDFromVolume(s.m)), s.SnapshotRevert),
 		"snapshotBackup": s.fwd.Handler(s.fwd.HandleProxyRequestByNodeID, s.fwd.GetHTTPAddressByNodeID(OwnerIDFromVolume(s.m)), s.SnapshotBackup),
+		"backupCreate":   s.fwd.Handler(s.fwd.HandleProxyRequestByNodeID, s.fwd.GetHTTPAddressByNodeID(OwnerIDFromVolume(s.m)), s.BackupCreate),
 
 		"pvCreate":  s.PVCreate,
 		"pvcCreate": s.PVCCreate,
 
+		"kubernetesManifestExport": s.KubernetesManifestExport,
+
 		"recurringJobAdd":    s.VolumeRecurringAdd,
 		"recurringJobList":   s.VolumeRecurringList,
 		"recurringJobDelete": s.VolumeRecurringDelete,
@@ -119,6 +146,8 @@ func NewRouter(s *Server) *mux.Router {
 	r.Methods("GET").Path("/v1/disktags").Handler(f(schemas, s.DiskTagList))
 	r.Methods("GET").Path("/v1/nodetags").Handler(f(schemas, s.NodeTagList))
 
+	r.Methods("POST").Path("/v1/replicaplacements").Handler(f(schemas, s.ReplicaSchedulingDryRun))
+
 	r.Methods("GET").Path("/v1/instancemanagers").Handler(f(schemas, s.InstanceManagerList))
 	r.Methods("GET").Path("/v1/instancemanagers/{name}").Handler(f(schemas, s.InstanceManagerGet))
 
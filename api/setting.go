@@ -9,6 +9,7 @@ import (
 	"github.com/rancher/go-rancher/api"
 	"github.com/rancher/go-rancher/client"
 
+	"github.com/longhorn/longhorn-manager/manager"
 	"github.com/longhorn/longhorn-manager/types"
 )
 
@@ -67,3 +68,34 @@ func (s *Server) SettingSet(w http.ResponseWriter, req *http.Request) error {
 	apiContext.Write(toSettingResource(si))
 	return nil
 }
+
+func (s *Server) SettingSnapshotExport(w http.ResponseWriter, req *http.Request) error {
+	apiContext := api.GetApiContext(req)
+
+	values, err := s.m.ExportSettings()
+	if err != nil {
+		return errors.Wrap(err, "failed to export settings")
+	}
+	apiContext.Write(toSettingSnapshotResource(values))
+	return nil
+}
+
+func (s *Server) SettingSnapshotImport(w http.ResponseWriter, req *http.Request) error {
+	var input SettingSnapshot
+
+	apiContext := api.GetApiContext(req)
+	if err := apiContext.Read(&input); err != nil {
+		return err
+	}
+
+	applied, skipped, err := s.m.ImportSettings(input.Settings)
+	if err != nil {
+		if _, ok := err.(*manager.SettingsValidationError); ok {
+			writeErrWithStatus(w, req, http.StatusBadRequest, err)
+			return nil
+		}
+		return errors.Wrap(err, "failed to import settings")
+	}
+	apiContext.Write(toSettingSnapshotImportOutputResource(applied, skipped))
+	return nil
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/longhorn/longhorn-manager/types"
+
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
+)
+
+func TestSettingSnapshotImport(t *testing.T) {
+	assert := require.New(t)
+
+	type testCase struct {
+		body string
+
+		expectedStatus  int
+		expectedApplied []string
+		expectedValue   string
+	}
+	testCases := map[string]testCase{
+		"settings imported": {
+			body:            `{"settings":{"default-replica-count":"2"}}`,
+			expectedStatus:  http.StatusOK,
+			expectedApplied: []string{string(types.SettingNameDefaultReplicaCount)},
+			expectedValue:   "2",
+		},
+		"invalid value": {
+			body:           `{"settings":{"default-replica-count":"0"}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedValue:  "3",
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		ts := newTestServer(t)
+		setting, err := ts.lhClient.LonghornV1beta2().Settings(TestNamespace).Create(context.TODO(), &longhorn.Setting{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      string(types.SettingNameDefaultReplicaCount),
+				Namespace: TestNamespace,
+			},
+			Value: "3",
+		}, metav1.CreateOptions{})
+		assert.NoError(err)
+		sIndexer := ts.lhInformerFactory.Longhorn().V1beta2().Settings().Informer().GetIndexer()
+		assert.NoError(sIndexer.Add(setting))
+
+		rw := ts.serve(http.MethodPost, "/v1/settingsnapshot", strings.NewReader(tc.body), ts.s.SettingSnapshotImport)
+		assert.Equal(tc.expectedStatus, rw.Code, "test case: %v, body: %v", name, rw.Body.String())
+
+		setting, err = ts.lhClient.LonghornV1beta2().Settings(TestNamespace).Get(context.TODO(), string(types.SettingNameDefaultReplicaCount), metav1.GetOptions{})
+		assert.NoError(err, "test case: %v", name)
+		assert.Equal(tc.expectedValue, setting.Value, "test case: %v", name)
+		if tc.expectedStatus != http.StatusOK {
+			continue
+		}
+
+		resp := &SettingSnapshotImportOutput{}
+		assert.NoError(json.Unmarshal(rw.Body.Bytes(), resp), "test case: %v", name)
+		assert.Equal(tc.expectedApplied, resp.Applied, "test case: %v", name)
+	}
+}
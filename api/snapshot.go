@@ -13,6 +13,7 @@ import (
 	bsutil "github.com/longhorn/backupstore/util"
 
 	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
+	"github.com/longhorn/longhorn-manager/manager"
 	"github.com/longhorn/longhorn-manager/types"
 	"github.com/longhorn/longhorn-manager/util"
 )
@@ -39,11 +40,16 @@ func (s *Server) SnapshotCreate(w http.ResponseWriter, req *http.Request) (err e
 		return fmt.Errorf("cannot create snapshot for standby volume %v", vol.Name)
 	}
 
+	if vol.Status.State != longhorn.VolumeStateAttached {
+		writeErrWithStatus(w, req, http.StatusConflict, fmt.Errorf("cannot create snapshot for volume %v in state %v, the volume should be attached", vol.Name, vol.Status.State))
+		return nil
+	}
+
 	snapshot, err := s.m.CreateSnapshot(input.Name, input.Labels, volName)
 	if err != nil {
 		return err
 	}
-	apiContext.Write(toSnapshotResource(snapshot, ""))
+	apiContext.Write(toSnapshotResource(snapshot, "", nil))
 	return nil
 }
 
@@ -60,7 +66,13 @@ func (s *Server) SnapshotList(w http.ResponseWriter, req *http.Request) (err err
 	}
 
 	snapListRO, _ := s.m.ListSnapshots(volName)
-	api.GetApiContext(req).Write(toSnapshotCollection(snapList, snapListRO))
+
+	backupSources, err := s.m.GetSnapshotBackupSources(volName)
+	if err != nil {
+		return err
+	}
+
+	api.GetApiContext(req).Write(toSnapshotCollection(snapList, snapListRO, backupSources))
 
 	return nil
 }
@@ -88,7 +100,12 @@ func (s *Server) SnapshotGet(w http.ResponseWriter, req *http.Request) (err erro
 		checksum = snapRO.Status.Checksum
 	}
 
-	api.GetApiContext(req).Write(toSnapshotResource(snap, checksum))
+	backupSources, err := s.m.GetSnapshotBackupSources(volName)
+	if err != nil {
+		return err
+	}
+
+	api.GetApiContext(req).Write(toSnapshotResource(snap, checksum, backupSources))
 	return nil
 }
 
@@ -121,6 +138,44 @@ func (s *Server) SnapshotDelete(w http.ResponseWriter, req *http.Request) (err e
 	return s.responseWithVolume(w, req, volName, nil)
 }
 
+// SnapshotDeleteByName deletes a snapshot created by users. Unlike the
+// snapshotDelete action, it rejects the system snapshots and the snapshots used
+// by the backups in progress.
+func (s *Server) SnapshotDeleteByName(w http.ResponseWriter, req *http.Request) (err error) {
+	defer func() {
+		err = errors.Wrap(err, "failed to delete snapshot")
+	}()
+
+	volName := mux.Vars(req)["name"]
+	snapName := mux.Vars(req)["snapshotName"]
+
+	vol, err := s.m.Get(volName)
+	if err != nil {
+		return errors.Wrap(err, "unable to get volume")
+	}
+
+	if vol.Status.IsStandby {
+		writeErrWithStatus(w, req, http.StatusConflict, fmt.Errorf("cannot delete snapshot for standby volume %v", vol.Name))
+		return nil
+	}
+
+	snap, err := s.m.GetSnapshotInfo(snapName, volName)
+	if err != nil {
+		return err
+	}
+
+	backupSources, err := s.m.GetSnapshotBackupSources(volName)
+	if err != nil {
+		return err
+	}
+	if err := manager.CheckSnapshotDeletable(snap, backupSources); err != nil {
+		writeErrWithStatus(w, req, http.StatusConflict, err)
+		return nil
+	}
+
+	return s.m.DeleteSnapshot(snapName, volName)
+}
+
 func (s *Server) SnapshotRevert(w http.ResponseWriter, req *http.Request) (err error) {
 	defer func() {
 		err = errors.Wrap(err, "failed to revert snapshot")
@@ -159,43 +214,64 @@ func (s *Server) SnapshotBackup(w http.ResponseWriter, req *http.Request) (err e
 		err = errors.Wrap(err, "failed to backup snapshot")
 	}()
 
+	volName := mux.Vars(req)["name"]
+	if _, err := s.backupSnapshot(req, volName); err != nil {
+		return err
+	}
+
+	return s.responseWithVolume(w, req, volName, nil)
+}
+
+// BackupCreate backs up the snapshot like SnapshotBackup, but responds with
+// the created backup so that the caller can poll its progress by name with
+// the backupGet action of the backup volume.
+func (s *Server) BackupCreate(w http.ResponseWriter, req *http.Request) (err error) {
+	defer func() {
+		err = errors.Wrap(err, "failed to create backup")
+	}()
+
+	volName := mux.Vars(req)["name"]
+	backup, err := s.backupSnapshot(req, volName)
+	if err != nil {
+		return err
+	}
+
+	api.GetApiContext(req).Write(toBackupResource(backup))
+	return nil
+}
+
+func (s *Server) backupSnapshot(req *http.Request, volName string) (*longhorn.Backup, error) {
 	var input SnapshotInput
 
 	apiContext := api.GetApiContext(req)
 	if err := apiContext.Read(&input); err != nil {
-		return err
+		return nil, err
 	}
 
-	volName := mux.Vars(req)["name"]
-
 	vol, err := s.m.Get(volName)
 	if err != nil {
-		return errors.Wrap(err, "unable to get volume")
+		return nil, errors.Wrap(err, "unable to get volume")
 	}
 
 	if vol.Status.IsStandby {
-		return fmt.Errorf("cannot create backup for standby volume %v", vol.Name)
+		return nil, fmt.Errorf("cannot create backup for standby volume %v", vol.Name)
 	}
 
 	labels, err := util.ValidateSnapshotLabels(input.Labels)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Cannot directly compare the structs since KubernetesStatus contains a slice which cannot be compared.
 	if !reflect.DeepEqual(vol.Status.KubernetesStatus, longhorn.KubernetesStatus{}) {
 		kubeStatus, err := json.Marshal(vol.Status.KubernetesStatus)
 		if err != nil {
-			return errors.Wrapf(err, "BUG: could not convert volume %v's KubernetesStatus to json", volName)
+			return nil, errors.Wrapf(err, "BUG: could not convert volume %v's KubernetesStatus to json", volName)
 		}
 		labels[types.KubernetesStatusLabel] = string(kubeStatus)
 	}
 
-	if err := s.m.BackupSnapshot(bsutil.GenerateName("backup"), volName, input.Name, labels); err != nil {
-		return err
-	}
-
-	return s.responseWithVolume(w, req, volName, nil)
+	return s.m.BackupSnapshot(bsutil.GenerateName("backup"), volName, input.Name, labels)
 }
 
 func (s *Server) SnapshotPurge(w http.ResponseWriter, req *http.Request) (err error) {
@@ -4,12 +4,15 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"strconv"
 
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 	"github.com/rancher/go-rancher/api"
 	"github.com/rancher/go-rancher/client"
 
+	"k8s.io/apimachinery/pkg/labels"
+
 	"github.com/longhorn/longhorn-manager/datastore"
 	"github.com/longhorn/longhorn-manager/util"
 
@@ -23,7 +26,24 @@ func (s *Server) VolumeList(rw http.ResponseWriter, req *http.Request) (err erro
 
 	apiContext := api.GetApiContext(req)
 
-	resp, err := s.volumeList(apiContext)
+	query := req.URL.Query()
+	limit := 0
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 0 {
+			writeErrWithStatus(rw, req, http.StatusBadRequest, fmt.Errorf("invalid limit %v", limitStr))
+			return nil
+		}
+	}
+
+	labelSelector := query.Get("labelSelector")
+	selector, err := labels.Parse(labelSelector)
+	if err != nil {
+		writeErrWithStatus(rw, req, http.StatusBadRequest, errors.Wrapf(err, "invalid label selector %v", labelSelector))
+		return nil
+	}
+
+	resp, err := s.filteredVolumeList(apiContext, selector, limit, query.Get("continue"))
 	if err != nil {
 		return err
 	}
@@ -34,13 +54,46 @@ func (s *Server) VolumeList(rw http.ResponseWriter, req *http.Request) (err erro
 }
 
 func (s *Server) volumeList(apiContext *api.ApiContext) (*client.GenericCollection, error) {
+	return s.filteredVolumeList(apiContext, labels.Everything(), 0, "")
+}
+
+// filteredVolumeList lists the volumes matching the label selector, sorted by name.
+// If limit is positive, at most limit volumes starting from the volume named
+// continueToken are returned, and the name of the first volume of the next
+// page is set as the next token of the pagination.
+func (s *Server) filteredVolumeList(apiContext *api.ApiContext, selector labels.Selector, limit int, continueToken string) (*client.GenericCollection, error) {
 	resp := &client.GenericCollection{}
 
-	volumes, err := s.m.ListSorted()
+	allVolumes, err := s.m.ListSorted()
 	if err != nil {
 		return nil, err
 	}
 
+	volumes := []*longhorn.Volume{}
+	for _, v := range allVolumes {
+		if !selector.Matches(labels.Set(v.Labels)) {
+			continue
+		}
+		if continueToken != "" && v.Name < continueToken {
+			continue
+		}
+		volumes = append(volumes, v)
+	}
+
+	if limit > 0 {
+		pageLimit := int64(limit)
+		pagination := &client.Pagination{
+			Marker: continueToken,
+			Limit:  &pageLimit,
+		}
+		if len(volumes) > limit {
+			pagination.Next = volumes[limit].Name
+			pagination.Partial = true
+			volumes = volumes[:limit]
+		}
+		resp.Pagination = pagination
+	}
+
 	for _, v := range volumes {
 		controllers, err := s.m.GetEnginesSorted(v.Name)
 		if err != nil {
@@ -167,6 +220,8 @@ func (s *Server) VolumeCreate(rw http.ResponseWriter, req *http.Request) error {
 		DataSource:                volume.DataSource,
 		NumberOfReplicas:          volume.NumberOfReplicas,
 		ReplicaAutoBalance:        volume.ReplicaAutoBalance,
+		ReplicaPlacementPolicy:    volume.ReplicaPlacementPolicy,
+		ReplicaPlacementNodes:     volume.ReplicaPlacementNodes,
 		DataLocality:              volume.DataLocality,
 		StaleReplicaTimeout:       volume.StaleReplicaTimeout,
 		BackingImage:              volume.BackingImage,
@@ -176,6 +231,8 @@ func (s *Server) VolumeCreate(rw http.ResponseWriter, req *http.Request) error {
 		NodeSelector:              volume.NodeSelector,
 		SnapshotDataIntegrity:     volume.SnapshotDataIntegrity,
 		UnmapMarkSnapChainRemoved: volume.UnmapMarkSnapChainRemoved,
+		SnapshotMaxCount:          volume.SnapshotMaxCount,
+		BackupRetention:           volume.BackupRetention,
 	}, volume.RecurringJobSelector)
 	if err != nil {
 		return errors.Wrap(err, "unable to create volume")
@@ -542,6 +599,15 @@ func (s *Server) VolumeCancelExpansion(rw http.ResponseWriter, req *http.Request
 func (s *Server) VolumeFilesystemTrim(rw http.ResponseWriter, req *http.Request) error {
 	id := mux.Vars(req)["name"]
 
+	vol, err := s.m.Get(id)
+	if err != nil {
+		return errors.Wrap(err, "unable to get volume")
+	}
+	if vol.Status.State != longhorn.VolumeStateAttached {
+		writeErrWithStatus(rw, req, http.StatusConflict, fmt.Errorf("cannot trim filesystem for volume %v in state %v, the volume should be attached", vol.Name, vol.Status.State))
+		return nil
+	}
+
 	obj, err := util.RetryOnConflictCause(func() (interface{}, error) {
 		return s.m.TrimFilesystem(id)
 	})
@@ -583,6 +649,28 @@ func (s *Server) PVCreate(rw http.ResponseWriter, req *http.Request) error {
 	return s.responseWithVolume(rw, req, id, nil)
 }
 
+func (s *Server) KubernetesManifestExport(rw http.ResponseWriter, req *http.Request) error {
+	var input KubernetesManifestExportInput
+	id := mux.Vars(req)["name"]
+
+	apiContext := api.GetApiContext(req)
+	if err := apiContext.Read(&input); err != nil {
+		return errors.Wrapf(err, "error reading kubernetesManifestExportInput")
+	}
+
+	pv, pvc, err := s.m.GetKubernetesManifest(id, input.PVName, input.FSType, input.SecretNamespace, input.SecretName, input.Namespace, input.PVCName)
+	if err != nil {
+		return errors.Wrapf(err, "unable to export Kubernetes manifest for volume %v", id)
+	}
+
+	manifest, err := toKubernetesManifestResource(id, pv, pvc)
+	if err != nil {
+		return errors.Wrapf(err, "unable to encode Kubernetes manifest for volume %v", id)
+	}
+	apiContext.Write(manifest)
+	return nil
+}
+
 func (s *Server) PVCCreate(rw http.ResponseWriter, req *http.Request) error {
 	var input PVCCreateInput
 	id := mux.Vars(req)["name"]
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+
+	"github.com/longhorn/longhorn-manager/datastore"
+	"github.com/longhorn/longhorn-manager/manager"
+
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
+	lhfake "github.com/longhorn/longhorn-manager/k8s/pkg/client/clientset/versioned/fake"
+	lhinformers "github.com/longhorn/longhorn-manager/k8s/pkg/client/informers/externalversions"
+)
+
+const (
+	TestNamespace = "default"
+	TestNode1     = "test-node-1"
+)
+
+type testServer struct {
+	s                 *Server
+	lhClient          *lhfake.Clientset
+	lhInformerFactory lhinformers.SharedInformerFactory
+}
+
+func newTestServer(t *testing.T) *testServer {
+	// The fake informer caches are not updated by the fake clients
+	datastore.VerificationRetryCounts = 1
+
+	kubeClient := fake.NewSimpleClientset()
+	kubeInformerFactory := informers.NewSharedInformerFactory(kubeClient, 0)
+	lhClient := lhfake.NewSimpleClientset()
+	lhInformerFactory := lhinformers.NewSharedInformerFactory(lhClient, 0)
+	extensionsClient := apiextensionsfake.NewSimpleClientset()
+	ds, err := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+	require.NoError(t, err)
+
+	return &testServer{
+		s:                 NewServer(manager.NewVolumeManager(TestNode1, ds, nil, nil), nil),
+		lhClient:          lhClient,
+		lhInformerFactory: lhInformerFactory,
+	}
+}
+
+func (ts *testServer) serve(method, url string, body io.Reader, handler HandleFuncWithError) *httptest.ResponseRecorder {
+	rw := httptest.NewRecorder()
+	req := httptest.NewRequest(method, url, body)
+	HandleError(NewSchema(), handler).ServeHTTP(rw, req)
+	return rw
+}
+
+func newTestVolume(name string, labels map[string]string) *longhorn.Volume {
+	return &longhorn.Volume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: TestNamespace,
+			Labels:    labels,
+		},
+	}
+}
+
+func TestVolumeList(t *testing.T) {
+	assert := require.New(t)
+
+	type testCase struct {
+		query string
+
+		expectedStatus  int
+		expectedVolumes []string
+	}
+	testCases := map[string]testCase{
+		"list all": {
+			query:           "",
+			expectedStatus:  http.StatusOK,
+			expectedVolumes: []string{"volume-1", "volume-2", "volume-3"},
+		},
+		"list by page and label selector": {
+			query:           "?limit=1&labelSelector=app%3Dtest",
+			expectedStatus:  http.StatusOK,
+			expectedVolumes: []string{"volume-1"},
+		},
+		"unparsable limit": {
+			query:          "?limit=abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"negative limit": {
+			query:          "?limit=-1",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"unparsable label selector": {
+			query:          "?labelSelector=app+in+%28",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		ts := newTestServer(t)
+		vIndexer := ts.lhInformerFactory.Longhorn().V1beta2().Volumes().Informer().GetIndexer()
+		assert.NoError(vIndexer.Add(newTestVolume("volume-1", map[string]string{"app": "test"})))
+		assert.NoError(vIndexer.Add(newTestVolume("volume-2", nil)))
+		assert.NoError(vIndexer.Add(newTestVolume("volume-3", map[string]string{"app": "test"})))
+
+		rw := ts.serve(http.MethodGet, "/v1/volumes"+tc.query, nil, ts.s.VolumeList)
+		assert.Equal(tc.expectedStatus, rw.Code, "test case: %v, body: %v", name, rw.Body.String())
+		if tc.expectedStatus != http.StatusOK {
+			continue
+		}
+
+		resp := &struct {
+			Data []Volume `json:"data"`
+		}{}
+		assert.NoError(json.Unmarshal(rw.Body.Bytes(), resp), "test case: %v", name)
+		volumeNames := []string{}
+		for _, v := range resp.Data {
+			volumeNames = append(volumeNames, v.Name)
+		}
+		assert.Equal(tc.expectedVolumes, volumeNames, "test case: %v", name)
+	}
+}
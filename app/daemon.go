@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"sync"
+	"time"
 
 	_ "net/http/pprof" // for runtime profiling
 
@@ -11,6 +13,8 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 
+	"k8s.io/client-go/tools/clientcmd"
+
 	"github.com/longhorn/go-iscsi-helper/iscsi"
 	iscsi_util "github.com/longhorn/go-iscsi-helper/util"
 
@@ -34,6 +38,17 @@ const (
 	FlagSupportBundleManagerImage = "support-bundle-manager-image"
 	FlagServiceAccount            = "service-account"
 	FlagKubeConfig                = "kube-config"
+	FlagControllerWorkers         = "controller-workers"
+	FlagControllerRetryBaseDelay  = "controller-retry-base-delay"
+	FlagControllerRetryMaxDelay   = "controller-retry-max-delay"
+	FlagControllerMaxRetries      = "controller-max-retries"
+	FlagEventDedupWindow          = "event-dedup-window"
+)
+
+const (
+	// controllerDrainTimeout is kept below the default termination grace
+	// period of the pod, so the manager exits before it gets killed.
+	controllerDrainTimeout = 20 * time.Second
 )
 
 func DaemonCmd() cli.Command {
@@ -72,6 +87,31 @@ func DaemonCmd() cli.Command {
 				Name:  FlagKubeConfig,
 				Usage: "Specify path to kube config (optional)",
 			},
+			cli.IntFlag{
+				Name:  FlagControllerWorkers,
+				Usage: "Specify the number of workers of each controller. The setting controller always runs a single worker",
+				Value: controller.Workers,
+			},
+			cli.DurationFlag{
+				Name:  FlagControllerRetryBaseDelay,
+				Usage: "Specify the base delay of the exponential backoff when a controller retries a failed item",
+				Value: controller.RateLimiterBaseDelay,
+			},
+			cli.DurationFlag{
+				Name:  FlagControllerRetryMaxDelay,
+				Usage: "Specify the max delay of the exponential backoff when a controller retries a failed item",
+				Value: controller.RateLimiterMaxDelay,
+			},
+			cli.IntFlag{
+				Name:  FlagControllerMaxRetries,
+				Usage: "Specify the number of times a controller retries a failed item before dropping it out of the queue",
+				Value: controller.MaxRetries,
+			},
+			cli.DurationFlag{
+				Name:  FlagEventDedupWindow,
+				Usage: "Specify the window in which identical warning events of an object are recorded only once. 0 disables the deduplication",
+				Value: controller.EventDedupWindow,
+			},
 		},
 		Action: func(c *cli.Context) {
 			if err := startManager(c); err != nil {
@@ -115,6 +155,29 @@ func startManager(c *cli.Context) error {
 		return fmt.Errorf("require %v", FlagServiceAccount)
 	}
 	kubeconfigPath := c.String(FlagKubeConfig)
+	controllerWorkers := c.Int(FlagControllerWorkers)
+	if controllerWorkers < 1 {
+		return fmt.Errorf("invalid %v %v, it should be at least 1", FlagControllerWorkers, controllerWorkers)
+	}
+	controller.Workers = controllerWorkers
+	retryBaseDelay := c.Duration(FlagControllerRetryBaseDelay)
+	retryMaxDelay := c.Duration(FlagControllerRetryMaxDelay)
+	if retryBaseDelay <= 0 || retryMaxDelay < retryBaseDelay {
+		return fmt.Errorf("invalid %v %v and %v %v, the base delay should be positive and not greater than the max delay",
+			FlagControllerRetryBaseDelay, retryBaseDelay, FlagControllerRetryMaxDelay, retryMaxDelay)
+	}
+	controller.RateLimiterBaseDelay = retryBaseDelay
+	controller.RateLimiterMaxDelay = retryMaxDelay
+	maxRetries := c.Int(FlagControllerMaxRetries)
+	if maxRetries < 0 {
+		return fmt.Errorf("invalid %v %v, it should not be negative", FlagControllerMaxRetries, maxRetries)
+	}
+	controller.MaxRetries = maxRetries
+	eventDedupWindow := c.Duration(FlagEventDedupWindow)
+	if eventDedupWindow < 0 {
+		return fmt.Errorf("invalid %v %v, it should not be negative", FlagEventDedupWindow, eventDedupWindow)
+	}
+	controller.EventDedupWindow = eventDedupWindow
 
 	if err := environmentCheck(); err != nil {
 		logrus.Errorf("Failed environment check, please make sure you " +
@@ -133,6 +196,7 @@ func startManager(c *cli.Context) error {
 	}
 
 	done := make(chan struct{})
+	controllerWG := &sync.WaitGroup{}
 
 	logger := logrus.StandardLogger().WithField("node", currentNodeID)
 
@@ -142,12 +206,17 @@ func startManager(c *cli.Context) error {
 
 	proxyConnCounter := util.NewAtomicCounter()
 
-	ds, wsc, err := controller.StartControllers(logger, done, currentNodeID, serviceAccount, managerImage, kubeconfigPath, meta.Version, proxyConnCounter)
+	ds, wsc, err := controller.StartControllers(logger, done, controllerWG, currentNodeID, serviceAccount, managerImage, kubeconfigPath, meta.Version, proxyConnCounter)
 	if err != nil {
 		return err
 	}
 
-	m := manager.NewVolumeManager(currentNodeID, ds, proxyConnCounter)
+	kubeConfig, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	if err != nil {
+		return fmt.Errorf("unable to get client config: %v", err)
+	}
+
+	m := manager.NewVolumeManager(currentNodeID, ds, kubeConfig, proxyConnCounter)
 
 	metricsCollector.InitMetricsCollectorSystem(logger, currentNodeID, ds, kubeconfigPath, proxyConnCounter)
 
@@ -184,6 +253,8 @@ func startManager(c *cli.Context) error {
 		"/v1/nodes":        {},
 		"/v1/engineimages": {},
 		"/v1/events":       {},
+		"/healthz":         {},
+		"/readyz":          {},
 	}, os.Stdout, router)
 	router = handlers.ProxyHeaders(router)
 
@@ -203,9 +274,30 @@ func startManager(c *cli.Context) error {
 
 	util.RegisterShutdownChannel(done)
 	<-done
+	waitForControllersDrained(logger, controllerWG)
 	return nil
 }
 
+// waitForControllersDrained waits for the controllers to finish the work items
+// in process, so the reconciliation is not interrupted in the middle of a
+// sequence of datastore updates. The unprocessed items will be picked up by
+// the next manager since all controllers are level-triggered.
+func waitForControllersDrained(logger logrus.FieldLogger, wg *sync.WaitGroup) {
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+
+	logger.Info("Waiting for the controllers to drain the in-process work items")
+	select {
+	case <-drained:
+		logger.Info("All controllers are drained")
+	case <-time.After(controllerDrainTimeout):
+		logger.Warnf("Timed out after %v waiting for the controllers to drain, will exit anyway", controllerDrainTimeout)
+	}
+}
+
 func environmentCheck() error {
 	initiatorNSPath := iscsi_util.GetHostNamespacePath(util.HostProcPath)
 	namespace, err := iscsi_util.NewNamespaceExecutor(initiatorNSPath)
@@ -243,13 +335,20 @@ func updateRegistrySecretName(m *manager.VolumeManager) error {
 func initDaemonNode(ds *datastore.DataStore) error {
 	nodeName := os.Getenv("NODE_NAME")
 	if _, err := ds.GetNode(nodeName); err != nil {
-		// init default disk on node when starting longhorn-manager
-		if datastore.ErrorIsNotFound(err) {
-			if _, err = ds.CreateDefaultNode(nodeName); err != nil {
+		if !datastore.ErrorIsNotFound(err) {
+			return err
+		}
+		// init default disk on node when starting longhorn-manager. Retry
+		// the creation since checking the default disk paths on the host
+		// may fail temporarily.
+		for i := 0; ; i++ {
+			_, err = ds.CreateDefaultNode(nodeName)
+			if err == nil || i >= util.APIRetryCounts-1 {
 				return err
 			}
+			logrus.WithError(err).Warnf("Failed to create default node %v, will retry", nodeName)
+			time.Sleep(util.APIRetryInterval)
 		}
-		return err
 	}
 	return nil
 }
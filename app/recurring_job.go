@@ -17,12 +17,16 @@ import (
 	"github.com/urfave/cli"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientset "k8s.io/client-go/kubernetes"
 	typedv1core "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/longhorn/backupstore"
 
 	etypes "github.com/longhorn/longhorn-engine/pkg/types"
 
@@ -286,6 +290,23 @@ func (job *Job) handleVolumeDetachment() {
 	volumeAPI := job.api.Volume
 	volumeName := job.volumeName
 	jobName := job.labels[types.RecurringJobLabel]
+
+	// The volume attached by the attachment ticket of this recurring job is
+	// detached by the volume controller once the ticket is removed, unless
+	// the volume is requested by the other attachers.
+	for {
+		removed, err := job.removeAttachmentTicket()
+		if err == nil {
+			if removed {
+				job.logger.Infof("Removed the attachment ticket of volume %v", volumeName)
+				return
+			}
+			break
+		}
+		job.logger.WithError(err).Infof("Could not remove the attachment ticket of volume %v", volumeName)
+		time.Sleep(DetachingWaitInterval)
+	}
+
 	for {
 		volume, err := volumeAPI.ById(volumeName)
 		if err == nil {
@@ -340,12 +361,21 @@ func (job *Job) run() (err error) {
 			return errors.Wrapf(err, "cannot do auto attaching for volume %v", volumeName)
 		}
 
+		isAttachmentTicketSupported, err := job.isAttachmentTicketSupported()
+		if err != nil {
+			return err
+		}
+
 		jobName := job.labels[types.RecurringJobLabel]
 		// Automatically attach the volume
 		// Disable the volume's frontend make sure that pod cannot use the volume during the recurring job.
 		// This is necessary so that we can safely detach the volume when finishing the job.
 		job.logger.Infof("Automatically attach volume %v to node %v", volumeName, nodeToAttach)
-		if _, err = volumeAPI.ActionAttach(volume, &longhornclient.AttachInput{
+		if isAttachmentTicketSupported {
+			if err := job.addAttachmentTicket(nodeToAttach); err != nil {
+				return err
+			}
+		} else if _, err = volumeAPI.ActionAttach(volume, &longhornclient.AttachInput{
 			DisableFrontend: true,
 			HostId:          nodeToAttach,
 			AttachedBy:      jobName,
@@ -375,6 +405,90 @@ func (job *Job) run() (err error) {
 	return job.doRecurringSnapshot()
 }
 
+func (job *Job) isAttachmentTicketSupported() (bool, error) {
+	volume, err := job.lhClient.LonghornV1beta2().Volumes(job.namespace).Get(context.TODO(), job.volumeName, metav1.GetOptions{})
+	if err != nil {
+		return false, errors.Wrapf(err, "could not get volume %v", job.volumeName)
+	}
+	return types.IsAttachmentTicketSupported(volume), nil
+}
+
+func (job *Job) getAttachmentTicketID() string {
+	return types.GetAttachmentTicketID(longhorn.AttacherTypeRecurringJob, job.labels[types.RecurringJobLabel])
+}
+
+// addAttachmentTicket requests attaching the volume to the node with the
+// frontend disabled. The ticket has a lower priority than the ones of the CSI
+// attacher and the Longhorn API, so the workloads can always take the volume over.
+func (job *Job) addAttachmentTicket(nodeID string) error {
+	ticket := &longhorn.AttachmentTicket{
+		ID:     job.getAttachmentTicketID(),
+		Type:   longhorn.AttacherTypeRecurringJob,
+		NodeID: nodeID,
+		Parameters: map[string]string{
+			longhorn.AttachmentParameterDisableFrontend: strconv.FormatBool(true),
+		},
+	}
+
+	vaClient := job.lhClient.LonghornV1beta2().VolumeAttachments(job.namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		va, err := vaClient.Get(context.TODO(), job.volumeName, metav1.GetOptions{})
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				return err
+			}
+			_, err = vaClient.Create(context.TODO(), &longhorn.VolumeAttachment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   job.volumeName,
+					Labels: types.GetVolumeLabels(job.volumeName),
+				},
+				Spec: longhorn.VolumeAttachmentSpec{
+					AttachmentTickets: map[string]*longhorn.AttachmentTicket{
+						ticket.ID: ticket,
+					},
+					Volume: job.volumeName,
+				},
+			}, metav1.CreateOptions{})
+			return err
+		}
+
+		if va.Spec.AttachmentTickets == nil {
+			va.Spec.AttachmentTickets = map[string]*longhorn.AttachmentTicket{}
+		}
+		va.Spec.AttachmentTickets[ticket.ID] = ticket
+		_, err = vaClient.Update(context.TODO(), va, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// removeAttachmentTicket removes the attachment ticket of the recurring job,
+// and returns if the ticket existed.
+func (job *Job) removeAttachmentTicket() (removed bool, err error) {
+	vaClient := job.lhClient.LonghornV1beta2().VolumeAttachments(job.namespace)
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		removed = false
+		va, err := vaClient.Get(context.TODO(), job.volumeName, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+
+		ticketID := job.getAttachmentTicketID()
+		if _, ok := va.Spec.AttachmentTickets[ticketID]; !ok {
+			return nil
+		}
+		delete(va.Spec.AttachmentTickets, ticketID)
+		if _, err = vaClient.Update(context.TODO(), va, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		removed = true
+		return nil
+	})
+	return removed, err
+}
+
 func (job *Job) doRecurringSnapshot() (err error) {
 	defer func() {
 		err = errors.Wrapf(err, "failed recurring snapshot")
@@ -660,12 +774,125 @@ func (job *Job) doRecurringBackup() (err error) {
 		job.logger.Debugf("Cleaned up backup %v for %v", backup, volumeName)
 	}
 
+	if err := job.doBackupRetention(backupVolume); err != nil {
+		return err
+	}
+
 	if err := job.doSnapshotCleanup(true); err != nil {
 		return err
 	}
 	return nil
 }
 
+// doBackupRetention deletes the backups of the volume beyond the volume backup
+// retention policy.
+func (job *Job) doBackupRetention(backupVolume *longhornclient.BackupVolume) error {
+	backupAPI := job.api.BackupVolume
+
+	volume, err := job.GetVolume(job.volumeName)
+	if err != nil {
+		return err
+	}
+	retention := volume.Spec.BackupRetention
+	if retention.Count == 0 && retention.MaxAge == "" {
+		return nil
+	}
+	maxAge := time.Duration(0)
+	if retention.MaxAge != "" {
+		if maxAge, err = time.ParseDuration(retention.MaxAge); err != nil {
+			return errors.Wrapf(err, "invalid backup retention max age %v", retention.MaxAge)
+		}
+	}
+
+	backups, err := backupAPI.ActionBackupList(backupVolume)
+	if err != nil {
+		return err
+	}
+	inUseBackups, err := job.listBackupsInUseByRestoringVolumes()
+	if err != nil {
+		return err
+	}
+
+	for _, backup := range job.listBackupsBeyondRetention(backups.Data, retention.Count, maxAge, inUseBackups, time.Now()) {
+		if _, err := backupAPI.ActionBackupDelete(backupVolume, &longhornclient.BackupInput{
+			Name: backup,
+		}); err != nil {
+			return fmt.Errorf("cleaned up backup %v beyond retention failed for %v: %v", backup, job.volumeName, err)
+		}
+		job.logger.Debugf("Cleaned up backup %v beyond retention for %v", backup, job.volumeName)
+	}
+	return nil
+}
+
+// listBackupsInUseByRestoringVolumes returns the backups which the DR/standby
+// volumes and the volumes being restored depend on. Only the volumes in this
+// cluster are visible, the DR volumes of other clusters are not considered.
+func (job *Job) listBackupsInUseByRestoringVolumes() (map[string]struct{}, error) {
+	volumes, err := job.lhClient.LonghornV1beta2().Volumes(job.namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	inUseBackups := map[string]struct{}{}
+	for _, v := range volumes.Items {
+		if !v.Spec.Standby && !v.Status.IsStandby && !v.Status.RestoreRequired {
+			continue
+		}
+		if v.Status.LastBackup != "" {
+			inUseBackups[v.Status.LastBackup] = struct{}{}
+		}
+		if v.Spec.FromBackup != "" {
+			backupName, _, _, err := backupstore.DecodeBackupURL(v.Spec.FromBackup)
+			if err != nil {
+				job.logger.WithError(err).Warnf("Failed to decode backup URL %v of volume %v", v.Spec.FromBackup, v.Name)
+				continue
+			}
+			inUseBackups[backupName] = struct{}{}
+		}
+	}
+	return inUseBackups, nil
+}
+
+// listBackupsBeyondRetention returns the completed backups which are beyond the
+// retain count or older than the max age. The latest backup and the backups in
+// use are always retained.
+func (job *Job) listBackupsBeyondRetention(backups []longhornclient.Backup, retainCount int, maxAge time.Duration, inUseBackups map[string]struct{}, now time.Time) []string {
+	sts := []NameWithTimestamp{}
+	for _, backup := range backups {
+		if backup.State != string(longhorn.BackupStateCompleted) {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, backup.Created)
+		if err != nil {
+			job.logger.Errorf("Failed to parse datetime %v for backup %v",
+				backup.Created, backup)
+			continue
+		}
+		sts = append(sts, NameWithTimestamp{
+			Name:      backup.Name,
+			Timestamp: t,
+		})
+	}
+	sort.Slice(sts, func(i, j int) bool {
+		return sts[i].Timestamp.Before(sts[j].Timestamp)
+	})
+
+	ret := []string{}
+	for i := 0; i < len(sts)-1; i++ {
+		beyondCount := retainCount > 0 && i < len(sts)-retainCount
+		beyondAge := maxAge > 0 && now.Sub(sts[i].Timestamp) > maxAge
+		if !beyondCount && !beyondAge {
+			continue
+		}
+		if _, inUse := inUseBackups[sts[i].Name]; inUse {
+			job.logger.Infof("Skipped cleaning up backup %v beyond retention since a DR or restoring volume depends on it", sts[i].Name)
+			continue
+		}
+		ret = append(ret, sts[i].Name)
+	}
+	return ret
+}
+
 // waitForBackupProcessStart timeout in second
 // Return nil if the backup progress has started; error if error or timeout
 func (job *Job) waitForBackupProcessStart(timeout int) error {
@@ -0,0 +1,232 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	longhornclient "github.com/longhorn/longhorn-manager/client"
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
+	lhfake "github.com/longhorn/longhorn-manager/k8s/pkg/client/clientset/versioned/fake"
+)
+
+const (
+	testRecurringJobNamespace = "default"
+	testRecurringJobVolume    = "test-volume"
+)
+
+// fakeBackupVolumeOperations serves the backups of a backup volume and
+// records the deleted ones
+type fakeBackupVolumeOperations struct {
+	longhornclient.BackupVolumeOperations
+
+	backups []longhornclient.Backup
+	deleted []string
+}
+
+func (o *fakeBackupVolumeOperations) ActionBackupList(*longhornclient.BackupVolume) (*longhornclient.BackupListOutput, error) {
+	return &longhornclient.BackupListOutput{Data: o.backups}, nil
+}
+
+func (o *fakeBackupVolumeOperations) ActionBackupDelete(backupVolume *longhornclient.BackupVolume, input *longhornclient.BackupInput) (*longhornclient.BackupVolume, error) {
+	o.deleted = append(o.deleted, input.Name)
+	return backupVolume, nil
+}
+
+func newTestRecurringJobBackup(name string, state longhorn.BackupState, created time.Time) longhornclient.Backup {
+	return longhornclient.Backup{
+		Name:    name,
+		State:   string(state),
+		Created: created.Format(time.RFC3339),
+	}
+}
+
+func newTestRecurringJobVolume(name string, retention longhorn.VolumeBackupRetention) *longhorn.Volume {
+	return &longhorn.Volume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testRecurringJobNamespace,
+		},
+		Spec: longhorn.VolumeSpec{
+			BackupRetention: retention,
+		},
+	}
+}
+
+func TestListBackupsBeyondRetention(t *testing.T) {
+	assert := require.New(t)
+
+	now := time.Now()
+	day := 24 * time.Hour
+	backups := []longhornclient.Backup{
+		newTestRecurringJobBackup("backup-4", longhorn.BackupStateCompleted, now.Add(-1*day)),
+		newTestRecurringJobBackup("backup-1", longhorn.BackupStateCompleted, now.Add(-10*day)),
+		newTestRecurringJobBackup("backup-3", longhorn.BackupStateCompleted, now.Add(-3*day)),
+		newTestRecurringJobBackup("backup-2", longhorn.BackupStateCompleted, now.Add(-5*day)),
+		newTestRecurringJobBackup("backup-5", longhorn.BackupStateInProgress, now),
+	}
+
+	type testCase struct {
+		backups      []longhornclient.Backup
+		retainCount  int
+		maxAge       time.Duration
+		inUseBackups map[string]struct{}
+
+		expectedBackups []string
+	}
+	testCases := map[string]testCase{
+		"no retention": {
+			backups:         backups,
+			expectedBackups: []string{},
+		},
+		"beyond count": {
+			backups:         backups,
+			retainCount:     2,
+			expectedBackups: []string{"backup-1", "backup-2"},
+		},
+		"beyond age": {
+			backups:         backups,
+			maxAge:          4 * day,
+			expectedBackups: []string{"backup-1", "backup-2"},
+		},
+		"beyond count or age": {
+			backups:         backups,
+			retainCount:     3,
+			maxAge:          2 * day,
+			expectedBackups: []string{"backup-1", "backup-2", "backup-3"},
+		},
+		"latest backup kept beyond age": {
+			backups:         backups,
+			maxAge:          time.Hour,
+			expectedBackups: []string{"backup-1", "backup-2", "backup-3"},
+		},
+		"in use backups skipped": {
+			backups:         backups,
+			retainCount:     1,
+			inUseBackups:    map[string]struct{}{"backup-2": {}},
+			expectedBackups: []string{"backup-1", "backup-3"},
+		},
+		"incomplete and invalid backups ignored": {
+			backups: []longhornclient.Backup{
+				newTestRecurringJobBackup("backup-1", longhorn.BackupStateCompleted, now.Add(-2*day)),
+				newTestRecurringJobBackup("backup-2", longhorn.BackupStateError, now.Add(-1*day)),
+				{Name: "backup-3", State: string(longhorn.BackupStateCompleted), Created: "invalid"},
+			},
+			retainCount:     1,
+			expectedBackups: []string{},
+		},
+	}
+
+	job := &Job{logger: logrus.StandardLogger()}
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		ret := job.listBackupsBeyondRetention(tc.backups, tc.retainCount, tc.maxAge, tc.inUseBackups, now)
+		sort.Strings(ret)
+		assert.Equal(tc.expectedBackups, ret, "test case: %v", name)
+	}
+}
+
+func TestDoBackupRetention(t *testing.T) {
+	assert := require.New(t)
+
+	now := time.Now()
+	day := 24 * time.Hour
+
+	type testCase struct {
+		retention longhorn.VolumeBackupRetention
+		volumes   []*longhorn.Volume
+
+		expectedDeleted []string
+		expectError     bool
+	}
+	testCases := map[string]testCase{
+		"no retention": {
+			retention:       longhorn.VolumeBackupRetention{},
+			expectedDeleted: nil,
+		},
+		"beyond count": {
+			retention:       longhorn.VolumeBackupRetention{Count: 1},
+			expectedDeleted: []string{"backup-1", "backup-2"},
+		},
+		"beyond age": {
+			retention:       longhorn.VolumeBackupRetention{MaxAge: "96h"},
+			expectedDeleted: []string{"backup-1"},
+		},
+		"backups of DR and restoring volumes skipped": {
+			retention: longhorn.VolumeBackupRetention{Count: 1},
+			volumes: []*longhorn.Volume{
+				func() *longhorn.Volume {
+					v := newTestRecurringJobVolume("dr-volume", longhorn.VolumeBackupRetention{})
+					v.Spec.Standby = true
+					v.Status.LastBackup = "backup-1"
+					return v
+				}(),
+				func() *longhorn.Volume {
+					v := newTestRecurringJobVolume("restoring-volume", longhorn.VolumeBackupRetention{})
+					v.Spec.FromBackup = "s3://backupbucket@us-east-1/?backup=backup-2&volume=" + testRecurringJobVolume
+					v.Status.RestoreRequired = true
+					return v
+				}(),
+			},
+			expectedDeleted: nil,
+		},
+		"backups of restored volumes deleted": {
+			retention: longhorn.VolumeBackupRetention{Count: 1},
+			volumes: []*longhorn.Volume{
+				func() *longhorn.Volume {
+					v := newTestRecurringJobVolume("restored-volume", longhorn.VolumeBackupRetention{})
+					v.Spec.FromBackup = "s3://backupbucket@us-east-1/?backup=backup-2&volume=" + testRecurringJobVolume
+					return v
+				}(),
+			},
+			expectedDeleted: []string{"backup-1", "backup-2"},
+		},
+		"invalid max age": {
+			retention:   longhorn.VolumeBackupRetention{MaxAge: "5d"},
+			expectError: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		lhClient := lhfake.NewSimpleClientset()
+		volumes := append([]*longhorn.Volume{newTestRecurringJobVolume(testRecurringJobVolume, tc.retention)}, tc.volumes...)
+		for _, v := range volumes {
+			_, err := lhClient.LonghornV1beta2().Volumes(testRecurringJobNamespace).Create(context.TODO(), v, metav1.CreateOptions{})
+			assert.NoError(err)
+		}
+
+		backupVolumeAPI := &fakeBackupVolumeOperations{
+			backups: []longhornclient.Backup{
+				newTestRecurringJobBackup("backup-1", longhorn.BackupStateCompleted, now.Add(-5*day)),
+				newTestRecurringJobBackup("backup-2", longhorn.BackupStateCompleted, now.Add(-3*day)),
+				newTestRecurringJobBackup("backup-3", longhorn.BackupStateCompleted, now.Add(-1*day)),
+			},
+		}
+		job := &Job{
+			logger:     logrus.StandardLogger(),
+			lhClient:   lhClient,
+			namespace:  testRecurringJobNamespace,
+			volumeName: testRecurringJobVolume,
+			api:        &longhornclient.RancherClient{BackupVolume: backupVolumeAPI},
+		}
+
+		err := job.doBackupRetention(&longhornclient.BackupVolume{Name: testRecurringJobVolume})
+		if tc.expectError {
+			assert.Error(err, "test case: %v", name)
+			continue
+		}
+		assert.NoError(err, "test case: %v", name)
+		sort.Strings(backupVolumeAPI.deleted)
+		assert.Equal(tc.expectedDeleted, backupVolumeAPI.deleted, "test case: %v", name)
+	}
+}
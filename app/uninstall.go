@@ -78,7 +78,10 @@ func uninstall(c *cli.Context) error {
 	kubeInformerFactory := informers.NewSharedInformerFactory(kubeClient, time.Second*30)
 	lhInformerFactory := lhinformers.NewSharedInformerFactory(lhClient, time.Second*30)
 
-	ds := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, namespace)
+	ds, err := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, namespace)
+	if err != nil {
+		return errors.Wrap(err, "unable to create datastore")
+	}
 
 	logger := logrus.StandardLogger()
 
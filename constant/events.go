@@ -42,9 +42,13 @@ const (
 	EventReasonUnknown        = "Unknown"
 	EventReasonFailedEviction = "FailedEviction"
 
+	EventReasonDiskPressureEviction = "DiskPressureEviction"
+	EventReasonEvictingReplicas     = "EvictingReplicas"
+
 	EventReasonDetachedUnexpectly = "DetachedUnexpectly"
 	EventReasonRemount            = "Remount"
 	EventReasonAutoSalvaged       = "AutoSalvaged"
+	EventReasonAutoDetached       = "AutoDetached"
 
 	EventReasonFetching = "Fetching"
 	EventReasonFetched  = "Fetched"
@@ -52,11 +56,23 @@ const (
 	EventReasonSyncing = "Syncing"
 	EventReasonSynced  = "Synced"
 
+	EventReasonFailedSyncing = "FailedSyncing"
+
 	EventReasonFailedSnapshotDataIntegrityCheck = "FailedSnapshotDataIntegrityCheck"
 
+	EventReasonPurgedSnapshots = "PurgedSnapshots"
+
+	EventReasonFailedCheckingUpgrade = "FailedCheckingUpgrade"
+	EventReasonUpgradeAvailable      = "UpgradeAvailable"
+
+	EventReasonFailedEngineUpgrade = "FailedEngineUpgrade"
+
 	EventReasonFailed   = "Failed"
 	EventReasonReady    = "Ready"
 	EventReasonUploaded = "Uploaded"
 
 	EventReasonRolloutSkippedFmt = "RolloutSkipped: %v %v"
+
+	EventReasonMaintenanceModeEnabled  = "MaintenanceModeEnabled"
+	EventReasonMaintenanceModeDisabled = "MaintenanceModeDisabled"
 )
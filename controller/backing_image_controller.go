@@ -42,7 +42,7 @@ type BackingImageController struct {
 
 	ds *datastore.DataStore
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 }
 
 func NewBackingImageController(
@@ -65,9 +65,11 @@ func NewBackingImageController(
 		serviceAccount: serviceAccount,
 
 		kubeClient:    kubeClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, corev1.EventSource{Component: "longhorn-backing-image-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, corev1.EventSource{Component: "longhorn-backing-image-controller"})),
 
 		ds: ds,
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	ds.BackingImageInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -75,40 +77,40 @@ func NewBackingImageController(
 		UpdateFunc: func(old, cur interface{}) { bic.enqueueBackingImage(cur) },
 		DeleteFunc: bic.enqueueBackingImage,
 	})
-	bic.cacheSyncs = append(bic.cacheSyncs, ds.BackingImageInformer.HasSynced)
+	bic.cacheSyncs["BackingImageInformer"] = ds.BackingImageInformer.HasSynced
 
 	ds.BackingImageManagerInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc:    bic.enqueueBackingImageForBackingImageManager,
 		UpdateFunc: func(old, cur interface{}) { bic.enqueueBackingImageForBackingImageManager(cur) },
 		DeleteFunc: bic.enqueueBackingImageForBackingImageManager,
 	}, 0)
-	bic.cacheSyncs = append(bic.cacheSyncs, ds.BackingImageManagerInformer.HasSynced)
+	bic.cacheSyncs["BackingImageManagerInformer"] = ds.BackingImageManagerInformer.HasSynced
 
 	ds.BackingImageDataSourceInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc:    bic.enqueueBackingImageForBackingImageDataSource,
 		UpdateFunc: func(old, cur interface{}) { bic.enqueueBackingImageForBackingImageDataSource(cur) },
 		DeleteFunc: bic.enqueueBackingImageForBackingImageDataSource,
 	}, 0)
-	bic.cacheSyncs = append(bic.cacheSyncs, ds.BackingImageDataSourceInformer.HasSynced)
+	bic.cacheSyncs["BackingImageDataSourceInformer"] = ds.BackingImageDataSourceInformer.HasSynced
 
 	ds.ReplicaInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc:    bic.enqueueBackingImageForReplica,
 		UpdateFunc: func(old, cur interface{}) { bic.enqueueBackingImageForReplica(cur) },
 		DeleteFunc: bic.enqueueBackingImageForReplica,
 	}, 0)
-	bic.cacheSyncs = append(bic.cacheSyncs, ds.ReplicaInformer.HasSynced)
+	bic.cacheSyncs["ReplicaInformer"] = ds.ReplicaInformer.HasSynced
 
 	return bic
 }
 
 func (bic *BackingImageController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer bic.queue.ShutDown()
+	defer bic.queue.ShutDownWithDrain()
 
 	logrus.Infof("Starting Longhorn Backing Image controller")
 	defer logrus.Infof("Shut down Longhorn Backing Image controller")
 
-	if !cache.WaitForNamedCacheSync("longhorn backing images", stopCh, bic.cacheSyncs...) {
+	if !bic.waitForCacheSync(stopCh, bic.cacheSyncs) {
 		return
 	}
 
@@ -132,7 +134,7 @@ func (bic *BackingImageController) processNextWorkItem() bool {
 	}
 	defer bic.queue.Done(key)
 
-	err := bic.syncBackingImage(key.(string))
+	err := bic.syncWithPanicRecovery(key.(string), bic.syncBackingImage)
 	bic.handleErr(err, key)
 
 	return true
@@ -144,7 +146,7 @@ func (bic *BackingImageController) handleErr(err error, key interface{}) {
 		return
 	}
 
-	if bic.queue.NumRequeues(key) < maxRetries {
+	if bic.queue.NumRequeues(key) < MaxRetries {
 		logrus.WithError(err).Warnf("Error syncing Longhorn backing image %v", key)
 		bic.queue.AddRateLimited(key)
 		return
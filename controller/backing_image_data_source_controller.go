@@ -59,7 +59,7 @@ type BackingImageDataSourceController struct {
 
 	backoff *flowcontrol.Backoff
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 
 	lock       *sync.RWMutex
 	monitorMap map[string]chan struct{}
@@ -100,7 +100,7 @@ func NewBackingImageDataSourceController(
 		serviceAccount: serviceAccount,
 
 		kubeClient:    kubeClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-backing-image-data-source-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-backing-image-data-source-controller"})),
 
 		ds: ds,
 
@@ -110,6 +110,8 @@ func NewBackingImageDataSourceController(
 		monitorMap: map[string]chan struct{}{},
 
 		proxyConnCounter: proxyConnCounter,
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	ds.BackingImageDataSourceInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -117,25 +119,25 @@ func NewBackingImageDataSourceController(
 		UpdateFunc: func(old, cur interface{}) { c.enqueueBackingImageDataSource(cur) },
 		DeleteFunc: c.enqueueBackingImageDataSource,
 	})
-	c.cacheSyncs = append(c.cacheSyncs, ds.BackingImageDataSourceInformer.HasSynced)
+	c.cacheSyncs["BackingImageDataSourceInformer"] = ds.BackingImageDataSourceInformer.HasSynced
 
 	ds.BackingImageInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc:    c.enqueueForBackingImage,
 		UpdateFunc: func(old, cur interface{}) { c.enqueueForBackingImage(cur) },
 		DeleteFunc: c.enqueueForBackingImage,
 	}, 0)
-	c.cacheSyncs = append(c.cacheSyncs, ds.BackingImageInformer.HasSynced)
+	c.cacheSyncs["BackingImageInformer"] = ds.BackingImageInformer.HasSynced
 
 	ds.VolumeInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		UpdateFunc: func(old, cur interface{}) { c.enqueueForVolume(cur) },
 	}, 0)
-	c.cacheSyncs = append(c.cacheSyncs, ds.VolumeInformer.HasSynced)
+	c.cacheSyncs["VolumeInformer"] = ds.VolumeInformer.HasSynced
 
 	ds.NodeInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		UpdateFunc: func(oldObj, cur interface{}) { c.enqueueForLonghornNode(cur) },
 		DeleteFunc: c.enqueueForLonghornNode,
 	}, 0)
-	c.cacheSyncs = append(c.cacheSyncs, ds.NodeInformer.HasSynced)
+	c.cacheSyncs["NodeInformer"] = ds.NodeInformer.HasSynced
 
 	ds.PodInformer.AddEventHandlerWithResyncPeriod(cache.FilteringResourceEventHandler{
 		FilterFunc: isBackingImageDataSourcePod,
@@ -145,19 +147,19 @@ func NewBackingImageDataSourceController(
 			DeleteFunc: c.enqueueForBackingImageDataSourcePod,
 		},
 	}, 0)
-	c.cacheSyncs = append(c.cacheSyncs, ds.PodInformer.HasSynced)
+	c.cacheSyncs["PodInformer"] = ds.PodInformer.HasSynced
 
 	return c
 }
 
 func (c *BackingImageDataSourceController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer c.queue.ShutDown()
+	defer c.queue.ShutDownWithDrain()
 
 	logrus.Infof("Starting Longhorn backing image data source controller")
 	defer logrus.Infof("Shut down Longhorn backing image data source controller")
 
-	if !cache.WaitForNamedCacheSync("longhorn backing image data source", stopCh, c.cacheSyncs...) {
+	if !c.waitForCacheSync(stopCh, c.cacheSyncs) {
 		return
 	}
 
@@ -181,7 +183,7 @@ func (c *BackingImageDataSourceController) processNextWorkItem() bool {
 	}
 	defer c.queue.Done(key)
 
-	err := c.syncBackingImageDataSource(key.(string))
+	err := c.syncWithPanicRecovery(key.(string), c.syncBackingImageDataSource)
 	c.handleErr(err, key)
 
 	return true
@@ -193,7 +195,7 @@ func (c *BackingImageDataSourceController) handleErr(err error, key interface{})
 		return
 	}
 
-	if c.queue.NumRequeues(key) < maxRetries {
+	if c.queue.NumRequeues(key) < MaxRetries {
 		logrus.WithError(err).Warnf("Error syncing Longhorn backing image data source %v", key)
 		c.queue.AddRateLimited(key)
 		return
@@ -52,7 +52,7 @@ type BackingImageManagerController struct {
 
 	ds *datastore.DataStore
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 
 	lock       *sync.RWMutex
 	monitorMap map[string]chan struct{}
@@ -114,7 +114,7 @@ func NewBackingImageManagerController(
 		serviceAccount: serviceAccount,
 
 		kubeClient:    kubeClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-backing-image-manager-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-backing-image-manager-controller"})),
 
 		ds: ds,
 
@@ -124,6 +124,8 @@ func NewBackingImageManagerController(
 		monitorMap: map[string]chan struct{}{},
 
 		versionUpdater: updateBackingImageManagerVersion,
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	ds.BackingImageManagerInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -131,20 +133,20 @@ func NewBackingImageManagerController(
 		UpdateFunc: func(old, cur interface{}) { c.enqueueBackingImageManager(cur) },
 		DeleteFunc: c.enqueueBackingImageManager,
 	})
-	c.cacheSyncs = append(c.cacheSyncs, ds.BackingImageManagerInformer.HasSynced)
+	c.cacheSyncs["BackingImageManagerInformer"] = ds.BackingImageManagerInformer.HasSynced
 
 	ds.BackingImageInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc:    c.enqueueForBackingImage,
 		UpdateFunc: func(old, cur interface{}) { c.enqueueForBackingImage(cur) },
 		DeleteFunc: c.enqueueForBackingImage,
 	}, 0)
-	c.cacheSyncs = append(c.cacheSyncs, ds.BackingImageInformer.HasSynced)
+	c.cacheSyncs["BackingImageInformer"] = ds.BackingImageInformer.HasSynced
 
 	ds.NodeInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		UpdateFunc: func(oldObj, cur interface{}) { c.enqueueForLonghornNode(cur) },
 		DeleteFunc: c.enqueueForLonghornNode,
 	}, 0)
-	c.cacheSyncs = append(c.cacheSyncs, ds.NodeInformer.HasSynced)
+	c.cacheSyncs["NodeInformer"] = ds.NodeInformer.HasSynced
 
 	ds.PodInformer.AddEventHandlerWithResyncPeriod(cache.FilteringResourceEventHandler{
 		FilterFunc: isBackingImageManagerPod,
@@ -154,19 +156,19 @@ func NewBackingImageManagerController(
 			DeleteFunc: c.enqueueForBackingImageManagerPod,
 		},
 	}, 0)
-	c.cacheSyncs = append(c.cacheSyncs, ds.PodInformer.HasSynced)
+	c.cacheSyncs["PodInformer"] = ds.PodInformer.HasSynced
 
 	return c
 }
 
 func (c *BackingImageManagerController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer c.queue.ShutDown()
+	defer c.queue.ShutDownWithDrain()
 
 	logrus.Infof("Starting Longhorn backing image manager controller")
 	defer logrus.Infof("Shut down Longhorn backing image manager controller")
 
-	if !cache.WaitForNamedCacheSync("longhorn backing image manager", stopCh, c.cacheSyncs...) {
+	if !c.waitForCacheSync(stopCh, c.cacheSyncs) {
 		return
 	}
 
@@ -190,7 +192,7 @@ func (c *BackingImageManagerController) processNextWorkItem() bool {
 	}
 	defer c.queue.Done(key)
 
-	err := c.syncBackingImageManager(key.(string))
+	err := c.syncWithPanicRecovery(key.(string), c.syncBackingImageManager)
 	c.handleErr(err, key)
 
 	return true
@@ -202,7 +204,7 @@ func (c *BackingImageManagerController) handleErr(err error, key interface{}) {
 		return
 	}
 
-	if c.queue.NumRequeues(key) < maxRetries {
+	if c.queue.NumRequeues(key) < MaxRetries {
 		logrus.WithError(err).Warnf("Error syncing Longhorn backing image manager %v", key)
 		c.queue.AddRateLimited(key)
 		return
@@ -35,13 +35,20 @@ import (
 )
 
 var (
-	// maxRetriesOnAcquireLockError should guarantee the cumulative retry time
-	// is larger than 150 seconds.
-	// With the current rate-limiter in use (5ms*2^(maxRetries-1)) the following numbers represent the times
-	// a deployment is going to be requeued:
-	//
-	// 5ms, 10ms, 20ms, ... , 81.92s, 163.84s
-	maxRetriesOnAcquireLockError = 16
+	// backupLockAcquisitionTimeout is how long the backup lock acquisition
+	// waits before timing out. The retries on the lock acquisition failure
+	// should last longer than it, see getMaxRetriesOnAcquireLockError.
+	backupLockAcquisitionTimeout = 150 * time.Second
+
+	// backupLimitRequeueInterval is how often a backup waiting for the
+	// concurrent backup limit checks whether it can start
+	backupLimitRequeueInterval = 10 * time.Second
+)
+
+const (
+	// BackupMessageKeyWaitingForLimit marks a pending backup that has not
+	// started because of the concurrent backup limit
+	BackupMessageKeyWaitingForLimit = "waitingForConcurrentBackupLimit"
 )
 
 type BackupController struct {
@@ -60,7 +67,7 @@ type BackupController struct {
 
 	ds *datastore.DataStore
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 
 	proxyConnCounter util.Counter
 }
@@ -93,9 +100,11 @@ func NewBackupController(
 		ds: ds,
 
 		kubeClient:    kubeClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-backup-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-backup-controller"})),
 
 		proxyConnCounter: proxyConnCounter,
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	ds.BackupInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -103,7 +112,7 @@ func NewBackupController(
 		UpdateFunc: func(old, cur interface{}) { bc.enqueueBackup(cur) },
 		DeleteFunc: bc.enqueueBackup,
 	})
-	bc.cacheSyncs = append(bc.cacheSyncs, ds.BackupInformer.HasSynced)
+	bc.cacheSyncs["BackupInformer"] = ds.BackupInformer.HasSynced
 
 	return bc
 }
@@ -118,18 +127,28 @@ func (bc *BackupController) enqueueBackup(obj interface{}) {
 	bc.queue.Add(key)
 }
 
+func (bc *BackupController) enqueueBackupAfter(obj interface{}, delay time.Duration) {
+	key, err := controller.KeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't get key for object %#v: %v", obj, err))
+		return
+	}
+
+	bc.queue.AddAfter(key, delay)
+}
+
 func (bc *BackupController) enqueueBackupForMonitor(key string) {
 	bc.queue.Add(key)
 }
 
 func (bc *BackupController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer bc.queue.ShutDown()
+	defer bc.queue.ShutDownWithDrain()
 
 	bc.logger.Infof("Starting Longhorn Backup controller")
 	defer bc.logger.Infof("Shut down Longhorn Backup controller")
 
-	if !cache.WaitForNamedCacheSync(bc.name, stopCh, bc.cacheSyncs...) {
+	if !bc.waitForCacheSync(stopCh, bc.cacheSyncs) {
 		return
 	}
 	for i := 0; i < workers; i++ {
@@ -149,7 +168,7 @@ func (bc *BackupController) processNextWorkItem() bool {
 		return false
 	}
 	defer bc.queue.Done(key)
-	err := bc.syncHandler(key.(string))
+	err := bc.syncWithPanicRecovery(key.(string), bc.syncHandler)
 	bc.handleErr(err, key)
 	return true
 }
@@ -160,19 +179,19 @@ func (bc *BackupController) handleErr(err error, key interface{}) {
 		return
 	}
 
-	// The resync period of the backup is one hour and the maxRetries is 3.
+	// The resync period of the backup is one hour and the MaxRetries is 3.
 	// Thus, the deletion failure of the backup in error state is caused by the shutdown of the replica during backing up,
 	// if the lock hold by the backup job is not released.
-	// The workaround is to increase the maxRetries number and to retry the deletion until the lock acquisition
+	// The workaround is to increase the MaxRetries number and to retry the deletion until the lock acquisition
 	// of the backup is timeout after 150 seconds.
 	if strings.Contains(err.Error(), "failed lock") {
-		if bc.queue.NumRequeues(key) < maxRetriesOnAcquireLockError {
+		if bc.queue.NumRequeues(key) < getMaxRetriesOnAcquireLockError() {
 			bc.logger.WithError(err).Warnf("Error syncing Longhorn backup %v because of the failure of lock acquisition", key)
 			bc.queue.AddRateLimited(key)
 			return
 		}
 	} else {
-		if bc.queue.NumRequeues(key) < maxRetries {
+		if bc.queue.NumRequeues(key) < MaxRetries {
 			bc.logger.WithError(err).Warnf("Error syncing Longhorn backup %v", key)
 			bc.queue.AddRateLimited(key)
 			return
@@ -184,6 +203,24 @@ func (bc *BackupController) handleErr(err error, key interface{}) {
 	bc.queue.Forget(key)
 }
 
+// getMaxRetriesOnAcquireLockError returns the number of retries whose
+// cumulative delay is larger than backupLockAcquisitionTimeout. The delays
+// follow the exponential backoff of the controller queues, which is
+// configurable. With the default base delay of 5ms the backup is requeued
+// after 5ms, 10ms, 20ms, ... , 40.96s and 81.92s, 15 times in total.
+func getMaxRetriesOnAcquireLockError() int {
+	retries := 0
+	cumulativeDelay := time.Duration(0)
+	for delay := RateLimiterBaseDelay; cumulativeDelay <= backupLockAcquisitionTimeout; retries++ {
+		cumulativeDelay += delay
+		delay *= 2
+		if delay > RateLimiterMaxDelay {
+			delay = RateLimiterMaxDelay
+		}
+	}
+	return retries
+}
+
 func (bc *BackupController) syncHandler(key string) (err error) {
 	defer func() {
 		err = errors.Wrapf(err, "%v: failed to sync backup %v", bc.name, key)
@@ -375,6 +412,24 @@ func (bc *BackupController) reconcile(backupName string) (err error) {
 			bc.syncBackupStatusWithSnapshotCreationTimeAndVolumeSize(volume, backup)
 		}
 
+		if bc.hasMonitor(backup.Name) == nil &&
+			(backup.Status.State == longhorn.BackupStateNew || isBackupWaitingForLimit(backup)) {
+			limitReached, err := bc.isConcurrentBackupLimitReached(backup)
+			if err != nil {
+				return err
+			}
+			if limitReached {
+				backup.Status.State = longhorn.BackupStatePending
+				backup.Status.Messages = map[string]string{
+					BackupMessageKeyWaitingForLimit: fmt.Sprintf("Waiting for running backups to finish since the %v is reached", types.SettingNameConcurrentBackupLimit),
+				}
+				bc.enqueueBackupAfter(backup, backupLimitRequeueInterval)
+				return nil
+			}
+			backup.Status.State = longhorn.BackupStateNew
+			delete(backup.Status.Messages, BackupMessageKeyWaitingForLimit)
+		}
+
 		monitor, err := bc.checkMonitor(backup, volume, backupTarget)
 		if err != nil {
 			return err
@@ -443,6 +498,61 @@ func (bc *BackupController) reconcile(backupName string) (err error) {
 	return nil
 }
 
+func isBackupWaitingForLimit(backup *longhorn.Backup) bool {
+	if backup.Status.State != longhorn.BackupStatePending {
+		return false
+	}
+	_, ok := backup.Status.Messages[BackupMessageKeyWaitingForLimit]
+	return ok
+}
+
+// isConcurrentBackupLimitReached checks if the backup has to keep waiting for
+// the concurrent backup limit. The backups of all nodes are counted by their
+// states, and the new or waiting backups created earlier start first. The
+// backups owned by a down node are not counted since they cannot make any
+// progress, otherwise they would block the younger backups forever.
+func (bc *BackupController) isConcurrentBackupLimitReached(backup *longhorn.Backup) (bool, error) {
+	limit, err := bc.ds.GetSettingAsInt(types.SettingNameConcurrentBackupLimit)
+	if err != nil {
+		return false, err
+	}
+	if limit <= 0 {
+		return false, nil
+	}
+
+	backups, err := bc.ds.ListBackupsRO()
+	if err != nil {
+		return false, err
+	}
+
+	count := int64(0)
+	for _, b := range backups {
+		if b.Name == backup.Name || !b.DeletionTimestamp.IsZero() ||
+			!b.Status.LastSyncedAt.IsZero() || b.Spec.SnapshotName == "" || b.Status.OwnerID == "" {
+			continue
+		}
+		switch {
+		case b.Status.State == longhorn.BackupStateNew, isBackupWaitingForLimit(b):
+			if !b.CreationTimestamp.Before(&backup.CreationTimestamp) &&
+				!(b.CreationTimestamp.Equal(&backup.CreationTimestamp) && b.Name < backup.Name) {
+				continue
+			}
+		case b.Status.State == longhorn.BackupStatePending, b.Status.State == longhorn.BackupStateInProgress:
+		default:
+			continue
+		}
+		isOwnerDown, err := bc.ds.IsNodeDownOrDeleted(b.Status.OwnerID)
+		if err != nil {
+			return false, err
+		}
+		if isOwnerDown {
+			continue
+		}
+		count++
+	}
+	return count >= limit, nil
+}
+
 func (bc *BackupController) isResponsibleFor(b *longhorn.Backup, defaultEngineImage string) (bool, error) {
 	var err error
 	defer func() {
@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kubernetes/pkg/controller"
+
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/longhorn/longhorn-manager/datastore"
+	"github.com/longhorn/longhorn-manager/engineapi"
+	"github.com/longhorn/longhorn-manager/types"
+	"github.com/longhorn/longhorn-manager/util"
+
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
+	lhfake "github.com/longhorn/longhorn-manager/k8s/pkg/client/clientset/versioned/fake"
+	lhinformers "github.com/longhorn/longhorn-manager/k8s/pkg/client/informers/externalversions"
+
+	. "gopkg.in/check.v1"
+)
+
+func newBackupForConcurrentLimit(name string, createdAt time.Time, state longhorn.BackupState, waiting bool) *longhorn.Backup {
+	backup := &longhorn.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         TestNamespace,
+			CreationTimestamp: metav1.Time{Time: createdAt},
+		},
+		Spec: longhorn.BackupSpec{
+			SnapshotName: name + "-snapshot",
+		},
+		Status: longhorn.BackupStatus{
+			OwnerID: TestNode1,
+			State:   state,
+		},
+	}
+	if waiting {
+		backup.Status.Messages = map[string]string{
+			BackupMessageKeyWaitingForLimit: "",
+		}
+	}
+	return backup
+}
+
+func (s *TestSuite) TestIsConcurrentBackupLimitReached(c *C) {
+	now := time.Now()
+	earlier := now.Add(-time.Minute)
+
+	type testCase struct {
+		limit     string
+		backups   []*longhorn.Backup
+		downNodes []string
+
+		expectedLimitReached bool
+	}
+	testCases := map[string]testCase{
+		"limit disabled": {
+			limit: "0",
+			backups: []*longhorn.Backup{
+				newBackupForConcurrentLimit("running", earlier, longhorn.BackupStateInProgress, false),
+			},
+			expectedLimitReached: false,
+		},
+		"running backups below the limit": {
+			limit: "2",
+			backups: []*longhorn.Backup{
+				newBackupForConcurrentLimit("running", earlier, longhorn.BackupStateInProgress, false),
+			},
+			expectedLimitReached: false,
+		},
+		"running backups reach the limit": {
+			limit: "2",
+			backups: []*longhorn.Backup{
+				newBackupForConcurrentLimit("running-1", earlier, longhorn.BackupStateInProgress, false),
+				newBackupForConcurrentLimit("running-2", earlier, longhorn.BackupStatePending, false),
+			},
+			expectedLimitReached: true,
+		},
+		"finished backups are not counted": {
+			limit: "1",
+			backups: []*longhorn.Backup{
+				newBackupForConcurrentLimit("completed", earlier, longhorn.BackupStateCompleted, false),
+				newBackupForConcurrentLimit("error", earlier, longhorn.BackupStateError, false),
+			},
+			expectedLimitReached: false,
+		},
+		"new backups created earlier are counted": {
+			limit: "1",
+			backups: []*longhorn.Backup{
+				newBackupForConcurrentLimit("started", earlier, longhorn.BackupStateNew, false),
+			},
+			expectedLimitReached: true,
+		},
+		"new backups created later are not counted": {
+			limit: "1",
+			backups: []*longhorn.Backup{
+				newBackupForConcurrentLimit("started", now.Add(time.Minute), longhorn.BackupStateNew, false),
+			},
+			expectedLimitReached: false,
+		},
+		"backups owned by a down node are not counted": {
+			limit: "1",
+			backups: []*longhorn.Backup{
+				newBackupForConcurrentLimit("running", earlier, longhorn.BackupStateInProgress, false),
+				newBackupForConcurrentLimit("waiting", earlier, longhorn.BackupStatePending, true),
+			},
+			downNodes:            []string{TestNode1},
+			expectedLimitReached: false,
+		},
+		"backups waiting longer start first": {
+			limit: "1",
+			backups: []*longhorn.Backup{
+				newBackupForConcurrentLimit("waiting", earlier, longhorn.BackupStatePending, true),
+			},
+			expectedLimitReached: true,
+		},
+		"backups waiting shorter start later": {
+			limit: "1",
+			backups: []*longhorn.Backup{
+				newBackupForConcurrentLimit("waiting", now.Add(time.Minute), longhorn.BackupStatePending, true),
+			},
+			expectedLimitReached: false,
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		kubeClient := fake.NewSimpleClientset()
+		kubeInformerFactory := informers.NewSharedInformerFactory(kubeClient, controller.NoResyncPeriodFunc())
+
+		lhClient := lhfake.NewSimpleClientset()
+		lhInformerFactory := lhinformers.NewSharedInformerFactory(lhClient, controller.NoResyncPeriodFunc())
+
+		extensionsClient := apiextensionsfake.NewSimpleClientset()
+
+		sIndexer := lhInformerFactory.Longhorn().V1beta2().Settings().Informer().GetIndexer()
+		bIndexer := lhInformerFactory.Longhorn().V1beta2().Backups().Informer().GetIndexer()
+		nIndexer := lhInformerFactory.Longhorn().V1beta2().Nodes().Informer().GetIndexer()
+
+		ds, err := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+		c.Assert(err, IsNil)
+		bc := &BackupController{
+			monitors: map[string]*engineapi.BackupMonitor{},
+			ds:       ds,
+		}
+
+		err = sIndexer.Add(newSetting(string(types.SettingNameConcurrentBackupLimit), tc.limit))
+		c.Assert(err, IsNil)
+		for _, backup := range tc.backups {
+			err = bIndexer.Add(backup)
+			c.Assert(err, IsNil)
+		}
+		node := newNode(TestNode1, TestNamespace, true, longhorn.ConditionStatusTrue, "")
+		if util.Contains(tc.downNodes, TestNode1) {
+			node = newNode(TestNode1, TestNamespace, true, longhorn.ConditionStatusFalse, string(longhorn.NodeConditionReasonKubernetesNodeGone))
+		}
+		err = nIndexer.Add(node)
+		c.Assert(err, IsNil)
+
+		backup := newBackupForConcurrentLimit(TestBackupName, now, longhorn.BackupStateNew, false)
+		limitReached, err := bc.isConcurrentBackupLimitReached(backup)
+		c.Assert(err, IsNil)
+		c.Assert(limitReached, Equals, tc.expectedLimitReached, Commentf("test case: %v", name))
+	}
+}
+
+func (s *TestSuite) TestGetMaxRetriesOnAcquireLockError(c *C) {
+	type testCase struct {
+		baseDelay time.Duration
+		maxDelay  time.Duration
+
+		expectedMaxRetries int
+	}
+	testCases := map[string]testCase{
+		"default backoff": {
+			baseDelay:          5 * time.Millisecond,
+			maxDelay:           1000 * time.Second,
+			expectedMaxRetries: 15,
+		},
+		"larger base delay": {
+			baseDelay:          1 * time.Second,
+			maxDelay:           1000 * time.Second,
+			expectedMaxRetries: 8,
+		},
+		"max delay reached": {
+			baseDelay:          1 * time.Second,
+			maxDelay:           10 * time.Second,
+			expectedMaxRetries: 18,
+		},
+	}
+
+	defaultBaseDelay, defaultMaxDelay := RateLimiterBaseDelay, RateLimiterMaxDelay
+	defer func() {
+		RateLimiterBaseDelay, RateLimiterMaxDelay = defaultBaseDelay, defaultMaxDelay
+	}()
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		RateLimiterBaseDelay, RateLimiterMaxDelay = tc.baseDelay, tc.maxDelay
+		c.Assert(getMaxRetriesOnAcquireLockError(), Equals, tc.expectedMaxRetries, Commentf("test case: %v", name))
+	}
+}
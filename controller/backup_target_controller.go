@@ -24,6 +24,7 @@ import (
 
 	systembackupstore "github.com/longhorn/backupstore/systembackup"
 
+	"github.com/longhorn/longhorn-manager/constant"
 	"github.com/longhorn/longhorn-manager/datastore"
 	"github.com/longhorn/longhorn-manager/engineapi"
 	"github.com/longhorn/longhorn-manager/types"
@@ -45,7 +46,7 @@ type BackupTargetController struct {
 
 	ds *datastore.DataStore
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 
 	proxyConnCounter util.Counter
 }
@@ -74,16 +75,18 @@ func NewBackupTargetController(
 		ds: ds,
 
 		kubeClient:    kubeClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-backup-target-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-backup-target-controller"})),
 
 		proxyConnCounter: proxyConnCounter,
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	ds.BackupTargetInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    btc.enqueueBackupTarget,
 		UpdateFunc: func(old, cur interface{}) { btc.enqueueBackupTarget(cur) },
 	})
-	btc.cacheSyncs = append(btc.cacheSyncs, ds.BackupTargetInformer.HasSynced)
+	btc.cacheSyncs["BackupTargetInformer"] = ds.BackupTargetInformer.HasSynced
 
 	ds.EngineImageInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		UpdateFunc: func(old, cur interface{}) {
@@ -98,7 +101,7 @@ func NewBackupTargetController(
 			btc.enqueueEngineImage(cur)
 		},
 	}, 0)
-	btc.cacheSyncs = append(btc.cacheSyncs, ds.EngineImageInformer.HasSynced)
+	btc.cacheSyncs["EngineImageInformer"] = ds.EngineImageInformer.HasSynced
 
 	return btc
 }
@@ -132,12 +135,12 @@ func (btc *BackupTargetController) enqueueEngineImage(obj interface{}) {
 
 func (btc *BackupTargetController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer btc.queue.ShutDown()
+	defer btc.queue.ShutDownWithDrain()
 
 	btc.logger.Infof("Starting Longhorn Backup Target controller")
 	defer btc.logger.Infof("Shut down Longhorn Backup Target controller")
 
-	if !cache.WaitForNamedCacheSync(btc.name, stopCh, btc.cacheSyncs...) {
+	if !btc.waitForCacheSync(stopCh, btc.cacheSyncs) {
 		return
 	}
 	for i := 0; i < workers; i++ {
@@ -157,7 +160,7 @@ func (btc *BackupTargetController) processNextWorkItem() bool {
 		return false
 	}
 	defer btc.queue.Done(key)
-	err := btc.syncHandler(key.(string))
+	err := btc.syncWithPanicRecovery(key.(string), btc.syncHandler)
 	btc.handleErr(err, key)
 	return true
 }
@@ -190,7 +193,7 @@ func (btc *BackupTargetController) handleErr(err error, key interface{}) {
 		return
 	}
 
-	if btc.queue.NumRequeues(key) < maxRetries {
+	if btc.queue.NumRequeues(key) < MaxRetries {
 		btc.logger.WithError(err).Warnf("Error syncing Longhorn backup target %v", key)
 		btc.queue.AddRateLimited(key)
 		return
@@ -361,6 +364,7 @@ func (btc *BackupTargetController) reconcile(name string) (err error) {
 			longhorn.BackupTargetConditionTypeUnavailable, longhorn.ConditionStatusTrue,
 			longhorn.BackupTargetConditionReasonUnavailable, err.Error())
 		log.WithError(err).Error("Error init backup target clients")
+		btc.eventRecorder.Eventf(backupTarget, v1.EventTypeWarning, constant.EventReasonFailedSyncing, "Failed to init backup target client: %v", err)
 		return nil // Ignore error to allow status update as well as preventing enqueue
 	}
 	defer engineClientProxy.Close()
@@ -399,6 +403,7 @@ func (btc *BackupTargetController) syncBackupVolume(backupTarget *longhorn.Backu
 			longhorn.BackupTargetConditionTypeUnavailable, longhorn.ConditionStatusTrue,
 			longhorn.BackupTargetConditionReasonUnavailable, err.Error())
 		log.WithError(err).Error("Error listing backup volumes from backup target")
+		btc.eventRecorder.Eventf(backupTarget, v1.EventTypeWarning, constant.EventReasonFailedSyncing, "Failed to list backup volumes from backup target %v: %v", backupTarget.Spec.BackupTargetURL, err)
 		return nil // Ignore error to allow status update as well as preventing enqueue
 	}
 	backupStoreBackupVolumes := sets.NewString(res...)
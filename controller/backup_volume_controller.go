@@ -43,7 +43,7 @@ type BackupVolumeController struct {
 
 	ds *datastore.DataStore
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 
 	proxyConnCounter util.Counter
 }
@@ -73,9 +73,11 @@ func NewBackupVolumeController(
 		ds: ds,
 
 		kubeClient:    kubeClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-backup-volume-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-backup-volume-controller"})),
 
 		proxyConnCounter: proxyConnCounter,
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	ds.BackupVolumeInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -83,7 +85,7 @@ func NewBackupVolumeController(
 		UpdateFunc: func(old, cur interface{}) { bvc.enqueueBackupVolume(cur) },
 		DeleteFunc: bvc.enqueueBackupVolume,
 	})
-	bvc.cacheSyncs = append(bvc.cacheSyncs, ds.BackupVolumeInformer.HasSynced)
+	bvc.cacheSyncs["BackupVolumeInformer"] = ds.BackupVolumeInformer.HasSynced
 
 	return bvc
 }
@@ -100,12 +102,12 @@ func (bvc *BackupVolumeController) enqueueBackupVolume(obj interface{}) {
 
 func (bvc *BackupVolumeController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer bvc.queue.ShutDown()
+	defer bvc.queue.ShutDownWithDrain()
 
 	bvc.logger.Infof("Starting Longhorn Backup Volume controller")
 	defer bvc.logger.Infof("Shut down Longhorn Backup Volume controller")
 
-	if !cache.WaitForNamedCacheSync(bvc.name, stopCh, bvc.cacheSyncs...) {
+	if !bvc.waitForCacheSync(stopCh, bvc.cacheSyncs) {
 		return
 	}
 	for i := 0; i < workers; i++ {
@@ -125,7 +127,7 @@ func (bvc *BackupVolumeController) processNextWorkItem() bool {
 		return false
 	}
 	defer bvc.queue.Done(key)
-	err := bvc.syncHandler(key.(string))
+	err := bvc.syncWithPanicRecovery(key.(string), bvc.syncHandler)
 	bvc.handleErr(err, key)
 	return true
 }
@@ -152,7 +154,7 @@ func (bvc *BackupVolumeController) handleErr(err error, key interface{}) {
 		return
 	}
 
-	if bvc.queue.NumRequeues(key) < maxRetries {
+	if bvc.queue.NumRequeues(key) < MaxRetries {
 		bvc.logger.WithError(err).Warnf("Error syncing Longhorn backup volume %v", key)
 		bvc.queue.AddRateLimited(key)
 		return
@@ -277,6 +279,7 @@ func (bvc *BackupVolumeController) reconcile(backupVolumeName string) (err error
 		return nil // Ignore error to prevent enqueue
 	}
 	backupStoreBackups := sets.NewString(res...)
+	backupVolume.Status.BackupCount = backupStoreBackups.Len()
 
 	// Get a list of all the backups that exist as custom resources in the cluster
 	clusterBackups, err := bvc.ds.ListBackupsWithBackupVolumeName(backupVolumeName)
@@ -1,18 +1,31 @@
 package controller
 
 import (
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"time"
+
 	"github.com/sirupsen/logrus"
 
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 )
 
+const (
+	// cacheSyncPollPeriod is the interval of checking whether the caches
+	// are synced, the same as the one used by cache.WaitForCacheSync
+	cacheSyncPollPeriod = 100 * time.Millisecond
+)
+
 var (
-	// maxRetries is the number of times a deployment will be retried before it is dropped out of the queue.
-	// With the current rate-limiter in use (5ms*2^(maxRetries-1)) the following numbers represent the times
+	// MaxRetries is the number of times a deployment will be retried before it is dropped out of the queue.
+	// With the default rate-limiter in use (5ms*2^(MaxRetries-1)) the following numbers represent the times
 	// a deployment is going to be requeued:
 	//
 	// 5ms, 10ms, 20ms
-	maxRetries = 3
+	MaxRetries = 3
 )
 
 type baseController struct {
@@ -36,3 +49,51 @@ func newBaseControllerWithQueue(name string, logger logrus.FieldLogger,
 
 	return c
 }
+
+// syncWithPanicRecovery calls the sync handler with the key and converts a
+// panic in it into an error, so that the object is requeued by handleErr
+// instead of crashing the worker goroutine.
+func (c *baseController) syncWithPanicRecovery(key string, sync func(string) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.logger.WithField("key", key).Errorf("Recovered from panic: %v\n%s", r, debug.Stack())
+			err = fmt.Errorf("%v: panic while syncing %v: %v", c.name, key, r)
+		}
+	}()
+	return sync(key)
+}
+
+// waitForCacheSync waits until all the caches the controller depends on are
+// synced, so that the controller doesn't act on a partial view of the cluster
+// and report spurious not found errors. The caches are named by the informers,
+// so that the logs tell which one delays the startup or fails to sync.
+func (c *baseController) waitForCacheSync(stopCh <-chan struct{}, cacheSyncs map[string]cache.InformerSynced) bool {
+	c.logger.Info("Waiting for caches to sync")
+
+	startedAt := time.Now()
+	synced := make(map[string]bool, len(cacheSyncs))
+	lastSynced := ""
+	err := wait.PollImmediateUntil(cacheSyncPollPeriod, func() (bool, error) {
+		for name, cacheSync := range cacheSyncs {
+			if !synced[name] && cacheSync() {
+				synced[name] = true
+				lastSynced = name
+			}
+		}
+		return len(synced) == len(cacheSyncs), nil
+	}, stopCh)
+	if err != nil {
+		notSynced := []string{}
+		for name := range cacheSyncs {
+			if !synced[name] {
+				notSynced = append(notSynced, name)
+			}
+		}
+		sort.Strings(notSynced)
+		c.logger.WithError(err).Errorf("Failed to sync caches %v of %v caches", notSynced, len(cacheSyncs))
+		return false
+	}
+
+	c.logger.Infof("Synced %v caches in %v, cache %v synced last", len(cacheSyncs), time.Since(startedAt), lastSynced)
+	return true
+}
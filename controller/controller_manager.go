@@ -5,6 +5,7 @@ import (
 	"math"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -33,9 +34,14 @@ import (
 var (
 	Workers              = 5
 	longhornFinalizerKey = longhorn.SchemeGroupVersion.Group
+
+	// RateLimiterBaseDelay and RateLimiterMaxDelay are the base and the max
+	// delay of the per-item exponential backoff of the controller queues.
+	RateLimiterBaseDelay = 5 * time.Millisecond
+	RateLimiterMaxDelay  = 1000 * time.Second
 )
 
-func StartControllers(logger logrus.FieldLogger, stopCh chan struct{}, controllerID, serviceAccount, managerImage, kubeconfigPath, version string, proxyConnCounter util.Counter) (*datastore.DataStore, *WebsocketController, error) {
+func StartControllers(logger logrus.FieldLogger, stopCh chan struct{}, wg *sync.WaitGroup, controllerID, serviceAccount, managerImage, kubeconfigPath, version string, proxyConnCounter util.Counter) (*datastore.DataStore, *WebsocketController, error) {
 	namespace := os.Getenv(types.EnvPodNamespace)
 	if namespace == "" {
 		logrus.Warnf("Cannot detect pod namespace, environment variable %v is missing, "+
@@ -80,7 +86,10 @@ func StartControllers(logger logrus.FieldLogger, stopCh chan struct{}, controlle
 	kubeInformerFactory := informers.NewSharedInformerFactory(kubeClient, time.Second*30)
 	lhInformerFactory := lhinformers.NewSharedInformerFactory(lhClient, time.Second*30)
 
-	ds := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, namespace)
+	ds, err := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, namespace)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to create datastore")
+	}
 
 	rc := NewReplicaController(logger, ds, scheme, kubeClient, namespace, controllerID)
 	ec := NewEngineController(logger, ds, scheme, kubeClient, &engineapi.EngineCollection{}, namespace, controllerID, proxyConnCounter)
@@ -99,7 +108,7 @@ func StartControllers(logger logrus.FieldLogger, stopCh chan struct{}, controlle
 	bidsc := NewBackingImageDataSourceController(logger, ds, scheme, kubeClient, namespace, controllerID, serviceAccount, proxyConnCounter)
 	rjc := NewRecurringJobController(logger, ds, scheme, kubeClient, namespace, controllerID, serviceAccount, managerImage)
 	oc := NewOrphanController(logger, ds, scheme, kubeClient, controllerID, namespace)
-	snapc := NewSnapshotController(logger, ds, scheme, kubeClient, namespace, controllerID, &engineapi.EngineCollection{}, proxyConnCounter)
+	snapc := NewSnapshotController(logger, ds, scheme, kubeClient, config, namespace, controllerID, &engineapi.EngineCollection{}, proxyConnCounter)
 	bundlec := NewSupportBundleController(logger, ds, scheme, kubeClient, controllerID, namespace, serviceAccount)
 	sbc := NewSystemBackupController(logger, ds, scheme, kubeClient, namespace, controllerID, managerImage)
 	src := NewSystemRestoreController(logger, ds, scheme, kubeClient, namespace, controllerID)
@@ -115,38 +124,48 @@ func StartControllers(logger logrus.FieldLogger, stopCh chan struct{}, controlle
 	if !ds.Sync(stopCh) {
 		return nil, nil, fmt.Errorf("datastore cache sync up failed")
 	}
-	go rc.Run(Workers, stopCh)
-	go ec.Run(Workers, stopCh)
-	go vc.Run(Workers, stopCh)
-	go ic.Run(Workers, stopCh)
-	go nc.Run(Workers, stopCh)
-	go ws.Run(stopCh)
-	go sc.Run(stopCh)
-	go imc.Run(Workers, stopCh)
-	go smc.Run(Workers, stopCh)
-	go bic.Run(Workers, stopCh)
-	go bimc.Run(Workers, stopCh)
-	go bidsc.Run(Workers, stopCh)
-	go btc.Run(Workers, stopCh)
-	go bvc.Run(Workers, stopCh)
-	go bc.Run(Workers, stopCh)
-	go rjc.Run(Workers, stopCh)
-	go oc.Run(Workers, stopCh)
-	go snapc.Run(Workers, stopCh)
-	go bundlec.Run(Workers, stopCh)
-	go sbc.Run(Workers, stopCh)
-	go src.Run(Workers, stopCh)
-
-	go kpvc.Run(Workers, stopCh)
-	go knc.Run(Workers, stopCh)
-	go kpc.Run(Workers, stopCh)
-	go kcfmc.Run(Workers, stopCh)
-	go ksc.Run(Workers, stopCh)
-	go kpdbc.Run(Workers, stopCh)
+	runController(wg, func() { rc.Run(Workers, stopCh) })
+	runController(wg, func() { ec.Run(Workers, stopCh) })
+	runController(wg, func() { vc.Run(Workers, stopCh) })
+	runController(wg, func() { ic.Run(Workers, stopCh) })
+	runController(wg, func() { nc.Run(Workers, stopCh) })
+	runController(wg, func() { ws.Run(stopCh) })
+	runController(wg, func() { sc.Run(stopCh) })
+	runController(wg, func() { imc.Run(Workers, stopCh) })
+	runController(wg, func() { smc.Run(Workers, stopCh) })
+	runController(wg, func() { bic.Run(Workers, stopCh) })
+	runController(wg, func() { bimc.Run(Workers, stopCh) })
+	runController(wg, func() { bidsc.Run(Workers, stopCh) })
+	runController(wg, func() { btc.Run(Workers, stopCh) })
+	runController(wg, func() { bvc.Run(Workers, stopCh) })
+	runController(wg, func() { bc.Run(Workers, stopCh) })
+	runController(wg, func() { rjc.Run(Workers, stopCh) })
+	runController(wg, func() { oc.Run(Workers, stopCh) })
+	runController(wg, func() { snapc.Run(Workers, stopCh) })
+	runController(wg, func() { bundlec.Run(Workers, stopCh) })
+	runController(wg, func() { sbc.Run(Workers, stopCh) })
+	runController(wg, func() { src.Run(Workers, stopCh) })
+
+	runController(wg, func() { kpvc.Run(Workers, stopCh) })
+	runController(wg, func() { knc.Run(Workers, stopCh) })
+	runController(wg, func() { kpc.Run(Workers, stopCh) })
+	runController(wg, func() { kcfmc.Run(Workers, stopCh) })
+	runController(wg, func() { ksc.Run(Workers, stopCh) })
+	runController(wg, func() { kpdbc.Run(Workers, stopCh) })
 
 	return ds, ws, nil
 }
 
+// runController runs the controller in a goroutine tracked by wg, so the
+// caller can wait for the in-flight work items to be drained on shutdown.
+func runController(wg *sync.WaitGroup, run func()) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		run()
+	}()
+}
+
 func ParseResourceRequirement(val string) (*corev1.ResourceRequirements, error) {
 	quantity, err := resource.ParseQuantity(val)
 	if err != nil {
@@ -212,6 +231,62 @@ func GetInstanceManagerCPURequirement(ds *datastore.DataStore, imName string) (*
 	}
 }
 
+func GetInstanceManagerMemoryRequirement(ds *datastore.DataStore, imType longhorn.InstanceManagerType) (*resource.Quantity, error) {
+	var settingName types.SettingName
+	switch imType {
+	case longhorn.InstanceManagerTypeEngine:
+		settingName = types.SettingNameGuaranteedEngineManagerMemory
+	case longhorn.InstanceManagerTypeReplica:
+		settingName = types.SettingNameGuaranteedReplicaManagerMemory
+	default:
+		return nil, fmt.Errorf("unknown instance manager type %v", imType)
+	}
+
+	memSetting, err := ds.GetSetting(settingName)
+	if err != nil {
+		return nil, err
+	}
+	if memSetting.Value == "" {
+		return nil, nil
+	}
+	quantity, err := resource.ParseQuantity(memSetting.Value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse value %v to a quantity", memSetting.Value)
+	}
+	if quantity.IsZero() {
+		return nil, nil
+	}
+	return &quantity, nil
+}
+
+// GetInstanceManagerResourceRequirement returns the CPU and memory requests
+// of the instance manager pod. It returns nil if neither is set.
+func GetInstanceManagerResourceRequirement(ds *datastore.DataStore, imName string) (*corev1.ResourceRequirements, error) {
+	im, err := ds.GetInstanceManager(imName)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceReq, err := GetInstanceManagerCPURequirement(ds, imName)
+	if err != nil {
+		return nil, err
+	}
+	memRequest, err := GetInstanceManagerMemoryRequirement(ds, im.Spec.Type)
+	if err != nil {
+		return nil, err
+	}
+	if memRequest == nil {
+		return resourceReq, nil
+	}
+	if resourceReq == nil {
+		resourceReq = &corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{},
+		}
+	}
+	resourceReq.Requests[corev1.ResourceMemory] = *memRequest
+	return resourceReq, nil
+}
+
 func isControllerResponsibleFor(controllerID string, ds *datastore.DataStore, name, preferredOwnerID, currentOwnerID string) bool {
 	// we use this approach so that if there is an issue with the data store
 	// we don't accidentally transfer ownership
@@ -233,7 +308,7 @@ func isControllerResponsibleFor(controllerID string, ds *datastore.DataStore, na
 // See https://github.com/longhorn/longhorn/issues/1058 for details
 func EnhancedDefaultControllerRateLimiter() workqueue.RateLimiter {
 	return workqueue.NewMaxOfRateLimiter(
-		workqueue.NewItemExponentialFailureRateLimiter(5*time.Millisecond, 1000*time.Second),
+		workqueue.NewItemExponentialFailureRateLimiter(RateLimiterBaseDelay, RateLimiterMaxDelay),
 		// 100 qps, 1000 bucket size
 		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(100), 1000)},
 	)
@@ -249,3 +324,14 @@ func IsSameGuaranteedCPURequirement(a, b *corev1.ResourceRequirements) bool {
 	}
 	return (&aQ).Cmp(bQ) == 0
 }
+
+func IsSameGuaranteedMemoryRequirement(a, b *corev1.ResourceRequirements) bool {
+	var aQ, bQ resource.Quantity
+	if a != nil && a.Requests != nil {
+		aQ = a.Requests[corev1.ResourceMemory]
+	}
+	if b != nil && b.Requests != nil {
+		bQ = b.Requests[corev1.ResourceMemory]
+	}
+	return (&aQ).Cmp(bQ) == 0
+}
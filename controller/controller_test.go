@@ -11,6 +11,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/kubernetes/pkg/controller"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -21,6 +22,7 @@ import (
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/longhorn/longhorn-manager/constant"
 	"github.com/longhorn/longhorn-manager/engineapi"
 	"github.com/longhorn/longhorn-manager/types"
 
@@ -512,3 +514,84 @@ func (s *TestSuite) TestIsSameGuaranteedCPURequirement(c *C) {
 	a.Requests[corev1.ResourceCPU], err = resource.ParseQuantity("0.25")
 	c.Assert(IsSameGuaranteedCPURequirement(a, b), Equals, true)
 }
+
+func (s *TestSuite) TestIsSameGuaranteedMemoryRequirement(c *C) {
+	var (
+		a, b *corev1.ResourceRequirements
+		err  error
+	)
+
+	c.Assert(IsSameGuaranteedMemoryRequirement(a, b), Equals, true)
+
+	b = &corev1.ResourceRequirements{}
+	c.Assert(IsSameGuaranteedMemoryRequirement(a, b), Equals, true)
+
+	b.Requests = corev1.ResourceList{}
+	b.Requests[corev1.ResourceMemory], err = resource.ParseQuantity("0")
+	c.Assert(err, IsNil)
+	c.Assert(IsSameGuaranteedMemoryRequirement(a, b), Equals, true)
+
+	b.Requests[corev1.ResourceCPU], err = resource.ParseQuantity("250m")
+	c.Assert(err, IsNil)
+	c.Assert(IsSameGuaranteedMemoryRequirement(a, b), Equals, true)
+
+	b.Requests[corev1.ResourceMemory], err = resource.ParseQuantity("256Mi")
+	c.Assert(err, IsNil)
+	c.Assert(IsSameGuaranteedMemoryRequirement(a, b), Equals, false)
+
+	a = &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{},
+	}
+	a.Requests[corev1.ResourceMemory], err = resource.ParseQuantity("268435456")
+	c.Assert(err, IsNil)
+	c.Assert(IsSameGuaranteedMemoryRequirement(a, b), Equals, true)
+}
+
+func (s *TestSuite) TestDedupEventRecorder(c *C) {
+	fakeRecorder := record.NewFakeRecorder(10)
+	recorder := &dedupEventRecorder{
+		EventRecorder: fakeRecorder,
+		window:        time.Minute,
+		entries:       map[string]*dedupEventEntry{},
+	}
+	v := &longhorn.Volume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      TestVolumeName,
+			Namespace: TestNamespace,
+			UID:       uuid.NewUUID(),
+		},
+	}
+	key, ok := getEventDedupKey(v, constant.EventReasonFailedStarting, "failed")
+	c.Assert(ok, Equals, true)
+
+	now := time.Now()
+	message, ok := recorder.dedup(key, "failed", now)
+	c.Assert(ok, Equals, true)
+	c.Assert(message, Equals, "failed")
+
+	// Identical events within the window are suppressed
+	_, ok = recorder.dedup(key, "failed", now.Add(10*time.Second))
+	c.Assert(ok, Equals, false)
+	_, ok = recorder.dedup(key, "failed", now.Add(20*time.Second))
+	c.Assert(ok, Equals, false)
+
+	// The first event after the window carries the repeat count
+	message, ok = recorder.dedup(key, "failed", now.Add(time.Minute))
+	c.Assert(ok, Equals, true)
+	c.Assert(message, Equals, "failed (repeated 3 times in the last 1m0s)")
+
+	// Normal events are never suppressed
+	recorder.Event(v, corev1.EventTypeNormal, constant.EventReasonStart, "started")
+	recorder.Event(v, corev1.EventTypeNormal, constant.EventReasonStart, "started")
+	c.Assert(len(fakeRecorder.Events), Equals, 2)
+
+	recorder.Eventf(v, corev1.EventTypeWarning, constant.EventReasonFailedStarting, "failed to start %v", v.Name)
+	recorder.Eventf(v, corev1.EventTypeWarning, constant.EventReasonFailedStarting, "failed to start %v", v.Name)
+	c.Assert(len(fakeRecorder.Events), Equals, 3)
+
+	// The annotated events are deduplicated the same way
+	annotations := map[string]string{"key": "value"}
+	recorder.AnnotatedEventf(v, annotations, corev1.EventTypeWarning, constant.EventReasonFailedStopping, "failed to stop %v", v.Name)
+	recorder.AnnotatedEventf(v, annotations, corev1.EventTypeWarning, constant.EventReasonFailedStopping, "failed to stop %v", v.Name)
+	c.Assert(len(fakeRecorder.Events), Equals, 4)
+}
@@ -74,7 +74,7 @@ type EngineController struct {
 
 	ds *datastore.DataStore
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 
 	backoff *flowcontrol.Backoff
 
@@ -138,7 +138,7 @@ func NewEngineController(
 
 		controllerID:  controllerID,
 		kubeClient:    kubeClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-engine-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-engine-controller"})),
 
 		backoff: flowcontrol.NewBackOff(time.Second*10, time.Minute*5),
 
@@ -149,6 +149,8 @@ func NewEngineController(
 		proxyConnCounter:      proxyConnCounter,
 		restoringCounter:      util.NewAtomicCounter(),
 		restoringCounterMutex: &sync.Mutex{},
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 	ec.instanceHandler = NewInstanceHandler(ds, ec, ec.eventRecorder)
 
@@ -157,26 +159,26 @@ func NewEngineController(
 		UpdateFunc: func(old, cur interface{}) { ec.enqueueEngine(cur) },
 		DeleteFunc: ec.enqueueEngine,
 	})
-	ec.cacheSyncs = append(ec.cacheSyncs, ds.EngineInformer.HasSynced)
+	ec.cacheSyncs["EngineInformer"] = ds.EngineInformer.HasSynced
 
 	ds.InstanceManagerInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc:    ec.enqueueInstanceManagerChange,
 		UpdateFunc: func(old, cur interface{}) { ec.enqueueInstanceManagerChange(cur) },
 		DeleteFunc: ec.enqueueInstanceManagerChange,
 	}, 0)
-	ec.cacheSyncs = append(ec.cacheSyncs, ds.InstanceManagerInformer.HasSynced)
+	ec.cacheSyncs["InstanceManagerInformer"] = ds.InstanceManagerInformer.HasSynced
 
 	return ec
 }
 
 func (ec *EngineController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer ec.queue.ShutDown()
+	defer ec.queue.ShutDownWithDrain()
 
 	ec.logger.Info("Starting Longhorn engine controller")
 	defer ec.logger.Info("Shut down Longhorn engine controller")
 
-	if !cache.WaitForNamedCacheSync("longhorn engines", stopCh, ec.cacheSyncs...) {
+	if !ec.waitForCacheSync(stopCh, ec.cacheSyncs) {
 		return
 	}
 
@@ -200,7 +202,7 @@ func (ec *EngineController) processNextWorkItem() bool {
 	}
 	defer ec.queue.Done(key)
 
-	err := ec.syncEngine(key.(string))
+	err := ec.syncWithPanicRecovery(key.(string), ec.syncEngine)
 	ec.handleErr(err, key)
 
 	return true
@@ -213,7 +215,7 @@ func (ec *EngineController) handleErr(err error, key interface{}) {
 	}
 
 	log := ec.logger.WithField("engine", key)
-	if ec.queue.NumRequeues(key) < maxRetries {
+	if ec.queue.NumRequeues(key) < MaxRetries {
 		log.WithError(err).Warn("Error syncing Longhorn engine")
 		ec.queue.AddRateLimited(key)
 		return
@@ -508,10 +510,7 @@ func (ec *EngineController) DeleteInstance(obj interface{}) (err error) {
 		}
 	}
 
-	isRWXVolume := false
-	if v != nil && v.Spec.AccessMode == longhorn.AccessModeReadWriteMany && !v.Spec.Migratable {
-		isRWXVolume = true
-	}
+	isRWXVolume := v != nil && types.IsSharedVolume(v)
 
 	// For a RWX volume, the node down, for example, caused by kubelet restart, leads to share-manager pod deletion/recreation
 	// and volume detachment/attachment.
@@ -1383,28 +1382,25 @@ func checkSizeBeforeRestoration(log logrus.FieldLogger, engine *longhorn.Engine,
 		return false, err
 	}
 
-	for i := 0; i < ConflictRetryCount; i++ {
-		v, err := ds.GetVolume(engine.Spec.VolumeName)
-		if err != nil {
-			return false, err
-		}
+	v, err := ds.GetVolumeRO(engine.Spec.VolumeName)
+	if err != nil {
+		return false, err
+	}
 
-		if bvSize < v.Spec.Size {
-			return false, fmt.Errorf("engine monitor: BUG: the backup volume size %v is smaller than the size %v of the DR volume %v", bvSize, engine.Spec.VolumeSize, v.Name)
-		} else if bvSize > v.Spec.Size {
-			// TODO: Find a way to update volume.Spec.Size outside of the controller
-			// The volume controller will update `engine.Spec.VolumeSize` later then trigger expansion call
-			log.WithField("volume", v.Name).Infof("Preparing to expand the DR volume size from %v to %v", v.Spec.Size, bvSize)
-			v.Spec.Size = bvSize
-			if _, err := ds.UpdateVolume(v); err != nil {
-				if !datastore.ErrorIsConflict(err) {
-					return false, err
-				}
-				log.WithField("volume", v.Name).Debug("Retrying size update for DR volume before restore")
-				continue
+	if bvSize < v.Spec.Size {
+		return false, fmt.Errorf("engine monitor: BUG: the backup volume size %v is smaller than the size %v of the DR volume %v", bvSize, engine.Spec.VolumeSize, v.Name)
+	} else if bvSize > v.Spec.Size {
+		// TODO: Find a way to update volume.Spec.Size outside of the controller
+		// The volume controller will update `engine.Spec.VolumeSize` later then trigger expansion call
+		log.WithField("volume", v.Name).Infof("Preparing to expand the DR volume size from %v to %v", v.Spec.Size, bvSize)
+		if _, err := ds.UpdateVolumeWithRetry(v.Name, func(v *longhorn.Volume) {
+			if bvSize > v.Spec.Size {
+				v.Spec.Size = bvSize
 			}
-			return false, nil
+		}); err != nil {
+			return false, err
 		}
+		return false, nil
 	}
 
 	return true, nil
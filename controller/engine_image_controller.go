@@ -53,7 +53,7 @@ type EngineImageController struct {
 
 	ds *datastore.DataStore
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 
 	// for unit test
 	nowHandler                func() string
@@ -81,13 +81,15 @@ func NewEngineImageController(
 		serviceAccount: serviceAccount,
 
 		kubeClient:    kubeClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-engine-image-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-engine-image-controller"})),
 
 		ds: ds,
 
 		nowHandler:                util.Now,
 		engineBinaryChecker:       types.EngineBinaryExistOnHostForImage,
 		engineImageVersionUpdater: updateEngineImageVersion,
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	ds.EngineImageInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -95,33 +97,33 @@ func NewEngineImageController(
 		UpdateFunc: func(old, cur interface{}) { ic.enqueueEngineImage(cur) },
 		DeleteFunc: ic.enqueueEngineImage,
 	})
-	ic.cacheSyncs = append(ic.cacheSyncs, ds.EngineImageInformer.HasSynced)
+	ic.cacheSyncs["EngineImageInformer"] = ds.EngineImageInformer.HasSynced
 
 	ds.VolumeInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc:    func(obj interface{}) { ic.enqueueVolumes(obj) },
 		UpdateFunc: func(old, cur interface{}) { ic.enqueueVolumes(old, cur) },
 		DeleteFunc: func(obj interface{}) { ic.enqueueVolumes(obj) },
 	}, 0)
-	ic.cacheSyncs = append(ic.cacheSyncs, ds.VolumeInformer.HasSynced)
+	ic.cacheSyncs["VolumeInformer"] = ds.VolumeInformer.HasSynced
 
 	ds.DaemonSetInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc:    ic.enqueueControlleeChange,
 		UpdateFunc: func(old, cur interface{}) { ic.enqueueControlleeChange(cur) },
 		DeleteFunc: ic.enqueueControlleeChange,
 	}, 0)
-	ic.cacheSyncs = append(ic.cacheSyncs, ds.DaemonSetInformer.HasSynced)
+	ic.cacheSyncs["DaemonSetInformer"] = ds.DaemonSetInformer.HasSynced
 
 	return ic
 }
 
 func (ic *EngineImageController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer ic.queue.ShutDown()
+	defer ic.queue.ShutDownWithDrain()
 
 	ic.logger.Info("Starting Longhorn Engine Image controller")
 	defer ic.logger.Info("Shut down Longhorn Engine Image controller")
 
-	if !cache.WaitForNamedCacheSync("longhorn engine images", stopCh, ic.cacheSyncs...) {
+	if !ic.waitForCacheSync(stopCh, ic.cacheSyncs) {
 		return
 	}
 
@@ -145,7 +147,7 @@ func (ic *EngineImageController) processNextWorkItem() bool {
 	}
 	defer ic.queue.Done(key)
 
-	err := ic.syncEngineImage(key.(string))
+	err := ic.syncWithPanicRecovery(key.(string), ic.syncEngineImage)
 	ic.handleErr(err, key)
 
 	return true
@@ -158,7 +160,7 @@ func (ic *EngineImageController) handleErr(err error, key interface{}) {
 	}
 
 	log := ic.logger.WithField("engineImage", key)
-	if ic.queue.NumRequeues(key) < maxRetries {
+	if ic.queue.NumRequeues(key) < MaxRetries {
 		log.WithError(err).Warn("Error syncing Longhorn engine image")
 		ic.queue.AddRateLimited(key)
 		return
@@ -436,9 +438,9 @@ func (ic *EngineImageController) handleAutoUpgradeEngineImageToDefaultEngineImag
 	for _, vs := range limitedCandidates {
 		for _, v := range vs {
 			ic.logger.WithFields(logrus.Fields{"volume": v.Name, "engineImage": v.Spec.EngineImage}).Infof("automatically upgrade volume engine image to the default engine image %v", defaultEngineImage)
-			v.Spec.EngineImage = defaultEngineImage
-			v, err = ic.ds.UpdateVolume(v)
-			if err != nil {
+			if _, err := ic.ds.UpdateVolumeWithRetry(v.Name, func(v *longhorn.Volume) {
+				v.Spec.EngineImage = defaultEngineImage
+			}); err != nil {
 				return err
 			}
 		}
@@ -521,11 +523,7 @@ func (ic *EngineImageController) canDoLiveEngineImageUpgrade(v *longhorn.Volume,
 		ic.logger.WithError(err).Warnf("canDoLiveEngineImageUpgrade: cannot get engine image resource for engine image %v ", v.Status.CurrentImage)
 		return false
 	}
-	if oldEngineImageResource.Status.ControllerAPIVersion > newEngineImageResource.Status.ControllerAPIVersion ||
-		oldEngineImageResource.Status.ControllerAPIVersion < newEngineImageResource.Status.ControllerAPIMinVersion {
-		return false
-	}
-	return true
+	return types.CheckEngineImageLiveUpgradeCompatibility(oldEngineImageResource, newEngineImageResource) == nil
 }
 
 func updateEngineImageVersion(ei *longhorn.EngineImage) error {
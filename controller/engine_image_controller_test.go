@@ -49,12 +49,15 @@ type EngineImageControllerTestCase struct {
 }
 
 func newTestEngineImageController(lhInformerFactory lhinformerfactory.SharedInformerFactory, kubeInformerFactory informers.SharedInformerFactory,
-	lhClient *lhfake.Clientset, kubeClient *fake.Clientset, extensionsClient *apiextensionsfake.Clientset) *EngineImageController {
+	lhClient *lhfake.Clientset, kubeClient *fake.Clientset, extensionsClient *apiextensionsfake.Clientset) (*EngineImageController, error) {
 
 	// Skip the Lister check that occurs on creation of an Instance Manager.
 	datastore.SkipListerCheck = true
 
-	ds := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+	ds, err := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+	if err != nil {
+		return nil, err
+	}
 
 	logger := logrus.StandardLogger()
 	ic := NewEngineImageController(
@@ -71,7 +74,7 @@ func newTestEngineImageController(lhInformerFactory lhinformerfactory.SharedInfo
 	ic.engineBinaryChecker = fakeEngineBinaryChecker
 	ic.engineImageVersionUpdater = fakeEngineImageUpdater
 
-	return ic
+	return ic, nil
 }
 
 func getEngineImageControllerTestTemplate() *EngineImageControllerTestCase {
@@ -215,7 +218,8 @@ func (s *TestSuite) TestEngineImage(c *C) {
 		vIndexer := lhInformerFactory.Longhorn().V1beta2().Volumes().Informer().GetIndexer()
 		eIndexer := lhInformerFactory.Longhorn().V1beta2().Engines().Informer().GetIndexer()
 
-		ic := newTestEngineImageController(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient, extentionClient)
+		ic, err := newTestEngineImageController(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient, extentionClient)
+		c.Assert(err, IsNil)
 
 		setting, err := lhClient.LonghornV1beta2().Settings(TestNamespace).Create(context.TODO(), newSetting(string(types.SettingNameDefaultEngineImage), tc.defaultEngineImage), metav1.CreateOptions{})
 		c.Assert(err, IsNil)
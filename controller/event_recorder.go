@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+var (
+	// EventDedupWindow is the window in which identical warning events of an
+	// object are suppressed. 0 disables the deduplication.
+	EventDedupWindow = 5 * time.Minute
+)
+
+type dedupEventEntry struct {
+	firstSeen  time.Time
+	suppressed int
+}
+
+// dedupEventRecorder wraps an EventRecorder and suppresses the identical
+// (object, reason, message) warning events recorded within the dedup window.
+// Once the window has passed, the next occurrence is recorded with the number
+// of times it was repeated.
+type dedupEventRecorder struct {
+	record.EventRecorder
+
+	window time.Duration
+
+	lock    sync.Mutex
+	entries map[string]*dedupEventEntry
+}
+
+func newDedupEventRecorder(recorder record.EventRecorder) record.EventRecorder {
+	if EventDedupWindow <= 0 {
+		return recorder
+	}
+	return &dedupEventRecorder{
+		EventRecorder: recorder,
+		window:        EventDedupWindow,
+		entries:       map[string]*dedupEventEntry{},
+	}
+}
+
+func (r *dedupEventRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	if message, ok := r.filter(object, eventtype, reason, message); ok {
+		r.EventRecorder.Event(object, eventtype, reason, message)
+	}
+}
+
+func (r *dedupEventRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.Event(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r *dedupEventRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	if message, ok := r.filter(object, eventtype, reason, fmt.Sprintf(messageFmt, args...)); ok {
+		r.EventRecorder.AnnotatedEventf(object, annotations, eventtype, reason, "%s", message)
+	}
+}
+
+// filter returns the message to be recorded, and false if the event should be
+// suppressed. Only the warning events are deduplicated.
+func (r *dedupEventRecorder) filter(object runtime.Object, eventtype, reason, message string) (string, bool) {
+	if eventtype != corev1.EventTypeWarning {
+		return message, true
+	}
+
+	key, ok := getEventDedupKey(object, reason, message)
+	if !ok {
+		return message, true
+	}
+
+	return r.dedup(key, message, time.Now())
+}
+
+// dedup returns the message to be recorded, and false if the event should be
+// suppressed
+func (r *dedupEventRecorder) dedup(key, message string, now time.Time) (string, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	entry, ok := r.entries[key]
+	if ok && now.Sub(entry.firstSeen) < r.window {
+		entry.suppressed++
+		return "", false
+	}
+
+	if ok && entry.suppressed > 0 {
+		message = fmt.Sprintf("%v (repeated %v times in the last %v)", message, entry.suppressed+1, now.Sub(entry.firstSeen).Round(time.Second))
+	}
+
+	// Drop the expired entries so the map only grows with the active events
+	for k, e := range r.entries {
+		if now.Sub(e.firstSeen) >= r.window {
+			delete(r.entries, k)
+		}
+	}
+	r.entries[key] = &dedupEventEntry{firstSeen: now}
+
+	return message, true
+}
+
+func getEventDedupKey(object runtime.Object, reason, message string) (string, bool) {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return "", false
+	}
+	id := string(metadata.GetUID())
+	if id == "" {
+		id = metadata.GetNamespace() + "/" + metadata.GetName()
+	}
+	return id + "/" + reason + "/" + message, true
+}
@@ -1,9 +1,14 @@
 package controller
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/sirupsen/logrus"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
 
 	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
 	"github.com/longhorn/longhorn-manager/util"
@@ -189,3 +194,89 @@ func (s *TestSuite) TestBackingImageCleanup(c *C) {
 	BackingImageDiskFileCleanup(node, bi, bids, time.Duration(0), 0)
 	c.Assert(bi.Spec.Disks, DeepEquals, expectedBI.Spec.Disks)
 }
+
+func (s *TestSuite) TestSyncWithPanicRecovery(c *C) {
+	type testCase struct {
+		sync func(string) error
+
+		expectedErr string
+	}
+	testCases := map[string]testCase{
+		"sync succeeds": {
+			sync: func(key string) error {
+				return nil
+			},
+		},
+		"sync fails": {
+			sync: func(key string) error {
+				return fmt.Errorf("failed to sync %v", key)
+			},
+			expectedErr: "failed to sync " + TestVolumeName,
+		},
+		"sync panics": {
+			sync: func(key string) error {
+				var v *longhorn.Volume
+				return fmt.Errorf("volume %v", v.Name)
+			},
+			expectedErr: "panic while syncing " + TestVolumeName,
+		},
+	}
+
+	bc := newBaseController("test-controller", logrus.StandardLogger())
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		err := bc.syncWithPanicRecovery(TestVolumeName, tc.sync)
+		if tc.expectedErr == "" {
+			c.Assert(err, IsNil, Commentf("test case: %v", name))
+			continue
+		}
+		c.Assert(err, NotNil, Commentf("test case: %v", name))
+		c.Assert(strings.Contains(err.Error(), tc.expectedErr), Equals, true, Commentf("test case: %v, error: %v", name, err))
+	}
+}
+
+func (s *TestSuite) TestWaitForCacheSync(c *C) {
+	neverReady := func() bool { return false }
+	readyAfter := func(polls int) cache.InformerSynced {
+		count := 0
+		return func() bool {
+			count++
+			return count > polls
+		}
+	}
+
+	type testCase struct {
+		cacheSyncs map[string]cache.InformerSynced
+
+		expectedSynced bool
+	}
+	testCases := map[string]testCase{
+		"no caches": {
+			expectedSynced: true,
+		},
+		"all caches ready": {
+			cacheSyncs:     map[string]cache.InformerSynced{"VolumeInformer": alwaysReady, "NodeInformer": alwaysReady},
+			expectedSynced: true,
+		},
+		"caches become ready at different times": {
+			cacheSyncs:     map[string]cache.InformerSynced{"VolumeInformer": readyAfter(2), "NodeInformer": alwaysReady, "EngineInformer": readyAfter(1)},
+			expectedSynced: true,
+		},
+		"cache never ready": {
+			cacheSyncs:     map[string]cache.InformerSynced{"VolumeInformer": alwaysReady, "NodeInformer": neverReady},
+			expectedSynced: false,
+		},
+	}
+
+	bc := newBaseController("test-controller", logrus.StandardLogger())
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		stopCh := make(chan struct{})
+		timer := time.AfterFunc(time.Second, func() { close(stopCh) })
+		synced := bc.waitForCacheSync(stopCh, tc.cacheSyncs)
+		timer.Stop()
+		c.Assert(synced, Equals, tc.expectedSynced, Commentf("test case: %v", name))
+	}
+}
@@ -402,7 +402,8 @@ func (s *TestSuite) TestReconcileInstanceState(c *C) {
 
 		extensionsClient := apiextensionsfake.NewSimpleClientset()
 
-		h := newTestInstanceHandler(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient, extensionsClient)
+		h, err := newTestInstanceHandler(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient, extensionsClient)
+		c.Assert(err, IsNil)
 
 		ei, err := lhClient.LonghornV1beta2().EngineImages(TestNamespace).Create(context.TODO(), newEngineImage(TestEngineImage, longhorn.EngineImageStateDeployed), metav1.CreateOptions{})
 		c.Assert(err, IsNil)
@@ -459,8 +460,11 @@ func (s *TestSuite) TestReconcileInstanceState(c *C) {
 }
 
 func newTestInstanceHandler(lhInformerFactory lhinformerfactory.SharedInformerFactory, kubeInformerFactory informers.SharedInformerFactory,
-	lhClient *lhfake.Clientset, kubeClient *fake.Clientset, extensionsClient *apiextensionsfake.Clientset) *InstanceHandler {
-	ds := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+	lhClient *lhfake.Clientset, kubeClient *fake.Clientset, extensionsClient *apiextensionsfake.Clientset) (*InstanceHandler, error) {
+	ds, err := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+	if err != nil {
+		return nil, err
+	}
 	fakeRecorder := record.NewFakeRecorder(100)
-	return NewInstanceHandler(ds, &MockInstanceManagerHandler{}, fakeRecorder)
+	return NewInstanceHandler(ds, &MockInstanceManagerHandler{}, fakeRecorder), nil
 }
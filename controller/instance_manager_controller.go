@@ -49,7 +49,7 @@ type InstanceManagerController struct {
 
 	ds *datastore.DataStore
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 
 	instanceManagerMonitorMutex *sync.Mutex
 	instanceManagerMonitorMap   map[string]chan struct{}
@@ -114,7 +114,7 @@ func NewInstanceManagerController(
 		serviceAccount: serviceAccount,
 
 		kubeClient:    kubeClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-instance-manager-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-instance-manager-controller"})),
 
 		ds: ds,
 
@@ -122,6 +122,8 @@ func NewInstanceManagerController(
 		instanceManagerMonitorMap:   map[string]chan struct{}{},
 
 		versionUpdater: updateInstanceManagerVersion,
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	ds.InstanceManagerInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -129,7 +131,7 @@ func NewInstanceManagerController(
 		UpdateFunc: func(old, cur interface{}) { imc.enqueueInstanceManager(cur) },
 		DeleteFunc: imc.enqueueInstanceManager,
 	})
-	imc.cacheSyncs = append(imc.cacheSyncs, ds.InstanceManagerInformer.HasSynced)
+	imc.cacheSyncs["InstanceManagerInformer"] = ds.InstanceManagerInformer.HasSynced
 
 	ds.PodInformer.AddEventHandlerWithResyncPeriod(cache.FilteringResourceEventHandler{
 		FilterFunc: isInstanceManagerPod,
@@ -139,13 +141,13 @@ func NewInstanceManagerController(
 			DeleteFunc: imc.enqueueInstanceManagerPod,
 		},
 	}, 0)
-	imc.cacheSyncs = append(imc.cacheSyncs, ds.PodInformer.HasSynced)
+	imc.cacheSyncs["PodInformer"] = ds.PodInformer.HasSynced
 
 	ds.KubeNodeInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		UpdateFunc: func(oldObj, cur interface{}) { imc.enqueueKubernetesNode(cur) },
 		DeleteFunc: imc.enqueueKubernetesNode,
 	}, 0)
-	imc.cacheSyncs = append(imc.cacheSyncs, ds.KubeNodeInformer.HasSynced)
+	imc.cacheSyncs["KubeNodeInformer"] = ds.KubeNodeInformer.HasSynced
 
 	ds.SettingInformer.AddEventHandlerWithResyncPeriod(
 		cache.FilteringResourceEventHandler{
@@ -154,7 +156,7 @@ func NewInstanceManagerController(
 				UpdateFunc: func(old, cur interface{}) { imc.enqueueSettingChange(cur) },
 			},
 		}, 0)
-	imc.cacheSyncs = append(imc.cacheSyncs, ds.SettingInformer.HasSynced)
+	imc.cacheSyncs["SettingInformer"] = ds.SettingInformer.HasSynced
 
 	return imc
 }
@@ -202,12 +204,12 @@ func isInstanceManagerPod(obj interface{}) bool {
 
 func (imc *InstanceManagerController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer imc.queue.ShutDown()
+	defer imc.queue.ShutDownWithDrain()
 
 	logrus.Infof("Starting Longhorn instance manager controller")
 	defer logrus.Infof("Shut down Longhorn instance manager controller")
 
-	if !cache.WaitForNamedCacheSync("longhorn instance manager", stopCh, imc.cacheSyncs...) {
+	if !imc.waitForCacheSync(stopCh, imc.cacheSyncs) {
 		return
 	}
 
@@ -231,7 +233,7 @@ func (imc *InstanceManagerController) processNextWorkItem() bool {
 	}
 	defer imc.queue.Done(key)
 
-	err := imc.syncInstanceManager(key.(string))
+	err := imc.syncWithPanicRecovery(key.(string), imc.syncInstanceManager)
 	imc.handleErr(err, key)
 
 	return true
@@ -243,7 +245,7 @@ func (imc *InstanceManagerController) handleErr(err error, key interface{}) {
 		return
 	}
 
-	if imc.queue.NumRequeues(key) < maxRetries {
+	if imc.queue.NumRequeues(key) < MaxRetries {
 		logrus.Warnf("Error syncing Longhorn instance manager %v: %v", key, err)
 		imc.queue.AddRateLimited(key)
 		return
@@ -724,6 +726,17 @@ func (imc *InstanceManagerController) canDeleteInstanceManagerPDB(im *longhorn.I
 		return false, err
 	}
 
+	// Block the drain until all healthy replicas have been evicted from the
+	// node. The failed replicas hold no data to relocate and are never evicted.
+	if nodeDrainingPolicy == string(types.NodeDrainPolicyBlockForEviction) {
+		for _, replica := range replicasOnCurrentNode {
+			if datastore.IsAvailableHealthyReplica(replica) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+
 	targetReplicas := []*longhorn.Replica{}
 	if nodeDrainingPolicy == string(types.NodeDrainPolicyAllowIfReplicaIsStopped) {
 		for _, replica := range replicasOnCurrentNode {
@@ -1110,13 +1123,13 @@ func (imc *InstanceManagerController) createGenericManagerPodSpec(im *longhorn.I
 	}
 
 	// Apply resource requirements to newly created Instance Manager Pods.
-	cpuResourceReq, err := GetInstanceManagerCPURequirement(imc.ds, im.Name)
+	resourceReq, err := GetInstanceManagerResourceRequirement(imc.ds, im.Name)
 	if err != nil {
 		return nil, err
 	}
-	// Do nothing for the CPU requests if the value is 0.
-	if cpuResourceReq != nil {
-		podSpec.Spec.Containers[0].Resources = *cpuResourceReq
+	// Do nothing for the resource requests if both CPU and memory values are 0.
+	if resourceReq != nil {
+		podSpec.Spec.Containers[0].Resources = *resourceReq
 	}
 
 	return podSpec, nil
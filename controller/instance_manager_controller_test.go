@@ -58,9 +58,12 @@ func fakeInstanceManagerVersionUpdater(im *longhorn.InstanceManager) error {
 
 func newTestInstanceManagerController(lhInformerFactory lhinformerfactory.SharedInformerFactory,
 	kubeInformerFactory informers.SharedInformerFactory, lhClient *lhfake.Clientset, kubeClient *fake.Clientset,
-	extensionsClient *apiextensionsfake.Clientset, controllerID string) *InstanceManagerController {
+	extensionsClient *apiextensionsfake.Clientset, controllerID string) (*InstanceManagerController, error) {
 
-	ds := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+	ds, err := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+	if err != nil {
+		return nil, err
+	}
 
 	logger := logrus.StandardLogger()
 
@@ -72,11 +75,10 @@ func newTestInstanceManagerController(lhInformerFactory lhinformerfactory.Shared
 	}
 	imc.versionUpdater = fakeInstanceManagerVersionUpdater
 
-	return imc
+	return imc, nil
 }
 
 func (s *TestSuite) TestSyncInstanceManager(c *C) {
-	var err error
 
 	testCases := map[string]InstanceManagerTestCase{
 		"instance manager change ownership": {
@@ -211,8 +213,9 @@ func (s *TestSuite) TestSyncInstanceManager(c *C) {
 
 		extensionsClient := apiextensionsfake.NewSimpleClientset()
 
-		imc := newTestInstanceManagerController(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient,
+		imc, err := newTestInstanceManagerController(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient,
 			extensionsClient, tc.controllerID)
+		c.Assert(err, IsNil)
 
 		// Controller logic depends on the existence of DefaultInstanceManagerImage Setting and Toleration Setting.
 		tolerationSetting := newTolerationSetting()
@@ -299,3 +302,66 @@ func (s *TestSuite) TestSyncInstanceManager(c *C) {
 		c.Assert(updatedIM.Status, DeepEquals, tc.expectedStatus)
 	}
 }
+
+func (s *TestSuite) TestCanDeleteInstanceManagerPDBBlockForEviction(c *C) {
+	type testCase struct {
+		replicaFailed []bool
+
+		expectedCanDelete bool
+	}
+	testCases := map[string]testCase{
+		"no replica on node": {
+			expectedCanDelete: true,
+		},
+		"healthy replica on node": {
+			replicaFailed:     []bool{false},
+			expectedCanDelete: false,
+		},
+		"only failed replicas on node": {
+			replicaFailed:     []bool{true, true},
+			expectedCanDelete: true,
+		},
+		"healthy and failed replicas on node": {
+			replicaFailed:     []bool{true, false},
+			expectedCanDelete: false,
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		kubeClient := fake.NewSimpleClientset()
+		kubeInformerFactory := informers.NewSharedInformerFactory(kubeClient, controller.NoResyncPeriodFunc())
+		lhClient := lhfake.NewSimpleClientset()
+		lhInformerFactory := lhinformerfactory.NewSharedInformerFactory(lhClient, controller.NoResyncPeriodFunc())
+		extensionsClient := apiextensionsfake.NewSimpleClientset()
+
+		imc, err := newTestInstanceManagerController(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient, extensionsClient, TestNode1)
+		c.Assert(err, IsNil)
+
+		sIndexer := lhInformerFactory.Longhorn().V1beta2().Settings().Informer().GetIndexer()
+		err = sIndexer.Add(newSetting(string(types.SettingNameAllowNodeDrainWithLastHealthyReplica), "false"))
+		c.Assert(err, IsNil)
+		err = sIndexer.Add(newSetting(string(types.SettingNameNodeDrainPolicy), string(types.NodeDrainPolicyBlockForEviction)))
+		c.Assert(err, IsNil)
+
+		v := newVolume(TestVolumeName, 2)
+		e := newEngineForVolume(v)
+		rIndexer := lhInformerFactory.Longhorn().V1beta2().Replicas().Informer().GetIndexer()
+		for _, failed := range tc.replicaFailed {
+			r := newReplicaForVolume(v, e, TestNode1, TestDiskID1)
+			r.Namespace = TestNamespace
+			r.Spec.HealthyAt = getTestNow()
+			if failed {
+				r.Spec.FailedAt = getTestNow()
+			}
+			err = rIndexer.Add(r)
+			c.Assert(err, IsNil)
+		}
+
+		im := newInstanceManager(TestReplicaManagerName, longhorn.InstanceManagerTypeReplica, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1, nil, false)
+		canDelete, err := imc.canDeleteInstanceManagerPDB(im)
+		c.Assert(err, IsNil)
+		c.Assert(canDelete, Equals, tc.expectedCanDelete, Commentf("test case: %v", name))
+	}
+}
@@ -39,7 +39,7 @@ type KubernetesConfigMapController struct {
 
 	ds *datastore.DataStore
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 }
 
 func NewKubernetesConfigMapController(
@@ -65,7 +65,9 @@ func NewKubernetesConfigMapController(
 		ds: ds,
 
 		kubeClient:    kubeClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-kubernetes-configmap-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-kubernetes-configmap-controller"})),
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	ds.ConfigMapInformer.AddEventHandlerWithResyncPeriod(
@@ -84,19 +86,20 @@ func NewKubernetesConfigMapController(
 			},
 		}, 0)
 
-	kc.cacheSyncs = append(kc.cacheSyncs, ds.ConfigMapInformer.HasSynced, ds.StorageClassInformer.HasSynced)
+	kc.cacheSyncs["ConfigMapInformer"] = ds.ConfigMapInformer.HasSynced
+	kc.cacheSyncs["StorageClassInformer"] = ds.StorageClassInformer.HasSynced
 
 	return kc
 }
 
 func (kc *KubernetesConfigMapController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer kc.queue.ShutDown()
+	defer kc.queue.ShutDownWithDrain()
 
 	kc.logger.Infof("Start")
 	defer kc.logger.Infof("Shutting down")
 
-	if !cache.WaitForNamedCacheSync(kc.name, stopCh, kc.cacheSyncs...) {
+	if !kc.waitForCacheSync(stopCh, kc.cacheSyncs) {
 		return
 	}
 	for i := 0; i < workers; i++ {
@@ -116,7 +119,7 @@ func (kc *KubernetesConfigMapController) processNextWorkItem() bool {
 		return false
 	}
 	defer kc.queue.Done(key)
-	err := kc.syncHandler(key.(string))
+	err := kc.syncWithPanicRecovery(key.(string), kc.syncHandler)
 	kc.handleErr(err, key)
 	return true
 }
@@ -127,7 +130,7 @@ func (kc *KubernetesConfigMapController) handleErr(err error, key interface{}) {
 		return
 	}
 
-	if kc.queue.NumRequeues(key) < maxRetries {
+	if kc.queue.NumRequeues(key) < MaxRetries {
 		kc.logger.WithError(err).Warnf("Error syncing ConfigMap %v", key)
 		kc.queue.AddRateLimited(key)
 		return
@@ -36,7 +36,7 @@ type KubernetesNodeController struct {
 
 	ds *datastore.DataStore
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 }
 
 func NewKubernetesNodeController(
@@ -57,23 +57,25 @@ func NewKubernetesNodeController(
 		controllerID: controllerID,
 
 		kubeClient:    kubeClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-kubernetes-node-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-kubernetes-node-controller"})),
 
 		ds: ds,
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	ds.KubeNodeInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		UpdateFunc: func(old, cur interface{}) { knc.enqueueNode(cur) },
 		DeleteFunc: knc.enqueueNode,
 	})
-	knc.cacheSyncs = append(knc.cacheSyncs, ds.KubeNodeInformer.HasSynced)
+	knc.cacheSyncs["KubeNodeInformer"] = ds.KubeNodeInformer.HasSynced
 
 	ds.NodeInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc:    knc.enqueueLonghornNode,
 		UpdateFunc: func(old, cur interface{}) { knc.enqueueLonghornNode(cur) },
 		DeleteFunc: knc.enqueueLonghornNode,
 	}, 0)
-	knc.cacheSyncs = append(knc.cacheSyncs, ds.NodeInformer.HasSynced)
+	knc.cacheSyncs["NodeInformer"] = ds.NodeInformer.HasSynced
 
 	ds.SettingInformer.AddEventHandlerWithResyncPeriod(
 		cache.FilteringResourceEventHandler{
@@ -83,7 +85,7 @@ func NewKubernetesNodeController(
 				UpdateFunc: func(old, cur interface{}) { knc.enqueueSetting(cur) },
 			},
 		}, 0)
-	knc.cacheSyncs = append(knc.cacheSyncs, ds.SettingInformer.HasSynced)
+	knc.cacheSyncs["SettingInformer"] = ds.SettingInformer.HasSynced
 
 	return knc
 }
@@ -108,12 +110,12 @@ func isSettingCreateDefaultDiskLabeledNodes(obj interface{}) bool {
 
 func (knc *KubernetesNodeController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer knc.queue.ShutDown()
+	defer knc.queue.ShutDownWithDrain()
 
 	logrus.Infof("Starting Longhorn Kubernetes node controller")
 	defer logrus.Infof("Shut down Longhorn Kubernetes node controller")
 
-	if !cache.WaitForNamedCacheSync("longhorn kubernetes node", stopCh, knc.cacheSyncs...) {
+	if !knc.waitForCacheSync(stopCh, knc.cacheSyncs) {
 		return
 	}
 
@@ -137,7 +139,7 @@ func (knc *KubernetesNodeController) processNextWorkItem() bool {
 	}
 	defer knc.queue.Done(key)
 
-	err := knc.syncKubernetesNode(key.(string))
+	err := knc.syncWithPanicRecovery(key.(string), knc.syncKubernetesNode)
 	knc.handleErr(err, key)
 
 	return true
@@ -149,7 +151,7 @@ func (knc *KubernetesNodeController) handleErr(err error, key interface{}) {
 		return
 	}
 
-	if knc.queue.NumRequeues(key) < maxRetries {
+	if knc.queue.NumRequeues(key) < MaxRetries {
 		logrus.Warnf("Error syncing Longhorn node %v: %v", key, err)
 		knc.queue.AddRateLimited(key)
 		return
@@ -292,7 +294,11 @@ func (knc *KubernetesNodeController) syncDefaultDisks(node *longhorn.Node) (err
 		if err != nil {
 			return err
 		}
-		disks, err = types.CreateDefaultDisk(dataPath)
+		storageReservedPercentage, err := knc.ds.GetSettingAsInt(types.SettingNameStorageReservedPercentageForDefaultDisk)
+		if err != nil {
+			return err
+		}
+		disks, err = types.CreateDefaultDisk(dataPath, storageReservedPercentage)
 		if err != nil {
 			return err
 		}
@@ -41,7 +41,7 @@ type KubernetesPDBController struct {
 
 	ds *datastore.DataStore
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 }
 
 func NewKubernetesPDBController(
@@ -59,6 +59,8 @@ func NewKubernetesPDBController(
 
 		ds:         ds,
 		kubeClient: kubeClient,
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	ds.DeploymentInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -66,26 +68,26 @@ func NewKubernetesPDBController(
 		UpdateFunc: func(old, cur interface{}) { pc.enqueueDeployment(cur) },
 		DeleteFunc: pc.enqueueDeployment,
 	})
-	pc.cacheSyncs = append(pc.cacheSyncs, ds.DeploymentInformer.HasSynced)
+	pc.cacheSyncs["DeploymentInformer"] = ds.DeploymentInformer.HasSynced
 
 	ds.VolumeInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc:    pc.enqueueVolume,
 		UpdateFunc: func(old, cur interface{}) { pc.enqueueVolume(cur) },
 		DeleteFunc: pc.enqueueVolume,
 	}, 0)
-	pc.cacheSyncs = append(pc.cacheSyncs, ds.VolumeInformer.HasSynced)
+	pc.cacheSyncs["VolumeInformer"] = ds.VolumeInformer.HasSynced
 
 	return pc
 }
 
 func (pc *KubernetesPDBController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer pc.queue.ShutDown()
+	defer pc.queue.ShutDownWithDrain()
 
 	pc.logger.Infof("Starting Kubernetes PDB controller")
 	defer pc.logger.Infof("Shut down Kubernetes PDB controller")
 
-	if !cache.WaitForNamedCacheSync(pc.name, stopCh, pc.cacheSyncs...) {
+	if !pc.waitForCacheSync(stopCh, pc.cacheSyncs) {
 		return
 	}
 	for i := 0; i < workers; i++ {
@@ -105,7 +107,7 @@ func (pc *KubernetesPDBController) processNextWorkItem() bool {
 		return false
 	}
 	defer pc.queue.Done(key)
-	err := pc.syncHandler(key.(string))
+	err := pc.syncWithPanicRecovery(key.(string), pc.syncHandler)
 	pc.handleErr(err, key)
 	return true
 }
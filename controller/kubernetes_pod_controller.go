@@ -41,7 +41,7 @@ type KubernetesPodController struct {
 
 	ds *datastore.DataStore
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 }
 
 func NewKubernetesPodController(
@@ -66,7 +66,9 @@ func NewKubernetesPodController(
 		ds: ds,
 
 		kubeClient:    kubeClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-kubernetes-pod-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-kubernetes-pod-controller"})),
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	ds.PodInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -74,19 +76,19 @@ func NewKubernetesPodController(
 		UpdateFunc: func(old, cur interface{}) { kc.enqueuePodChange(cur) },
 		DeleteFunc: kc.enqueuePodChange,
 	})
-	kc.cacheSyncs = append(kc.cacheSyncs, ds.PodInformer.HasSynced)
+	kc.cacheSyncs["PodInformer"] = ds.PodInformer.HasSynced
 
 	return kc
 }
 
 func (kc *KubernetesPodController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer kc.queue.ShutDown()
+	defer kc.queue.ShutDownWithDrain()
 
 	kc.logger.Infof("Start %v", controllerAgentName)
 	defer kc.logger.Infof("Shutting down %v", controllerAgentName)
 
-	if !cache.WaitForNamedCacheSync(controllerAgentName, stopCh, kc.cacheSyncs...) {
+	if !kc.waitForCacheSync(stopCh, kc.cacheSyncs) {
 		return
 	}
 	for i := 0; i < workers; i++ {
@@ -106,7 +108,7 @@ func (kc *KubernetesPodController) processNextWorkItem() bool {
 		return false
 	}
 	defer kc.queue.Done(key)
-	err := kc.syncHandler(key.(string))
+	err := kc.syncWithPanicRecovery(key.(string), kc.syncHandler)
 	kc.handleErr(err, key)
 	return true
 }
@@ -117,7 +119,7 @@ func (kc *KubernetesPodController) handleErr(err error, key interface{}) {
 		return
 	}
 
-	if kc.queue.NumRequeues(key) < maxRetries {
+	if kc.queue.NumRequeues(key) < MaxRetries {
 		kc.logger.WithError(err).Warnf("%v: Error syncing Longhorn kubernetes pod %v", controllerAgentName, key)
 		kc.queue.AddRateLimited(key)
 		return
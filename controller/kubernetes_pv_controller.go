@@ -39,7 +39,7 @@ type KubernetesPVController struct {
 
 	ds *datastore.DataStore
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 
 	// key is <PVName>, value is <VolumeName>
 	pvToVolumeCache sync.Map
@@ -68,11 +68,13 @@ func NewKubernetesPVController(
 		ds: ds,
 
 		kubeClient:    kubeClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-kubernetes-pv-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-kubernetes-pv-controller"})),
 
 		pvToVolumeCache: sync.Map{},
 
 		nowHandler: util.Now,
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	ds.PersistentVolumeInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -83,26 +85,26 @@ func NewKubernetesPVController(
 			kc.enqueuePVDeletion(obj)
 		},
 	})
-	kc.cacheSyncs = append(kc.cacheSyncs, ds.PersistentVolumeInformer.HasSynced)
+	kc.cacheSyncs["PersistentVolumeInformer"] = ds.PersistentVolumeInformer.HasSynced
 
 	ds.PodInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc:    kc.enqueuePodChange,
 		UpdateFunc: func(old, cur interface{}) { kc.enqueuePodChange(cur) },
 		DeleteFunc: kc.enqueuePodChange,
 	}, 0)
-	kc.cacheSyncs = append(kc.cacheSyncs, ds.PodInformer.HasSynced)
+	kc.cacheSyncs["PodInformer"] = ds.PodInformer.HasSynced
 
 	return kc
 }
 
 func (kc *KubernetesPVController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer kc.queue.ShutDown()
+	defer kc.queue.ShutDownWithDrain()
 
 	logrus.Infof("Start kubernetes controller")
 	defer logrus.Infof("Shutting down kubernetes controller")
 
-	if !cache.WaitForNamedCacheSync("kubernetes", stopCh, kc.cacheSyncs...) {
+	if !kc.waitForCacheSync(stopCh, kc.cacheSyncs) {
 		return
 	}
 
@@ -126,7 +128,7 @@ func (kc *KubernetesPVController) processNextWorkItem() bool {
 	}
 	defer kc.queue.Done(key)
 
-	err := kc.syncKubernetesStatus(key.(string))
+	err := kc.syncWithPanicRecovery(key.(string), kc.syncKubernetesStatus)
 	kc.handleErr(err, key)
 
 	return true
@@ -138,7 +140,7 @@ func (kc *KubernetesPVController) handleErr(err error, key interface{}) {
 		return
 	}
 
-	if kc.queue.NumRequeues(key) < maxRetries {
+	if kc.queue.NumRequeues(key) < MaxRetries {
 		logrus.Warnf("Error syncing Longhorn volume kubernetes status %v: %v", key, err)
 		kc.queue.AddRateLimited(key)
 		return
@@ -458,6 +460,7 @@ func (kc *KubernetesPVController) setWorkloads(ks *longhorn.KubernetesStatus, po
 		ws := longhorn.WorkloadStatus{
 			PodName:   p.Name,
 			PodStatus: string(p.Status.Phase),
+			NodeID:    p.Spec.NodeName,
 		}
 		ws.WorkloadName, ws.WorkloadType = kc.detectWorkload(p)
 		ks.WorkloadsStatus = append(ks.WorkloadsStatus, ws)
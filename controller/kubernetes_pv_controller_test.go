@@ -169,8 +169,11 @@ func newPodWithPVC(podName string) *corev1.Pod {
 }
 
 func newTestKubernetesPVController(lhInformerFactory lhinformerfactory.SharedInformerFactory, kubeInformerFactory informers.SharedInformerFactory,
-	lhClient *lhfake.Clientset, kubeClient *fake.Clientset, extensionsClient *apiextensionsfake.Clientset) *KubernetesPVController {
-	ds := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+	lhClient *lhfake.Clientset, kubeClient *fake.Clientset, extensionsClient *apiextensionsfake.Clientset) (*KubernetesPVController, error) {
+	ds, err := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+	if err != nil {
+		return nil, err
+	}
 
 	logger := logrus.StandardLogger()
 	kc := NewKubernetesPVController(logger, ds, scheme.Scheme, kubeClient, TestNode1)
@@ -182,7 +185,7 @@ func newTestKubernetesPVController(lhInformerFactory lhinformerfactory.SharedInf
 	}
 	kc.nowHandler = getTestNow
 
-	return kc
+	return kc, nil
 }
 
 func (s *TestSuite) TestSyncKubernetesStatus(c *C) {
@@ -198,11 +201,13 @@ func (s *TestSuite) TestSyncKubernetesStatus(c *C) {
 	tc.pods = append(tc.pods, newPodWithPVC(TestPod2))
 	workloads = []longhorn.WorkloadStatus{}
 	for _, p := range tc.pods {
+		p.Spec.NodeName = TestNode1
 		ws := longhorn.WorkloadStatus{
 			PodName:      p.Name,
 			PodStatus:    string(p.Status.Phase),
 			WorkloadName: TestWorkloadName,
 			WorkloadType: TestWorkloadKind,
+			NodeID:       TestNode1,
 		}
 		workloads = append(workloads, ws)
 	}
@@ -472,7 +477,8 @@ func (s *TestSuite) runKubernetesTestCases(c *C, testCases map[string]*Kubernete
 
 		extensionsClient := apiextensionsfake.NewSimpleClientset()
 
-		kc := newTestKubernetesPVController(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient, extensionsClient)
+		kc, err := newTestKubernetesPVController(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient, extensionsClient)
+		c.Assert(err, IsNil)
 
 		// Need to create pv, pvc, pod and longhorn volume
 		var v *longhorn.Volume
@@ -40,7 +40,7 @@ type KubernetesSecretController struct {
 
 	ds *datastore.DataStore
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 }
 
 func NewKubernetesSecretController(
@@ -66,7 +66,9 @@ func NewKubernetesSecretController(
 		ds: ds,
 
 		kubeClient:    kubeClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-kubernetes-secret-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-kubernetes-secret-controller"})),
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	ds.SecretInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -84,19 +86,19 @@ func NewKubernetesSecretController(
 		},
 		DeleteFunc: ks.enqueueSecretChange,
 	})
-	ks.cacheSyncs = append(ks.cacheSyncs, ds.SecretInformer.HasSynced)
+	ks.cacheSyncs["SecretInformer"] = ds.SecretInformer.HasSynced
 
 	return ks
 }
 
 func (ks *KubernetesSecretController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer ks.queue.ShutDown()
+	defer ks.queue.ShutDownWithDrain()
 
 	ks.logger.Info("Starting Longhorn Kubernetes secret controller")
 	defer ks.logger.Info("Shut down Longhorn Kubernetes secret controller")
 
-	if !cache.WaitForNamedCacheSync(ks.name, stopCh, ks.cacheSyncs...) {
+	if !ks.waitForCacheSync(stopCh, ks.cacheSyncs) {
 		return
 	}
 	for i := 0; i < workers; i++ {
@@ -116,7 +118,7 @@ func (ks *KubernetesSecretController) processNextWorkItem() bool {
 		return false
 	}
 	defer ks.queue.Done(key)
-	err := ks.syncHandler(key.(string))
+	err := ks.syncWithPanicRecovery(key.(string), ks.syncHandler)
 	ks.handleErr(err, key)
 	return true
 }
@@ -127,7 +129,7 @@ func (ks *KubernetesSecretController) handleErr(err error, key interface{}) {
 		return
 	}
 
-	if ks.queue.NumRequeues(key) < maxRetries {
+	if ks.queue.NumRequeues(key) < MaxRetries {
 		ks.logger.WithError(err).Warnf("Error syncing Secret %v", key)
 		ks.queue.AddRateLimited(key)
 		return
@@ -252,6 +254,8 @@ func (ks *KubernetesSecretController) triggerSyncBackupTarget(backupTarget *long
 		return nil
 	}
 
+	// The backup target is from the informer cache, copy it before changing the spec
+	backupTarget = backupTarget.DeepCopy()
 	backupTarget.Spec.SyncRequestedAt = metav1.Time{Time: time.Now().UTC()}
 	if _, err := ks.ds.UpdateBackupTarget(backupTarget); err != nil && !apierrors.IsConflict(errors.Cause(err)) {
 		ks.logger.WithError(err).Warn("Failed to updating backup target")
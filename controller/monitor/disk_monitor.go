@@ -37,10 +37,16 @@ type NodeMonitor struct {
 
 	syncCallback func(key string)
 
+	// The disk benchmarks running in the background or not recorded in the
+	// disk status yet, keyed by the disk UUID. The value is nil while running.
+	benchmarksLock sync.Mutex
+	benchmarks     map[string]*longhorn.DiskBenchmark
+
 	getDiskStatHandler               GetDiskStatHandler
 	getDiskConfig                    GetDiskConfig
 	generateDiskConfig               GenerateDiskConfig
 	getPossibleReplicaDirectoryNames GetPossibleReplicaDirectoryNames
+	benchmarkDiskHandler             BenchmarkDiskHandler
 }
 
 type CollectedDiskInfo struct {
@@ -50,12 +56,14 @@ type CollectedDiskInfo struct {
 	DiskUUID                      string
 	Condition                     *longhorn.Condition
 	OrphanedReplicaDirectoryNames map[string]string
+	Benchmark                     *longhorn.DiskBenchmark
 }
 
 type GetDiskStatHandler func(string) (*util.DiskStat, error)
 type GetDiskConfig func(string) (*util.DiskConfig, error)
 type GenerateDiskConfig func(string) (*util.DiskConfig, error)
 type GetPossibleReplicaDirectoryNames func(*longhorn.Node, string, string, string) map[string]string
+type BenchmarkDiskHandler func(string) (*util.DiskBenchmark, error)
 
 func NewDiskMonitor(logger logrus.FieldLogger, ds *datastore.DataStore, nodeName string, syncCallback func(key string)) (*NodeMonitor, error) {
 	ctx, quit := context.WithCancel(context.Background())
@@ -71,10 +79,14 @@ func NewDiskMonitor(logger logrus.FieldLogger, ds *datastore.DataStore, nodeName
 
 		syncCallback: syncCallback,
 
+		benchmarksLock: sync.Mutex{},
+		benchmarks:     make(map[string]*longhorn.DiskBenchmark, 0),
+
 		getDiskStatHandler:               util.GetDiskStat,
 		getDiskConfig:                    util.GetDiskConfig,
 		generateDiskConfig:               util.GenerateDiskConfig,
 		getPossibleReplicaDirectoryNames: getPossibleReplicaDirectoryNames,
+		benchmarkDiskHandler:             util.BenchmarkDisk,
 	}
 
 	go m.Start()
@@ -141,8 +153,15 @@ func (m *NodeMonitor) collectDiskData(node *longhorn.Node) map[string]*Collected
 	diskInfoMap := make(map[string]*CollectedDiskInfo, 0)
 	orphanedReplicaDirectoryNames := map[string]string{}
 
+	// The disks temporarily failing the collection still keep their benchmarks
+	diskUUIDs := map[string]struct{}{}
+	defer m.pruneDiskBenchmarks(diskUUIDs)
+
 	for diskName, disk := range node.Spec.Disks {
 		nodeOrDiskEvicted := isNodeOrDiskEvicted(node, disk)
+		if diskStatus, ok := node.Status.DiskStatus[diskName]; ok && diskStatus.DiskUUID != "" {
+			diskUUIDs[diskStatus.DiskUUID] = struct{}{}
+		}
 
 		stat, err := m.getDiskStatHandler(disk.Path)
 		if err != nil {
@@ -173,16 +192,111 @@ func (m *NodeMonitor) collectDiskData(node *longhorn.Node) map[string]*Collected
 			}
 		}
 
+		diskUUIDs[diskConfig.DiskUUID] = struct{}{}
+
 		replicaDirectoryNames := m.getPossibleReplicaDirectoryNames(node, diskName, diskConfig.DiskUUID, disk.Path)
 		orphanedReplicaDirectoryNames := m.getOrphanedReplicaDirectoryNames(node, diskName, diskConfig.DiskUUID, disk.Path, replicaDirectoryNames)
 
 		diskInfoMap[diskName] = NewDiskInfo(disk.Path, diskConfig.DiskUUID, nodeOrDiskEvicted, stat,
 			orphanedReplicaDirectoryNames, string(longhorn.DiskConditionReasonNoDiskInfo), "")
+		diskInfoMap[diskName].Benchmark = m.getDiskBenchmark(node, diskName, diskConfig.DiskUUID, disk.Path)
 	}
 
 	return diskInfoMap
 }
 
+// getDiskBenchmark benchmarks the newly added disk in the background if the
+// benchmark on disk addition is enabled, and returns the result once it's done.
+// The disks already benchmarked or holding replicas are skipped, since the
+// benchmark briefly loads the disk.
+func (m *NodeMonitor) getDiskBenchmark(node *longhorn.Node, diskName, diskUUID, diskPath string) *longhorn.DiskBenchmark {
+	if !canCollectDiskData(node, diskName, diskUUID, diskPath) {
+		return nil
+	}
+	diskStatus := node.Status.DiskStatus[diskName]
+	if diskStatus.Benchmark != nil {
+		m.benchmarksLock.Lock()
+		delete(m.benchmarks, diskUUID)
+		m.benchmarksLock.Unlock()
+		return nil
+	}
+
+	// Reuse the running or finished benchmark that hasn't been recorded in the
+	// disk status yet
+	m.benchmarksLock.Lock()
+	benchmark, exists := m.benchmarks[diskUUID]
+	m.benchmarksLock.Unlock()
+	if exists {
+		return benchmark
+	}
+
+	enabled, err := m.ds.GetSettingAsBool(types.SettingNameDiskBenchmarkOnDiskAddition)
+	if err != nil {
+		m.logger.WithError(err).Warnf("Failed to get setting %v", types.SettingNameDiskBenchmarkOnDiskAddition)
+		return nil
+	}
+	if !enabled {
+		return nil
+	}
+
+	if len(diskStatus.ScheduledReplica) != 0 {
+		return nil
+	}
+	replicas, err := m.ds.ListReplicasByDiskUUID(diskUUID)
+	if err != nil {
+		m.logger.WithError(err).Warnf("Failed to list replicas on disk %v(%v) on node %v", diskName, diskPath, node.Name)
+		return nil
+	}
+	if len(replicas) != 0 {
+		return nil
+	}
+
+	m.benchmarksLock.Lock()
+	m.benchmarks[diskUUID] = nil
+	m.benchmarksLock.Unlock()
+	go m.benchmarkDisk(node.Name, diskName, diskUUID, diskPath)
+
+	return nil
+}
+
+// benchmarkDisk runs the benchmark of the disk and keeps the result for the
+// following disk data collections.
+func (m *NodeMonitor) benchmarkDisk(nodeName, diskName, diskUUID, diskPath string) {
+	m.logger.Infof("Benchmarking disk %v(%v) on node %v", diskName, diskPath, nodeName)
+	benchmark := &longhorn.DiskBenchmark{
+		BenchmarkedAt: util.Now(),
+	}
+	result, err := m.benchmarkDiskHandler(diskPath)
+	if err != nil {
+		m.logger.WithError(err).Warnf("Failed to benchmark disk %v(%v) on node %v", diskName, diskPath, nodeName)
+		benchmark.Error = err.Error()
+	} else {
+		benchmark.SequentialThroughput = result.SequentialThroughput
+		benchmark.SyncWriteIOPS = result.SyncWriteIOPS
+		benchmark.RandomWriteIOPS = result.RandomWriteIOPS
+	}
+
+	m.benchmarksLock.Lock()
+	defer m.benchmarksLock.Unlock()
+	// Drop the result if the disk was removed during the benchmark
+	if _, exists := m.benchmarks[diskUUID]; !exists {
+		return
+	}
+	m.benchmarks[diskUUID] = benchmark
+}
+
+// pruneDiskBenchmarks forgets the benchmarks of the disks removed from the
+// node before their results are recorded in the disk status.
+func (m *NodeMonitor) pruneDiskBenchmarks(diskUUIDs map[string]struct{}) {
+	m.benchmarksLock.Lock()
+	defer m.benchmarksLock.Unlock()
+	for diskUUID := range m.benchmarks {
+		if _, exists := diskUUIDs[diskUUID]; !exists {
+			delete(m.benchmarks, diskUUID)
+		}
+	}
+}
+
 func isNodeOrDiskEvicted(node *longhorn.Node, disk longhorn.DiskSpec) bool {
 	return node.Spec.EvictionRequested || disk.EvictionRequested
 }
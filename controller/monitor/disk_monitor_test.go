@@ -0,0 +1,230 @@
+package monitor
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/longhorn/longhorn-manager/datastore"
+	"github.com/longhorn/longhorn-manager/types"
+	"github.com/longhorn/longhorn-manager/util"
+
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
+	lhfake "github.com/longhorn/longhorn-manager/k8s/pkg/client/clientset/versioned/fake"
+	lhinformers "github.com/longhorn/longhorn-manager/k8s/pkg/client/informers/externalversions"
+)
+
+const (
+	testNamespace = "default"
+	testNodeName  = "test-node"
+	testDiskName  = "test-disk"
+	testDiskPath  = "/var/lib/longhorn"
+)
+
+func newTestNodeForDiskBenchmark(scheduledReplica map[string]int64, benchmark *longhorn.DiskBenchmark) *longhorn.Node {
+	return &longhorn.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testNodeName,
+			Namespace: testNamespace,
+		},
+		Spec: longhorn.NodeSpec{
+			Disks: map[string]longhorn.DiskSpec{
+				testDiskName: {
+					Path: testDiskPath,
+				},
+			},
+		},
+		Status: longhorn.NodeStatus{
+			DiskStatus: map[string]*longhorn.DiskStatus{
+				testDiskName: {
+					Conditions: []longhorn.Condition{
+						{
+							Type:   longhorn.DiskConditionTypeReady,
+							Status: longhorn.ConditionStatusTrue,
+						},
+					},
+					ScheduledReplica: scheduledReplica,
+					DiskUUID:         TestDiskID1,
+					Benchmark:        benchmark,
+				},
+			},
+		},
+	}
+}
+
+func TestGetDiskBenchmark(t *testing.T) {
+	assert := require.New(t)
+
+	existingBenchmark := &longhorn.DiskBenchmark{
+		SequentialThroughput: 1,
+		SyncWriteIOPS:        1,
+	}
+
+	type testCase struct {
+		enabled          string
+		node             *longhorn.Node
+		replicaOnDisk    bool
+		benchmarkFailure bool
+
+		expectedBenchmark *longhorn.DiskBenchmark
+	}
+	testCases := map[string]testCase{
+		"benchmark disabled": {
+			enabled: "false",
+			node:    newTestNodeForDiskBenchmark(nil, nil),
+		},
+		"new disk benchmarked": {
+			enabled: "true",
+			node:    newTestNodeForDiskBenchmark(nil, nil),
+			expectedBenchmark: &longhorn.DiskBenchmark{
+				SequentialThroughput: TestDiskSequentialThroughput,
+				SyncWriteIOPS:        TestDiskSyncWriteIOPS,
+				RandomWriteIOPS:      TestDiskRandomWriteIOPS,
+			},
+		},
+		"disk already benchmarked": {
+			enabled: "true",
+			node:    newTestNodeForDiskBenchmark(nil, existingBenchmark),
+		},
+		"disk with scheduled replicas": {
+			enabled: "true",
+			node:    newTestNodeForDiskBenchmark(map[string]int64{TestOrphanedReplicaDirectoryName: 1}, nil),
+		},
+		"disk with replicas": {
+			enabled:       "true",
+			node:          newTestNodeForDiskBenchmark(nil, nil),
+			replicaOnDisk: true,
+		},
+		"benchmark failure recorded": {
+			enabled:          "true",
+			node:             newTestNodeForDiskBenchmark(nil, nil),
+			benchmarkFailure: true,
+			expectedBenchmark: &longhorn.DiskBenchmark{
+				Error: "failed to write",
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		kubeClient := fake.NewSimpleClientset()
+		kubeInformerFactory := informers.NewSharedInformerFactory(kubeClient, 0)
+		lhClient := lhfake.NewSimpleClientset()
+		lhInformerFactory := lhinformers.NewSharedInformerFactory(lhClient, 0)
+		extensionsClient := apiextensionsfake.NewSimpleClientset()
+		ds, err := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, testNamespace)
+		assert.NoError(err)
+
+		sIndexer := lhInformerFactory.Longhorn().V1beta2().Settings().Informer().GetIndexer()
+		err = sIndexer.Add(&longhorn.Setting{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      string(types.SettingNameDiskBenchmarkOnDiskAddition),
+				Namespace: testNamespace,
+			},
+			Value: tc.enabled,
+		})
+		assert.Nil(err)
+
+		if tc.replicaOnDisk {
+			rIndexer := lhInformerFactory.Longhorn().V1beta2().Replicas().Informer().GetIndexer()
+			err = rIndexer.Add(&longhorn.Replica{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      TestOrphanedReplicaDirectoryName,
+					Namespace: testNamespace,
+					Labels: map[string]string{
+						types.LonghornDiskUUIDKey: TestDiskID1,
+					},
+				},
+			})
+			assert.Nil(err)
+		}
+
+		m, err := NewFakeNodeMonitor(logrus.StandardLogger(), ds, testNodeName, func(string) {})
+		assert.Nil(err)
+		if tc.benchmarkFailure {
+			m.benchmarkDiskHandler = func(string) (*util.DiskBenchmark, error) {
+				return nil, fmt.Errorf("failed to write")
+			}
+		}
+
+		// The benchmark runs in the background, and the result is returned by
+		// the following calls once it's done
+		benchmark := m.getDiskBenchmark(tc.node, testDiskName, TestDiskID1, testDiskPath)
+		assert.Nil(benchmark, "test case: %v", name)
+		if tc.expectedBenchmark == nil {
+			assert.NotContains(m.benchmarks, TestDiskID1, "test case: %v", name)
+			continue
+		}
+		assert.Eventually(func() bool {
+			benchmark = m.getDiskBenchmark(tc.node, testDiskName, TestDiskID1, testDiskPath)
+			return benchmark != nil
+		}, time.Second, 10*time.Millisecond, "test case: %v", name)
+		assert.NotNil(benchmark, "test case: %v", name)
+		assert.NotEmpty(benchmark.BenchmarkedAt, "test case: %v", name)
+		assert.Equal(tc.expectedBenchmark.SequentialThroughput, benchmark.SequentialThroughput, "test case: %v", name)
+		assert.Equal(tc.expectedBenchmark.SyncWriteIOPS, benchmark.SyncWriteIOPS, "test case: %v", name)
+		assert.Equal(tc.expectedBenchmark.RandomWriteIOPS, benchmark.RandomWriteIOPS, "test case: %v", name)
+		assert.Equal(tc.expectedBenchmark.Error, benchmark.Error, "test case: %v", name)
+	}
+}
+
+func TestDiskBenchmarkOfRemovedDisk(t *testing.T) {
+	assert := require.New(t)
+
+	kubeClient := fake.NewSimpleClientset()
+	kubeInformerFactory := informers.NewSharedInformerFactory(kubeClient, 0)
+	lhClient := lhfake.NewSimpleClientset()
+	lhInformerFactory := lhinformers.NewSharedInformerFactory(lhClient, 0)
+	extensionsClient := apiextensionsfake.NewSimpleClientset()
+	ds, err := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, testNamespace)
+	assert.NoError(err)
+
+	sIndexer := lhInformerFactory.Longhorn().V1beta2().Settings().Informer().GetIndexer()
+	err = sIndexer.Add(&longhorn.Setting{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      string(types.SettingNameDiskBenchmarkOnDiskAddition),
+			Namespace: testNamespace,
+		},
+		Value: "true",
+	})
+	assert.Nil(err)
+
+	m, err := NewFakeNodeMonitor(logrus.StandardLogger(), ds, testNodeName, func(string) {})
+	assert.Nil(err)
+	benchmarkStarted := make(chan struct{})
+	benchmarkDone := make(chan struct{})
+	m.benchmarkDiskHandler = func(diskPath string) (*util.DiskBenchmark, error) {
+		close(benchmarkStarted)
+		<-benchmarkDone
+		return fakeBenchmarkDisk(diskPath)
+	}
+
+	node := newTestNodeForDiskBenchmark(nil, nil)
+	assert.Nil(m.getDiskBenchmark(node, testDiskName, TestDiskID1, testDiskPath))
+	<-benchmarkStarted
+	assert.Contains(m.benchmarks, TestDiskID1)
+
+	// The disk is removed before the benchmark result is collected
+	node.Spec.Disks = map[string]longhorn.DiskSpec{}
+	delete(node.Status.DiskStatus, testDiskName)
+	m.collectDiskData(node)
+	assert.NotContains(m.benchmarks, TestDiskID1)
+
+	close(benchmarkDone)
+	assert.Never(func() bool {
+		m.benchmarksLock.Lock()
+		defer m.benchmarksLock.Unlock()
+		_, exists := m.benchmarks[TestDiskID1]
+		return exists
+	}, 100*time.Millisecond, 10*time.Millisecond)
+}
@@ -15,6 +15,10 @@ const (
 	TestDiskID1 = "fsid"
 
 	TestOrphanedReplicaDirectoryName = "test-volume-r-000000000"
+
+	TestDiskSequentialThroughput = 500 * 1024 * 1024
+	TestDiskSyncWriteIOPS        = 10000
+	TestDiskRandomWriteIOPS      = 5000
 )
 
 func NewFakeNodeMonitor(logger logrus.FieldLogger, ds *datastore.DataStore, nodeName string, syncCallback func(key string)) (*NodeMonitor, error) {
@@ -31,10 +35,14 @@ func NewFakeNodeMonitor(logger logrus.FieldLogger, ds *datastore.DataStore, node
 
 		syncCallback: syncCallback,
 
+		benchmarksLock: sync.Mutex{},
+		benchmarks:     make(map[string]*longhorn.DiskBenchmark, 0),
+
 		getDiskStatHandler:               fakeGetDiskStat,
 		getDiskConfig:                    fakeGetDiskConfig,
 		generateDiskConfig:               fakeGenerateDiskConfig,
 		getPossibleReplicaDirectoryNames: fakeGetPossibleReplicaDirectoryNames,
+		benchmarkDiskHandler:             fakeBenchmarkDisk,
 	}
 
 	return m, nil
@@ -71,3 +79,11 @@ func fakeGenerateDiskConfig(path string) (*util.DiskConfig, error) {
 		DiskUUID: TestDiskID1,
 	}, nil
 }
+
+func fakeBenchmarkDisk(path string) (*util.DiskBenchmark, error) {
+	return &util.DiskBenchmark{
+		SequentialThroughput: TestDiskSequentialThroughput,
+		SyncWriteIOPS:        TestDiskSyncWriteIOPS,
+		RandomWriteIOPS:      TestDiskRandomWriteIOPS,
+	}, nil
+}
@@ -486,19 +486,23 @@ func (m *SnapshotMonitor) syncHashStatusFromEngineReplicas(engine *longhorn.Engi
 		}
 	}
 
-	m.kickOutCorruptedReplicas(engine, engineClientProxy, checksum, hashStatus)
+	m.kickOutCorruptedReplicas(engine, engineClientProxy, snapshotName, checksum, hashStatus)
 
 	return nil
 }
 
 func (m *SnapshotMonitor) kickOutCorruptedReplicas(engine *longhorn.Engine, engineClientProxy engineapi.EngineClientProxy,
-	checksum string, hashStatus map[string]*longhorn.HashStatus) {
+	snapshotName, checksum string, hashStatus map[string]*longhorn.HashStatus) {
 	for address, status := range hashStatus {
 		if status.Checksum == checksum {
 			continue
 		}
 
-		m.eventRecorder.Eventf(engine, v1.EventTypeWarning, constant.EventReasonFaulted, "Detected corrupted replica %v", address)
+		m.eventRecorder.Eventf(engine, v1.EventTypeWarning, constant.EventReasonFaulted, "Detected corrupted replica %v with mismatched checksum of snapshot %v", address, snapshotName)
+
+		if err := m.recordCorruptedSnapshot(engine, address, snapshotName); err != nil {
+			m.logger.WithField("monitor", monitorName).WithError(err).Warnf("failed to record corrupted snapshot %v for replica %v", snapshotName, address)
+		}
 
 		if err := engineClientProxy.ReplicaModeUpdate(engine, address, string(etypes.ERR)); err != nil {
 			m.logger.WithField("monitor", monitorName).Errorf("failed to update replica %v mode to ERR", address)
@@ -506,6 +510,32 @@ func (m *SnapshotMonitor) kickOutCorruptedReplicas(engine *longhorn.Engine, engi
 	}
 }
 
+// recordCorruptedSnapshot records the snapshot with the mismatched checksum in
+// the status of the replica of the address.
+func (m *SnapshotMonitor) recordCorruptedSnapshot(engine *longhorn.Engine, address, snapshotName string) error {
+	replicaName := ""
+	for name, replicaAddress := range engine.Status.CurrentReplicaAddressMap {
+		if replicaAddress == engineapi.GetAddressFromBackendReplicaURL(address) {
+			replicaName = name
+			break
+		}
+	}
+	if replicaName == "" {
+		return fmt.Errorf("cannot find the replica of address %v in engine %v", address, engine.Name)
+	}
+
+	replica, err := m.ds.GetReplica(replicaName)
+	if err != nil {
+		return err
+	}
+	if replica.Status.LastCorruptedSnapshot == snapshotName {
+		return nil
+	}
+	replica.Status.LastCorruptedSnapshot = snapshotName
+	_, err = m.ds.UpdateReplicaStatus(replica)
+	return err
+}
+
 func determineChecksumFromHashStatus(log logrus.FieldLogger, snapshotName, existingChecksum string, hashStatus map[string]*longhorn.HashStatus) (string, error) {
 	checksum := ""
 	defer func() {
@@ -1,13 +1,24 @@
 package monitor
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/longhorn/longhorn-manager/datastore"
+
 	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
+	lhfake "github.com/longhorn/longhorn-manager/k8s/pkg/client/clientset/versioned/fake"
+	lhinformers "github.com/longhorn/longhorn-manager/k8s/pkg/client/informers/externalversions"
 )
 
 type testset struct {
@@ -546,3 +557,81 @@ func TestDetermineChecksumFromHashStatus(t *testing.T) {
 		}
 	}
 }
+
+func TestRecordCorruptedSnapshot(t *testing.T) {
+	assert := require.New(t)
+
+	replicaName := "test-volume-r-000000001"
+	engine := &longhorn.Engine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-volume-e-0",
+			Namespace: testNamespace,
+		},
+		Status: longhorn.EngineStatus{
+			CurrentReplicaAddressMap: map[string]string{
+				replicaName: "10.0.0.1:10000",
+			},
+		},
+	}
+
+	type testCase struct {
+		address string
+
+		expectedSnapshot string
+		expectError      bool
+	}
+	testCases := map[string]testCase{
+		"replica found by address": {
+			address:          "tcp://10.0.0.1:10000",
+			expectedSnapshot: "snap-01",
+		},
+		"unknown replica address": {
+			address:     "tcp://10.0.0.2:10000",
+			expectError: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		kubeClient := fake.NewSimpleClientset()
+		kubeInformerFactory := informers.NewSharedInformerFactory(kubeClient, 0)
+		lhClient := lhfake.NewSimpleClientset()
+		lhInformerFactory := lhinformers.NewSharedInformerFactory(lhClient, 0)
+		extensionsClient := apiextensionsfake.NewSimpleClientset()
+		ds, err := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, testNamespace)
+		assert.NoError(err)
+
+		replica, err := lhClient.LonghornV1beta2().Replicas(testNamespace).Create(context.TODO(), &longhorn.Replica{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      replicaName,
+				Namespace: testNamespace,
+			},
+			Spec: longhorn.ReplicaSpec{
+				InstanceSpec: longhorn.InstanceSpec{
+					VolumeName: "test-volume",
+				},
+			},
+		}, metav1.CreateOptions{})
+		assert.NoError(err)
+		err = lhInformerFactory.Longhorn().V1beta2().Replicas().Informer().GetIndexer().Add(replica)
+		assert.NoError(err)
+
+		ctx, quit := context.WithCancel(context.Background())
+		m := &SnapshotMonitor{
+			baseMonitor: newBaseMonitor(ctx, quit, logrus.StandardLogger(), ds, 0),
+		}
+
+		err = m.recordCorruptedSnapshot(engine, tc.address, "snap-01")
+		quit()
+		if tc.expectError {
+			assert.Error(err, "test case: %v", name)
+			continue
+		}
+		assert.NoError(err, "test case: %v", name)
+
+		replica, err = lhClient.LonghornV1beta2().Replicas(testNamespace).Get(context.TODO(), replicaName, metav1.GetOptions{})
+		assert.NoError(err)
+		assert.Equal(tc.expectedSnapshot, replica.Status.LastCorruptedSnapshot, "test case: %v", name)
+	}
+}
@@ -3,6 +3,7 @@ package controller
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -59,7 +60,7 @@ type NodeController struct {
 
 	ds *datastore.DataStore
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 
 	topologyLabelsChecker TopologyLabelsChecker
 
@@ -87,7 +88,7 @@ func NewNodeController(
 		controllerID: controllerID,
 
 		kubeClient:    kubeClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-node-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-node-controller"})),
 
 		ds: ds,
 
@@ -96,6 +97,8 @@ func NewNodeController(
 		topologyLabelsChecker: util.IsKubernetesVersionAtLeast,
 
 		snapshotChangeEventQueue: workqueue.New(),
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	nc.scheduler = scheduler.NewReplicaScheduler(ds)
@@ -108,7 +111,7 @@ func NewNodeController(
 		DeleteFunc: nc.enqueueNode,
 	}, nodeControllerResyncPeriod)
 
-	nc.cacheSyncs = append(nc.cacheSyncs, ds.NodeInformer.HasSynced)
+	nc.cacheSyncs["NodeInformer"] = ds.NodeInformer.HasSynced
 
 	ds.SettingInformer.AddEventHandlerWithResyncPeriod(
 		cache.FilteringResourceEventHandler{
@@ -118,7 +121,7 @@ func NewNodeController(
 				UpdateFunc: func(old, cur interface{}) { nc.enqueueSetting(cur) },
 			},
 		}, 0)
-	nc.cacheSyncs = append(nc.cacheSyncs, ds.SettingInformer.HasSynced)
+	nc.cacheSyncs["SettingInformer"] = ds.SettingInformer.HasSynced
 
 	ds.ReplicaInformer.AddEventHandlerWithResyncPeriod(
 		cache.FilteringResourceEventHandler{
@@ -129,7 +132,7 @@ func NewNodeController(
 				DeleteFunc: nc.enqueueReplica,
 			},
 		}, 0)
-	nc.cacheSyncs = append(nc.cacheSyncs, ds.ReplicaInformer.HasSynced)
+	nc.cacheSyncs["ReplicaInformer"] = ds.ReplicaInformer.HasSynced
 
 	ds.SnapshotInformer.AddEventHandlerWithResyncPeriod(
 		cache.FilteringResourceEventHandler{
@@ -138,7 +141,7 @@ func NewNodeController(
 				UpdateFunc: func(old, cur interface{}) { nc.enqueueSnapshot(old, cur) },
 			},
 		}, 0)
-	nc.cacheSyncs = append(nc.cacheSyncs, ds.SnapshotInformer.HasSynced)
+	nc.cacheSyncs["SnapshotInformer"] = ds.SnapshotInformer.HasSynced
 
 	ds.PodInformer.AddEventHandlerWithResyncPeriod(
 		cache.FilteringResourceEventHandler{
@@ -149,13 +152,13 @@ func NewNodeController(
 				DeleteFunc: nc.enqueueManagerPod,
 			},
 		}, 0)
-	nc.cacheSyncs = append(nc.cacheSyncs, ds.PodInformer.HasSynced)
+	nc.cacheSyncs["PodInformer"] = ds.PodInformer.HasSynced
 
 	ds.KubeNodeInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		UpdateFunc: func(old, cur interface{}) { nc.enqueueKubernetesNode(cur) },
 		DeleteFunc: nc.enqueueKubernetesNode,
 	}, 0)
-	nc.cacheSyncs = append(nc.cacheSyncs, ds.KubeNodeInformer.HasSynced)
+	nc.cacheSyncs["KubeNodeInformer"] = ds.KubeNodeInformer.HasSynced
 
 	return nc
 }
@@ -177,7 +180,10 @@ func (nc *NodeController) isResponsibleForSetting(obj interface{}) bool {
 
 	return types.SettingName(setting.Name) == types.SettingNameStorageMinimalAvailablePercentage ||
 		types.SettingName(setting.Name) == types.SettingNameBackingImageCleanupWaitInterval ||
-		types.SettingName(setting.Name) == types.SettingNameOrphanAutoDeletion
+		types.SettingName(setting.Name) == types.SettingNameOrphanAutoDeletion ||
+		types.SettingName(setting.Name) == types.SettingNameOrphanAutoDeletionGracePeriod ||
+		types.SettingName(setting.Name) == types.SettingNameDiskPressurePercentage ||
+		types.SettingName(setting.Name) == types.SettingNameNodeDrainPolicy
 }
 
 func (nc *NodeController) isResponsibleForReplica(obj interface{}) bool {
@@ -274,12 +280,12 @@ func isManagerPod(obj interface{}) bool {
 
 func (nc *NodeController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer nc.queue.ShutDown()
+	defer nc.queue.ShutDownWithDrain()
 
 	logrus.Infof("Starting Longhorn node controller")
 	defer logrus.Infof("Shut down Longhorn node controller")
 
-	if !cache.WaitForNamedCacheSync("longhorn node", stopCh, nc.cacheSyncs...) {
+	if !nc.waitForCacheSync(stopCh, nc.cacheSyncs) {
 		return
 	}
 
@@ -303,7 +309,7 @@ func (nc *NodeController) processNextWorkItem() bool {
 	}
 	defer nc.queue.Done(key)
 
-	err := nc.syncNode(key.(string))
+	err := nc.syncWithPanicRecovery(key.(string), nc.syncNode)
 	nc.handleErr(err, key)
 
 	return true
@@ -315,7 +321,7 @@ func (nc *NodeController) handleErr(err error, key interface{}) {
 		return
 	}
 
-	if nc.queue.NumRequeues(key) < maxRetries {
+	if nc.queue.NumRequeues(key) < MaxRetries {
 		logrus.Warnf("Error syncing Longhorn node %v: %v", key, err)
 		nc.queue.AddRateLimited(key)
 		return
@@ -486,6 +492,10 @@ func (nc *NodeController) syncNode(key string) (err error) {
 		}
 
 		node.Status.Region, node.Status.Zone = types.GetRegionAndZone(kubeNode.Labels)
+
+		if err := nc.syncNodeAutoEvicting(node, kubeSpec.Unschedulable); err != nil {
+			return err
+		}
 	}
 
 	if nc.controllerID != node.Name {
@@ -804,6 +814,9 @@ func (nc *NodeController) updateReadyDiskStatusReadyCondition(node *longhorn.Nod
 			usableStorage := (diskInfoMap[diskName].DiskStat.StorageAvailable / truncateTo) * truncateTo
 			diskStatus.StorageAvailable = usableStorage
 			diskStatus.StorageMaximum = diskInfoMap[diskName].DiskStat.StorageMaximum
+			if diskStatus.Benchmark == nil && info.Benchmark != nil {
+				diskStatus.Benchmark = info.Benchmark
+			}
 			diskStatusMap[diskName].Conditions = types.SetConditionAndRecord(diskStatusMap[diskName].Conditions,
 				longhorn.DiskConditionTypeReady, longhorn.ConditionStatusTrue,
 				"", fmt.Sprintf("Disk %v(%v) on node %v is ready", diskName, diskInfoMap[diskName].Path, node.Name),
@@ -823,12 +836,21 @@ func (nc *NodeController) updateDiskStatusSchedulableCondition(node *longhorn.No
 	if err != nil {
 		return err
 	}
+	diskPressurePercentage, err := nc.ds.GetSettingAsInt(types.SettingNameDiskPressurePercentage)
+	if err != nil {
+		return err
+	}
+	maintenanceMode, err := nc.ds.GetSettingAsBool(types.SettingNameMaintenanceMode)
+	if err != nil {
+		return err
+	}
 
 	for diskName, disk := range node.Spec.Disks {
 		diskStatus := diskStatusMap[diskName]
 
 		if types.GetCondition(diskStatus.Conditions, longhorn.DiskConditionTypeReady).Status != longhorn.ConditionStatusTrue {
 			diskStatus.StorageScheduled = 0
+			diskStatus.StorageSchedulable = 0
 			diskStatus.ScheduledReplica = map[string]int64{}
 			diskStatus.Conditions = types.SetConditionAndRecord(diskStatus.Conditions,
 				longhorn.DiskConditionTypeSchedulable, longhorn.ConditionStatusFalse,
@@ -876,11 +898,17 @@ func (nc *NodeController) updateDiskStatusSchedulableCondition(node *longhorn.No
 			}
 			diskStatus.StorageScheduled = storageScheduled
 			diskStatus.ScheduledReplica = scheduledReplica
+			if !maintenanceMode {
+				if err := nc.syncDiskPressureEviction(node, diskName, diskStatus, replicas, diskPressurePercentage); err != nil {
+					return err
+				}
+			}
 			// check disk pressure
 			info, err := nc.scheduler.GetDiskSchedulingInfo(disk, diskStatus)
 			if err != nil {
 				return err
 			}
+			diskStatus.StorageSchedulable = nc.scheduler.GetDiskStorageSchedulable(info)
 			if !nc.scheduler.IsSchedulableToDisk(0, 0, info) {
 				diskStatus.Conditions = types.SetConditionAndRecord(diskStatus.Conditions,
 					longhorn.DiskConditionTypeSchedulable, longhorn.ConditionStatusFalse,
@@ -894,6 +922,7 @@ func (nc *NodeController) updateDiskStatusSchedulableCondition(node *longhorn.No
 					"", fmt.Sprintf("Disk %v(%v) on node %v is schedulable", diskName, disk.Path, node.Name),
 					nc.eventRecorder, node, v1.EventTypeNormal)
 			}
+			nc.updateDiskStatusEvictedCondition(node, diskName, disk, diskStatus)
 		}
 
 		diskStatusMap[diskName] = diskStatus
@@ -902,6 +931,145 @@ func (nc *NodeController) updateDiskStatusSchedulableCondition(node *longhorn.No
 	return nil
 }
 
+// updateDiskStatusEvictedCondition reports whether the replicas have been
+// drained from a disk of which the eviction is requested or the scheduling is
+// disabled. The replica controller requests the eviction of the replicas on
+// such a disk. The condition stays False while replicas remain on the disk, so
+// the disk is not considered fully disabled before the replicas are relocated.
+func (nc *NodeController) updateDiskStatusEvictedCondition(node *longhorn.Node, diskName string, disk longhorn.DiskSpec, diskStatus *longhorn.DiskStatus) {
+	if !node.Spec.EvictionRequested && !disk.EvictionRequested && disk.AllowScheduling {
+		diskStatus.Conditions = types.SetCondition(diskStatus.Conditions,
+			longhorn.DiskConditionTypeEvicted, longhorn.ConditionStatusFalse,
+			string(longhorn.DiskConditionReasonEvictionNotRequested), "")
+		return
+	}
+
+	if len(diskStatus.ScheduledReplica) > 0 {
+		diskStatus.Conditions = types.SetConditionAndRecord(diskStatus.Conditions,
+			longhorn.DiskConditionTypeEvicted, longhorn.ConditionStatusFalse,
+			string(longhorn.DiskConditionReasonEvictionInProgress),
+			fmt.Sprintf("the disk %v(%v) on the node %v still has %v replica(s) to evict", diskName, disk.Path, node.Name, len(diskStatus.ScheduledReplica)),
+			nc.eventRecorder, node, v1.EventTypeNormal)
+		return
+	}
+
+	diskStatus.Conditions = types.SetConditionAndRecord(diskStatus.Conditions,
+		longhorn.DiskConditionTypeEvicted, longhorn.ConditionStatusTrue,
+		"", fmt.Sprintf("All replicas have been evicted from disk %v(%v) on node %v", diskName, disk.Path, node.Name),
+		nc.eventRecorder, node, v1.EventTypeNormal)
+}
+
+// syncDiskPressureEviction requests eviction for the replicas on the disk when
+// the disk usage exceeds the disk pressure percentage. The largest replicas are
+// picked first until the estimated usage drops below the threshold. The request
+// is withdrawn once the disk is no longer under pressure.
+func (nc *NodeController) syncDiskPressureEviction(node *longhorn.Node, diskName string, diskStatus *longhorn.DiskStatus, replicas map[string]*longhorn.Replica, diskPressurePercentage int64) error {
+	log := getLoggerForNode(nc.logger, node).WithField("disk", diskName)
+
+	evictionKey := types.GetLonghornLabelKey(types.DiskPressureEvictionAnnotationKeySuffix)
+
+	underPressure := scheduler.IsDiskUnderPressure(diskStatus, diskPressurePercentage)
+	bytesToFree := diskStatus.StorageMaximum - diskStatus.StorageAvailable - diskStatus.StorageMaximum*diskPressurePercentage/100
+
+	candidates := []*longhorn.Replica{}
+	for _, replica := range replicas {
+		_, requested := replica.Annotations[evictionKey]
+		if !underPressure {
+			if requested {
+				if _, err := nc.ds.UpdateReplicaWithRetry(replica.Name, func(r *longhorn.Replica) {
+					delete(r.Annotations, evictionKey)
+				}); err != nil {
+					return err
+				}
+				log.Infof("Cancelled eviction of replica %v since the disk is no longer under pressure", replica.Name)
+			}
+			continue
+		}
+		// the replicas already being evicted will free up the space as well
+		if requested || replica.Status.EvictionRequested {
+			bytesToFree -= replica.Spec.VolumeSize
+			continue
+		}
+		if replica.DeletionTimestamp != nil || replica.Spec.FailedAt != "" {
+			continue
+		}
+		candidates = append(candidates, replica)
+	}
+	if !underPressure || bytesToFree <= 0 {
+		return nil
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Spec.VolumeSize > candidates[j].Spec.VolumeSize
+	})
+	for _, replica := range candidates {
+		if bytesToFree <= 0 {
+			break
+		}
+		hasOtherHealthyReplica, err := nc.hasOtherNonEvictingHealthyReplica(replica)
+		if err != nil {
+			return err
+		}
+		if !hasOtherHealthyReplica {
+			continue
+		}
+		if _, err := nc.ds.UpdateReplicaWithRetry(replica.Name, func(r *longhorn.Replica) {
+			if r.Annotations == nil {
+				r.Annotations = map[string]string{}
+			}
+			r.Annotations[evictionKey] = ""
+		}); err != nil {
+			return err
+		}
+		bytesToFree -= replica.Spec.VolumeSize
+		nc.eventRecorder.Eventf(node, v1.EventTypeWarning, constant.EventReasonDiskPressureEviction,
+			"Evicting replica %v from disk %v since the disk usage exceeds %v%%", replica.Name, diskName, diskPressurePercentage)
+	}
+	return nil
+}
+
+// hasOtherNonEvictingHealthyReplica checks if the volume of the replica has
+// another healthy replica that is not being evicted
+func (nc *NodeController) hasOtherNonEvictingHealthyReplica(replica *longhorn.Replica) (bool, error) {
+	volumeReplicas, err := nc.ds.ListVolumeReplicas(replica.Spec.VolumeName)
+	if err != nil {
+		return false, err
+	}
+	evictionKey := types.GetLonghornLabelKey(types.DiskPressureEvictionAnnotationKeySuffix)
+	for _, r := range volumeReplicas {
+		if r.Name == replica.Name || !datastore.IsAvailableHealthyReplica(r) || r.Status.EvictionRequested {
+			continue
+		}
+		if _, requested := r.Annotations[evictionKey]; requested {
+			continue
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// syncNodeAutoEvicting requests the eviction of all replicas on a cordoned node
+// when the node drain policy is block-for-eviction. The replica instance manager
+// PDB keeps blocking the drain until no replica is left on the node.
+func (nc *NodeController) syncNodeAutoEvicting(node *longhorn.Node, unschedulable bool) error {
+	nodeDrainPolicy, err := nc.ds.GetSettingValueExisted(types.SettingNameNodeDrainPolicy)
+	if err != nil {
+		return err
+	}
+
+	autoEvicting := unschedulable && nodeDrainPolicy == string(types.NodeDrainPolicyBlockForEviction)
+	if autoEvicting == node.Status.AutoEvicting {
+		return nil
+	}
+	node.Status.AutoEvicting = autoEvicting
+	if autoEvicting {
+		nc.eventRecorder.Eventf(node, v1.EventTypeNormal, constant.EventReasonEvictingReplicas, "Evicting replicas from node %v since it is cordoned", node.Name)
+	} else {
+		nc.eventRecorder.Eventf(node, v1.EventTypeNormal, constant.EventReasonEvictingReplicas, "Stopped evicting replicas from node %v", node.Name)
+	}
+	return nil
+}
+
 func (nc *NodeController) syncNodeStatus(pod *v1.Pod, node *longhorn.Node) error {
 	// sync bidirectional mount propagation for node status to check whether the node could deploy CSI driver
 	for _, mount := range pod.Spec.Containers[0].VolumeMounts {
@@ -1207,6 +1375,10 @@ func (nc *NodeController) deleteOrphans(node *longhorn.Node, diskName string, di
 	if err != nil {
 		return errors.Wrapf(err, "failed to get %v setting", types.SettingNameOrphanAutoDeletion)
 	}
+	gracePeriod, err := nc.ds.GetSettingAsInt(types.SettingNameOrphanAutoDeletionGracePeriod)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get %v setting", types.SettingNameOrphanAutoDeletionGracePeriod)
+	}
 
 	for dirName := range missingOrphanedReplicaDirectoryNames {
 		orphanName := types.GetOrphanChecksumNameForOrphanedDirectory(node.Name, diskName, diskInfo.Path, diskInfo.DiskUUID, dirName)
@@ -1230,7 +1402,9 @@ func (nc *NodeController) deleteOrphans(node *longhorn.Node, diskName string, di
 			continue
 		}
 
-		if autoDeletionEnabled || dataCleanableCondition.Status == longhorn.ConditionStatusFalse {
+		autoDeletable := autoDeletionEnabled &&
+			time.Since(orphan.CreationTimestamp.Time) >= time.Duration(gracePeriod)*time.Second
+		if autoDeletable || dataCleanableCondition.Status == longhorn.ConditionStatusFalse {
 			if err := nc.ds.DeleteOrphan(orphan.Name); err != nil && !apierrors.IsNotFound(err) {
 				return errors.Wrapf(err, "failed to delete orphan %v", orphan.Name)
 			}
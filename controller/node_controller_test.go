@@ -53,8 +53,11 @@ type NodeTestCase struct {
 }
 
 func newTestNodeController(lhInformerFactory lhinformerfactory.SharedInformerFactory, kubeInformerFactory informers.SharedInformerFactory,
-	lhClient *lhfake.Clientset, kubeClient *fake.Clientset, extensionsClient *apiextensionsfake.Clientset, controllerID string) *NodeController {
-	ds := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+	lhClient *lhfake.Clientset, kubeClient *fake.Clientset, extensionsClient *apiextensionsfake.Clientset, controllerID string) (*NodeController, error) {
+	ds, err := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+	if err != nil {
+		return nil, err
+	}
 
 	logger := logrus.StandardLogger()
 	nc := NewNodeController(logger, ds, scheme.Scheme, kubeClient, TestNamespace, controllerID)
@@ -67,14 +70,14 @@ func newTestNodeController(lhInformerFactory lhinformerfactory.SharedInformerFac
 	}
 	mon, err := monitor.NewFakeNodeMonitor(nc.logger, nc.ds, controllerID, enqueueNodeForMonitor)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 	nc.diskMonitor = mon
 
 	for index := range nc.cacheSyncs {
 		nc.cacheSyncs[index] = alwaysReady
 	}
-	return nc
+	return nc, nil
 }
 
 func fakeTopologyLabelsChecker(kubeClient clientset.Interface, vers string) (bool, error) {
@@ -269,6 +272,7 @@ func (s *TestSuite) TestSyncNode(c *C) {
 					Conditions: []longhorn.Condition{
 						newNodeCondition(longhorn.DiskConditionTypeReady, longhorn.ConditionStatusTrue, ""),
 						newNodeCondition(longhorn.DiskConditionTypeSchedulable, longhorn.ConditionStatusFalse, string(longhorn.DiskConditionReasonDiskPressure)),
+						newNodeCondition(longhorn.DiskConditionTypeEvicted, longhorn.ConditionStatusFalse, string(longhorn.DiskConditionReasonEvictionNotRequested)),
 					},
 					ScheduledReplica: map[string]int64{
 						replica1.Name: replica1.Spec.VolumeSize,
@@ -358,6 +362,7 @@ func (s *TestSuite) TestSyncNode(c *C) {
 					Conditions: []longhorn.Condition{
 						newNodeCondition(longhorn.DiskConditionTypeSchedulable, longhorn.ConditionStatusFalse, string(longhorn.DiskConditionReasonDiskPressure)),
 						newNodeCondition(longhorn.DiskConditionTypeReady, longhorn.ConditionStatusTrue, ""),
+						newNodeCondition(longhorn.DiskConditionTypeEvicted, longhorn.ConditionStatusFalse, string(longhorn.DiskConditionReasonEvictionNotRequested)),
 					},
 					ScheduledReplica: map[string]int64{},
 					DiskUUID:         TestDiskID1,
@@ -513,6 +518,7 @@ func (s *TestSuite) TestSyncNode(c *C) {
 					Conditions: []longhorn.Condition{
 						newNodeCondition(longhorn.DiskConditionTypeSchedulable, longhorn.ConditionStatusFalse, string(longhorn.DiskConditionReasonDiskPressure)),
 						newNodeCondition(longhorn.DiskConditionTypeReady, longhorn.ConditionStatusTrue, ""),
+						newNodeCondition(longhorn.DiskConditionTypeEvicted, longhorn.ConditionStatusFalse, string(longhorn.DiskConditionReasonEvictionNotRequested)),
 					},
 					ScheduledReplica: map[string]int64{},
 					DiskUUID:         TestDiskID1,
@@ -575,6 +581,7 @@ func (s *TestSuite) TestSyncNode(c *C) {
 					Conditions: []longhorn.Condition{
 						newNodeCondition(longhorn.DiskConditionTypeSchedulable, longhorn.ConditionStatusFalse, string(longhorn.DiskConditionReasonDiskPressure)),
 						newNodeCondition(longhorn.DiskConditionTypeReady, longhorn.ConditionStatusTrue, ""),
+						newNodeCondition(longhorn.DiskConditionTypeEvicted, longhorn.ConditionStatusFalse, string(longhorn.DiskConditionReasonEvictionNotRequested)),
 					},
 					ScheduledReplica: map[string]int64{},
 					DiskUUID:         TestDiskID1,
@@ -711,7 +718,8 @@ func (s *TestSuite) TestSyncNode(c *C) {
 
 		extensionsClient := apiextensionsfake.NewSimpleClientset()
 
-		nc := newTestNodeController(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient, extensionsClient, TestNode1)
+		nc, err := newTestNodeController(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient, extensionsClient, TestNode1)
+		c.Assert(err, IsNil)
 		c.Assert(err, IsNil)
 
 		// create manager pod
@@ -822,3 +830,75 @@ func (s *TestSuite) TestSyncNode(c *C) {
 		}
 	}
 }
+
+func (s *TestSuite) TestUpdateDiskStatusEvictedCondition(c *C) {
+	type testCase struct {
+		nodeEvictionRequested  bool
+		diskEvictionRequested  bool
+		diskSchedulingDisabled bool
+		scheduledReplica       map[string]int64
+
+		expectedStatus longhorn.ConditionStatus
+		expectedReason string
+	}
+	testCases := map[string]testCase{
+		"eviction not requested": {
+			scheduledReplica: map[string]int64{"replica-1": TestVolumeSize},
+			expectedStatus:   longhorn.ConditionStatusFalse,
+			expectedReason:   string(longhorn.DiskConditionReasonEvictionNotRequested),
+		},
+		"disk eviction in progress": {
+			diskEvictionRequested: true,
+			scheduledReplica:      map[string]int64{"replica-1": TestVolumeSize},
+			expectedStatus:        longhorn.ConditionStatusFalse,
+			expectedReason:        string(longhorn.DiskConditionReasonEvictionInProgress),
+		},
+		"node eviction in progress": {
+			nodeEvictionRequested: true,
+			scheduledReplica:      map[string]int64{"replica-1": TestVolumeSize},
+			expectedStatus:        longhorn.ConditionStatusFalse,
+			expectedReason:        string(longhorn.DiskConditionReasonEvictionInProgress),
+		},
+		"disk scheduling disabled with replicas": {
+			diskSchedulingDisabled: true,
+			scheduledReplica:       map[string]int64{"replica-1": TestVolumeSize},
+			expectedStatus:         longhorn.ConditionStatusFalse,
+			expectedReason:         string(longhorn.DiskConditionReasonEvictionInProgress),
+		},
+		"all replicas drained from disk with scheduling disabled": {
+			diskSchedulingDisabled: true,
+			scheduledReplica:       map[string]int64{},
+			expectedStatus:         longhorn.ConditionStatusTrue,
+			expectedReason:         "",
+		},
+		"all replicas evicted": {
+			diskEvictionRequested: true,
+			scheduledReplica:      map[string]int64{},
+			expectedStatus:        longhorn.ConditionStatusTrue,
+			expectedReason:        "",
+		},
+	}
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		nc := &NodeController{
+			eventRecorder: record.NewFakeRecorder(100),
+		}
+		node := newNode(TestNode1, TestNamespace, true, longhorn.ConditionStatusTrue, "")
+		node.Spec.EvictionRequested = tc.nodeEvictionRequested
+		disk := longhorn.DiskSpec{
+			Path:              TestDefaultDataPath,
+			AllowScheduling:   !tc.diskSchedulingDisabled,
+			EvictionRequested: tc.diskEvictionRequested,
+		}
+		diskStatus := &longhorn.DiskStatus{
+			ScheduledReplica: tc.scheduledReplica,
+		}
+
+		nc.updateDiskStatusEvictedCondition(node, TestDiskID1, disk, diskStatus)
+
+		condition := types.GetCondition(diskStatus.Conditions, longhorn.DiskConditionTypeEvicted)
+		c.Assert(condition.Status, Equals, tc.expectedStatus, Commentf("test case: %v", name))
+		c.Assert(condition.Reason, Equals, tc.expectedReason, Commentf("test case: %v", name))
+	}
+}
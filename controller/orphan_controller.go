@@ -39,7 +39,7 @@ type OrphanController struct {
 
 	ds *datastore.DataStore
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 }
 
 func NewOrphanController(
@@ -66,7 +66,9 @@ func NewOrphanController(
 		ds: ds,
 
 		kubeClient:    kubeClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-orphan-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-orphan-controller"})),
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	ds.OrphanInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -74,14 +76,14 @@ func NewOrphanController(
 		UpdateFunc: func(old, cur interface{}) { oc.enqueueOrphan(cur) },
 		DeleteFunc: oc.enqueueOrphan,
 	})
-	oc.cacheSyncs = append(oc.cacheSyncs, ds.OrphanInformer.HasSynced)
+	oc.cacheSyncs["OrphanInformer"] = ds.OrphanInformer.HasSynced
 
 	ds.NodeInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc:    func(cur interface{}) { oc.enqueueForLonghornNode(cur) },
 		UpdateFunc: func(old, cur interface{}) { oc.enqueueForLonghornNode(cur) },
 		DeleteFunc: func(cur interface{}) { oc.enqueueForLonghornNode(cur) },
 	}, 0)
-	oc.cacheSyncs = append(oc.cacheSyncs, ds.NodeInformer.HasSynced)
+	oc.cacheSyncs["NodeInformer"] = ds.NodeInformer.HasSynced
 
 	return oc
 }
@@ -125,12 +127,12 @@ func (oc *OrphanController) enqueueForLonghornNode(obj interface{}) {
 
 func (oc *OrphanController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer oc.queue.ShutDown()
+	defer oc.queue.ShutDownWithDrain()
 
 	oc.logger.Infof("Starting Longhorn Orphan controller")
 	defer oc.logger.Infof("Shut down Longhorn Orphan controller")
 
-	if !cache.WaitForNamedCacheSync(oc.name, stopCh, oc.cacheSyncs...) {
+	if !oc.waitForCacheSync(stopCh, oc.cacheSyncs) {
 		return
 	}
 	for i := 0; i < workers; i++ {
@@ -150,7 +152,7 @@ func (oc *OrphanController) processNextWorkItem() bool {
 		return false
 	}
 	defer oc.queue.Done(key)
-	err := oc.syncOrphan(key.(string))
+	err := oc.syncWithPanicRecovery(key.(string), oc.syncOrphan)
 	oc.handleErr(err, key)
 	return true
 }
@@ -163,7 +165,7 @@ func (oc *OrphanController) handleErr(err error, key interface{}) {
 
 	log := oc.logger.WithField("orphan", key)
 
-	if oc.queue.NumRequeues(key) < maxRetries {
+	if oc.queue.NumRequeues(key) < MaxRetries {
 		log.WithError(err).Warnf("Error syncing Longhorn orphan %v: %v", key, err)
 
 		oc.queue.AddRateLimited(key)
@@ -43,7 +43,7 @@ type RecurringJobController struct {
 
 	ds *datastore.DataStore
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 }
 
 func NewRecurringJobController(
@@ -68,9 +68,11 @@ func NewRecurringJobController(
 		serviceAccount: serviceAccount,
 
 		kubeClient:    kubeClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, corev1.EventSource{Component: "longhorn-recurring-job-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, corev1.EventSource{Component: "longhorn-recurring-job-controller"})),
 
 		ds: ds,
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	ds.RecurringJobInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -78,7 +80,7 @@ func NewRecurringJobController(
 		UpdateFunc: func(old, cur interface{}) { rjc.enqueueRecurringJob(cur) },
 		DeleteFunc: rjc.enqueueRecurringJob,
 	})
-	rjc.cacheSyncs = append(rjc.cacheSyncs, ds.RecurringJobInformer.HasSynced)
+	rjc.cacheSyncs["RecurringJobInformer"] = ds.RecurringJobInformer.HasSynced
 
 	return rjc
 }
@@ -95,12 +97,12 @@ func (control *RecurringJobController) enqueueRecurringJob(obj interface{}) {
 
 func (control *RecurringJobController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer control.queue.ShutDown()
+	defer control.queue.ShutDownWithDrain()
 
 	logrus.Infof("Starting Longhorn Recurring Job controller")
 	defer logrus.Infof("Shut down Longhorn Recurring Job controller")
 
-	if !cache.WaitForNamedCacheSync("longhorn recurring jobs", stopCh, control.cacheSyncs...) {
+	if !control.waitForCacheSync(stopCh, control.cacheSyncs) {
 		return
 	}
 
@@ -124,7 +126,7 @@ func (control *RecurringJobController) processNextWorkItem() bool {
 	}
 	defer control.queue.Done(key)
 
-	err := control.syncRecurringJob(key.(string))
+	err := control.syncWithPanicRecovery(key.(string), control.syncRecurringJob)
 	control.handleErr(err, key)
 
 	return true
@@ -136,7 +138,7 @@ func (control *RecurringJobController) handleErr(err error, key interface{}) {
 		return
 	}
 
-	if control.queue.NumRequeues(key) < maxRetries {
+	if control.queue.NumRequeues(key) < MaxRetries {
 		logrus.Warnf("Error syncing Longhorn recurring job %v: %v", key, err)
 		control.queue.AddRateLimited(key)
 		return
@@ -295,6 +297,13 @@ func (control *RecurringJobController) cleanupVolumeRecurringJob(recurringJob *l
 }
 
 func (control *RecurringJobController) detachVolumeAutoAttachedByRecurringJob(name string, v *longhorn.Volume) error {
+	ticketID := types.GetAttachmentTicketID(longhorn.AttacherTypeRecurringJob, name)
+	if err := control.ds.RemoveAttachmentTickets(v.Name, func(ticket *longhorn.AttachmentTicket) bool {
+		return ticket.ID == ticketID
+	}); err != nil {
+		return err
+	}
+
 	if v.Spec.LastAttachedBy != name {
 		return nil
 	}
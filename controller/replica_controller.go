@@ -49,7 +49,7 @@ type ReplicaController struct {
 
 	ds *datastore.DataStore
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 
 	instanceHandler *InstanceHandler
 
@@ -76,12 +76,14 @@ func NewReplicaController(
 		controllerID: controllerID,
 
 		kubeClient:    kubeClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-replica-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-replica-controller"})),
 
 		ds: ds,
 
 		rebuildingLock:          &sync.Mutex{},
 		inProgressRebuildingMap: map[string]struct{}{},
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 	rc.instanceHandler = NewInstanceHandler(ds, rc, rc.eventRecorder)
 
@@ -98,45 +100,45 @@ func NewReplicaController(
 		},
 		DeleteFunc: rc.enqueueReplica,
 	})
-	rc.cacheSyncs = append(rc.cacheSyncs, ds.ReplicaInformer.HasSynced)
+	rc.cacheSyncs["ReplicaInformer"] = ds.ReplicaInformer.HasSynced
 
 	ds.InstanceManagerInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc:    rc.enqueueInstanceManagerChange,
 		UpdateFunc: func(old, cur interface{}) { rc.enqueueInstanceManagerChange(cur) },
 		DeleteFunc: rc.enqueueInstanceManagerChange,
 	}, 0)
-	rc.cacheSyncs = append(rc.cacheSyncs, ds.InstanceManagerInformer.HasSynced)
+	rc.cacheSyncs["InstanceManagerInformer"] = ds.InstanceManagerInformer.HasSynced
 
 	ds.NodeInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc:    rc.enqueueNodeAddOrDelete,
 		UpdateFunc: rc.enqueueNodeChange,
 		DeleteFunc: rc.enqueueNodeAddOrDelete,
 	}, 0)
-	rc.cacheSyncs = append(rc.cacheSyncs, ds.NodeInformer.HasSynced)
+	rc.cacheSyncs["NodeInformer"] = ds.NodeInformer.HasSynced
 
 	ds.BackingImageInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc:    rc.enqueueBackingImageChange,
 		UpdateFunc: func(old, cur interface{}) { rc.enqueueBackingImageChange(cur) },
 		DeleteFunc: rc.enqueueBackingImageChange,
 	}, 0)
-	rc.cacheSyncs = append(rc.cacheSyncs, ds.BackingImageInformer.HasSynced)
+	rc.cacheSyncs["BackingImageInformer"] = ds.BackingImageInformer.HasSynced
 
 	ds.SettingInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		UpdateFunc: func(old, cur interface{}) { rc.enqueueSettingChange(cur) },
 	}, 0)
-	rc.cacheSyncs = append(rc.cacheSyncs, ds.SettingInformer.HasSynced)
+	rc.cacheSyncs["SettingInformer"] = ds.SettingInformer.HasSynced
 
 	return rc
 }
 
 func (rc *ReplicaController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer rc.queue.ShutDown()
+	defer rc.queue.ShutDownWithDrain()
 
 	rc.logger.Info("Starting Longhorn replica controller")
 	defer rc.logger.Info("Shut down Longhorn replica controller")
 
-	if !cache.WaitForNamedCacheSync("longhorn replicas", stopCh, rc.cacheSyncs...) {
+	if !rc.waitForCacheSync(stopCh, rc.cacheSyncs) {
 		return
 	}
 
@@ -160,7 +162,7 @@ func (rc *ReplicaController) processNextWorkItem() bool {
 	}
 	defer rc.queue.Done(key)
 
-	err := rc.syncReplica(key.(string))
+	err := rc.syncWithPanicRecovery(key.(string), rc.syncReplica)
 	rc.handleErr(err, key)
 
 	return true
@@ -172,7 +174,7 @@ func (rc *ReplicaController) handleErr(err error, key interface{}) {
 		return
 	}
 
-	if rc.queue.NumRequeues(key) < maxRetries {
+	if rc.queue.NumRequeues(key) < MaxRetries {
 		rc.logger.WithError(err).Warnf("Error syncing Longhorn replica %v", key)
 		rc.queue.AddRateLimited(key)
 		return
@@ -195,7 +197,8 @@ func getLoggerForReplica(logger logrus.FieldLogger, r *longhorn.Replica) *logrus
 }
 
 // From replica to check Node.Spec.EvictionRequested of the node
-// this replica first, then check Node.Spec.Disks.EvictionRequested
+// this replica first, then check Node.Spec.Disks.EvictionRequested.
+// The replicas on a disk with scheduling disabled are drained as well.
 func (rc *ReplicaController) isEvictionRequested(replica *longhorn.Replica) bool {
 	// Return false if this replica has not been assigned to a node.
 	if replica.Spec.NodeID == "" {
@@ -222,6 +225,16 @@ func (rc *ReplicaController) isEvictionRequested(replica *longhorn.Replica) bool
 		return true
 	}
 
+	// Check if the node is being drained with the block-for-eviction policy.
+	if node.Status.AutoEvicting {
+		return true
+	}
+
+	// Check if the node controller has requested eviction because of disk pressure.
+	if _, ok := replica.Annotations[types.GetLonghornLabelKey(types.DiskPressureEvictionAnnotationKeySuffix)]; ok {
+		return true
+	}
+
 	// Check if disk has been request eviction.
 	for diskName, diskStatus := range node.Status.DiskStatus {
 		if diskStatus.DiskUUID != replica.Spec.DiskID {
@@ -232,7 +245,7 @@ func (rc *ReplicaController) isEvictionRequested(replica *longhorn.Replica) bool
 			log.Warnf("Cannot continue handling replica eviction since there is no spec for disk name %v on node %v", diskName, node.Name)
 			return false
 		}
-		return diskSpec.EvictionRequested
+		return diskSpec.EvictionRequested || !diskSpec.AllowScheduling
 	}
 
 	return false
@@ -793,11 +806,13 @@ func (rc *ReplicaController) enqueueNodeChange(oldObj, currObj interface{}) {
 		}
 	}
 
-	// if a node or disk changes its EvictionRequested, enqueue all replicas on that node/disk
-	evictionRequestedChangeOnNodeLevel := currNode.Spec.EvictionRequested != oldNode.Spec.EvictionRequested
+	// if a node or disk changes its EvictionRequested, or a disk changes its AllowScheduling, enqueue all replicas on that node/disk
+	evictionRequestedChangeOnNodeLevel := currNode.Spec.EvictionRequested != oldNode.Spec.EvictionRequested ||
+		currNode.Status.AutoEvicting != oldNode.Status.AutoEvicting
 	for diskName, newDiskSpec := range currNode.Spec.Disks {
 		oldDiskSpec, ok := oldNode.Spec.Disks[diskName]
-		evictionRequestedChangeOnDiskLevel := !ok || (newDiskSpec.EvictionRequested != oldDiskSpec.EvictionRequested)
+		evictionRequestedChangeOnDiskLevel := !ok || (newDiskSpec.EvictionRequested != oldDiskSpec.EvictionRequested) ||
+			(newDiskSpec.AllowScheduling != oldDiskSpec.AllowScheduling)
 		if diskStatus, existed := currNode.Status.DiskStatus[diskName]; existed && (evictionRequestedChangeOnNodeLevel || evictionRequestedChangeOnDiskLevel) {
 			for replicaName := range diskStatus.ScheduledReplica {
 				if replica, err := rc.ds.GetReplica(replicaName); err == nil {
@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"reflect"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -42,11 +44,48 @@ const (
 )
 
 var (
-	upgradeCheckInterval          = time.Hour
+	upgradeCheckInterval          = 24 * time.Hour
+	upgradeCheckerHTTPTimeout     = 10 * time.Second
 	settingControllerResyncPeriod = time.Hour
-	checkUpgradeURL               = "https://longhorn-upgrade-responder.rancher.io/v1/checkupgrade"
+	// settingUpdateCoalescePeriod delays the sync of an updated setting so
+	// that rapid successive updates are handled by a single reconcile
+	settingUpdateCoalescePeriod = time.Second
+	checkUpgradeURL             = "https://longhorn-upgrade-responder.rancher.io/v1/checkupgrade"
 )
 
+// UpgradeCheckResult is the result of the last upgrade check done by the
+// setting controller of this manager.
+type UpgradeCheckResult struct {
+	CurrentVersion string
+	LatestVersion  string
+	Available      bool
+}
+
+var (
+	upgradeCheckResultLock sync.RWMutex
+	upgradeCheckResult     *UpgradeCheckResult
+)
+
+// GetUpgradeCheckResult returns the result of the last upgrade check, or nil
+// if no check has completed since the upgrade checker was enabled.
+func GetUpgradeCheckResult() *UpgradeCheckResult {
+	upgradeCheckResultLock.RLock()
+	defer upgradeCheckResultLock.RUnlock()
+
+	if upgradeCheckResult == nil {
+		return nil
+	}
+	result := *upgradeCheckResult
+	return &result
+}
+
+func setUpgradeCheckResult(result *UpgradeCheckResult) {
+	upgradeCheckResultLock.Lock()
+	defer upgradeCheckResultLock.Unlock()
+
+	upgradeCheckResult = result
+}
+
 type SettingController struct {
 	*baseController
 
@@ -58,14 +97,22 @@ type SettingController struct {
 
 	ds *datastore.DataStore
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 
 	// upgrade checker
 	lastUpgradeCheckedTimestamp time.Time
 	version                     string
+	// the invalid upgrade check interval whose fallback has been logged
+	invalidUpgradeCheckInterval string
 
 	// backup store timer is responsible for updating the backupTarget.spec.syncRequestAt
 	bsTimer *BackupStoreTimer
+
+	// the last maintenance mode announced by this controller
+	maintenanceMode bool
+
+	// the log level set by the flags, used while the log level setting is empty
+	flagLogLevel logrus.Level
 }
 
 type BackupStoreTimer struct {
@@ -111,46 +158,58 @@ func NewSettingController(
 		controllerID: controllerID,
 
 		kubeClient:    kubeClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-setting-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-setting-controller"})),
 
 		ds: ds,
 
 		version: version,
+
+		flagLogLevel: logrus.GetLevel(),
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	ds.SettingInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc:    sc.enqueueSetting,
-		UpdateFunc: func(old, cur interface{}) { sc.enqueueSetting(cur) },
+		UpdateFunc: sc.enqueueSettingForUpdate,
 		DeleteFunc: sc.enqueueSetting,
 	}, settingControllerResyncPeriod)
-	sc.cacheSyncs = append(sc.cacheSyncs, ds.SettingInformer.HasSynced)
+	sc.cacheSyncs["SettingInformer"] = ds.SettingInformer.HasSynced
 
 	ds.NodeInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc:    sc.enqueueSettingForNode,
 		UpdateFunc: func(old, cur interface{}) { sc.enqueueSettingForNode(cur) },
 		DeleteFunc: sc.enqueueSettingForNode,
 	}, 0)
-	sc.cacheSyncs = append(sc.cacheSyncs, ds.NodeInformer.HasSynced)
+	sc.cacheSyncs["NodeInformer"] = ds.NodeInformer.HasSynced
 
 	ds.BackupTargetInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		DeleteFunc: sc.enqueueSettingForBackupTarget,
 	}, 0)
-	sc.cacheSyncs = append(sc.cacheSyncs, ds.BackupTargetInformer.HasSynced)
+	sc.cacheSyncs["BackupTargetInformer"] = ds.BackupTargetInformer.HasSynced
 
 	return sc
 }
 
 func (sc *SettingController) Run(stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer sc.queue.ShutDown()
+	defer sc.queue.ShutDownWithDrain()
 
 	sc.logger.Info("Starting Longhorn Setting controller")
 	defer sc.logger.Info("Shut down Longhorn Setting controller")
 
-	if !cache.WaitForNamedCacheSync("longhorn settings", stopCh, sc.cacheSyncs...) {
+	if !sc.waitForCacheSync(stopCh, sc.cacheSyncs) {
 		return
 	}
 
+	// make sure the settings exist in case of a partial installation,
+	// otherwise syncing them would keep failing
+	if err := sc.ds.CreateDefaultSettingsIfNotExist(); err != nil {
+		sc.logger.WithError(err).Warn("Failed to create the missing default settings")
+	}
+
+	sc.initLastUpgradeCheckedTimestamp()
+
 	// must remain single threaded since backup store timer is not thread-safe now
 	go wait.Until(sc.worker, time.Second, stopCh)
 
@@ -170,7 +229,7 @@ func (sc *SettingController) processNextWorkItem() bool {
 	}
 	defer sc.queue.Done(key)
 
-	err := sc.syncSetting(key.(string))
+	err := sc.syncWithPanicRecovery(key.(string), sc.syncSetting)
 	sc.handleErr(err, key)
 
 	return true
@@ -182,7 +241,7 @@ func (sc *SettingController) handleErr(err error, key interface{}) {
 		return
 	}
 
-	if sc.queue.NumRequeues(key) < maxRetries {
+	if sc.queue.NumRequeues(key) < MaxRetries {
 		sc.logger.WithError(err).Warnf("Error syncing Longhorn setting %v", key)
 		sc.queue.AddRateLimited(key)
 		return
@@ -203,7 +262,7 @@ func (sc *SettingController) syncSetting(key string) (err error) {
 		return err
 	}
 	switch name {
-	case string(types.SettingNameUpgradeChecker):
+	case string(types.SettingNameUpgradeChecker), string(types.SettingNameUpgradeCheckInterval), string(types.SettingNameUpgradeCheckerChannel):
 		if err := sc.syncUpgradeChecker(); err != nil {
 			return err
 		}
@@ -219,9 +278,11 @@ func (sc *SettingController) syncSetting(key string) (err error) {
 		if err := sc.updateNodeSelector(); err != nil {
 			return err
 		}
-	case string(types.SettingNameGuaranteedEngineManagerCPU):
-	case string(types.SettingNameGuaranteedReplicaManagerCPU):
-		if err := sc.updateInstanceManagerCPURequest(); err != nil {
+	case string(types.SettingNameGuaranteedEngineManagerCPU),
+		string(types.SettingNameGuaranteedReplicaManagerCPU),
+		string(types.SettingNameGuaranteedEngineManagerMemory),
+		string(types.SettingNameGuaranteedReplicaManagerMemory):
+		if err := sc.updateInstanceManagerResourceRequest(); err != nil {
 			return err
 		}
 	case string(types.SettingNamePriorityClass):
@@ -240,6 +301,14 @@ func (sc *SettingController) syncSetting(key string) (err error) {
 		if err := sc.cleanupFailedSupportBundles(); err != nil {
 			return err
 		}
+	case string(types.SettingNameLogLevel):
+		if err := sc.updateLogLevel(); err != nil {
+			return err
+		}
+	case string(types.SettingNameMaintenanceMode):
+		if err := sc.syncMaintenanceMode(); err != nil {
+			return err
+		}
 	default:
 	}
 
@@ -811,12 +880,25 @@ func (bst *BackupStoreTimer) Stop() {
 	bst.stopCh <- struct{}{}
 }
 
-func (sc *SettingController) syncUpgradeChecker() error {
+func (sc *SettingController) syncUpgradeChecker() (err error) {
 	upgradeCheckerEnabled, err := sc.ds.GetSettingAsBool(types.SettingNameUpgradeChecker)
 	if err != nil {
 		return err
 	}
 
+	upgradeChecker, err := sc.ds.GetSetting(types.SettingNameUpgradeChecker)
+	if err != nil {
+		return err
+	}
+	_, manuallyTriggered := upgradeChecker.Annotations[types.GetLonghornLabelKey(types.UpgradeCheckTriggerAnnotationKeySuffix)]
+	if manuallyTriggered {
+		defer func() {
+			if err == nil {
+				err = sc.removeUpgradeCheckTrigger()
+			}
+		}()
+	}
+
 	latestLonghornVersion, err := sc.ds.GetSetting(types.SettingNameLatestLonghornVersion)
 	if err != nil {
 		return err
@@ -829,25 +911,39 @@ func (sc *SettingController) syncUpgradeChecker() error {
 	if !upgradeCheckerEnabled {
 		if latestLonghornVersion.Value != "" {
 			latestLonghornVersion.Value = ""
-			if _, err := sc.ds.UpdateSetting(latestLonghornVersion); err != nil {
+			if _, err := sc.ds.UpdateReadOnlySetting(latestLonghornVersion); err != nil {
 				return err
 			}
 		}
 		if stableLonghornVersions.Value != "" {
 			stableLonghornVersions.Value = ""
-			if _, err := sc.ds.UpdateSetting(stableLonghornVersions); err != nil {
+			if _, err := sc.ds.UpdateReadOnlySetting(stableLonghornVersions); err != nil {
 				return err
 			}
 		}
 		// reset timestamp so it can be triggered immediately when
 		// setting changes next time
 		sc.lastUpgradeCheckedTimestamp = time.Time{}
+		setUpgradeCheckResult(nil)
+		if err := sc.updateLastUpgradeCheckTimestamp(); err != nil {
+			return err
+		}
 		return nil
 	}
 
+	checkInterval := sc.getUpgradeCheckInterval()
 	now := time.Now()
-	if now.Before(sc.lastUpgradeCheckedTimestamp.Add(upgradeCheckInterval)) {
-		return nil
+	if manuallyTriggered {
+		sc.logger.Info("Upgrade check is triggered manually")
+	} else {
+		if checkInterval == 0 {
+			// periodic check is disabled
+			return nil
+		}
+		if nextCheckTime := sc.lastUpgradeCheckedTimestamp.Add(checkInterval); now.Before(nextCheckTime) {
+			sc.enqueueUpgradeCheckerAfter(nextCheckTime.Sub(now))
+			return nil
+		}
 	}
 
 	currentLatestVersion := latestLonghornVersion.Value
@@ -856,14 +952,33 @@ func (sc *SettingController) syncUpgradeChecker() error {
 	if err != nil {
 		// non-critical error, don't retry
 		sc.logger.WithError(err).Debug("Failed to check for the latest and stable Longhorn versions")
+		sc.eventRecorder.Eventf(upgradeChecker, v1.EventTypeWarning, constant.EventReasonFailedCheckingUpgrade, "Failed to check for the latest and stable Longhorn versions: %v", err)
 		return nil
 	}
 
 	sc.lastUpgradeCheckedTimestamp = now
+	setUpgradeCheckResult(&UpgradeCheckResult{
+		CurrentVersion: sc.version,
+		LatestVersion:  latestLonghornVersion.Value,
+		Available:      latestLonghornVersion.Value != "" && latestLonghornVersion.Value != sc.version,
+	})
+	if checkInterval > 0 {
+		sc.enqueueUpgradeCheckerAfter(checkInterval)
+	}
+	if err := sc.updateLastUpgradeCheckTimestamp(); err != nil {
+		// non-critical error, don't retry
+		sc.logger.WithError(err).Debug("Cannot update last upgrade check timestamp")
+	}
 
 	if latestLonghornVersion.Value != currentLatestVersion {
 		sc.logger.Infof("Latest Longhorn version is %v", latestLonghornVersion.Value)
-		if _, err := sc.ds.UpdateSetting(latestLonghornVersion); err != nil {
+		if latestLonghornVersion.Value != sc.version {
+			sc.eventRecorder.Eventf(upgradeChecker, v1.EventTypeNormal, constant.EventReasonUpgradeAvailable, "New Longhorn version %v is available, the current version is %v", latestLonghornVersion.Value, sc.version)
+		}
+		latestVersion := latestLonghornVersion.Value
+		if _, err := sc.ds.UpdateReadOnlySettingWithRetry(types.SettingNameLatestLonghornVersion, func(setting *longhorn.Setting) {
+			setting.Value = latestVersion
+		}); err != nil {
 			// non-critical error, don't retry
 			sc.logger.WithError(err).Debug("Cannot update latest Longhorn version")
 			return nil
@@ -871,7 +986,7 @@ func (sc *SettingController) syncUpgradeChecker() error {
 	}
 	if stableLonghornVersions.Value != currentStableVersions {
 		sc.logger.Infof("The latest stable version of every minor release line: %v", stableLonghornVersions.Value)
-		if _, err := sc.ds.UpdateSetting(stableLonghornVersions); err != nil {
+		if _, err := sc.ds.UpdateReadOnlySetting(stableLonghornVersions); err != nil {
 			// non-critical error, don't retry
 			sc.logger.WithError(err).Debug("Cannot update stable Longhorn versions")
 			return nil
@@ -881,6 +996,141 @@ func (sc *SettingController) syncUpgradeChecker() error {
 	return nil
 }
 
+// removeUpgradeCheckTrigger removes the manual trigger annotation from the
+// upgrade checker setting once the triggered check is done.
+func (sc *SettingController) removeUpgradeCheckTrigger() error {
+	upgradeChecker, err := sc.ds.GetSetting(types.SettingNameUpgradeChecker)
+	if err != nil {
+		return err
+	}
+	triggerKey := types.GetLonghornLabelKey(types.UpgradeCheckTriggerAnnotationKeySuffix)
+	if _, ok := upgradeChecker.Annotations[triggerKey]; !ok {
+		return nil
+	}
+	delete(upgradeChecker.Annotations, triggerKey)
+	_, err = sc.ds.UpdateSetting(upgradeChecker)
+	return err
+}
+
+// initLastUpgradeCheckedTimestamp restores the last upgrade check time from the
+// setting so that a manager restart doesn't force an immediate check.
+func (sc *SettingController) initLastUpgradeCheckedTimestamp() {
+	setting, err := sc.ds.GetSetting(types.SettingNameLastUpgradeCheckTimestamp)
+	if err != nil {
+		sc.logger.WithError(err).Warnf("Failed to get setting %v", types.SettingNameLastUpgradeCheckTimestamp)
+		return
+	}
+	if setting.Value == "" {
+		return
+	}
+	timestamp, err := util.ParseTimeZ(setting.Value)
+	if err != nil {
+		sc.logger.WithError(err).Warnf("Failed to parse setting %v value %v", types.SettingNameLastUpgradeCheckTimestamp, setting.Value)
+		return
+	}
+	sc.lastUpgradeCheckedTimestamp = timestamp
+}
+
+// updateLastUpgradeCheckTimestamp persists the in-memory last upgrade check time
+// into the setting. A zero time clears the setting.
+func (sc *SettingController) updateLastUpgradeCheckTimestamp() error {
+	setting, err := sc.ds.GetSetting(types.SettingNameLastUpgradeCheckTimestamp)
+	if err != nil {
+		return err
+	}
+
+	value := ""
+	if !sc.lastUpgradeCheckedTimestamp.IsZero() {
+		value = util.FormatTimeZ(sc.lastUpgradeCheckedTimestamp)
+	}
+	if setting.Value == value {
+		return nil
+	}
+
+	setting.Value = value
+	_, err = sc.ds.UpdateReadOnlySetting(setting)
+	return err
+}
+
+// getUpgradeCheckInterval returns the interval between two upgrade checks.
+// It falls back to the default interval if the setting value is invalid.
+func (sc *SettingController) getUpgradeCheckInterval() time.Duration {
+	setting, err := sc.ds.GetSetting(types.SettingNameUpgradeCheckInterval)
+	if err != nil {
+		sc.logger.WithError(err).Warnf("Failed to get setting %v, will use the default interval %v", types.SettingNameUpgradeCheckInterval, upgradeCheckInterval)
+		return upgradeCheckInterval
+	}
+
+	interval, err := sc.ds.GetSettingAsDuration(types.SettingNameUpgradeCheckInterval)
+	if err != nil {
+		if sc.invalidUpgradeCheckInterval != setting.Value {
+			sc.invalidUpgradeCheckInterval = setting.Value
+			sc.logger.WithError(err).Warnf("Invalid setting %v, will use the default interval %v", setting.Name, upgradeCheckInterval)
+		}
+		return upgradeCheckInterval
+	}
+	sc.invalidUpgradeCheckInterval = ""
+
+	return interval
+}
+
+// getUpgradeResponderURL returns the upgrade responder URL configured by the
+// setting, or the default one if the setting is empty.
+func (sc *SettingController) getUpgradeResponderURL() (string, error) {
+	setting, err := sc.ds.GetSetting(types.SettingNameUpgradeResponderURL)
+	if err != nil {
+		return "", err
+	}
+	if setting.Value == "" {
+		return checkUpgradeURL, nil
+	}
+	if _, err := url.Parse(setting.Value); err != nil {
+		return "", errors.Wrapf(err, "invalid value %v for setting %v", setting.Value, types.SettingNameUpgradeResponderURL)
+	}
+	return setting.Value, nil
+}
+
+// getUpgradeCheckerChannel returns the release channel tracked by the upgrade
+// checker, which is the version tag to look for in the upgrade responder response.
+func (sc *SettingController) getUpgradeCheckerChannel() (string, error) {
+	setting, err := sc.ds.GetSetting(types.SettingNameUpgradeCheckerChannel)
+	if err != nil {
+		return "", err
+	}
+	if setting.Value == "" {
+		return VersionTagLatest, nil
+	}
+	return setting.Value, nil
+}
+
+// newUpgradeCheckerHTTPClient returns an HTTP client using the proxy configured
+// by the setting. It falls back to the proxy from the environment variables if
+// the setting is empty.
+func (sc *SettingController) newUpgradeCheckerHTTPClient() (*http.Client, error) {
+	setting, err := sc.ds.GetSetting(types.SettingNameUpgradeCheckerHTTPProxy)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if setting.Value != "" {
+		proxyURL, err := url.Parse(setting.Value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value %v for setting %v", setting.Value, types.SettingNameUpgradeCheckerHTTPProxy)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+
+	return &http.Client{
+		Transport: transport,
+		// Prevent a hung upgrade responder or proxy from blocking the worker
+		Timeout: upgradeCheckerHTTPTimeout,
+	}, nil
+}
+
 func (sc *SettingController) CheckLatestAndStableLonghornVersions() (string, string, error) {
 	var (
 		resp    CheckUpgradeResponse
@@ -898,10 +1148,18 @@ func (sc *SettingController) CheckLatestAndStableLonghornVersions() (string, str
 	if err := json.NewEncoder(&content).Encode(req); err != nil {
 		return "", "", err
 	}
-	r, err := http.Post(checkUpgradeURL, "application/json", &content)
+	upgradeResponderURL, err := sc.getUpgradeResponderURL()
 	if err != nil {
 		return "", "", err
 	}
+	client, err := sc.newUpgradeCheckerHTTPClient()
+	if err != nil {
+		return "", "", err
+	}
+	r, err := client.Post(upgradeResponderURL, "application/json", &content)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to query upgrade responder %v", upgradeResponderURL)
+	}
 	defer r.Body.Close()
 	if r.StatusCode != http.StatusOK {
 		message := ""
@@ -914,6 +1172,11 @@ func (sc *SettingController) CheckLatestAndStableLonghornVersions() (string, str
 		return "", "", fmt.Errorf("query return status code %v, message %v", r.StatusCode, message)
 	}
 	if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+		return "", "", errors.Wrapf(err, "failed to decode the response of upgrade responder %v", upgradeResponderURL)
+	}
+
+	channel, err := sc.getUpgradeCheckerChannel()
+	if err != nil {
 		return "", "", err
 	}
 
@@ -921,7 +1184,7 @@ func (sc *SettingController) CheckLatestAndStableLonghornVersions() (string, str
 	stableVersions := []string{}
 	for _, v := range resp.Versions {
 		for _, tag := range v.Tags {
-			if tag == VersionTagLatest {
+			if tag == channel {
 				latestVersion = v.Name
 			}
 			if tag == VersionTagStable {
@@ -930,7 +1193,7 @@ func (sc *SettingController) CheckLatestAndStableLonghornVersions() (string, str
 		}
 	}
 	if latestVersion == "" {
-		return "", "", fmt.Errorf("cannot find latest Longhorn version during CheckLatestAndStableLonghornVersions")
+		return "", "", fmt.Errorf("cannot find any Longhorn version tagged with channel %v during CheckLatestAndStableLonghornVersions", channel)
 	}
 	sort.Strings(stableVersions)
 	return latestVersion, strings.Join(stableVersions, ","), nil
@@ -946,6 +1209,59 @@ func (sc *SettingController) enqueueSetting(obj interface{}) {
 	sc.queue.Add(key)
 }
 
+// enqueueSettingForUpdate skips the updates that don't need a reconcile, e.g.
+// the writes of the setting controller itself, and coalesces the rest.
+func (sc *SettingController) enqueueSettingForUpdate(old, cur interface{}) {
+	oldSetting, ok := old.(*longhorn.Setting)
+	if !ok {
+		return
+	}
+	curSetting, ok := cur.(*longhorn.Setting)
+	if !ok {
+		return
+	}
+
+	key, err := controller.KeyFunc(curSetting)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't get key for object %#v: %v", cur, err))
+		return
+	}
+
+	// periodic resync
+	if oldSetting.ResourceVersion == curSetting.ResourceVersion {
+		sc.queue.Add(key)
+		return
+	}
+
+	if !isSettingUpdateReconcileRequired(oldSetting, curSetting) {
+		return
+	}
+	sc.queue.AddAfter(key, settingUpdateCoalescePeriod)
+}
+
+// isSettingUpdateReconcileRequired returns false for the settings maintained
+// by the setting controller itself and for the updates that change neither the
+// value nor the upgrade check trigger.
+func isSettingUpdateReconcileRequired(oldSetting, curSetting *longhorn.Setting) bool {
+	switch types.SettingName(curSetting.Name) {
+	case types.SettingNameLatestLonghornVersion, types.SettingNameStableLonghornVersions, types.SettingNameLastUpgradeCheckTimestamp:
+		return false
+	}
+
+	if oldSetting.Value != curSetting.Value {
+		return true
+	}
+
+	triggerKey := types.GetLonghornLabelKey(types.UpgradeCheckTriggerAnnotationKeySuffix)
+	_, oldTriggered := oldSetting.Annotations[triggerKey]
+	_, curTriggered := curSetting.Annotations[triggerKey]
+	return !oldTriggered && curTriggered
+}
+
+func (sc *SettingController) enqueueUpgradeCheckerAfter(duration time.Duration) {
+	sc.queue.AddAfter(sc.namespace+"/"+string(types.SettingNameUpgradeChecker), duration)
+}
+
 func (sc *SettingController) enqueueSettingForNode(obj interface{}) {
 	if _, ok := obj.(*longhorn.Node); !ok {
 		// Ignore deleted node
@@ -964,10 +1280,10 @@ func (sc *SettingController) enqueueSettingForBackupTarget(obj interface{}) {
 	sc.queue.Add(sc.namespace + "/" + string(types.SettingNameBackupTarget))
 }
 
-func (sc *SettingController) updateInstanceManagerCPURequest() error {
+func (sc *SettingController) updateInstanceManagerResourceRequest() error {
 	imPodList, err := sc.ds.ListInstanceManagerPods()
 	if err != nil {
-		return errors.Wrapf(err, "failed to list instance manager pods for toleration update")
+		return errors.Wrapf(err, "failed to list instance manager pods for resource request update")
 	}
 	imMap, err := sc.ds.ListInstanceManagers()
 	if err != nil {
@@ -985,15 +1301,15 @@ func (sc *SettingController) updateInstanceManagerCPURequest() error {
 			continue
 		}
 
-		resourceReq, err := GetInstanceManagerCPURequirement(sc.ds, imPod.Name)
+		resourceReq, err := GetInstanceManagerResourceRequirement(sc.ds, imPod.Name)
 		if err != nil {
 			return err
 		}
 		podResourceReq := imPod.Spec.Containers[0].Resources
-		if IsSameGuaranteedCPURequirement(resourceReq, &podResourceReq) {
+		if IsSameGuaranteedCPURequirement(resourceReq, &podResourceReq) && IsSameGuaranteedMemoryRequirement(resourceReq, &podResourceReq) {
 			continue
 		}
-		sc.logger.Infof("Delete instance manager pod %v to refresh CPU request option", imPod.Name)
+		sc.logger.Infof("Delete instance manager pod %v to refresh CPU and memory request options", imPod.Name)
 		if err := sc.ds.DeletePod(imPod.Name); err != nil {
 			return err
 		}
@@ -1002,6 +1318,55 @@ func (sc *SettingController) updateInstanceManagerCPURequest() error {
 	return nil
 }
 
+func (sc *SettingController) updateLogLevel() error {
+	setting, err := sc.ds.GetSetting(types.SettingNameLogLevel)
+	if err != nil {
+		return err
+	}
+
+	// The empty setting follows the log level set by the flags
+	level := sc.flagLogLevel
+	if setting.Value != "" {
+		if level, err = logrus.ParseLevel(setting.Value); err != nil {
+			return err
+		}
+	}
+	if logrus.GetLevel() == level {
+		return nil
+	}
+
+	sc.logger.Infof("Updating log level from %v to %v", logrus.GetLevel(), level)
+	logrus.SetLevel(level)
+	return nil
+}
+
+// syncMaintenanceMode announces the maintenance mode. The controllers check the
+// setting by themselves while reconciling.
+func (sc *SettingController) syncMaintenanceMode() error {
+	setting, err := sc.ds.GetSetting(types.SettingNameMaintenanceMode)
+	if err != nil {
+		return err
+	}
+
+	enabled, err := strconv.ParseBool(setting.Value)
+	if err != nil {
+		return err
+	}
+	if enabled == sc.maintenanceMode {
+		return nil
+	}
+	sc.maintenanceMode = enabled
+
+	if enabled {
+		sc.logger.Info("Maintenance mode is enabled, pausing replica rebuilding, rebalancing, eviction and auto salvage")
+		sc.eventRecorder.Event(setting, v1.EventTypeNormal, constant.EventReasonMaintenanceModeEnabled, "Paused replica rebuilding, rebalancing, eviction and auto salvage")
+	} else {
+		sc.logger.Info("Maintenance mode is disabled, resuming replica rebuilding, rebalancing, eviction and auto salvage")
+		sc.eventRecorder.Event(setting, v1.EventTypeNormal, constant.EventReasonMaintenanceModeDisabled, "Resumed replica rebuilding, rebalancing, eviction and auto salvage")
+	}
+	return nil
+}
+
 func (sc *SettingController) cleanupFailedSupportBundles() error {
 	failedLimit, err := sc.ds.GetSettingAsInt(types.SettingNameSupportBundleFailedHistoryLimit)
 	if err != nil {
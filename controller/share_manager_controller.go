@@ -41,7 +41,7 @@ type ShareManagerController struct {
 
 	ds *datastore.DataStore
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 }
 
 func NewShareManagerController(
@@ -68,9 +68,11 @@ func NewShareManagerController(
 		serviceAccount: serviceAccount,
 
 		kubeClient:    kubeClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-share-manager-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-share-manager-controller"})),
 
 		ds: ds,
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	// need shared volume manager informer
@@ -79,7 +81,7 @@ func NewShareManagerController(
 		UpdateFunc: func(old, cur interface{}) { c.enqueueShareManager(cur) },
 		DeleteFunc: c.enqueueShareManager,
 	})
-	c.cacheSyncs = append(c.cacheSyncs, ds.ShareManagerInformer.HasSynced)
+	c.cacheSyncs["ShareManagerInformer"] = ds.ShareManagerInformer.HasSynced
 
 	// need information for volumes, to be able to claim them
 	ds.VolumeInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
@@ -87,7 +89,7 @@ func NewShareManagerController(
 		UpdateFunc: func(old, cur interface{}) { c.enqueueShareManagerForVolume(cur) },
 		DeleteFunc: c.enqueueShareManagerForVolume,
 	}, 0)
-	c.cacheSyncs = append(c.cacheSyncs, ds.VolumeInformer.HasSynced)
+	c.cacheSyncs["VolumeInformer"] = ds.VolumeInformer.HasSynced
 
 	// we are only interested in pods for which we are responsible for managing
 	ds.PodInformer.AddEventHandlerWithResyncPeriod(cache.FilteringResourceEventHandler{
@@ -98,7 +100,7 @@ func NewShareManagerController(
 			DeleteFunc: c.enqueueShareManagerForPod,
 		},
 	}, 0)
-	c.cacheSyncs = append(c.cacheSyncs, ds.PodInformer.HasSynced)
+	c.cacheSyncs["PodInformer"] = ds.PodInformer.HasSynced
 
 	return c
 }
@@ -141,7 +143,7 @@ func (c *ShareManagerController) enqueueShareManagerForVolume(obj interface{}) {
 		}
 	}
 
-	if volume.Spec.AccessMode == longhorn.AccessModeReadWriteMany && !volume.Spec.Migratable {
+	if types.IsSharedVolume(volume) {
 		// we can queue the key directly since a share manager only manages a single volume from it's own namespace
 		// and there is no need for us to retrieve the whole object, since we already know the volume name
 		getLoggerForVolume(c.logger, volume).Trace("Enqueuing share manager for volume")
@@ -203,12 +205,12 @@ func isShareManagerPod(obj interface{}) bool {
 
 func (c *ShareManagerController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer c.queue.ShutDown()
+	defer c.queue.ShutDownWithDrain()
 
 	c.logger.Infof("Starting Longhorn share manager controller")
 	defer c.logger.Infof("Shut down Longhorn share manager controller")
 
-	if !cache.WaitForNamedCacheSync("longhorn-share-manager-controller", stopCh, c.cacheSyncs...) {
+	if !c.waitForCacheSync(stopCh, c.cacheSyncs) {
 		return
 	}
 	for i := 0; i < workers; i++ {
@@ -228,7 +230,7 @@ func (c *ShareManagerController) processNextWorkItem() bool {
 		return false
 	}
 	defer c.queue.Done(key)
-	err := c.syncShareManager(key.(string))
+	err := c.syncWithPanicRecovery(key.(string), c.syncShareManager)
 	c.handleErr(err, key)
 	return true
 }
@@ -239,7 +241,7 @@ func (c *ShareManagerController) handleErr(err error, key interface{}) {
 		return
 	}
 
-	if c.queue.NumRequeues(key) < maxRetries {
+	if c.queue.NumRequeues(key) < MaxRetries {
 		c.logger.WithError(err).Warnf("Error syncing Longhorn share manager %v", key)
 		c.queue.AddRateLimited(key)
 		return
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -16,6 +17,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	clientset "k8s.io/client-go/kubernetes"
 	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/kubernetes/pkg/controller"
@@ -38,8 +40,15 @@ type SnapshotController struct {
 	eventRecorder record.EventRecorder
 
 	ds                     *datastore.DataStore
-	cacheSyncs             []cache.InformerSynced
+	cacheSyncs             map[string]cache.InformerSynced
 	engineClientCollection engineapi.EngineClientCollection
+	hookRunner             *engineapi.SnapshotHookRunner
+
+	// The snapshots being created with hooks in the background, and the
+	// errors of the ones failed, which are reported by the next reconcile.
+	hookedCreationLock   *sync.Mutex
+	hookedCreations      map[string]bool
+	hookedCreationErrors map[string]error
 
 	proxyConnCounter util.Counter
 }
@@ -49,6 +58,7 @@ func NewSnapshotController(
 	ds *datastore.DataStore,
 	scheme *runtime.Scheme,
 	kubeClient clientset.Interface,
+	kubeConfig *rest.Config,
 	namespace string,
 	controllerID string,
 	engineClientCollection engineapi.EngineClientCollection,
@@ -67,27 +77,34 @@ func NewSnapshotController(
 		namespace:              namespace,
 		controllerID:           controllerID,
 		kubeClient:             kubeClient,
-		eventRecorder:          eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-snapshot-controller"}),
+		eventRecorder:          newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-snapshot-controller"})),
 		ds:                     ds,
 		engineClientCollection: engineClientCollection,
 		proxyConnCounter:       proxyConnCounter,
+
+		hookedCreationLock:   &sync.Mutex{},
+		hookedCreations:      map[string]bool{},
+		hookedCreationErrors: map[string]error{},
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
+	sc.hookRunner = engineapi.NewSnapshotHookRunner(ds, engineapi.NewWebsocketPodCommandExecutor(kubeConfig), sc.logger)
 
 	ds.SnapshotInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc:    sc.enqueueSnapshot,
 		UpdateFunc: func(old, cur interface{}) { sc.enqueueSnapshot(cur) },
 		DeleteFunc: sc.enqueueSnapshot,
 	}, 0)
-	sc.cacheSyncs = append(sc.cacheSyncs, ds.SnapshotInformer.HasSynced)
+	sc.cacheSyncs["SnapshotInformer"] = ds.SnapshotInformer.HasSynced
 	ds.EngineInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		UpdateFunc: sc.enqueueEngineChange,
 	}, 0)
-	sc.cacheSyncs = append(sc.cacheSyncs, ds.EngineInformer.HasSynced)
+	sc.cacheSyncs["EngineInformer"] = ds.EngineInformer.HasSynced
 
 	ds.VolumeInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		DeleteFunc: sc.enqueueVolumeChange,
 	}, 0)
-	sc.cacheSyncs = append(sc.cacheSyncs, ds.VolumeInformer.HasSynced)
+	sc.cacheSyncs["VolumeInformer"] = ds.VolumeInformer.HasSynced
 
 	return sc
 }
@@ -218,12 +235,12 @@ func (sc *SnapshotController) enqueueVolumeChange(obj interface{}) {
 
 func (sc *SnapshotController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer sc.queue.ShutDown()
+	defer sc.queue.ShutDownWithDrain()
 
 	sc.logger.Info("Starting Longhorn Snapshot Controller")
 	defer sc.logger.Info("Shut down Longhorn Snapshot Controller")
 
-	if !cache.WaitForNamedCacheSync(sc.name, stopCh, sc.cacheSyncs...) {
+	if !sc.waitForCacheSync(stopCh, sc.cacheSyncs) {
 		return
 	}
 
@@ -244,7 +261,7 @@ func (sc *SnapshotController) processNextWorkItem() bool {
 		return false
 	}
 	defer sc.queue.Done(key)
-	err := sc.syncHandler(key.(string))
+	err := sc.syncWithPanicRecovery(key.(string), sc.syncHandler)
 	sc.handlerErr(err, key)
 	return true
 }
@@ -281,6 +298,9 @@ func (sc *SnapshotController) reconcile(snapshotName string) (err error) {
 		if !apierrors.IsNotFound(err) {
 			return err
 		}
+		sc.hookedCreationLock.Lock()
+		delete(sc.hookedCreationErrors, snapshotName)
+		sc.hookedCreationLock.Unlock()
 		return nil
 	}
 
@@ -482,13 +502,66 @@ func (sc *SnapshotController) handleSnapshotCreate(snapshot *longhorn.Snapshot,
 	if err != nil {
 		return err
 	}
-	if snapshotInfo == nil {
+	if snapshotInfo != nil {
+		return nil
+	}
+
+	volume, err := sc.ds.GetVolumeRO(snapshot.Spec.Volume)
+	if err != nil {
+		return err
+	}
+	if !sc.hookRunner.HasSnapshotHooks(volume) {
 		sc.logger.Infof("creating snapshot %v of volume %v", snapshot.Name, snapshot.Spec.Volume)
 		_, err = engineClientProxy.SnapshotCreate(engine, snapshot.Name, snapshot.Spec.Labels)
-		if err != nil {
+		return err
+	}
+	return sc.startHookedSnapshotCreation(snapshot, volume, engine)
+}
+
+// startHookedSnapshotCreation creates the snapshot between the snapshot hooks
+// in the background, since the hooks may take a while and should not block
+// the controller workers. The snapshot is enqueued once the creation is done,
+// and the error if any is returned by the next call.
+func (sc *SnapshotController) startHookedSnapshotCreation(snapshot *longhorn.Snapshot, volume *longhorn.Volume, engine *longhorn.Engine) error {
+	sc.hookedCreationLock.Lock()
+	defer sc.hookedCreationLock.Unlock()
+
+	if sc.hookedCreations[snapshot.Name] {
+		return nil
+	}
+	if err, ok := sc.hookedCreationErrors[snapshot.Name]; ok {
+		delete(sc.hookedCreationErrors, snapshot.Name)
+		return err
+	}
+	sc.hookedCreations[snapshot.Name] = true
+
+	snapshot = snapshot.DeepCopy()
+	go func() {
+		err := sc.hookRunner.CreateSnapshotWithHooks(volume, func() error {
+			engineCliClient, err := GetBinaryClientForEngine(engine, sc.engineClientCollection, engine.Status.CurrentImage)
+			if err != nil {
+				return err
+			}
+			engineClientProxy, err := engineapi.GetCompatibleClient(engine, engineCliClient, sc.ds, sc.logger, sc.proxyConnCounter)
+			if err != nil {
+				return err
+			}
+			defer engineClientProxy.Close()
+
+			sc.logger.Infof("creating snapshot %v of volume %v with hooks", snapshot.Name, snapshot.Spec.Volume)
+			_, err = engineClientProxy.SnapshotCreate(engine, snapshot.Name, snapshot.Spec.Labels)
 			return err
+		})
+
+		sc.hookedCreationLock.Lock()
+		delete(sc.hookedCreations, snapshot.Name)
+		if err != nil {
+			sc.hookedCreationErrors[snapshot.Name] = err
 		}
-	}
+		sc.hookedCreationLock.Unlock()
+
+		sc.enqueueSnapshot(snapshot)
+	}()
 	return nil
 }
 
@@ -79,7 +79,7 @@ type SupportBundleController struct {
 
 	ds *datastore.DataStore
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 
 	httpClient rest.HTTPClient
 }
@@ -111,7 +111,9 @@ func NewSupportBundleController(
 		kubeClient: kubeClient,
 		httpClient: &http.Client{Timeout: 30 * time.Second},
 
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, corev1.EventSource{Component: "longhorn-support-bundle-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, corev1.EventSource{Component: "longhorn-support-bundle-controller"})),
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	ds.SupportBundleInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
@@ -119,7 +121,7 @@ func NewSupportBundleController(
 		UpdateFunc: func(old, cur interface{}) { c.enqueue(cur) },
 		DeleteFunc: c.enqueue,
 	}, 0)
-	c.cacheSyncs = append(c.cacheSyncs, ds.OrphanInformer.HasSynced)
+	c.cacheSyncs["OrphanInformer"] = ds.OrphanInformer.HasSynced
 
 	return c
 }
@@ -146,12 +148,12 @@ func (c *SupportBundleController) enqueueAfter(obj interface{}, delay time.Durat
 
 func (c *SupportBundleController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer c.queue.ShutDown()
+	defer c.queue.ShutDownWithDrain()
 
 	c.logger.Info("Starting Longhorn Support Bundle controller")
 	defer c.logger.Info("Shut down Longhorn Support Bundle controller")
 
-	if !cache.WaitForNamedCacheSync(c.name, stopCh, c.cacheSyncs...) {
+	if !c.waitForCacheSync(stopCh, c.cacheSyncs) {
 		return
 	}
 	for i := 0; i < workers; i++ {
@@ -172,7 +174,7 @@ func (c *SupportBundleController) processNextWorkItem() bool {
 	}
 	defer c.queue.Done(key)
 
-	err := c.syncSupportBundle(key.(string))
+	err := c.syncWithPanicRecovery(key.(string), c.syncSupportBundle)
 	c.handleErr(err, key)
 
 	return true
@@ -186,7 +188,7 @@ func (c *SupportBundleController) handleErr(err error, key interface{}) {
 
 	log := c.logger.WithField("supportBundle", key)
 
-	if c.queue.NumRequeues(key) < maxRetries {
+	if c.queue.NumRequeues(key) < MaxRetries {
 		log.WithError(err).Warn("Error syncing Longhorn SupportBundle")
 
 		c.queue.AddRateLimited(key)
@@ -171,11 +171,12 @@ func (s *TestSuite) TestReconcileSupportBundle(c *C) {
 		fakeSupportBundleManagerImageSetting(c, lhInformerFactory, lhClient)
 		fakeSupportBundleFailedHistoryLimitSetting(tc.supportBundleFailedHistoryLimit, c, lhInformerFactory, lhClient)
 
-		supportBundleController := newFakeSupportBundleController(
+		supportBundleController, err := newFakeSupportBundleController(
 			lhInformerFactory, kubeInformerFactory,
 			lhClient, kubeClient, extensionsClient,
 			tc.controllerID,
 		)
+		c.Assert(err, IsNil)
 
 		var supportBundle *longhorn.SupportBundle
 		for _, supportBundleName := range tc.supportBundleNames {
@@ -191,7 +192,7 @@ func (s *TestSuite) TestReconcileSupportBundle(c *C) {
 		}
 		c.Assert(supportBundle, NotNil)
 
-		err := supportBundleController.reconcile(tc.supportBundleNames[0])
+		err = supportBundleController.reconcile(tc.supportBundleNames[0])
 		c.Assert(err, IsNil)
 
 		for _, supportBundleName := range tc.supportBundleNames {
@@ -225,9 +226,12 @@ func newFakeSupportBundleController(
 	lhClient *lhfake.Clientset,
 	kubeClient *fake.Clientset,
 	extensionsClient *apiextensionsfake.Clientset,
-	controllerID string) *SupportBundleController {
+	controllerID string) (*SupportBundleController, error) {
 
-	ds := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+	ds, err := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+	if err != nil {
+		return nil, err
+	}
 
 	logger := logrus.StandardLogger()
 	logrus.SetLevel(logrus.DebugLevel)
@@ -240,7 +244,7 @@ func newFakeSupportBundleController(
 
 	c.httpClient = &fakeSupportBundleHTTPClient{}
 
-	return c
+	return c, nil
 }
 
 func fakeSupportBundle(name, currentOwnerID string, state longhorn.SupportBundleState, c *C, informerFactory lhinformers.SharedInformerFactory, client *lhfake.Clientset) *longhorn.SupportBundle {
@@ -104,7 +104,7 @@ type SystemBackupController struct {
 
 	ds *datastore.DataStore
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 }
 
 func NewSystemBackupController(
@@ -133,7 +133,9 @@ func NewSystemBackupController(
 		ds: ds,
 
 		kubeClient:    kubeClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, corev1.EventSource{Component: SystemBackupControllerName + "-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, corev1.EventSource{Component: SystemBackupControllerName + "-controller"})),
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	ds.SystemBackupInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
@@ -141,7 +143,7 @@ func NewSystemBackupController(
 		UpdateFunc: func(old, cur interface{}) { c.enqueue(cur) },
 		DeleteFunc: c.enqueue,
 	}, 0)
-	c.cacheSyncs = append(c.cacheSyncs, ds.SystemBackupInformer.HasSynced)
+	c.cacheSyncs["SystemBackupInformer"] = ds.SystemBackupInformer.HasSynced
 
 	return c
 }
@@ -158,12 +160,12 @@ func (c *SystemBackupController) enqueue(obj interface{}) {
 
 func (c *SystemBackupController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer c.queue.ShutDown()
+	defer c.queue.ShutDownWithDrain()
 
 	c.logger.Info("Starting Longhorn SystemBackup controller")
 	defer c.logger.Info("Shut down Longhorn SystemBackup controller")
 
-	if !cache.WaitForNamedCacheSync(c.name, stopCh, c.cacheSyncs...) {
+	if !c.waitForCacheSync(stopCh, c.cacheSyncs) {
 		return
 	}
 	for i := 0; i < workers; i++ {
@@ -184,7 +186,7 @@ func (c *SystemBackupController) processNextWorkItem() bool {
 	}
 	defer c.queue.Done(key)
 
-	err := c.syncSystemBackup(key.(string))
+	err := c.syncWithPanicRecovery(key.(string), c.syncSystemBackup)
 	c.handleErr(err, key)
 
 	return true
@@ -198,7 +200,7 @@ func (c *SystemBackupController) handleErr(err error, key interface{}) {
 
 	log := c.logger.WithField("systemBackup", key)
 
-	if c.queue.NumRequeues(key) < maxRetries {
+	if c.queue.NumRequeues(key) < MaxRetries {
 		log.WithError(err).Warn("Failed to sync Longhorn SystemBackup, and requeuing to reconcile")
 
 		c.queue.AddRateLimited(key)
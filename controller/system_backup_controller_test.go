@@ -201,11 +201,12 @@ func (s *TestSuite) TestReconcileSystemBackup(c *C) {
 
 		extensionsClient := apiextensionsfake.NewSimpleClientset()
 
-		systemBackupController := newFakeSystemBackupController(
+		systemBackupController, err := newFakeSystemBackupController(
 			lhInformerFactory, kubeInformerFactory,
 			lhClient, kubeClient, extensionsClient,
 			tc.controllerID,
 		)
+		c.Assert(err, IsNil)
 
 		systemBackup := fakeSystemBackup(tc.systemBackupName, rolloutOwnerID, tc.systemBackupVersion, tc.isDeleting, tc.state, c, lhInformerFactory, lhClient)
 		if tc.notExist {
@@ -264,9 +265,12 @@ func newFakeSystemBackupController(
 	lhClient *lhfake.Clientset,
 	kubeClient *fake.Clientset,
 	extensionsClient *apiextensionsfake.Clientset,
-	controllerID string) *SystemBackupController {
+	controllerID string) (*SystemBackupController, error) {
 
-	ds := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+	ds, err := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+	if err != nil {
+		return nil, err
+	}
 
 	logger := logrus.StandardLogger()
 	logrus.SetLevel(logrus.DebugLevel)
@@ -277,7 +281,7 @@ func newFakeSystemBackupController(
 		c.cacheSyncs[index] = alwaysReady
 	}
 
-	return c
+	return c, nil
 }
 
 func fakeSystemBackup(name, currentOwnerID, longhornVersion string, isDeleting bool, state longhorn.SystemBackupState, c *C, informerFactory lhinformers.SharedInformerFactory, client *lhfake.Clientset) *longhorn.SystemBackup {
@@ -70,7 +70,7 @@ type SystemRestoreController struct {
 
 	ds *datastore.DataStore
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 }
 
 func NewSystemRestoreController(
@@ -97,7 +97,9 @@ func NewSystemRestoreController(
 		ds: ds,
 
 		kubeClient:    kubeClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, corev1.EventSource{Component: SystemRestoreControllerName + "-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, corev1.EventSource{Component: SystemRestoreControllerName + "-controller"})),
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	ds.SystemRestoreInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
@@ -105,7 +107,7 @@ func NewSystemRestoreController(
 		UpdateFunc: func(old, cur interface{}) { c.enqueueSystemRestore(cur) },
 		DeleteFunc: c.enqueueSystemRestore,
 	}, 0)
-	c.cacheSyncs = append(c.cacheSyncs, ds.SystemRestoreInformer.HasSynced)
+	c.cacheSyncs["SystemRestoreInformer"] = ds.SystemRestoreInformer.HasSynced
 
 	return c
 }
@@ -122,12 +124,12 @@ func (c *SystemRestoreController) enqueueSystemRestore(obj interface{}) {
 
 func (c *SystemRestoreController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer c.queue.ShutDown()
+	defer c.queue.ShutDownWithDrain()
 
 	c.logger.Info("Starting Longhorn SystemRestore controller")
 	defer c.logger.Info("Shut down Longhorn SystemRestore controller")
 
-	if !cache.WaitForNamedCacheSync(c.name, stopCh, c.cacheSyncs...) {
+	if !c.waitForCacheSync(stopCh, c.cacheSyncs) {
 		return
 	}
 	for i := 0; i < workers; i++ {
@@ -148,7 +150,7 @@ func (c *SystemRestoreController) processNextWorkItem() bool {
 	}
 	defer c.queue.Done(key)
 
-	err := c.syncSystemRestore(key.(string))
+	err := c.syncWithPanicRecovery(key.(string), c.syncSystemRestore)
 	c.handleErr(err, key)
 
 	return true
@@ -162,7 +164,7 @@ func (c *SystemRestoreController) handleErr(err error, key interface{}) {
 
 	log := c.logger.WithField("systemRestore", key)
 
-	if c.queue.NumRequeues(key) < maxRetries {
+	if c.queue.NumRequeues(key) < MaxRetries {
 		log.WithError(err).Warn("Failed to sync SystemRestore")
 
 		c.queue.AddRateLimited(key)
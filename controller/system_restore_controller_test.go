@@ -116,18 +116,19 @@ func (s *TestSuite) TestReconcileSystemRestore(c *C) {
 		lhClient := lhfake.NewSimpleClientset()
 		lhInformerFactory := lhinformers.NewSharedInformerFactory(lhClient, controller.NoResyncPeriodFunc())
 
-		fakeSystemRolloutManagerPod(c, kubeInformerFactory, kubeClient)
-		fakeSystemRolloutSettingDefaultEngineImage(c, lhInformerFactory, lhClient)
-		fakeSystemRolloutBackupTargetDefault(c, lhInformerFactory, lhClient)
-		fakeSystemBackup(tc.systemBackupName, systemRestoreOwnerID, "", false, longhorn.SystemBackupStateGenerating, c, lhInformerFactory, lhClient)
-
 		extensionsClient := apiextensionsfake.NewSimpleClientset()
 
-		systemRestoreController := newFakeSystemRestoreController(
+		systemRestoreController, err := newFakeSystemRestoreController(
 			lhInformerFactory, kubeInformerFactory,
 			lhClient, kubeClient, extensionsClient,
 			tc.controllerID,
 		)
+		c.Assert(err, IsNil)
+
+		fakeSystemRolloutManagerPod(c, kubeInformerFactory, kubeClient)
+		fakeSystemRolloutSettingDefaultEngineImage(c, lhInformerFactory, lhClient)
+		fakeSystemRolloutBackupTargetDefault(c, lhInformerFactory, lhClient)
+		fakeSystemBackup(tc.systemBackupName, systemRestoreOwnerID, "", false, longhorn.SystemBackupStateGenerating, c, lhInformerFactory, lhClient)
 
 		if !tc.notExist {
 			if tc.state == "" {
@@ -141,7 +142,7 @@ func (s *TestSuite) TestReconcileSystemRestore(c *C) {
 			}
 		}
 
-		err := systemRestoreController.reconcile(systemRestoreName, backupTargetClient)
+		err = systemRestoreController.reconcile(systemRestoreName, backupTargetClient)
 		if tc.expectError {
 			c.Assert(err, NotNil)
 		} else {
@@ -173,9 +174,12 @@ func newFakeSystemRestoreController(
 	lhClient *lhfake.Clientset,
 	kubeClient *fake.Clientset,
 	extensionsClient *apiextensionsfake.Clientset,
-	controllerID string) *SystemRestoreController {
+	controllerID string) (*SystemRestoreController, error) {
 
-	ds := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+	ds, err := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+	if err != nil {
+		return nil, err
+	}
 
 	logger := logrus.StandardLogger()
 	logrus.SetLevel(logrus.DebugLevel)
@@ -186,7 +190,7 @@ func newFakeSystemRestoreController(
 		c.cacheSyncs[index] = alwaysReady
 	}
 
-	return c
+	return c, nil
 }
 
 func fakeSystemRestore(name, currentOwnerID string, isInProgress, isDeleting bool, state longhorn.SystemRestoreState,
@@ -149,7 +149,7 @@ type SystemRolloutController struct {
 	extractedResources
 
 	cacheErrors util.MultiError
-	cacheSyncs  []cache.InformerSynced
+	cacheSyncs  map[string]cache.InformerSynced
 }
 
 func NewSystemRolloutController(
@@ -182,16 +182,18 @@ func NewSystemRolloutController(
 		kubeClient: kubeClient,
 
 		ds:            ds,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, corev1.EventSource{Component: SystemRolloutControllerName + "-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, corev1.EventSource{Component: SystemRolloutControllerName + "-controller"})),
 
 		systemRestoreName: systemRestoreName,
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	ds.SystemRestoreInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    func(obj interface{}) { c.enqueue() },
 		UpdateFunc: func(old, cur interface{}) { c.enqueue() },
 	})
-	c.cacheSyncs = append(c.cacheSyncs, ds.SystemRestoreInformer.HasSynced)
+	c.cacheSyncs["SystemRestoreInformer"] = ds.SystemRestoreInformer.HasSynced
 
 	return c
 }
@@ -202,9 +204,9 @@ func (c *SystemRolloutController) enqueue() {
 
 func (c *SystemRolloutController) Run() error {
 	defer utilruntime.HandleCrash()
-	defer c.queue.ShutDown()
+	defer c.queue.ShutDownWithDrain()
 
-	if !cache.WaitForNamedCacheSync("longhorn system rollout", c.stopCh, c.cacheSyncs...) {
+	if !c.waitForCacheSync(c.stopCh, c.cacheSyncs) {
 		return fmt.Errorf("failed to sync informers")
 	}
 
@@ -232,7 +234,9 @@ func (c *SystemRolloutController) processNextWorkItem() bool {
 	}
 	defer c.queue.Done(key)
 
-	err := c.syncSystemRollout()
+	err := c.syncWithPanicRecovery(key.(string), func(string) error {
+		return c.syncSystemRollout()
+	})
 	c.handleErr(err, key)
 
 	return true
@@ -1783,7 +1787,7 @@ func (c *SystemRolloutController) restoreSettings() (err error) {
 			if !ok {
 				return nil, fmt.Errorf(SystemRolloutErrFailedConvertToObjectFmt, exist.GetObjectKind(), types.LonghornKindSetting)
 			}
-			return c.ds.UpdateSetting(obj)
+			return c.ds.UpdateReadOnlySetting(obj)
 		}
 		_, err = c.rolloutResource(exist, fnUpdate, isSkipped, log, SystemRolloutMsgSkipIdentical)
 		if err != nil {
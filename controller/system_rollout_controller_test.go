@@ -972,6 +972,10 @@ func (s *TestSuite) TestSystemRollout(c *C) {
 		lhClient := lhfake.NewSimpleClientset()
 		lhInformerFactory := lhinformers.NewSharedInformerFactory(lhClient, controller.NoResyncPeriodFunc())
 
+		// The data store adds the indexers, which requires the informer caches to be empty
+		ds, err := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+		c.Assert(err, IsNil)
+
 		fakeSystemRolloutBackupTargetDefault(c, lhInformerFactory, lhClient)
 
 		fakeSystemRolloutSettings(tc.backupSettings, c, lhInformerFactory, lhClient)
@@ -993,7 +997,6 @@ func (s *TestSuite) TestSystemRollout(c *C) {
 		fakeSystemRolloutStorageClasses(tc.backupStorageClasses, c, kubeInformerFactory, kubeClient)
 		fakeSystemRolloutVolumes(tc.backupVolumes, c, lhInformerFactory, lhClient)
 
-		ds := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
 		doneCh := make(chan struct{})
 		if tc.expectState != longhorn.SystemRestoreStateCompleted && tc.expectState != longhorn.SystemRestoreStateError {
 			doneChs = append(doneChs, doneCh)
@@ -1005,7 +1008,6 @@ func (s *TestSuite) TestSystemRollout(c *C) {
 
 		fakeSystemRestore(tc.systemRestoreName, systemRolloutOwnerID, tc.isInProgress, false, tc.state, c, lhInformerFactory, lhClient, controller.ds)
 
-		var err error
 		controller.systemRestore, err = lhClient.LonghornV1beta2().SystemRestores(TestNamespace).Get(context.TODO(), tc.systemRestoreName, metav1.GetOptions{})
 		c.Assert(err, IsNil)
 
@@ -1426,11 +1428,12 @@ func fakeSystemBackupArchieve(c *C, systemBackupName, systemRolloutOwnerID, roll
 	extensionsClient *apiextensionsfake.Clientset) {
 	fakeSystemRolloutNamespace(c, kubeInformerFactory, kubeClient)
 
-	systemBackupController := newFakeSystemBackupController(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient, extensionsClient, rolloutControllerID)
+	systemBackupController, err := newFakeSystemBackupController(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient, extensionsClient, rolloutControllerID)
+	c.Assert(err, IsNil)
 	systemBackup := fakeSystemBackup(systemBackupName, systemRolloutOwnerID, "", false, longhorn.SystemBackupStateGenerating, c, lhInformerFactory, lhClient)
 
 	systemBackupController.GenerateSystemBackup(systemBackup, downloadPath, tempDir)
-	systemBackup, err := lhClient.LonghornV1beta2().SystemBackups(TestNamespace).Get(context.TODO(), systemBackupName, metav1.GetOptions{})
+	systemBackup, err = lhClient.LonghornV1beta2().SystemBackups(TestNamespace).Get(context.TODO(), systemBackupName, metav1.GetOptions{})
 	c.Assert(err, IsNil)
 	c.Assert(systemBackup.Status.State, Equals, longhorn.SystemBackupStateUploading)
 }
@@ -61,7 +61,7 @@ type UninstallController struct {
 
 	kubeClient clientset.Interface
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 }
 
 func NewUninstallController(
@@ -91,75 +91,75 @@ func NewUninstallController(
 	ds.CSIDriverInformer.AddEventHandler(c.controlleeHandler())
 	ds.DaemonSetInformer.AddEventHandler(c.namespacedControlleeHandler())
 	ds.DeploymentInformer.AddEventHandler(c.namespacedControlleeHandler())
-	cacheSyncs := []cache.InformerSynced{
-		ds.CSIDriverInformer.HasSynced,
-		ds.DaemonSetInformer.HasSynced,
-		ds.DeploymentInformer.HasSynced,
+	cacheSyncs := map[string]cache.InformerSynced{
+		"CSIDriverInformer":  ds.CSIDriverInformer.HasSynced,
+		"DaemonSetInformer":  ds.DaemonSetInformer.HasSynced,
+		"DeploymentInformer": ds.DeploymentInformer.HasSynced,
 	}
 
 	if _, err := extensionsClient.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), CRDEngineName, metav1.GetOptions{}); err == nil {
 		ds.EngineInformer.AddEventHandler(c.controlleeHandler())
-		cacheSyncs = append(cacheSyncs, ds.EngineInformer.HasSynced)
+		cacheSyncs["EngineInformer"] = ds.EngineInformer.HasSynced
 	}
 	if _, err := extensionsClient.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), CRDReplicaName, metav1.GetOptions{}); err == nil {
 		ds.ReplicaInformer.AddEventHandler(c.controlleeHandler())
-		cacheSyncs = append(cacheSyncs, ds.ReplicaInformer.HasSynced)
+		cacheSyncs["ReplicaInformer"] = ds.ReplicaInformer.HasSynced
 	}
 	if _, err := extensionsClient.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), CRDVolumeName, metav1.GetOptions{}); err == nil {
 		ds.VolumeInformer.AddEventHandler(c.controlleeHandler())
-		cacheSyncs = append(cacheSyncs, ds.VolumeInformer.HasSynced)
+		cacheSyncs["VolumeInformer"] = ds.VolumeInformer.HasSynced
 	}
 	if _, err := extensionsClient.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), CRDEngineImageName, metav1.GetOptions{}); err == nil {
 		ds.EngineImageInformer.AddEventHandler(c.controlleeHandler())
-		cacheSyncs = append(cacheSyncs, ds.EngineImageInformer.HasSynced)
+		cacheSyncs["EngineImageInformer"] = ds.EngineImageInformer.HasSynced
 	}
 	if _, err := extensionsClient.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), CRDNodeName, metav1.GetOptions{}); err == nil {
 		ds.NodeInformer.AddEventHandler(c.controlleeHandler())
-		cacheSyncs = append(cacheSyncs, ds.NodeInformer.HasSynced)
+		cacheSyncs["NodeInformer"] = ds.NodeInformer.HasSynced
 	}
 	if _, err := extensionsClient.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), CRDInstanceManagerName, metav1.GetOptions{}); err == nil {
 		ds.InstanceManagerInformer.AddEventHandler(c.controlleeHandler())
-		cacheSyncs = append(cacheSyncs, ds.InstanceManagerInformer.HasSynced)
+		cacheSyncs["InstanceManagerInformer"] = ds.InstanceManagerInformer.HasSynced
 	}
 	if _, err := extensionsClient.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), CRDShareManagerName, metav1.GetOptions{}); err == nil {
 		ds.ShareManagerInformer.AddEventHandler(c.controlleeHandler())
-		cacheSyncs = append(cacheSyncs, ds.ShareManagerInformer.HasSynced)
+		cacheSyncs["ShareManagerInformer"] = ds.ShareManagerInformer.HasSynced
 	}
 	if _, err := extensionsClient.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), CRDBackingImageName, metav1.GetOptions{}); err == nil {
 		ds.BackingImageInformer.AddEventHandler(c.controlleeHandler())
-		cacheSyncs = append(cacheSyncs, ds.BackingImageInformer.HasSynced)
+		cacheSyncs["BackingImageInformer"] = ds.BackingImageInformer.HasSynced
 	}
 	if _, err := extensionsClient.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), CRDBackingImageManagerName, metav1.GetOptions{}); err == nil {
 		ds.BackingImageManagerInformer.AddEventHandler(c.controlleeHandler())
-		cacheSyncs = append(cacheSyncs, ds.BackingImageManagerInformer.HasSynced)
+		cacheSyncs["BackingImageManagerInformer"] = ds.BackingImageManagerInformer.HasSynced
 	}
 	if _, err := extensionsClient.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), CRDBackingImageDataSourceName, metav1.GetOptions{}); err == nil {
 		ds.BackingImageDataSourceInformer.AddEventHandler(c.controlleeHandler())
-		cacheSyncs = append(cacheSyncs, ds.BackingImageDataSourceInformer.HasSynced)
+		cacheSyncs["BackingImageDataSourceInformer"] = ds.BackingImageDataSourceInformer.HasSynced
 	}
 	if _, err := extensionsClient.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), CRDBackupTargetName, metav1.GetOptions{}); err == nil {
 		ds.BackupTargetInformer.AddEventHandler(c.controlleeHandler())
-		cacheSyncs = append(cacheSyncs, ds.BackupTargetInformer.HasSynced)
+		cacheSyncs["BackupTargetInformer"] = ds.BackupTargetInformer.HasSynced
 	}
 	if _, err := extensionsClient.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), CRDBackupVolumeName, metav1.GetOptions{}); err == nil {
 		ds.BackupVolumeInformer.AddEventHandler(c.controlleeHandler())
-		cacheSyncs = append(cacheSyncs, ds.BackupVolumeInformer.HasSynced)
+		cacheSyncs["BackupVolumeInformer"] = ds.BackupVolumeInformer.HasSynced
 	}
 	if _, err := extensionsClient.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), CRDBackupName, metav1.GetOptions{}); err == nil {
 		ds.BackupInformer.AddEventHandler(c.controlleeHandler())
-		cacheSyncs = append(cacheSyncs, ds.BackupInformer.HasSynced)
+		cacheSyncs["BackupInformer"] = ds.BackupInformer.HasSynced
 	}
 	if _, err := extensionsClient.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), CRDRecurringJobName, metav1.GetOptions{}); err == nil {
 		ds.RecurringJobInformer.AddEventHandler(c.controlleeHandler())
-		cacheSyncs = append(cacheSyncs, ds.RecurringJobInformer.HasSynced)
+		cacheSyncs["RecurringJobInformer"] = ds.RecurringJobInformer.HasSynced
 	}
 	if _, err := extensionsClient.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), CRDOrphanName, metav1.GetOptions{}); err == nil {
 		ds.OrphanInformer.AddEventHandler(c.controlleeHandler())
-		cacheSyncs = append(cacheSyncs, ds.OrphanInformer.HasSynced)
+		cacheSyncs["OrphanInformer"] = ds.OrphanInformer.HasSynced
 	}
 	if _, err := extensionsClient.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), CRDSnapshotName, metav1.GetOptions{}); err == nil {
 		ds.SnapshotInformer.AddEventHandler(c.controlleeHandler())
-		cacheSyncs = append(cacheSyncs, ds.SnapshotInformer.HasSynced)
+		cacheSyncs["SnapshotInformer"] = ds.SnapshotInformer.HasSynced
 	}
 
 	c.cacheSyncs = cacheSyncs
@@ -201,9 +201,9 @@ func (c *UninstallController) enqueueNamespacedControlleeChange(obj interface{})
 
 func (c *UninstallController) Run() error {
 	defer utilruntime.HandleCrash()
-	defer c.queue.ShutDown()
+	defer c.queue.ShutDownWithDrain()
 
-	if !cache.WaitForNamedCacheSync("longhorn uninstall", c.stopCh, c.cacheSyncs...) {
+	if !c.waitForCacheSync(c.stopCh, c.cacheSyncs) {
 		return fmt.Errorf("failed to sync informers")
 	}
 
@@ -237,7 +237,9 @@ func (c *UninstallController) processNextWorkItem() bool {
 	}
 	defer c.queue.Done(key)
 
-	err := c.uninstall()
+	err := c.syncWithPanicRecovery(key.(string), func(string) error {
+		return c.uninstall()
+	})
 	c.handleErr(err, key)
 
 	return true
@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -68,7 +69,7 @@ type VolumeController struct {
 
 	ds *datastore.DataStore
 
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 
 	scheduler *scheduler.ReplicaScheduler
 
@@ -78,6 +79,11 @@ type VolumeController struct {
 	nowHandler func() string
 
 	proxyConnCounter util.Counter
+
+	// the last known time the nodes of the volumes became down, so that the
+	// auto detach timeout still applies after a down node is deleted
+	nodeDownTimesLock sync.Mutex
+	nodeDownTimes     map[string]time.Time
 }
 
 func NewVolumeController(
@@ -103,13 +109,15 @@ func NewVolumeController(
 		controllerID: controllerID,
 
 		kubeClient:    kubeClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-volume-controller"}),
+		eventRecorder: newDedupEventRecorder(eventBroadcaster.NewRecorder(scheme, v1.EventSource{Component: "longhorn-volume-controller"})),
 
 		backoff: flowcontrol.NewBackOff(time.Minute, time.Minute*3),
 
 		nowHandler: util.Now,
 
 		proxyConnCounter: proxyConnCounter,
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	vc.scheduler = scheduler.NewReplicaScheduler(ds)
@@ -119,48 +127,55 @@ func NewVolumeController(
 		UpdateFunc: func(old, cur interface{}) { vc.enqueueVolume(cur) },
 		DeleteFunc: vc.enqueueVolume,
 	})
-	vc.cacheSyncs = append(vc.cacheSyncs, ds.VolumeInformer.HasSynced)
+	vc.cacheSyncs["VolumeInformer"] = ds.VolumeInformer.HasSynced
 
 	ds.EngineInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc:    vc.enqueueControlleeChange,
 		UpdateFunc: func(old, cur interface{}) { vc.enqueueControlleeChange(cur) },
 		DeleteFunc: vc.enqueueControlleeChange,
 	}, 0)
-	vc.cacheSyncs = append(vc.cacheSyncs, ds.EngineInformer.HasSynced)
+	vc.cacheSyncs["EngineInformer"] = ds.EngineInformer.HasSynced
 
 	ds.ReplicaInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc:    vc.enqueueControlleeChange,
 		UpdateFunc: func(old, cur interface{}) { vc.enqueueControlleeChange(cur) },
 		DeleteFunc: vc.enqueueControlleeChange,
 	}, 0)
-	vc.cacheSyncs = append(vc.cacheSyncs, ds.ReplicaInformer.HasSynced)
+	vc.cacheSyncs["ReplicaInformer"] = ds.ReplicaInformer.HasSynced
 
 	ds.ShareManagerInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc:    vc.enqueueVolumesForShareManager,
 		UpdateFunc: func(old, cur interface{}) { vc.enqueueVolumesForShareManager(cur) },
 		DeleteFunc: vc.enqueueVolumesForShareManager,
 	}, 0)
-	vc.cacheSyncs = append(vc.cacheSyncs, ds.ShareManagerInformer.HasSynced)
+	vc.cacheSyncs["ShareManagerInformer"] = ds.ShareManagerInformer.HasSynced
+
+	ds.LHVolumeAttachmentInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
+		AddFunc:    vc.enqueueVolumeForVolumeAttachment,
+		UpdateFunc: func(old, cur interface{}) { vc.enqueueVolumeForVolumeAttachment(cur) },
+		DeleteFunc: vc.enqueueVolumeForVolumeAttachment,
+	}, 0)
+	vc.cacheSyncs["LHVolumeAttachmentInformer"] = ds.LHVolumeAttachmentInformer.HasSynced
 
 	ds.BackupVolumeInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		UpdateFunc: func(old, cur interface{}) { vc.enqueueVolumesForBackupVolume(cur) },
 		DeleteFunc: vc.enqueueVolumesForBackupVolume,
 	}, 0)
-	vc.cacheSyncs = append(vc.cacheSyncs, ds.BackupVolumeInformer.HasSynced)
+	vc.cacheSyncs["BackupVolumeInformer"] = ds.BackupVolumeInformer.HasSynced
 
 	ds.BackingImageDataSourceInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc:    vc.enqueueVolumesForBackingImageDataSource,
 		UpdateFunc: func(old, cur interface{}) { vc.enqueueVolumesForBackingImageDataSource(cur) },
 		DeleteFunc: vc.enqueueVolumesForBackingImageDataSource,
 	}, 0)
-	vc.cacheSyncs = append(vc.cacheSyncs, ds.BackingImageDataSourceInformer.HasSynced)
+	vc.cacheSyncs["BackingImageDataSourceInformer"] = ds.BackingImageDataSourceInformer.HasSynced
 
 	ds.NodeInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc:    vc.enqueueNodeChange,
 		UpdateFunc: func(old, cur interface{}) { vc.enqueueNodeChange(cur) },
 		DeleteFunc: vc.enqueueNodeChange,
 	}, 0)
-	vc.cacheSyncs = append(vc.cacheSyncs, ds.NodeInformer.HasSynced)
+	vc.cacheSyncs["NodeInformer"] = ds.NodeInformer.HasSynced
 
 	ds.SettingInformer.AddEventHandlerWithResyncPeriod(cache.FilteringResourceEventHandler{
 		FilterFunc: isSettingRelatedToVolume,
@@ -169,19 +184,19 @@ func NewVolumeController(
 			UpdateFunc: func(old, cur interface{}) { vc.enqueueSettingChange(cur) },
 		},
 	}, 0)
-	vc.cacheSyncs = append(vc.cacheSyncs, ds.SettingInformer.HasSynced)
+	vc.cacheSyncs["SettingInformer"] = ds.SettingInformer.HasSynced
 
 	return vc
 }
 
 func (vc *VolumeController) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer vc.queue.ShutDown()
+	defer vc.queue.ShutDownWithDrain()
 
 	vc.logger.Infof("Starting Longhorn volume controller")
 	defer vc.logger.Infof("Shut down Longhorn volume controller")
 
-	if !cache.WaitForNamedCacheSync("longhorn engines", stopCh, vc.cacheSyncs...) {
+	if !vc.waitForCacheSync(stopCh, vc.cacheSyncs) {
 		return
 	}
 
@@ -205,7 +220,7 @@ func (vc *VolumeController) processNextWorkItem() bool {
 	}
 	defer vc.queue.Done(key)
 
-	err := vc.syncVolume(key.(string))
+	err := vc.syncWithPanicRecovery(key.(string), vc.syncVolume)
 	vc.handleErr(err, key)
 
 	return true
@@ -217,7 +232,7 @@ func (vc *VolumeController) handleErr(err error, key interface{}) {
 		return
 	}
 
-	if vc.queue.NumRequeues(key) < maxRetries {
+	if vc.queue.NumRequeues(key) < MaxRetries {
 		vc.logger.WithError(err).Warnf("Error syncing Longhorn volume %v", key)
 		vc.queue.AddRateLimited(key)
 		return
@@ -323,6 +338,10 @@ func (vc *VolumeController) syncVolume(key string) (err error) {
 			vc.eventRecorder.Eventf(volume, v1.EventTypeNormal, constant.EventReasonDelete, "Deleting volume %v", volume.Name)
 		}
 
+		if err := vc.ds.DeleteLHVolumeAttachment(volume.Name); err != nil && !datastore.ErrorIsNotFound(err) {
+			return err
+		}
+
 		if volume.Spec.AccessMode == longhorn.AccessModeReadWriteMany {
 			log.Info("Removing share manager for deleted volume")
 			if err := vc.ds.DeleteShareManager(volume.Name); err != nil && !datastore.ErrorIsNotFound(err) {
@@ -386,6 +405,16 @@ func (vc *VolumeController) syncVolume(key string) (err error) {
 		return vc.ds.RemoveFinalizerForVolume(volume)
 	}
 
+	if volume, err = vc.detachVolumeFromDownNode(volume); err != nil {
+		return err
+	}
+
+	// The volume spec updated for the attachment tickets is reconciled in the
+	// next sync, which is triggered by the update
+	if isSpecUpdated, err := vc.reconcileVolumeAttachment(volume); err != nil || isSpecUpdated {
+		return err
+	}
+
 	existingVolume := volume.DeepCopy()
 	existingEngines := map[string]*longhorn.Engine{}
 	for k, e := range engines {
@@ -470,11 +499,19 @@ func (vc *VolumeController) syncVolume(key string) (err error) {
 	if err := vc.ReconcileVolumeState(volume, engines, replicas); err != nil {
 		return err
 	}
+	// The volume may be handed over to another node for the auto attachment
+	if volume.Status.OwnerID != vc.controllerID {
+		return nil
+	}
 
 	if err := vc.cleanupReplicas(volume, engines, replicas); err != nil {
 		return err
 	}
 
+	if err := vc.purgeSnapshotsExceedingMaxCount(volume); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -492,6 +529,34 @@ func handleConditionLastTransitionTime(existingStatus, newStatus *longhorn.Volum
 	}
 }
 
+// syncVolumeRebuildStatus records the progress of the replicas being rebuilt
+// by the engine, as well as the time each rebuild was first observed.
+func (vc *VolumeController) syncVolumeRebuildStatus(v *longhorn.Volume, e *longhorn.Engine, replicaList []*longhorn.Replica) {
+	rebuildStatus := map[string]*longhorn.VolumeRebuildStatus{}
+	for addr, status := range e.Status.RebuildStatus {
+		if status == nil || (!status.IsRebuilding && status.Error == "") {
+			continue
+		}
+		rName := datastore.ReplicaAddressToReplicaName(addr, replicaList)
+		startedAt := vc.nowHandler()
+		if existing, ok := v.Status.RebuildStatus[rName]; ok && existing != nil && existing.StartedAt != "" {
+			startedAt = existing.StartedAt
+		}
+		rebuildStatus[rName] = &longhorn.VolumeRebuildStatus{
+			Progress:    status.Progress,
+			State:       status.State,
+			Error:       status.Error,
+			FromReplica: datastore.ReplicaAddressToReplicaName(status.FromReplicaAddress, replicaList),
+			StartedAt:   startedAt,
+		}
+	}
+	if len(rebuildStatus) == 0 {
+		v.Status.RebuildStatus = nil
+		return
+	}
+	v.Status.RebuildStatus = rebuildStatus
+}
+
 // EvictReplicas do creating one more replica for eviction, if requested
 func (vc *VolumeController) EvictReplicas(v *longhorn.Volume,
 	e *longhorn.Engine, rs map[string]*longhorn.Replica, healthyCount int) (err error) {
@@ -538,6 +603,9 @@ func (vc *VolumeController) ReconcileEngineReplicaState(v *longhorn.Volume, es m
 	if err != nil {
 		return err
 	}
+	if e == nil || e.Status.CurrentState != longhorn.InstanceStateRunning {
+		v.Status.RebuildStatus = nil
+	}
 	if e == nil {
 		return nil
 	}
@@ -595,6 +663,8 @@ func (vc *VolumeController) ReconcileEngineReplicaState(v *longhorn.Volume, es m
 		}
 	}
 
+	vc.syncVolumeRebuildStatus(v, e, replicaList)
+
 	// 1. remove ERR replicas
 	// 2. count RW replicas
 	healthyCount := 0
@@ -655,6 +725,9 @@ func (vc *VolumeController) ReconcileEngineReplicaState(v *longhorn.Volume, es m
 		return nil
 	} else if healthyCount >= v.Spec.NumberOfReplicas {
 		v.Status.Robustness = longhorn.VolumeRobustnessHealthy
+		if oldRobustness != longhorn.VolumeRobustnessHealthy {
+			v.Status.LastHealthyAt = vc.nowHandler()
+		}
 		if oldRobustness == longhorn.VolumeRobustnessDegraded {
 			vc.eventRecorder.Eventf(v, v1.EventTypeNormal, constant.EventReasonHealthy, "volume %v became healthy", v.Name)
 		}
@@ -827,6 +900,7 @@ func (vc *VolumeController) cleanupCorruptedOrStaleReplicas(v *longhorn.Volume,
 	healthyCount := getHealthyAndActiveReplicaCount(rs)
 	cleanupLeftoverReplicas := !vc.isVolumeUpgrading(v) && !vc.isVolumeMigrating(v)
 	log := getLoggerForVolume(vc.logger, v)
+	rebuildRetryLimit := vc.scheduler.GetReplicaRebuildRetryLimit()
 
 	for _, r := range rs {
 		if cleanupLeftoverReplicas {
@@ -859,10 +933,16 @@ func (vc *VolumeController) cleanupCorruptedOrStaleReplicas(v *longhorn.Volume,
 		}
 
 		// 1. failed for multiple times or failed at rebuilding (`Spec.RebuildRetryCount` of a newly created rebuilding replica
-		//    is `FailedReplicaMaxRetryCount`) before ever became healthy/ mode RW,
+		//    is `scheduler.NonReusableReplicaRebuildRetryCount`) before ever became healthy/ mode RW,
 		// 2. failed too long ago, became stale and unnecessary to keep around, unless we don't have any healthy replicas
 		// 3. failed for race condition at upgrading when waiting IM-r to start and it would never became healty
-		if (r.Spec.RebuildRetryCount >= scheduler.FailedReplicaMaxRetryCount) || (healthyCount != 0 && staled) || (r.Spec.EngineImage != v.Status.CurrentImage) {
+		nonReusable := r.Spec.RebuildRetryCount == scheduler.NonReusableReplicaRebuildRetryCount
+		retryLimitReached := !nonReusable && r.Spec.RebuildRetryCount >= rebuildRetryLimit
+		if nonReusable || retryLimitReached || (healthyCount != 0 && staled) || (r.Spec.EngineImage != v.Status.CurrentImage) {
+			if retryLimitReached {
+				vc.eventRecorder.Eventf(v, v1.EventTypeWarning, constant.EventReasonFailedRebuilding,
+					"Replica %v on disk %v reached the rebuild retry limit %v, a new replica will be replenished", r.Name, r.Spec.DiskID, rebuildRetryLimit)
+			}
 			log.WithField("replica", r.Name).Info("Cleaning up corrupted, staled replica")
 			if err := vc.deleteReplica(r, rs); err != nil {
 				return errors.Wrapf(err, "cannot cleanup staled replica %v", r.Name)
@@ -900,6 +980,14 @@ func (vc *VolumeController) cleanupExtraHealthyReplicas(v *longhorn.Volume, e *l
 		return nil
 	}
 
+	maintenanceMode, err := vc.ds.GetSettingAsBool(types.SettingNameMaintenanceMode)
+	if err != nil {
+		return err
+	}
+	if maintenanceMode {
+		return nil
+	}
+
 	var cleaned bool
 	if cleaned, err = vc.cleanupEvictionRequestedReplicas(v, rs); err != nil || cleaned {
 		return err
@@ -1288,9 +1376,14 @@ func (vc *VolumeController) ReconcileVolumeState(v *longhorn.Volume, es map[stri
 		return err
 	}
 
-	if err := vc.checkForAutoAttachment(v, e, rs, scheduled); err != nil {
+	handedOver, err := vc.checkForAutoAttachment(v, e, rs, scheduled)
+	if err != nil {
 		return err
 	}
+	if handedOver {
+		// The new owner takes over the reconciliation
+		return nil
+	}
 	if err := vc.checkForAutoDetachment(v, e, rs); err != nil {
 		return err
 	}
@@ -1315,6 +1408,13 @@ func (vc *VolumeController) ReconcileVolumeState(v *longhorn.Volume, es map[stri
 		if err != nil {
 			return err
 		}
+		maintenanceMode, err := vc.ds.GetSettingAsBool(types.SettingNameMaintenanceMode)
+		if err != nil {
+			return err
+		}
+		if maintenanceMode {
+			autoSalvage = false
+		}
 		// To make sure that we don't miss the `isAutoSalvageNeeded` event, This IF statement makes sure the `e.Spec.SalvageRequested=true`
 		// persist in ETCD before Longhorn salvages the failed replicas in the IF statement below it.
 		// More explanation: when all replicas fails, Longhorn tries to set `e.Spec.SalvageRequested=true`
@@ -1483,7 +1583,7 @@ func (vc *VolumeController) ReconcileVolumeState(v *longhorn.Volume, es map[stri
 					// unscheduled replicas marked failed here when volume detached
 					// check if NodeId or DiskID is empty to avoid deleting reusableFailedReplica when replenished.
 					if r.Spec.NodeID == "" || r.Spec.DiskID == "" {
-						r.Spec.RebuildRetryCount = scheduler.FailedReplicaMaxRetryCount
+						r.Spec.RebuildRetryCount = scheduler.NonReusableReplicaRebuildRetryCount
 					}
 					rs[r.Name] = r
 				}
@@ -1802,6 +1902,16 @@ func (vc *VolumeController) replenishReplicas(v *longhorn.Volume, e *longhorn.En
 		return nil
 	}
 
+	maintenanceMode, err := vc.ds.GetSettingAsBool(types.SettingNameMaintenanceMode)
+	if err != nil {
+		return err
+	}
+	// The maintenance mode pauses the rebuilding, rebalancing and eviction
+	// except the first time creation.
+	if (len(rs) != 0) && maintenanceMode {
+		return nil
+	}
+
 	if vc.isVolumeMigrating(v) {
 		return nil
 	}
@@ -2425,6 +2535,29 @@ func (vc *VolumeController) hasEngineStatusSynced(e *longhorn.Engine, rs map[str
 	return true
 }
 
+// updateEngineUpgradeBlockedCondition records in the volume condition why the
+// live engine upgrade is blocked. The warning is only emitted when the reason
+// changes, rather than on every sync while the upgrade stays blocked.
+func (vc *VolumeController) updateEngineUpgradeBlockedCondition(v *longhorn.Volume, err error) {
+	condition := types.GetCondition(v.Status.Conditions, longhorn.VolumeConditionTypeEngineUpgradeBlocked)
+	if err == nil {
+		if condition.Status == longhorn.ConditionStatusTrue {
+			v.Status.Conditions = types.SetCondition(v.Status.Conditions,
+				longhorn.VolumeConditionTypeEngineUpgradeBlocked, longhorn.ConditionStatusFalse, "", "")
+		}
+		return
+	}
+
+	message := fmt.Sprintf("Unable to live upgrade the engine from %v to %v: %v", v.Status.CurrentImage, v.Spec.EngineImage, err)
+	if condition.Status != longhorn.ConditionStatusTrue || condition.Message != message {
+		getLoggerForVolume(vc.logger, v).Warn(message)
+		vc.eventRecorder.Event(v, v1.EventTypeWarning, constant.EventReasonFailedEngineUpgrade, message)
+	}
+	v.Status.Conditions = types.SetCondition(v.Status.Conditions,
+		longhorn.VolumeConditionTypeEngineUpgradeBlocked, longhorn.ConditionStatusTrue,
+		longhorn.VolumeConditionReasonIncompatibleEngineImage, message)
+}
+
 func (vc *VolumeController) upgradeEngineForVolume(v *longhorn.Volume, es map[string]*longhorn.Engine, rs map[string]*longhorn.Replica) error {
 	var err error
 
@@ -2446,6 +2579,7 @@ func (vc *VolumeController) upgradeEngineForVolume(v *longhorn.Volume, es map[st
 	})
 
 	if !vc.isVolumeUpgrading(v) {
+		vc.updateEngineUpgradeBlockedCondition(v, nil)
 		// it must be a rollback
 		if e.Spec.EngineImage != v.Spec.EngineImage {
 			e.Spec.EngineImage = v.Spec.EngineImage
@@ -2517,12 +2651,9 @@ func (vc *VolumeController) upgradeEngineForVolume(v *longhorn.Volume, es map[st
 		return nil
 	}
 
-	if oldImage.Status.ControllerAPIVersion > newImage.Status.ControllerAPIVersion ||
-		oldImage.Status.ControllerAPIVersion < newImage.Status.ControllerAPIMinVersion {
-		log.Warnf("Unable to live upgrade from %v to %v: the old controller version %v "+
-			"is not compatible with the new controller version %v and the new controller minimal version %v",
-			oldImage.Spec.Image, newImage.Spec.Image,
-			oldImage.Status.ControllerAPIVersion, newImage.Status.ControllerAPIVersion, newImage.Status.ControllerAPIMinVersion)
+	err = types.CheckEngineImageLiveUpgradeCompatibility(oldImage, newImage)
+	vc.updateEngineUpgradeBlockedCondition(v, err)
+	if err != nil {
 		return nil
 	}
 
@@ -2736,6 +2867,11 @@ func (vc *VolumeController) checkAndInitVolumeClone(v *longhorn.Volume) (err err
 		}
 		return err
 	}
+	if sourceVol.Status.Robustness == longhorn.VolumeRobustnessFaulted {
+		v.Status.CloneStatus.State = longhorn.VolumeCloneStateFailed
+		vc.eventRecorder.Eventf(v, v1.EventTypeWarning, constant.EventReasonVolumeCloneFailed, "the source volume %v is faulted", sourceVolName)
+		return nil
+	}
 	// Wait for the source volume to be attach
 	if sourceVol.Status.State != longhorn.VolumeStateAttached {
 		return nil
@@ -2825,25 +2961,28 @@ func (vc *VolumeController) reconcileVolumeSize(v *longhorn.Volume, e *longhorn.
 	return nil
 }
 
-func (vc *VolumeController) checkForAutoAttachment(v *longhorn.Volume, e *longhorn.Engine, rs map[string]*longhorn.Replica, scheduled bool) error {
+// checkForAutoAttachment auto attaches the volume for the operations
+// requiring the engine. It returns true if the volume is handed over to
+// another node instead, which will do the auto attachment.
+func (vc *VolumeController) checkForAutoAttachment(v *longhorn.Volume, e *longhorn.Engine, rs map[string]*longhorn.Replica, scheduled bool) (bool, error) {
 	if v.Spec.NodeID != "" || v.Status.CurrentNodeID != "" {
-		return nil
+		return false, nil
 	}
 	if !(v.Status.State == "" || v.Status.State == longhorn.VolumeStateDetached) {
-		return nil
+		return false, nil
 	}
 	// Do not intervene the auto reattachment workflow during the engine crashing and volume recovery.
 	if v.Status.PendingNodeID != "" {
-		return nil
+		return false, nil
 	}
 	// It's meaningless to do auto attachment if the volume scheduling fails
 	if !scheduled {
-		return nil
+		return false, nil
 	}
 
 	exportingBackingImageDataSources, err := vc.ds.ListBackingImageDataSourcesExportingFromVolume(v.Name)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	// Do auto attachment for:
@@ -2860,15 +2999,329 @@ func (vc *VolumeController) checkForAutoAttachment(v *longhorn.Volume, e *longho
 	sourceVolumeOfCloning, err := vc.isSourceVolumeOfCloning(v)
 	isExportingBackingImage := len(exportingBackingImageDataSources) != 0
 	if err != nil {
-		return err
+		return false, err
 	}
 	if isRestoringDRVol || isExpansionVol || isEvictionRequestedOnVol ||
 		isTargetVolOfCloning || sourceVolumeOfCloning || isExportingBackingImage {
+		handedOver, err := vc.handOverToReplicaNodeForAutoAttachment(v, rs)
+		if err != nil || handedOver {
+			return handedOver, err
+		}
 		// Should use vc.controllerID or v.Status.OwnerID as CurrentNodeID,
 		// otherwise they may be not equal
 		v.Status.CurrentNodeID = v.Status.OwnerID
 	}
 
+	return false, nil
+}
+
+// handOverToReplicaNodeForAutoAttachment transfers the ownership of the volume
+// to a node hosting a healthy replica if the current owner hosts none, so that
+// the new owner attaches the volume next to a replica. It is a best-effort
+// preference and returns true if the ownership is transferred.
+func (vc *VolumeController) handOverToReplicaNodeForAutoAttachment(v *longhorn.Volume, rs map[string]*longhorn.Replica) (bool, error) {
+	preferReplicaNode, err := vc.ds.GetSettingAsBool(types.SettingNameAutoAttachPreferReplicaNode)
+	if err != nil {
+		return false, err
+	}
+	if !preferReplicaNode {
+		return false, nil
+	}
+
+	defaultEngineImage, err := vc.ds.GetSettingValueExisted(types.SettingNameDefaultEngineImage)
+	if err != nil {
+		return false, err
+	}
+
+	candidates := []string{}
+	for _, r := range rs {
+		if !datastore.IsAvailableHealthyReplica(r) || r.Spec.NodeID == "" || r.Status.EvictionRequested {
+			continue
+		}
+		if r.Spec.NodeID == v.Status.OwnerID {
+			return false, nil
+		}
+		candidates = append(candidates, r.Spec.NodeID)
+	}
+	sort.Strings(candidates)
+
+	for _, nodeID := range candidates {
+		if isDown, err := vc.ds.IsNodeDownOrDeletedOrMissingManager(nodeID); err != nil || isDown {
+			continue
+		}
+		// The new owner needs the default engine image to stay responsible
+		// for the volume
+		if isReady, err := vc.ds.CheckEngineImageReadiness(defaultEngineImage, nodeID); err != nil || !isReady {
+			continue
+		}
+		if isReady, err := vc.ds.CheckEngineImageReadiness(v.Status.CurrentImage, nodeID); err != nil || !isReady {
+			continue
+		}
+		getLoggerForVolume(vc.logger, v).Infof("Handing over volume to node %v hosting a healthy replica for auto attachment", nodeID)
+		v.Status.OwnerID = nodeID
+		return true, nil
+	}
+	return false, nil
+}
+
+// detachVolumeFromDownNode detaches the volume from its node if Kubernetes has
+// reported the node down or deleted for longer than the auto detach timeout,
+// so that the volume can be reattached to another node. The timeout of a
+// deleted node counts from the last time the node was seen down.
+func (vc *VolumeController) detachVolumeFromDownNode(v *longhorn.Volume) (*longhorn.Volume, error) {
+	if v.Spec.NodeID == "" || v.Spec.MigrationNodeID != "" || v.Status.IsStandby {
+		return v, nil
+	}
+	// the share manager takes care of the shared volumes
+	if types.IsSharedVolume(v) {
+		return v, nil
+	}
+
+	log := getLoggerForVolume(vc.logger, v)
+
+	timeout, err := vc.ds.GetSettingAsDuration(types.SettingNameAutoDetachTimeout)
+	if err != nil {
+		log.WithError(err).Warnf("Failed to get setting %v", types.SettingNameAutoDetachTimeout)
+		return v, nil
+	}
+	if timeout <= 0 {
+		return v, nil
+	}
+
+	isDownOrDeleted, err := vc.ds.IsNodeDownOrDeleted(v.Spec.NodeID)
+	if err != nil {
+		return v, err
+	}
+	if !isDownOrDeleted {
+		vc.forgetNodeDownTime(v.Spec.NodeID)
+		return v, nil
+	}
+	node, err := vc.ds.GetNodeRO(v.Spec.NodeID)
+	if err != nil && !datastore.ErrorIsNotFound(err) {
+		return v, err
+	}
+	now, err := util.ParseTime(vc.nowHandler())
+	if err != nil {
+		return v, err
+	}
+	downAt := now
+	if node != nil {
+		readyCondition := types.GetCondition(node.Status.Conditions, longhorn.NodeConditionTypeReady)
+		if downAt, err = util.ParseTime(readyCondition.LastTransitionTime); err != nil {
+			log.WithError(err).Warnf("Failed to parse the time node %v became down", node.Name)
+			return v, nil
+		}
+	}
+	// A deleted node is considered down since the last time it was seen down,
+	// or since now if it has never been seen down by this controller.
+	downAt = vc.recordNodeDownTime(v.Spec.NodeID, downAt, node == nil)
+	if downFor := now.Sub(downAt); downFor < timeout {
+		vc.enqueueVolumeAfter(v, timeout-downFor)
+		return v, nil
+	}
+
+	nodeID := v.Spec.NodeID
+	log.Infof("Auto detaching volume from node %v that has been down for more than %v", nodeID, timeout)
+	v.Spec.NodeID = ""
+	if v, err = vc.ds.UpdateVolume(v); err != nil {
+		return nil, err
+	}
+	vc.eventRecorder.Eventf(v, v1.EventTypeWarning, constant.EventReasonAutoDetached, "Auto detached volume from node %v that has been down for more than %v", nodeID, timeout)
+	return v, nil
+}
+
+// recordNodeDownTime records the time the node became down and returns the
+// time to count the auto detach timeout from. For a deleted node, the time
+// recorded while the node still existed is kept.
+func (vc *VolumeController) recordNodeDownTime(nodeName string, downAt time.Time, deleted bool) time.Time {
+	vc.nodeDownTimesLock.Lock()
+	defer vc.nodeDownTimesLock.Unlock()
+
+	if vc.nodeDownTimes == nil {
+		vc.nodeDownTimes = map[string]time.Time{}
+	}
+	if recordedDownAt, ok := vc.nodeDownTimes[nodeName]; ok && deleted {
+		return recordedDownAt
+	}
+	vc.nodeDownTimes[nodeName] = downAt
+	return downAt
+}
+
+func (vc *VolumeController) forgetNodeDownTime(nodeName string) {
+	vc.nodeDownTimesLock.Lock()
+	defer vc.nodeDownTimesLock.Unlock()
+
+	delete(vc.nodeDownTimes, nodeName)
+}
+
+// reconcileVolumeAttachment arbitrates the attachment tickets of the volume.
+// The volume is attached as the ticket with the highest priority requests, and
+// the volume attached for another ticket is detached first. The volumes without
+// the attachment tickets are attached by setting the spec directly as before.
+// It returns true if the volume spec is updated, in which case the caller
+// should stop syncing the stale volume object.
+func (vc *VolumeController) reconcileVolumeAttachment(v *longhorn.Volume) (bool, error) {
+	if !types.IsAttachmentTicketSupported(v) || v.Status.IsStandby || v.Status.RestoreRequired {
+		return false, nil
+	}
+
+	va, err := vc.ds.GetLHVolumeAttachment(v.Name)
+	if err != nil {
+		if datastore.ErrorIsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	log := getLoggerForVolume(vc.logger, v)
+
+	desiredNodeID, desiredDisableFrontend, winnerID := "", false, ""
+	if winner := types.GetWinningAttachmentTicket(va.Spec.AttachmentTickets); winner != nil {
+		desiredNodeID = winner.NodeID
+		desiredDisableFrontend = types.IsAttachmentTicketFrontendDisabled(winner)
+		winnerID = winner.ID
+	}
+
+	isDesiredNodeDown := false
+	if desiredNodeID != "" {
+		if isDesiredNodeDown, err = vc.ds.IsNodeDownOrDeleted(desiredNodeID); err != nil {
+			return false, err
+		}
+	}
+
+	isSpecChanged := false
+	v = v.DeepCopy()
+	switch {
+	case v.Spec.NodeID == desiredNodeID && (desiredNodeID == "" || v.Spec.DisableFrontend == desiredDisableFrontend):
+	case v.Spec.NodeID != "":
+		log.Infof("Detaching volume from node %v for attachment ticket %v", v.Spec.NodeID, winnerID)
+		v.Spec.NodeID = ""
+		v.Spec.DisableFrontend = false
+		isSpecChanged = true
+	case v.Status.State == longhorn.VolumeStateDetached && !isDesiredNodeDown:
+		log.Infof("Attaching volume to node %v for attachment ticket %v", desiredNodeID, winnerID)
+		v.Spec.NodeID = desiredNodeID
+		v.Spec.DisableFrontend = desiredDisableFrontend
+		v.Spec.LastAttachedBy = winnerID
+		isSpecChanged = true
+	}
+	if isSpecChanged {
+		if _, err := vc.ds.UpdateVolume(v); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	ticketStatuses := map[string]*longhorn.AttachmentTicketStatus{}
+	for id, ticket := range va.Spec.AttachmentTickets {
+		if ticket == nil {
+			continue
+		}
+		status := &longhorn.AttachmentTicketStatus{ID: id}
+		switch {
+		case ticket.NodeID != desiredNodeID || types.IsAttachmentTicketFrontendDisabled(ticket) != desiredDisableFrontend:
+			status.Message = fmt.Sprintf("waiting for attachment ticket %v with higher priority", winnerID)
+		case isDesiredNodeDown:
+			status.Message = fmt.Sprintf("node %v is down or deleted", ticket.NodeID)
+		case v.Status.State == longhorn.VolumeStateAttached && v.Status.CurrentNodeID == ticket.NodeID && v.Spec.NodeID == ticket.NodeID:
+			status.Satisfied = true
+		default:
+			status.Message = fmt.Sprintf("volume is being attached to node %v", ticket.NodeID)
+		}
+		ticketStatuses[id] = status
+	}
+	if len(ticketStatuses) == 0 {
+		ticketStatuses = nil
+	}
+	if !reflect.DeepEqual(va.Status.AttachmentTicketStatuses, ticketStatuses) {
+		va.Status.AttachmentTicketStatuses = ticketStatuses
+		if _, err := vc.ds.UpdateLHVolumeAttachmentStatus(va); err != nil {
+			return false, err
+		}
+	}
+
+	return false, nil
+}
+
+// purgeSnapshotsExceedingMaxCount deletes the oldest user created snapshots of
+// the volume once the number of snapshots exceeds the snapshot max count.
+// Snapshots referenced by backups or used for cloning volumes are kept.
+func (vc *VolumeController) purgeSnapshotsExceedingMaxCount(v *longhorn.Volume) error {
+	if v.Status.State != longhorn.VolumeStateAttached || v.Status.IsStandby || v.Status.RestoreRequired || v.Spec.MigrationNodeID != "" {
+		return nil
+	}
+
+	log := getLoggerForVolume(vc.logger, v)
+
+	maxCount := v.Spec.SnapshotMaxCount
+	if maxCount == 0 {
+		count, err := vc.ds.GetSettingAsInt(types.SettingNameSnapshotMaxCount)
+		if err != nil {
+			log.WithError(err).Warnf("Failed to get setting %v", types.SettingNameSnapshotMaxCount)
+			return nil
+		}
+		maxCount = int(count)
+	}
+	if maxCount <= 0 {
+		return nil
+	}
+
+	snapshots, err := vc.ds.ListVolumeSnapshotsRO(v.Name)
+	if err != nil {
+		return err
+	}
+	activeCount := 0
+	for _, snapshot := range snapshots {
+		if snapshot.DeletionTimestamp == nil && !snapshot.Status.MarkRemoved {
+			activeCount++
+		}
+	}
+	if activeCount <= maxCount {
+		return nil
+	}
+
+	backups, err := vc.ds.ListBackupsWithBackupVolumeName(v.Name)
+	if err != nil {
+		return err
+	}
+	backedUpSnapshots := map[string]struct{}{}
+	for _, backup := range backups {
+		backedUpSnapshots[backup.Spec.SnapshotName] = struct{}{}
+		backedUpSnapshots[backup.Status.SnapshotName] = struct{}{}
+	}
+
+	candidates := []*longhorn.Snapshot{}
+	for _, snapshot := range snapshots {
+		if snapshot.DeletionTimestamp != nil || snapshot.Status.MarkRemoved || !snapshot.Status.UserCreated {
+			continue
+		}
+		if _, ok := backedUpSnapshots[snapshot.Name]; ok {
+			continue
+		}
+		if _, ok := snapshot.Labels[types.GetLonghornLabelKey(types.LonghornLabelSnapshotForCloningVolume)]; ok {
+			continue
+		}
+		candidates = append(candidates, snapshot)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Status.CreationTime < candidates[j].Status.CreationTime
+	})
+
+	purged := []string{}
+	for _, snapshot := range candidates {
+		if activeCount <= maxCount {
+			break
+		}
+		if err := vc.ds.DeleteSnapshot(snapshot.Name); err != nil && !datastore.ErrorIsNotFound(err) {
+			return errors.Wrapf(err, "failed to purge snapshot %v", snapshot.Name)
+		}
+		purged = append(purged, snapshot.Name)
+		activeCount--
+	}
+	if len(purged) != 0 {
+		log.Infof("Purged snapshots %v since the snapshot count exceeds %v", purged, maxCount)
+		vc.eventRecorder.Eventf(v, v1.EventTypeNormal, constant.EventReasonPurgedSnapshots, "Purged snapshots %v since the snapshot count exceeds %v", strings.Join(purged, ", "), maxCount)
+	}
 	return nil
 }
 
@@ -3089,7 +3542,7 @@ func (vc *VolumeController) createReplica(v *longhorn.Volume, e *longhorn.Engine
 	if isRebuildingReplica {
 		log.Debugf("A new replica %v will be replenished during rebuilding", replica.Name)
 		// Prevent this new replica from being reused after rebuilding failure.
-		replica.Spec.RebuildRetryCount = scheduler.FailedReplicaMaxRetryCount
+		replica.Spec.RebuildRetryCount = scheduler.NonReusableReplicaRebuildRetryCount
 	}
 
 	replica, err := vc.ds.CreateReplica(replica)
@@ -3841,6 +4294,27 @@ func (vc *VolumeController) enqueueVolumesForShareManager(obj interface{}) {
 	vc.queue.Add(key)
 }
 
+func (vc *VolumeController) enqueueVolumeForVolumeAttachment(obj interface{}) {
+	va, isVolumeAttachment := obj.(*longhorn.VolumeAttachment)
+	if !isVolumeAttachment {
+		deletedState, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("received unexpected obj: %#v", obj))
+			return
+		}
+
+		// use the last known state, to requeue the volume
+		va, ok = deletedState.Obj.(*longhorn.VolumeAttachment)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("DeletedFinalStateUnknown contained non VolumeAttachment object: %#v", deletedState.Obj))
+			return
+		}
+	}
+
+	key := va.Namespace + "/" + va.Spec.Volume
+	vc.queue.Add(key)
+}
+
 // ReconcileShareManagerState is responsible for syncing the state of shared volumes with their share manager
 func (vc *VolumeController) ReconcileShareManagerState(volume *longhorn.Volume) error {
 	log := getLoggerForVolume(vc.logger, volume)
@@ -3850,7 +4324,7 @@ func (vc *VolumeController) ReconcileShareManagerState(volume *longhorn.Volume)
 		return err
 	}
 
-	if volume.Spec.AccessMode != longhorn.AccessModeReadWriteMany || volume.Spec.Migratable {
+	if !types.IsSharedVolume(volume) {
 		if sm != nil {
 			log.Info("Removing share manager for non shared volume")
 			if err := vc.ds.DeleteShareManager(volume.Name); err != nil && !datastore.ErrorIsNotFound(err) {
@@ -4018,6 +4492,17 @@ func (vc *VolumeController) enqueueNodeChange(obj interface{}) {
 			vc.enqueueVolume(vol)
 		}
 	}
+
+	// The volumes attached to the node handle the node becoming down or
+	// being deleted
+	volumes, err := vc.ds.ListVolumesByNodeRO(node.Name)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to list volumes when enqueuing node %v: %v", node.Name, err))
+		return
+	}
+	for _, v := range volumes {
+		vc.enqueueVolume(v)
+	}
 }
 
 func isSettingRelatedToVolume(obj interface{}) bool {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -20,7 +21,9 @@ import (
 	"github.com/longhorn/backupstore"
 	imutil "github.com/longhorn/longhorn-instance-manager/pkg/util"
 
+	"github.com/longhorn/longhorn-manager/constant"
 	"github.com/longhorn/longhorn-manager/datastore"
+	"github.com/longhorn/longhorn-manager/scheduler"
 	"github.com/longhorn/longhorn-manager/types"
 	"github.com/longhorn/longhorn-manager/util"
 
@@ -52,8 +55,11 @@ func initSettingsNameValue(name, value string) *longhorn.Setting {
 
 func newTestVolumeController(lhInformerFactory lhinformerfactory.SharedInformerFactory, kubeInformerFactory informers.SharedInformerFactory,
 	lhClient *lhfake.Clientset, kubeClient *fake.Clientset, extensionsClient *apiextensionsfake.Clientset,
-	controllerID string) *VolumeController {
-	ds := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+	controllerID string) (*VolumeController, error) {
+	ds, err := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+	if err != nil {
+		return nil, err
+	}
 
 	proxyConnCounter := util.NewAtomicCounter()
 
@@ -68,7 +74,7 @@ func newTestVolumeController(lhInformerFactory lhinformerfactory.SharedInformerF
 	}
 	vc.nowHandler = getTestNow
 
-	return vc
+	return vc, nil
 }
 
 type VolumeTestCase struct {
@@ -224,6 +230,7 @@ func (s *TestSuite) TestVolumeLifeCycle(c *C) {
 	for _, r := range tc.expectReplicas {
 		r.Spec.HealthyAt = getTestNow()
 	}
+	tc.expectVolume.Status.LastHealthyAt = getTestNow()
 	testCases["volume attached"] = tc
 
 	tc = generateVolumeTestCaseTemplate()
@@ -320,6 +327,7 @@ func (s *TestSuite) TestVolumeLifeCycle(c *C) {
 	tc.expectVolume.Status.Robustness = longhorn.VolumeRobustnessHealthy
 	tc.expectVolume.Status.Conditions = setVolumeConditionWithoutTimestamp(tc.volume.Status.Conditions,
 		longhorn.VolumeConditionTypeRestore, longhorn.ConditionStatusTrue, longhorn.VolumeConditionReasonRestoreInProgress, "")
+	tc.expectVolume.Status.LastHealthyAt = getTestNow()
 	testCases["newly restored volume attaching to attached"] = tc
 
 	// Newly restored volume is waiting for restoration completed
@@ -711,6 +719,7 @@ func (s *TestSuite) TestVolumeLifeCycle(c *C) {
 	tc.copyCurrentToExpect()
 	tc.expectVolume.Status.State = longhorn.VolumeStateAttached
 	tc.expectVolume.Status.Robustness = longhorn.VolumeRobustnessHealthy
+	tc.expectVolume.Status.LastHealthyAt = getTestNow()
 	testCases["standby volume is not automatically detached"] = tc
 
 	// volume detaching - stop engine
@@ -748,6 +757,7 @@ func (s *TestSuite) TestVolumeLifeCycle(c *C) {
 		e.Spec.NodeID = ""
 		e.Spec.DesireState = longhorn.InstanceStateStopped
 	}
+	tc.expectVolume.Status.LastHealthyAt = getTestNow()
 	testCases["volume detaching - stop engine"] = tc
 
 	// volume detaching - stop replicas
@@ -1296,6 +1306,158 @@ func (tc *VolumeTestCase) copyCurrentToExpect() {
 	}
 }
 
+func (s *TestSuite) TestGetAutoBalancedReplicasSetting(c *C) {
+	type testCase struct {
+		volumeSetting longhorn.ReplicaAutoBalance
+		globalSetting string
+
+		expectSetting longhorn.ReplicaAutoBalance
+		expectErr     bool
+	}
+	testCases := map[string]testCase{
+		"volume setting takes precedence": {
+			volumeSetting: longhorn.ReplicaAutoBalanceBestEffort,
+			globalSetting: string(longhorn.ReplicaAutoBalanceDisabled),
+			expectSetting: longhorn.ReplicaAutoBalanceBestEffort,
+		},
+		"volume setting disabled": {
+			volumeSetting: longhorn.ReplicaAutoBalanceDisabled,
+			globalSetting: string(longhorn.ReplicaAutoBalanceLeastEffort),
+			expectSetting: longhorn.ReplicaAutoBalanceDisabled,
+		},
+		"ignored volume setting follows global setting": {
+			volumeSetting: longhorn.ReplicaAutoBalanceIgnored,
+			globalSetting: string(longhorn.ReplicaAutoBalanceLeastEffort),
+			expectSetting: longhorn.ReplicaAutoBalanceLeastEffort,
+		},
+		"ignored global setting is disabled": {
+			volumeSetting: longhorn.ReplicaAutoBalanceIgnored,
+			globalSetting: string(longhorn.ReplicaAutoBalanceIgnored),
+			expectSetting: longhorn.ReplicaAutoBalanceDisabled,
+		},
+		"invalid volume setting is disabled": {
+			volumeSetting: longhorn.ReplicaAutoBalance("invalid"),
+			globalSetting: string(longhorn.ReplicaAutoBalanceBestEffort),
+			expectSetting: longhorn.ReplicaAutoBalanceDisabled,
+			expectErr:     true,
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		kubeClient := fake.NewSimpleClientset()
+		kubeInformerFactory := informers.NewSharedInformerFactory(kubeClient, controller.NoResyncPeriodFunc())
+		lhClient := lhfake.NewSimpleClientset()
+		lhInformerFactory := lhinformerfactory.NewSharedInformerFactory(lhClient, controller.NoResyncPeriodFunc())
+		sIndexer := lhInformerFactory.Longhorn().V1beta2().Settings().Informer().GetIndexer()
+		extensionsClient := apiextensionsfake.NewSimpleClientset()
+
+		vc, err := newTestVolumeController(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient, extensionsClient, TestOwnerID1)
+		c.Assert(err, IsNil)
+
+		setting, err := lhClient.LonghornV1beta2().Settings(TestNamespace).Create(context.TODO(),
+			initSettingsNameValue(string(types.SettingNameReplicaAutoBalance), tc.globalSetting), metav1.CreateOptions{})
+		c.Assert(err, IsNil)
+		err = sIndexer.Add(setting)
+		c.Assert(err, IsNil)
+
+		v := newVolume(TestVolumeName, 2)
+		v.Spec.ReplicaAutoBalance = tc.volumeSetting
+
+		result, err := vc.getAutoBalancedReplicasSetting(v)
+		if tc.expectErr {
+			c.Assert(err, NotNil, Commentf("test case: %v", name))
+		} else {
+			c.Assert(err, IsNil, Commentf("test case: %v", name))
+		}
+		c.Assert(result, Equals, tc.expectSetting, Commentf("test case: %v", name))
+	}
+}
+
+func (s *TestSuite) TestHasLocalReplicaOnSameNodeAsEngine(c *C) {
+	v := newVolume(TestVolumeName, 2)
+	e := newEngineForVolume(v)
+	e.Spec.NodeID = TestNode1
+
+	localReplica := newReplicaForVolume(v, e, TestNode1, TestDiskID1)
+	remoteReplica := newReplicaForVolume(v, e, TestNode2, TestDiskID1)
+	pendingLocalReplica := newReplicaForVolume(v, e, "", "")
+	pendingLocalReplica.Spec.HardNodeAffinity = TestNode1
+
+	testCases := map[string]struct {
+		engineNodeID string
+		replicas     []*longhorn.Replica
+		expected     bool
+	}{
+		"local replica":                 {TestNode1, []*longhorn.Replica{localReplica, remoteReplica}, true},
+		"no local replica":              {TestNode1, []*longhorn.Replica{remoteReplica}, false},
+		"local replica to be scheduled": {TestNode1, []*longhorn.Replica{remoteReplica, pendingLocalReplica}, true},
+		"engine not scheduled":          {"", []*longhorn.Replica{pendingLocalReplica}, false},
+		"engine without replicas":       {TestNode1, []*longhorn.Replica{}, false},
+		"replicas on the other node":    {TestNode2, []*longhorn.Replica{localReplica, pendingLocalReplica}, false},
+	}
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+		e.Spec.NodeID = tc.engineNodeID
+		rs := map[string]*longhorn.Replica{}
+		for _, r := range tc.replicas {
+			rs[r.Name] = r
+		}
+		c.Assert(hasLocalReplicaOnSameNodeAsEngine(e, rs), Equals, tc.expected, Commentf("test case: %v", name))
+	}
+}
+
+func (s *TestSuite) TestSyncVolumeRebuildStatus(c *C) {
+	vc := &VolumeController{nowHandler: getTestNow}
+
+	v := newVolume(TestVolumeName, 2)
+	e := newEngineForVolume(v)
+	srcReplica := newReplicaForVolume(v, e, TestNode1, TestDiskID1)
+	srcReplica.Status.StorageIP = TestIP1
+	srcReplica.Status.Port = 10000
+	dstReplica := newReplicaForVolume(v, e, TestNode2, TestDiskID1)
+	dstReplica.Status.StorageIP = TestIP2
+	dstReplica.Status.Port = 10000
+	replicaList := []*longhorn.Replica{srcReplica, dstReplica}
+	dstAddress := "tcp://" + TestIP2 + ":10000"
+
+	e.Status.RebuildStatus = map[string]*longhorn.RebuildStatus{
+		dstAddress: {
+			IsRebuilding:       true,
+			Progress:           30,
+			State:              "in_progress",
+			FromReplicaAddress: "tcp://" + TestIP1 + ":10000",
+		},
+	}
+	vc.syncVolumeRebuildStatus(v, e, replicaList)
+	c.Assert(v.Status.RebuildStatus, DeepEquals, map[string]*longhorn.VolumeRebuildStatus{
+		dstReplica.Name: {
+			Progress:    30,
+			State:       "in_progress",
+			FromReplica: srcReplica.Name,
+			StartedAt:   TestTimeNow,
+		},
+	})
+
+	// The start time of an ongoing rebuild is kept
+	startedAt := "2000-01-01T00:00:00Z"
+	v.Status.RebuildStatus[dstReplica.Name].StartedAt = startedAt
+	e.Status.RebuildStatus[dstAddress].Progress = 60
+	vc.syncVolumeRebuildStatus(v, e, replicaList)
+	c.Assert(v.Status.RebuildStatus[dstReplica.Name].Progress, Equals, 60)
+	c.Assert(v.Status.RebuildStatus[dstReplica.Name].StartedAt, Equals, startedAt)
+
+	// The status is cleaned up once the rebuild completes
+	e.Status.RebuildStatus[dstAddress] = &longhorn.RebuildStatus{
+		IsRebuilding: false,
+		Progress:     100,
+		State:        "complete",
+	}
+	vc.syncVolumeRebuildStatus(v, e, replicaList)
+	c.Assert(v.Status.RebuildStatus, IsNil)
+}
+
 func (s *TestSuite) runTestCases(c *C, testCases map[string]*VolumeTestCase) {
 	//testCases = map[string]*VolumeTestCase{}
 	for name, tc := range testCases {
@@ -1318,7 +1480,8 @@ func (s *TestSuite) runTestCases(c *C, testCases map[string]*VolumeTestCase) {
 
 		extensionsClient := apiextensionsfake.NewSimpleClientset()
 
-		vc := newTestVolumeController(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient, extensionsClient, TestOwnerID1)
+		vc, err := newTestVolumeController(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient, extensionsClient, TestOwnerID1)
+		c.Assert(err, IsNil)
 
 		// Need to create daemon pod for node
 		daemon1 := newDaemonPod(v1.PodRunning, TestDaemon1, TestNamespace, TestNode1, TestIP1, nil)
@@ -1527,3 +1690,509 @@ func (s *TestSuite) runTestCases(c *C, testCases map[string]*VolumeTestCase) {
 		}
 	}
 }
+
+func (s *TestSuite) TestHandOverToReplicaNodeForAutoAttachment(c *C) {
+	type testCase struct {
+		preferReplicaNode string
+		replicaNodes      []string
+		downNodes         []string
+
+		expectedHandedOver    bool
+		expectedOwnerID       string
+		expectedCurrentNodeID string
+	}
+	testCases := map[string]testCase{
+		"setting disabled": {
+			preferReplicaNode:     "false",
+			replicaNodes:          []string{TestNode2},
+			expectedHandedOver:    false,
+			expectedOwnerID:       TestNode1,
+			expectedCurrentNodeID: TestNode1,
+		},
+		"owner hosts a healthy replica": {
+			preferReplicaNode:     "true",
+			replicaNodes:          []string{TestNode1, TestNode2},
+			expectedHandedOver:    false,
+			expectedOwnerID:       TestNode1,
+			expectedCurrentNodeID: TestNode1,
+		},
+		"owner hosts no healthy replica": {
+			preferReplicaNode:     "true",
+			replicaNodes:          []string{TestNode2},
+			expectedHandedOver:    true,
+			expectedOwnerID:       TestNode2,
+			expectedCurrentNodeID: "",
+		},
+		"replica node is down": {
+			preferReplicaNode:     "true",
+			replicaNodes:          []string{TestNode2},
+			downNodes:             []string{TestNode2},
+			expectedHandedOver:    false,
+			expectedOwnerID:       TestNode1,
+			expectedCurrentNodeID: TestNode1,
+		},
+	}
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		kubeClient := fake.NewSimpleClientset()
+		kubeInformerFactory := informers.NewSharedInformerFactory(kubeClient, controller.NoResyncPeriodFunc())
+
+		lhClient := lhfake.NewSimpleClientset()
+		lhInformerFactory := lhinformerfactory.NewSharedInformerFactory(lhClient, controller.NoResyncPeriodFunc())
+
+		extensionsClient := apiextensionsfake.NewSimpleClientset()
+
+		sIndexer := lhInformerFactory.Longhorn().V1beta2().Settings().Informer().GetIndexer()
+		nIndexer := lhInformerFactory.Longhorn().V1beta2().Nodes().Informer().GetIndexer()
+		eiIndexer := lhInformerFactory.Longhorn().V1beta2().EngineImages().Informer().GetIndexer()
+
+		ds, err := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+		c.Assert(err, IsNil)
+		vc := &VolumeController{
+			baseController: newBaseController("longhorn-volume", logrus.StandardLogger()),
+			ds:             ds,
+		}
+
+		c.Assert(sIndexer.Add(newSetting(string(types.SettingNameAutoAttachPreferReplicaNode), tc.preferReplicaNode)), IsNil)
+		c.Assert(sIndexer.Add(newSetting(string(types.SettingNameDefaultEngineImage), TestEngineImage)), IsNil)
+
+		ei := newEngineImage(TestEngineImage, longhorn.EngineImageStateDeployed)
+		for _, nodeName := range []string{TestNode1, TestNode2} {
+			node := newNode(nodeName, TestNamespace, true, longhorn.ConditionStatusTrue, "")
+			if util.Contains(tc.downNodes, nodeName) {
+				node = newNode(nodeName, TestNamespace, true, longhorn.ConditionStatusFalse, string(longhorn.NodeConditionReasonKubernetesNodeGone))
+			}
+			c.Assert(nIndexer.Add(node), IsNil)
+			ei.Status.NodeDeploymentMap[nodeName] = true
+		}
+		c.Assert(eiIndexer.Add(ei), IsNil)
+
+		v := newVolume(TestVolumeName, len(tc.replicaNodes))
+		v.Status.OwnerID = TestNode1
+		v.Status.CurrentImage = TestEngineImage
+		v.Status.State = longhorn.VolumeStateDetached
+		v.Status.RestoreRequired = true
+		e := newEngineForVolume(v)
+		rs := map[string]*longhorn.Replica{}
+		for _, nodeName := range tc.replicaNodes {
+			r := newReplicaForVolume(v, e, nodeName, TestDiskID1)
+			r.Spec.HealthyAt = getTestNow()
+			rs[r.Name] = r
+		}
+
+		handedOver, err := vc.checkForAutoAttachment(v, e, rs, true)
+		c.Assert(err, IsNil, Commentf("test case: %v", name))
+		c.Assert(handedOver, Equals, tc.expectedHandedOver, Commentf("test case: %v", name))
+		c.Assert(v.Status.OwnerID, Equals, tc.expectedOwnerID, Commentf("test case: %v", name))
+		// The volume handed over is attached by the new owner
+		c.Assert(v.Status.CurrentNodeID, Equals, tc.expectedCurrentNodeID, Commentf("test case: %v", name))
+	}
+}
+
+func (s *TestSuite) TestCleanupExtraHealthyReplicasMaintenanceMode(c *C) {
+	type testCase struct {
+		maintenanceMode string
+
+		expectedReplicaCount int
+	}
+	testCases := map[string]testCase{
+		"maintenance mode disabled": {
+			maintenanceMode:      "false",
+			expectedReplicaCount: 2,
+		},
+		"maintenance mode enabled": {
+			maintenanceMode:      "true",
+			expectedReplicaCount: 3,
+		},
+	}
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		kubeClient := fake.NewSimpleClientset()
+		kubeInformerFactory := informers.NewSharedInformerFactory(kubeClient, controller.NoResyncPeriodFunc())
+
+		lhClient := lhfake.NewSimpleClientset()
+		lhInformerFactory := lhinformerfactory.NewSharedInformerFactory(lhClient, controller.NoResyncPeriodFunc())
+
+		extensionsClient := apiextensionsfake.NewSimpleClientset()
+
+		sIndexer := lhInformerFactory.Longhorn().V1beta2().Settings().Informer().GetIndexer()
+		rIndexer := lhInformerFactory.Longhorn().V1beta2().Replicas().Informer().GetIndexer()
+
+		ds, err := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+		c.Assert(err, IsNil)
+		vc := &VolumeController{
+			baseController: newBaseController("longhorn-volume", logrus.StandardLogger()),
+			ds:             ds,
+		}
+
+		c.Assert(sIndexer.Add(newSetting(string(types.SettingNameMaintenanceMode), tc.maintenanceMode)), IsNil)
+
+		v := newVolume(TestVolumeName, 2)
+		e := newEngineForVolume(v)
+		rs := map[string]*longhorn.Replica{}
+		for i, nodeName := range []string{TestNode1, TestNode2, TestNode1} {
+			r := newReplicaForVolume(v, e, nodeName, TestDiskID1)
+			r.Spec.HealthyAt = getTestNow()
+			r.Spec.Active = true
+			r.Status.EvictionRequested = i == 2
+			r, err := lhClient.LonghornV1beta2().Replicas(TestNamespace).Create(context.TODO(), r, metav1.CreateOptions{})
+			c.Assert(err, IsNil)
+			c.Assert(rIndexer.Add(r), IsNil)
+			rs[r.Name] = r
+		}
+
+		err = vc.cleanupExtraHealthyReplicas(v, e, rs)
+		c.Assert(err, IsNil, Commentf("test case: %v", name))
+		c.Assert(rs, HasLen, tc.expectedReplicaCount, Commentf("test case: %v", name))
+		for _, r := range rs {
+			if tc.maintenanceMode == "false" {
+				c.Assert(r.Status.EvictionRequested, Equals, false, Commentf("test case: %v", name))
+			}
+		}
+	}
+}
+
+func (s *TestSuite) TestReconcileVolumeAttachment(c *C) {
+	csiTicketID := types.GetAttachmentTicketID(longhorn.AttacherTypeCSIAttacher, TestPod1)
+	recurringJobTicketID := types.GetAttachmentTicketID(longhorn.AttacherTypeRecurringJob, TestRecurringJobName)
+	csiTicket := &longhorn.AttachmentTicket{
+		ID:     csiTicketID,
+		Type:   longhorn.AttacherTypeCSIAttacher,
+		NodeID: TestNode1,
+	}
+	recurringJobTicket := &longhorn.AttachmentTicket{
+		ID:     recurringJobTicketID,
+		Type:   longhorn.AttacherTypeRecurringJob,
+		NodeID: TestNode2,
+		Parameters: map[string]string{
+			longhorn.AttachmentParameterDisableFrontend: "true",
+		},
+	}
+
+	type testCase struct {
+		tickets      map[string]*longhorn.AttachmentTicket
+		volumeState  longhorn.VolumeState
+		volumeNodeID string
+		downNodes    []string
+		noAttachment bool
+
+		expectedSpecUpdated bool
+		expectedSpec        longhorn.VolumeSpec
+		expectedSatisfied   map[string]bool
+	}
+	testCases := map[string]testCase{
+		"volume without attachment object": {
+			volumeState:  longhorn.VolumeStateAttached,
+			volumeNodeID: TestNode1,
+			noAttachment: true,
+			expectedSpec: longhorn.VolumeSpec{NodeID: TestNode1},
+		},
+		"detached volume attached for the ticket": {
+			tickets:             map[string]*longhorn.AttachmentTicket{recurringJobTicketID: recurringJobTicket},
+			volumeState:         longhorn.VolumeStateDetached,
+			expectedSpecUpdated: true,
+			expectedSpec:        longhorn.VolumeSpec{NodeID: TestNode2, DisableFrontend: true, LastAttachedBy: recurringJobTicketID},
+			expectedSatisfied:   map[string]bool{recurringJobTicketID: false},
+		},
+		"volume detached for the ticket with higher priority": {
+			tickets:             map[string]*longhorn.AttachmentTicket{csiTicketID: csiTicket, recurringJobTicketID: recurringJobTicket},
+			volumeState:         longhorn.VolumeStateAttached,
+			volumeNodeID:        TestNode2,
+			expectedSpecUpdated: true,
+			expectedSpec:        longhorn.VolumeSpec{},
+			expectedSatisfied:   map[string]bool{csiTicketID: false, recurringJobTicketID: false},
+		},
+		"volume detached without tickets": {
+			tickets:             map[string]*longhorn.AttachmentTicket{},
+			volumeState:         longhorn.VolumeStateAttached,
+			volumeNodeID:        TestNode1,
+			expectedSpecUpdated: true,
+			expectedSpec:        longhorn.VolumeSpec{},
+		},
+		"volume not attached to down node": {
+			tickets:           map[string]*longhorn.AttachmentTicket{csiTicketID: csiTicket},
+			volumeState:       longhorn.VolumeStateDetached,
+			downNodes:         []string{TestNode1},
+			expectedSpec:      longhorn.VolumeSpec{},
+			expectedSatisfied: map[string]bool{csiTicketID: false},
+		},
+		"ticket satisfied": {
+			tickets:           map[string]*longhorn.AttachmentTicket{csiTicketID: csiTicket, recurringJobTicketID: recurringJobTicket},
+			volumeState:       longhorn.VolumeStateAttached,
+			volumeNodeID:      TestNode1,
+			expectedSpec:      longhorn.VolumeSpec{NodeID: TestNode1},
+			expectedSatisfied: map[string]bool{csiTicketID: true, recurringJobTicketID: false},
+		},
+	}
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		kubeClient := fake.NewSimpleClientset()
+		kubeInformerFactory := informers.NewSharedInformerFactory(kubeClient, controller.NoResyncPeriodFunc())
+
+		lhClient := lhfake.NewSimpleClientset()
+		lhInformerFactory := lhinformerfactory.NewSharedInformerFactory(lhClient, controller.NoResyncPeriodFunc())
+
+		extensionsClient := apiextensionsfake.NewSimpleClientset()
+
+		nIndexer := lhInformerFactory.Longhorn().V1beta2().Nodes().Informer().GetIndexer()
+		vIndexer := lhInformerFactory.Longhorn().V1beta2().Volumes().Informer().GetIndexer()
+		vaIndexer := lhInformerFactory.Longhorn().V1beta2().VolumeAttachments().Informer().GetIndexer()
+
+		ds, err := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+		c.Assert(err, IsNil)
+		vc := &VolumeController{
+			baseController: newBaseController("longhorn-volume", logrus.StandardLogger()),
+			ds:             ds,
+		}
+
+		for _, nodeName := range []string{TestNode1, TestNode2} {
+			node := newNode(nodeName, TestNamespace, true, longhorn.ConditionStatusTrue, "")
+			if util.Contains(tc.downNodes, nodeName) {
+				node = newNode(nodeName, TestNamespace, true, longhorn.ConditionStatusFalse, string(longhorn.NodeConditionReasonKubernetesNodeGone))
+			}
+			c.Assert(nIndexer.Add(node), IsNil)
+		}
+
+		v := newVolume(TestVolumeName, 2)
+		v.Namespace = TestNamespace
+		v.Spec.NodeID = tc.volumeNodeID
+		v.Status.State = tc.volumeState
+		v.Status.CurrentNodeID = tc.volumeNodeID
+		v, err = lhClient.LonghornV1beta2().Volumes(TestNamespace).Create(context.TODO(), v, metav1.CreateOptions{})
+		c.Assert(err, IsNil)
+		c.Assert(vIndexer.Add(v), IsNil)
+
+		if !tc.noAttachment {
+			va := &longhorn.VolumeAttachment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      TestVolumeName,
+					Namespace: TestNamespace,
+				},
+				Spec: longhorn.VolumeAttachmentSpec{
+					AttachmentTickets: tc.tickets,
+					Volume:            TestVolumeName,
+				},
+			}
+			va, err = lhClient.LonghornV1beta2().VolumeAttachments(TestNamespace).Create(context.TODO(), va, metav1.CreateOptions{})
+			c.Assert(err, IsNil)
+			c.Assert(vaIndexer.Add(va), IsNil)
+		}
+
+		isSpecUpdated, err := vc.reconcileVolumeAttachment(v)
+		c.Assert(err, IsNil, Commentf("test case: %v", name))
+		c.Assert(isSpecUpdated, Equals, tc.expectedSpecUpdated, Commentf("test case: %v", name))
+		if isSpecUpdated {
+			// the ticket statuses are updated in the next sync of the updated volume
+			v, err = lhClient.LonghornV1beta2().Volumes(TestNamespace).Get(context.TODO(), TestVolumeName, metav1.GetOptions{})
+			c.Assert(err, IsNil)
+			c.Assert(vIndexer.Update(v), IsNil)
+			isSpecUpdated, err = vc.reconcileVolumeAttachment(v)
+			c.Assert(err, IsNil, Commentf("test case: %v", name))
+			c.Assert(isSpecUpdated, Equals, false, Commentf("test case: %v", name))
+		}
+		c.Assert(v.Spec.NodeID, Equals, tc.expectedSpec.NodeID, Commentf("test case: %v", name))
+		c.Assert(v.Spec.DisableFrontend, Equals, tc.expectedSpec.DisableFrontend, Commentf("test case: %v", name))
+		c.Assert(v.Spec.LastAttachedBy, Equals, tc.expectedSpec.LastAttachedBy, Commentf("test case: %v", name))
+
+		if tc.noAttachment {
+			continue
+		}
+		va, err := lhClient.LonghornV1beta2().VolumeAttachments(TestNamespace).Get(context.TODO(), TestVolumeName, metav1.GetOptions{})
+		c.Assert(err, IsNil)
+		c.Assert(va.Status.AttachmentTicketStatuses, HasLen, len(tc.expectedSatisfied), Commentf("test case: %v", name))
+		for id, satisfied := range tc.expectedSatisfied {
+			status := va.Status.AttachmentTicketStatuses[id]
+			c.Assert(status, NotNil, Commentf("test case: %v", name))
+			c.Assert(status.Satisfied, Equals, satisfied, Commentf("test case: %v, ticket %v", name, id))
+			c.Assert(status.Satisfied || status.Message != "", Equals, true, Commentf("test case: %v, ticket %v", name, id))
+		}
+	}
+}
+
+func (s *TestSuite) TestDetachVolumeFromDownNode(c *C) {
+	type testCase struct {
+		nodeDownAt     string
+		nodeDeleted    bool
+		recordedDownAt string
+
+		expectedNodeID string
+	}
+	testCases := map[string]testCase{
+		"node up": {
+			expectedNodeID: TestNode1,
+		},
+		"node down within timeout": {
+			nodeDownAt:     "2015-01-01T23:58:00Z",
+			expectedNodeID: TestNode1,
+		},
+		"node down beyond timeout": {
+			nodeDownAt:     "2015-01-01T23:50:00Z",
+			expectedNodeID: "",
+		},
+		"deleted node seen down within timeout": {
+			nodeDeleted:    true,
+			recordedDownAt: "2015-01-01T23:58:00Z",
+			expectedNodeID: TestNode1,
+		},
+		"deleted node seen down beyond timeout": {
+			nodeDeleted:    true,
+			recordedDownAt: "2015-01-01T23:50:00Z",
+			expectedNodeID: "",
+		},
+		"deleted node never seen down": {
+			nodeDeleted:    true,
+			expectedNodeID: TestNode1,
+		},
+	}
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		kubeClient := fake.NewSimpleClientset()
+		kubeInformerFactory := informers.NewSharedInformerFactory(kubeClient, controller.NoResyncPeriodFunc())
+
+		lhClient := lhfake.NewSimpleClientset()
+		lhInformerFactory := lhinformerfactory.NewSharedInformerFactory(lhClient, controller.NoResyncPeriodFunc())
+
+		extensionsClient := apiextensionsfake.NewSimpleClientset()
+
+		sIndexer := lhInformerFactory.Longhorn().V1beta2().Settings().Informer().GetIndexer()
+		nIndexer := lhInformerFactory.Longhorn().V1beta2().Nodes().Informer().GetIndexer()
+		vIndexer := lhInformerFactory.Longhorn().V1beta2().Volumes().Informer().GetIndexer()
+
+		vc, err := newTestVolumeController(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient, extensionsClient, TestNode2)
+		c.Assert(err, IsNil)
+
+		c.Assert(sIndexer.Add(newSetting(string(types.SettingNameAutoDetachTimeout), "5m")), IsNil)
+
+		if !tc.nodeDeleted {
+			node := newNode(TestNode1, TestNamespace, true, longhorn.ConditionStatusTrue, "")
+			if tc.nodeDownAt != "" {
+				node = newNode(TestNode1, TestNamespace, true, longhorn.ConditionStatusFalse, string(longhorn.NodeConditionReasonKubernetesNodeNotReady))
+				for i := range node.Status.Conditions {
+					node.Status.Conditions[i].LastTransitionTime = tc.nodeDownAt
+				}
+			}
+			c.Assert(nIndexer.Add(node), IsNil)
+		}
+		if tc.recordedDownAt != "" {
+			recordedDownAt, err := util.ParseTime(tc.recordedDownAt)
+			c.Assert(err, IsNil)
+			vc.recordNodeDownTime(TestNode1, recordedDownAt, false)
+		}
+
+		v := newVolume(TestVolumeName, 2)
+		v.Namespace = TestNamespace
+		v.Spec.NodeID = TestNode1
+		v.Status.State = longhorn.VolumeStateAttached
+		v.Status.CurrentNodeID = TestNode1
+		v, err = lhClient.LonghornV1beta2().Volumes(TestNamespace).Create(context.TODO(), v, metav1.CreateOptions{})
+		c.Assert(err, IsNil)
+		c.Assert(vIndexer.Add(v), IsNil)
+
+		v, err = vc.detachVolumeFromDownNode(v)
+		c.Assert(err, IsNil, Commentf("test case: %v", name))
+		c.Assert(v.Spec.NodeID, Equals, tc.expectedNodeID, Commentf("test case: %v", name))
+	}
+}
+
+func (s *TestSuite) TestCleanupReplicasReachingRebuildRetryLimit(c *C) {
+	type testCase struct {
+		rebuildRetryCount int
+
+		expectedCleanup bool
+		expectedEvent   bool
+	}
+	testCases := map[string]testCase{
+		"replica below the rebuild retry limit is kept": {
+			rebuildRetryCount: scheduler.FailedReplicaMaxRetryCount - 1,
+		},
+		"replica reaching the rebuild retry limit is cleaned up": {
+			rebuildRetryCount: scheduler.FailedReplicaMaxRetryCount,
+			expectedCleanup:   true,
+			expectedEvent:     true,
+		},
+		"non-reusable replica is cleaned up silently": {
+			rebuildRetryCount: scheduler.NonReusableReplicaRebuildRetryCount,
+			expectedCleanup:   true,
+		},
+	}
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		kubeClient := fake.NewSimpleClientset()
+		kubeInformerFactory := informers.NewSharedInformerFactory(kubeClient, controller.NoResyncPeriodFunc())
+
+		lhClient := lhfake.NewSimpleClientset()
+		lhInformerFactory := lhinformerfactory.NewSharedInformerFactory(lhClient, controller.NoResyncPeriodFunc())
+
+		extensionsClient := apiextensionsfake.NewSimpleClientset()
+
+		vc, err := newTestVolumeController(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient, extensionsClient, TestOwnerID1)
+		c.Assert(err, IsNil)
+		fakeRecorder := vc.eventRecorder.(*record.FakeRecorder)
+
+		v := newVolume(TestVolumeName, 2)
+		v.Status.CurrentImage = TestEngineImage
+		e := newEngineForVolume(v)
+		r := newReplicaForVolume(v, e, TestNode1, TestDiskID1)
+		r.Namespace = TestNamespace
+		r.Spec.FailedAt = getTestNow()
+		r.Spec.RebuildRetryCount = tc.rebuildRetryCount
+		r, err = lhClient.LonghornV1beta2().Replicas(TestNamespace).Create(context.TODO(), r, metav1.CreateOptions{})
+		c.Assert(err, IsNil)
+		rs := map[string]*longhorn.Replica{r.Name: r}
+
+		err = vc.cleanupCorruptedOrStaleReplicas(v, rs)
+		c.Assert(err, IsNil, Commentf("test case: %v", name))
+		_, exists := rs[r.Name]
+		c.Assert(exists, Equals, !tc.expectedCleanup, Commentf("test case: %v", name))
+
+		hasEvent := false
+		for len(fakeRecorder.Events) > 0 {
+			if strings.Contains(<-fakeRecorder.Events, constant.EventReasonFailedRebuilding) {
+				hasEvent = true
+			}
+		}
+		c.Assert(hasEvent, Equals, tc.expectedEvent, Commentf("test case: %v", name))
+	}
+}
+
+func (s *TestSuite) TestUpdateEngineUpgradeBlockedCondition(c *C) {
+	kubeClient := fake.NewSimpleClientset()
+	kubeInformerFactory := informers.NewSharedInformerFactory(kubeClient, controller.NoResyncPeriodFunc())
+
+	lhClient := lhfake.NewSimpleClientset()
+	lhInformerFactory := lhinformerfactory.NewSharedInformerFactory(lhClient, controller.NoResyncPeriodFunc())
+
+	extensionsClient := apiextensionsfake.NewSimpleClientset()
+
+	vc, err := newTestVolumeController(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient, extensionsClient, TestOwnerID1)
+	c.Assert(err, IsNil)
+	fakeRecorder := vc.eventRecorder.(*record.FakeRecorder)
+
+	v := newVolume(TestVolumeName, 2)
+	v.Status.CurrentImage = TestEngineImage
+	v.Spec.EngineImage = TestUpgradedEngineImage
+
+	// The warning is emitted once while the upgrade stays blocked
+	incompatibleErr := fmt.Errorf("incompatible controller API version")
+	vc.updateEngineUpgradeBlockedCondition(v, incompatibleErr)
+	vc.updateEngineUpgradeBlockedCondition(v, incompatibleErr)
+	condition := types.GetCondition(v.Status.Conditions, longhorn.VolumeConditionTypeEngineUpgradeBlocked)
+	c.Assert(condition.Status, Equals, longhorn.ConditionStatusTrue)
+	c.Assert(condition.Reason, Equals, longhorn.VolumeConditionReasonIncompatibleEngineImage)
+	c.Assert(len(fakeRecorder.Events), Equals, 1)
+
+	// And again when the reason changes
+	vc.updateEngineUpgradeBlockedCondition(v, fmt.Errorf("another incompatible controller API version"))
+	c.Assert(len(fakeRecorder.Events), Equals, 2)
+
+	vc.updateEngineUpgradeBlockedCondition(v, nil)
+	condition = types.GetCondition(v.Status.Conditions, longhorn.VolumeConditionTypeEngineUpgradeBlocked)
+	c.Assert(condition.Status, Equals, longhorn.ConditionStatusFalse)
+	c.Assert(condition.Message, Equals, "")
+	c.Assert(len(fakeRecorder.Events), Equals, 2)
+}
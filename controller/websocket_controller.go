@@ -32,7 +32,7 @@ func (w *Watcher) Close() {
 
 type WebsocketController struct {
 	*baseController
-	cacheSyncs []cache.InformerSynced
+	cacheSyncs map[string]cache.InformerSynced
 
 	watchers    []*Watcher
 	watcherLock sync.Mutex
@@ -45,34 +45,36 @@ func NewWebsocketController(
 
 	wc := &WebsocketController{
 		baseController: newBaseController("longhorn-websocket", logger),
+
+		cacheSyncs: map[string]cache.InformerSynced{},
 	}
 
 	ds.VolumeInformer.AddEventHandler(wc.notifyWatchersHandler("volume"))
-	wc.cacheSyncs = append(wc.cacheSyncs, ds.VolumeInformer.HasSynced)
+	wc.cacheSyncs["VolumeInformer"] = ds.VolumeInformer.HasSynced
 	ds.EngineInformer.AddEventHandler(wc.notifyWatchersHandler("engine"))
-	wc.cacheSyncs = append(wc.cacheSyncs, ds.EngineInformer.HasSynced)
+	wc.cacheSyncs["EngineInformer"] = ds.EngineInformer.HasSynced
 	ds.ReplicaInformer.AddEventHandler(wc.notifyWatchersHandler("replica"))
-	wc.cacheSyncs = append(wc.cacheSyncs, ds.ReplicaInformer.HasSynced)
+	wc.cacheSyncs["ReplicaInformer"] = ds.ReplicaInformer.HasSynced
 	ds.SettingInformer.AddEventHandler(wc.notifyWatchersHandler("setting"))
-	wc.cacheSyncs = append(wc.cacheSyncs, ds.SettingInformer.HasSynced)
+	wc.cacheSyncs["SettingInformer"] = ds.SettingInformer.HasSynced
 	ds.EngineImageInformer.AddEventHandler(wc.notifyWatchersHandler("engineImage"))
-	wc.cacheSyncs = append(wc.cacheSyncs, ds.EngineImageInformer.HasSynced)
+	wc.cacheSyncs["EngineImageInformer"] = ds.EngineImageInformer.HasSynced
 	ds.BackingImageInformer.AddEventHandler(wc.notifyWatchersHandler("backingImage"))
-	wc.cacheSyncs = append(wc.cacheSyncs, ds.BackingImageInformer.HasSynced)
+	wc.cacheSyncs["BackingImageInformer"] = ds.BackingImageInformer.HasSynced
 	ds.NodeInformer.AddEventHandler(wc.notifyWatchersHandler("node"))
-	wc.cacheSyncs = append(wc.cacheSyncs, ds.NodeInformer.HasSynced)
+	wc.cacheSyncs["NodeInformer"] = ds.NodeInformer.HasSynced
 	ds.BackupTargetInformer.AddEventHandler(wc.notifyWatchersHandler("backupTarget"))
-	wc.cacheSyncs = append(wc.cacheSyncs, ds.BackupTargetInformer.HasSynced)
+	wc.cacheSyncs["BackupTargetInformer"] = ds.BackupTargetInformer.HasSynced
 	ds.BackupVolumeInformer.AddEventHandler(wc.notifyWatchersHandler("backupVolume"))
-	wc.cacheSyncs = append(wc.cacheSyncs, ds.BackupVolumeInformer.HasSynced)
+	wc.cacheSyncs["BackupVolumeInformer"] = ds.BackupVolumeInformer.HasSynced
 	ds.BackupInformer.AddEventHandler(wc.notifyWatchersHandler("backup"))
-	wc.cacheSyncs = append(wc.cacheSyncs, ds.BackupInformer.HasSynced)
+	wc.cacheSyncs["BackupInformer"] = ds.BackupInformer.HasSynced
 	ds.RecurringJobInformer.AddEventHandler(wc.notifyWatchersHandler("recurringJob"))
-	wc.cacheSyncs = append(wc.cacheSyncs, ds.RecurringJobInformer.HasSynced)
+	wc.cacheSyncs["RecurringJobInformer"] = ds.RecurringJobInformer.HasSynced
 	ds.SystemBackupInformer.AddEventHandler(wc.notifyWatchersHandler("systemBackup"))
-	wc.cacheSyncs = append(wc.cacheSyncs, ds.SystemBackupInformer.HasSynced)
+	wc.cacheSyncs["SystemBackupInformer"] = ds.SystemBackupInformer.HasSynced
 	ds.SystemRestoreInformer.AddEventHandler(wc.notifyWatchersHandler("systemRestore"))
-	wc.cacheSyncs = append(wc.cacheSyncs, ds.SystemRestoreInformer.HasSynced)
+	wc.cacheSyncs["SystemRestoreInformer"] = ds.SystemRestoreInformer.HasSynced
 
 	return wc
 }
@@ -96,7 +98,7 @@ func (wc *WebsocketController) Run(stopCh <-chan struct{}) {
 	wc.logger.Infof("Starting Longhorn websocket controller")
 	defer wc.logger.Infof("Shut down Longhorn websocket controller")
 
-	if !cache.WaitForNamedCacheSync("longhorn websocket", stopCh, wc.cacheSyncs...) {
+	if !wc.waitForCacheSync(stopCh, wc.cacheSyncs) {
 		return
 	}
 
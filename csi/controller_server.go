@@ -435,8 +435,12 @@ func (cs *ControllerServer) ControllerPublishVolume(ctx context.Context, req *cs
 
 	// TODO: JM if volume is already attached to a different node, return code `codes.FailedPrecondition`
 	//  this should be handled by the processing of the api return code
+	// The volume auto attached by a recurring job is detached by Longhorn for the
+	// attachment ticket of the CSI attacher, which has a higher priority. Other
+	// attachments, e.g. the maintenance mode requested via the Longhorn API, are
+	// never pre-empted.
 	if !requiresSharedAccess(volume, volumeCapability) &&
-		volume.State == string(longhorn.VolumeStateAttached) &&
+		volume.State == string(longhorn.VolumeStateAttached) && !isVolumeAutoAttachedByRecurringJob(volume) &&
 		len(volume.Controllers) > 0 && volume.Controllers[0].HostId != nodeID {
 		return nil, status.Errorf(codes.FailedPrecondition, "volume %s cannot be attached to node %s is already attached to node %s",
 			volumeID, nodeID, volume.Controllers[0].HostId)
@@ -459,6 +463,7 @@ func (cs *ControllerServer) publishVolume(volume *longhornclient.Volume, nodeID
 	input := &longhornclient.AttachInput{
 		HostId:          nodeID,
 		DisableFrontend: false,
+		AttachedBy:      string(longhorn.AttacherTypeCSIAttacher),
 	}
 
 	logrus.Infof("ControllerPublishVolume: volume %s with accessMode %s requesting publishing to %s", volume.Name, volume.AccessMode, nodeID)
@@ -342,6 +342,12 @@ func makeFile(pathname string) error {
 // requiresSharedAccess checks if the volume is requested to be multi node capable
 // a volume that is already in shared access mode, must be used via shared access
 // even if single node access is requested.
+// isVolumeAutoAttachedByRecurringJob returns true if the volume is attached
+// with the frontend disabled for the attachment ticket of a recurring job
+func isVolumeAutoAttachedByRecurringJob(vol *longhornclient.Volume) bool {
+	return vol.DisableFrontend && strings.HasPrefix(vol.LastAttachedBy, string(longhorn.AttacherTypeRecurringJob)+"-")
+}
+
 func requiresSharedAccess(vol *longhornclient.Volume, cap *csi.VolumeCapability) bool {
 	isSharedVolume := false
 	if vol != nil {
@@ -1,6 +1,8 @@
 package datastore
 
 import (
+	"github.com/pkg/errors"
+
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/informers"
 	clientset "k8s.io/client-go/kubernetes"
@@ -37,10 +39,12 @@ type DataStore struct {
 
 	lhClient                       lhclientset.Interface
 	vLister                        lhlisters.VolumeLister
+	vIndexer                       cache.Indexer
 	VolumeInformer                 cache.SharedInformer
 	eLister                        lhlisters.EngineLister
 	EngineInformer                 cache.SharedInformer
 	rLister                        lhlisters.ReplicaLister
+	rIndexer                       cache.Indexer
 	ReplicaInformer                cache.SharedInformer
 	iLister                        lhlisters.EngineImageLister
 	EngineImageInformer            cache.SharedInformer
@@ -76,9 +80,12 @@ type DataStore struct {
 	SystemBackupInformer           cache.SharedInformer
 	srLister                       lhlisters.SystemRestoreLister
 	SystemRestoreInformer          cache.SharedInformer
+	lhVALister                     lhlisters.VolumeAttachmentLister
+	LHVolumeAttachmentInformer     cache.SharedInformer
 
 	kubeClient                    clientset.Interface
 	pLister                       corelisters.PodLister
+	pIndexer                      cache.Indexer
 	PodInformer                   cache.SharedInformer
 	cjLister                      batchlisters_v1beta1.CronJobLister
 	CronJobInformer               cache.SharedInformer
@@ -119,7 +126,7 @@ func NewDataStore(
 	kubeInformerFactory informers.SharedInformerFactory,
 	kubeClient clientset.Interface,
 	extensionsClient apiextensionsclientset.Interface,
-	namespace string) *DataStore {
+	namespace string) (*DataStore, error) {
 
 	cacheSyncs := []cache.InformerSynced{}
 
@@ -163,6 +170,8 @@ func NewDataStore(
 	cacheSyncs = append(cacheSyncs, systemBackupInformer.Informer().HasSynced)
 	systemRestoreInformer := lhInformerFactory.Longhorn().V1beta2().SystemRestores()
 	cacheSyncs = append(cacheSyncs, systemRestoreInformer.Informer().HasSynced)
+	lhVolumeAttachmentInformer := lhInformerFactory.Longhorn().V1beta2().VolumeAttachments()
+	cacheSyncs = append(cacheSyncs, lhVolumeAttachmentInformer.Informer().HasSynced)
 
 	podInformer := kubeInformerFactory.Core().V1().Pods()
 	cacheSyncs = append(cacheSyncs, podInformer.Informer().HasSynced)
@@ -195,6 +204,10 @@ func NewDataStore(
 	serviceInformer := kubeInformerFactory.Core().V1().Services()
 	cacheSyncs = append(cacheSyncs, serviceInformer.Informer().HasSynced)
 
+	if err := addNodeIndexers(replicaInformer.Informer(), volumeInformer.Informer(), podInformer.Informer()); err != nil {
+		return nil, errors.Wrap(err, "failed to add node indexers to the informers")
+	}
+
 	return &DataStore{
 		namespace: namespace,
 
@@ -202,10 +215,12 @@ func NewDataStore(
 
 		lhClient:                       lhClient,
 		vLister:                        volumeInformer.Lister(),
+		vIndexer:                       volumeInformer.Informer().GetIndexer(),
 		VolumeInformer:                 volumeInformer.Informer(),
 		eLister:                        engineInformer.Lister(),
 		EngineInformer:                 engineInformer.Informer(),
 		rLister:                        replicaInformer.Lister(),
+		rIndexer:                       replicaInformer.Informer().GetIndexer(),
 		ReplicaInformer:                replicaInformer.Informer(),
 		iLister:                        engineImageInformer.Lister(),
 		EngineImageInformer:            engineImageInformer.Informer(),
@@ -241,9 +256,12 @@ func NewDataStore(
 		SystemBackupInformer:           systemBackupInformer.Informer(),
 		srLister:                       systemRestoreInformer.Lister(),
 		SystemRestoreInformer:          systemRestoreInformer.Informer(),
+		lhVALister:                     lhVolumeAttachmentInformer.Lister(),
+		LHVolumeAttachmentInformer:     lhVolumeAttachmentInformer.Informer(),
 
 		kubeClient:                    kubeClient,
 		pLister:                       podInformer.Lister(),
+		pIndexer:                      podInformer.Informer().GetIndexer(),
 		PodInformer:                   podInformer.Informer(),
 		cjLister:                      cronJobInformer.Lister(),
 		CronJobInformer:               cronJobInformer.Informer(),
@@ -275,7 +293,7 @@ func NewDataStore(
 		ServiceInformer:               serviceInformer.Informer(),
 
 		extensionsClient: extensionsClient,
-	}
+	}, nil
 }
 
 // Sync returns WaitForCacheSync for Longhorn DataStore
@@ -283,6 +301,16 @@ func (s *DataStore) Sync(stopCh <-chan struct{}) bool {
 	return cache.WaitForNamedCacheSync("longhorn datastore", stopCh, s.cacheSyncs...)
 }
 
+// HasSynced returns true if all informers of the Longhorn DataStore have synced
+func (s *DataStore) HasSynced() bool {
+	for _, synced := range s.cacheSyncs {
+		if !synced() {
+			return false
+		}
+	}
+	return true
+}
+
 // ErrorIsNotFound checks if given error match
 // metav1.StatusReasonNotFound
 func ErrorIsNotFound(err error) bool {
@@ -0,0 +1,80 @@
+package datastore
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/longhorn/longhorn-manager/types"
+
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
+)
+
+const (
+	// NodeIndex is the informer index name used to look up objects by the node they belong to
+	NodeIndex = "node"
+)
+
+// replicaNodeIndexFunc indexes a replica by its longhornnode label, which
+// matches the selector used by the label based by-node listing
+func replicaNodeIndexFunc(obj interface{}) ([]string, error) {
+	r, ok := obj.(*longhorn.Replica)
+	if !ok {
+		return nil, fmt.Errorf("failed to index object %#v by node: not a replica", obj)
+	}
+	nodeID := r.Labels[types.LonghornNodeKey]
+	if nodeID == "" {
+		return []string{}, nil
+	}
+	return []string{nodeID}, nil
+}
+
+// volumeNodeIndexFunc indexes a volume by the node it is requested to be
+// attached to and the node it is currently attached to
+func volumeNodeIndexFunc(obj interface{}) ([]string, error) {
+	v, ok := obj.(*longhorn.Volume)
+	if !ok {
+		return nil, fmt.Errorf("failed to index object %#v by node: not a volume", obj)
+	}
+	nodeIDs := []string{}
+	if v.Spec.NodeID != "" {
+		nodeIDs = append(nodeIDs, v.Spec.NodeID)
+	}
+	if v.Status.CurrentNodeID != "" && v.Status.CurrentNodeID != v.Spec.NodeID {
+		nodeIDs = append(nodeIDs, v.Status.CurrentNodeID)
+	}
+	return nodeIDs, nil
+}
+
+// podNodeIndexFunc indexes a pod by the node it is bound to
+func podNodeIndexFunc(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return nil, fmt.Errorf("failed to index object %#v by node: not a pod", obj)
+	}
+	if pod.Spec.NodeName == "" {
+		return []string{}, nil
+	}
+	return []string{pod.Spec.NodeName}, nil
+}
+
+// addNodeIndexers registers the by-node indexers. It must be called before
+// the informers are started. The informers already having the indexers are
+// skipped, since the informer factories can be shared by multiple data stores.
+func addNodeIndexers(replicaInformer, volumeInformer, podInformer cache.SharedIndexInformer) error {
+	if err := addNodeIndexer(replicaInformer, replicaNodeIndexFunc); err != nil {
+		return err
+	}
+	if err := addNodeIndexer(volumeInformer, volumeNodeIndexFunc); err != nil {
+		return err
+	}
+	return addNodeIndexer(podInformer, podNodeIndexFunc)
+}
+
+func addNodeIndexer(informer cache.SharedIndexInformer, indexFunc cache.IndexFunc) error {
+	if _, exists := informer.GetIndexer().GetIndexers()[NodeIndex]; exists {
+		return nil
+	}
+	return informer.AddIndexers(cache.Indexers{NodeIndex: indexFunc})
+}
@@ -0,0 +1,280 @@
+package datastore
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+
+	"github.com/longhorn/longhorn-manager/types"
+
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
+)
+
+const (
+	testIndexerNode1 = "test-node-1"
+	testIndexerNode2 = "test-node-2"
+)
+
+func newTestIndexerReplica(name, namespace, nodeID string) *longhorn.Replica {
+	r := &longhorn.Replica{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{},
+		},
+	}
+	if nodeID != "" {
+		r.Labels[types.LonghornNodeKey] = nodeID
+	}
+	return r
+}
+
+func newTestIndexerVolume(name, namespace, nodeID, currentNodeID string) *longhorn.Volume {
+	return &longhorn.Volume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: longhorn.VolumeSpec{
+			NodeID: nodeID,
+		},
+		Status: longhorn.VolumeStatus{
+			CurrentNodeID: currentNodeID,
+		},
+	}
+}
+
+func newTestIndexerPod(name, namespace, nodeName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: corev1.PodSpec{
+			NodeName: nodeName,
+		},
+	}
+}
+
+func TestReplicaNodeIndexFunc(t *testing.T) {
+	assert := require.New(t)
+
+	type testCase struct {
+		obj interface{}
+
+		expectedIndexes []string
+		expectError     bool
+	}
+	testCases := map[string]testCase{
+		"replica with node label": {
+			obj:             newTestIndexerReplica("replica", TestNamespace, testIndexerNode1),
+			expectedIndexes: []string{testIndexerNode1},
+		},
+		"replica without node label": {
+			obj:             newTestIndexerReplica("replica", TestNamespace, ""),
+			expectedIndexes: []string{},
+		},
+		"not a replica": {
+			obj:         newTestIndexerPod("pod", TestNamespace, testIndexerNode1),
+			expectError: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		indexes, err := replicaNodeIndexFunc(tc.obj)
+		if tc.expectError {
+			assert.Error(err, "test case: %v", name)
+			continue
+		}
+		assert.NoError(err, "test case: %v", name)
+		assert.Equal(tc.expectedIndexes, indexes, "test case: %v", name)
+	}
+}
+
+func TestVolumeNodeIndexFunc(t *testing.T) {
+	assert := require.New(t)
+
+	type testCase struct {
+		obj interface{}
+
+		expectedIndexes []string
+		expectError     bool
+	}
+	testCases := map[string]testCase{
+		"volume attached": {
+			obj:             newTestIndexerVolume("volume", TestNamespace, testIndexerNode1, testIndexerNode1),
+			expectedIndexes: []string{testIndexerNode1},
+		},
+		"volume attaching": {
+			obj:             newTestIndexerVolume("volume", TestNamespace, testIndexerNode1, ""),
+			expectedIndexes: []string{testIndexerNode1},
+		},
+		"volume detaching": {
+			obj:             newTestIndexerVolume("volume", TestNamespace, "", testIndexerNode1),
+			expectedIndexes: []string{testIndexerNode1},
+		},
+		"volume moving to another node": {
+			obj:             newTestIndexerVolume("volume", TestNamespace, testIndexerNode2, testIndexerNode1),
+			expectedIndexes: []string{testIndexerNode2, testIndexerNode1},
+		},
+		"volume detached": {
+			obj:             newTestIndexerVolume("volume", TestNamespace, "", ""),
+			expectedIndexes: []string{},
+		},
+		"not a volume": {
+			obj:         newTestIndexerReplica("replica", TestNamespace, testIndexerNode1),
+			expectError: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		indexes, err := volumeNodeIndexFunc(tc.obj)
+		if tc.expectError {
+			assert.Error(err, "test case: %v", name)
+			continue
+		}
+		assert.NoError(err, "test case: %v", name)
+		assert.Equal(tc.expectedIndexes, indexes, "test case: %v", name)
+	}
+}
+
+func TestPodNodeIndexFunc(t *testing.T) {
+	assert := require.New(t)
+
+	type testCase struct {
+		obj interface{}
+
+		expectedIndexes []string
+		expectError     bool
+	}
+	testCases := map[string]testCase{
+		"pod bound to node": {
+			obj:             newTestIndexerPod("pod", TestNamespace, testIndexerNode1),
+			expectedIndexes: []string{testIndexerNode1},
+		},
+		"pod not scheduled": {
+			obj:             newTestIndexerPod("pod", TestNamespace, ""),
+			expectedIndexes: []string{},
+		},
+		"not a pod": {
+			obj:         newTestIndexerReplica("replica", TestNamespace, testIndexerNode1),
+			expectError: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		indexes, err := podNodeIndexFunc(tc.obj)
+		if tc.expectError {
+			assert.Error(err, "test case: %v", name)
+			continue
+		}
+		assert.NoError(err, "test case: %v", name)
+		assert.Equal(tc.expectedIndexes, indexes, "test case: %v", name)
+	}
+}
+
+func TestListByNodeRO(t *testing.T) {
+	assert := require.New(t)
+
+	tds := newTestDataStore(t)
+
+	rIndexer := tds.lhInformerFactory.Longhorn().V1beta2().Replicas().Informer().GetIndexer()
+	for _, r := range []*longhorn.Replica{
+		newTestIndexerReplica("replica-1", TestNamespace, testIndexerNode1),
+		newTestIndexerReplica("replica-2", TestNamespace, testIndexerNode2),
+		newTestIndexerReplica("replica-3", TestNamespace, testIndexerNode1),
+		newTestIndexerReplica("replica-4", "other-namespace", testIndexerNode1),
+		newTestIndexerReplica("replica-5", TestNamespace, ""),
+	} {
+		assert.NoError(rIndexer.Add(r))
+	}
+
+	vIndexer := tds.lhInformerFactory.Longhorn().V1beta2().Volumes().Informer().GetIndexer()
+	for _, v := range []*longhorn.Volume{
+		newTestIndexerVolume("volume-1", TestNamespace, testIndexerNode1, testIndexerNode1),
+		newTestIndexerVolume("volume-2", TestNamespace, testIndexerNode2, testIndexerNode1),
+		newTestIndexerVolume("volume-3", TestNamespace, testIndexerNode2, testIndexerNode2),
+		newTestIndexerVolume("volume-4", "other-namespace", testIndexerNode1, testIndexerNode1),
+		newTestIndexerVolume("volume-5", TestNamespace, "", ""),
+	} {
+		assert.NoError(vIndexer.Add(v))
+	}
+
+	pIndexer := tds.kubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+	for _, pod := range []*corev1.Pod{
+		newTestIndexerPod("pod-1", TestNamespace, testIndexerNode1),
+		newTestIndexerPod("pod-2", "other-namespace", testIndexerNode1),
+		newTestIndexerPod("pod-3", TestNamespace, testIndexerNode2),
+		newTestIndexerPod("pod-4", TestNamespace, ""),
+	} {
+		assert.NoError(pIndexer.Add(pod))
+	}
+
+	replicas, err := tds.ds.ListReplicasByNodeRO(testIndexerNode1)
+	assert.NoError(err)
+	replicaNames := []string{}
+	for _, r := range replicas {
+		replicaNames = append(replicaNames, r.Name)
+	}
+	sort.Strings(replicaNames)
+	assert.Equal([]string{"replica-1", "replica-3"}, replicaNames)
+
+	volumes, err := tds.ds.ListVolumesByNodeRO(testIndexerNode1)
+	assert.NoError(err)
+	volumeNames := []string{}
+	for _, v := range volumes {
+		volumeNames = append(volumeNames, v.Name)
+	}
+	sort.Strings(volumeNames)
+	assert.Equal([]string{"volume-1", "volume-2"}, volumeNames)
+
+	// The pods are listed in all namespaces
+	pods, err := tds.ds.ListPodsByNodeRO(testIndexerNode1)
+	assert.NoError(err)
+	podNames := []string{}
+	for _, pod := range pods {
+		podNames = append(podNames, pod.Name)
+	}
+	sort.Strings(podNames)
+	assert.Equal([]string{"pod-1", "pod-2"}, podNames)
+
+	// Objects updated to another node are moved to the index of that node
+	assert.NoError(rIndexer.Update(newTestIndexerReplica("replica-3", TestNamespace, testIndexerNode2)))
+	replicas, err = tds.ds.ListReplicasByNodeRO(testIndexerNode1)
+	assert.NoError(err)
+	assert.Len(replicas, 1)
+	assert.Equal("replica-1", replicas[0].Name)
+
+	replicas, err = tds.ds.ListReplicasByNodeRO("unknown-node")
+	assert.NoError(err)
+	assert.Empty(replicas)
+}
+
+func TestAddNodeIndexersToSharedInformers(t *testing.T) {
+	assert := require.New(t)
+
+	tds := newTestDataStore(t)
+
+	pIndexer := tds.kubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+	assert.NoError(pIndexer.Add(newTestIndexerPod("pod", TestNamespace, testIndexerNode1)))
+
+	// The indexers registered by the first data store are reused
+	ds, err := NewDataStore(tds.lhInformerFactory, tds.lhClient, tds.kubeInformerFactory, tds.kubeClient, apiextensionsfake.NewSimpleClientset(), TestNamespace)
+	assert.NoError(err)
+	pods, err := ds.ListPodsByNodeRO(testIndexerNode1)
+	assert.NoError(err)
+	assert.Len(pods, 1)
+}
@@ -309,6 +309,26 @@ func (s *DataStore) ListPodsRO(namespace string) ([]*corev1.Pod, error) {
 	return s.pLister.Pods(namespace).List(labels.Everything())
 }
 
+// ListPodsByNodeRO returns a list of all Pods bound to node Name in all namespaces,
+// the list contains direct references to the internal cache objects and should not be mutated.
+// The lookup uses the node index of the informer instead of scanning all pods.
+func (s *DataStore) ListPodsByNodeRO(name string) ([]*corev1.Pod, error) {
+	objs, err := s.pIndexer.ByIndex(NodeIndex, name)
+	if err != nil {
+		return nil, err
+	}
+
+	list := []*corev1.Pod{}
+	for _, obj := range objs {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok {
+			return nil, fmt.Errorf("BUG: datastore: unexpected object %#v in pod node index", obj)
+		}
+		list = append(list, pod)
+	}
+	return list, nil
+}
+
 // GetPod returns a mutable Pod object for the given name and namespace
 func (s *DataStore) GetPod(name string) (*corev1.Pod, error) {
 	resultRO, err := s.pLister.Pods(s.namespace).Get(name)
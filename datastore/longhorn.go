@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
@@ -70,6 +71,22 @@ func (s *DataStore) UpdateCustomizedSettings(defaultImages map[types.SettingName
 	return s.syncSettingCRsWithCustomizedDefaultSettings(availableCustomizedDefaultSettings, defaultSettingCM.ResourceVersion)
 }
 
+// CreateDefaultSettingsIfNotExist creates the missing Setting CRs with the
+// default values of the setting definitions
+func (s *DataStore) CreateDefaultSettingsIfNotExist() error {
+	configMapResourceVersion := ""
+	defaultSettingCM, err := s.GetConfigMapRO(s.namespace, types.DefaultDefaultSettingConfigMapName)
+	if err != nil {
+		if !ErrorIsNotFound(err) {
+			return err
+		}
+	} else {
+		configMapResourceVersion = defaultSettingCM.ResourceVersion
+	}
+
+	return s.createNonExistingSettingCRsWithDefaultSetting(configMapResourceVersion)
+}
+
 func (s *DataStore) createNonExistingSettingCRsWithDefaultSetting(configMapResourceVersion string) error {
 	for _, sName := range types.SettingNameList {
 		_, err := s.GetSettingExact(sName)
@@ -81,8 +98,11 @@ func (s *DataStore) createNonExistingSettingCRsWithDefaultSetting(configMapResou
 
 			setting := &longhorn.Setting{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:        string(sName),
-					Annotations: map[string]string{types.GetLonghornLabelKey(types.ConfigMapResourceVersionKey): configMapResourceVersion},
+					Name: string(sName),
+					Annotations: map[string]string{
+						types.GetLonghornLabelKey(types.ConfigMapResourceVersionKey): configMapResourceVersion,
+						types.GetLonghornLabelKey(types.ConfigMapAppliedValueKey):    definition.Default,
+					},
 				},
 				Value: definition.Default,
 			}
@@ -148,8 +168,11 @@ func (s *DataStore) createOrUpdateSetting(name types.SettingName, value, default
 
 		setting = &longhorn.Setting{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:        string(name),
-				Annotations: map[string]string{types.GetLonghornLabelKey(types.ConfigMapResourceVersionKey): defaultSettingCMResourceVersion},
+				Name: string(name),
+				Annotations: map[string]string{
+					types.GetLonghornLabelKey(types.ConfigMapResourceVersionKey): defaultSettingCMResourceVersion,
+					types.GetLonghornLabelKey(types.ConfigMapAppliedValueKey):    value,
+				},
 			},
 			Value: value,
 		}
@@ -165,8 +188,9 @@ func (s *DataStore) createOrUpdateSetting(name types.SettingName, value, default
 	}
 
 	setting.Annotations[types.GetLonghornLabelKey(types.ConfigMapResourceVersionKey)] = defaultSettingCMResourceVersion
+	setting.Annotations[types.GetLonghornLabelKey(types.ConfigMapAppliedValueKey)] = value
 	setting.Value = value
-	_, err = s.UpdateSetting(setting)
+	_, err = s.UpdateReadOnlySetting(setting)
 	return err
 }
 
@@ -189,17 +213,23 @@ func (s *DataStore) applyCustomizedDefaultSettingsToDefinitions(customizedDefaul
 	return nil
 }
 
+// syncSettingCRsWithCustomizedDefaultSettings applies the customized default
+// settings of a new default setting ConfigMap version to the setting CRs. The
+// settings changed by users since the last applied value are left untouched.
+// The settings created before the applied value is tracked are updated as
+// before.
 func (s *DataStore) syncSettingCRsWithCustomizedDefaultSettings(customizedDefaultSettings map[string]string, defaultSettingCMResourceVersion string) error {
 	for _, sName := range types.SettingNameList {
 		configMapResourceVersion := ""
-		if s, err := s.GetSettingExact(sName); err != nil {
+		isChangedByUser := false
+		if setting, err := s.GetSettingExact(sName); err != nil {
 			if !apierrors.IsNotFound(err) {
 				return err
 			}
-		} else {
-			if s.Annotations != nil {
-				configMapResourceVersion = s.Annotations[types.GetLonghornLabelKey(types.ConfigMapResourceVersionKey)]
-			}
+		} else if setting.Annotations != nil {
+			configMapResourceVersion = setting.Annotations[types.GetLonghornLabelKey(types.ConfigMapResourceVersionKey)]
+			appliedValue, ok := setting.Annotations[types.GetLonghornLabelKey(types.ConfigMapAppliedValueKey)]
+			isChangedByUser = ok && appliedValue != setting.Value
 		}
 
 		definition, ok := types.GetSettingDefinition(sName)
@@ -216,6 +246,10 @@ func (s *DataStore) syncSettingCRsWithCustomizedDefaultSettings(customizedDefaul
 			if definition.Required && value == "" {
 				continue
 			}
+			if isChangedByUser {
+				logrus.Infof("Skipped applying customized default value %v to setting %v since it is changed by the user", value, sName)
+				continue
+			}
 			if err := s.createOrUpdateSetting(sName, value, defaultSettingCMResourceVersion); err != nil {
 				return err
 			}
@@ -232,8 +266,63 @@ func (s *DataStore) CreateSetting(setting *longhorn.Setting) (*longhorn.Setting,
 	return s.lhClient.LonghornV1beta2().Settings(s.namespace).Create(context.TODO(), setting, metav1.CreateOptions{})
 }
 
-// UpdateSetting updates the given Longhorn Settings and verifies update
+// UpdateSetting validates the value against the setting definition, then
+// updates the given Longhorn Settings and verifies update. Read-only settings
+// are rejected, see UpdateReadOnlySetting for the system managed ones.
 func (s *DataStore) UpdateSetting(setting *longhorn.Setting) (*longhorn.Setting, error) {
+	return s.updateSetting(setting, false)
+}
+
+// UpdateReadOnlySetting is the same as UpdateSetting but allows updating
+// read-only settings. It is reserved for the internal callers that manage
+// these settings, e.g. the setting controller.
+func (s *DataStore) UpdateReadOnlySetting(setting *longhorn.Setting) (*longhorn.Setting, error) {
+	return s.updateSetting(setting, true)
+}
+
+// UpdateSettingWithRetry applies mutate to the latest cached copy of the
+// setting and updates it. On conflict, the setting is re-fetched and the
+// mutation is reapplied instead of failing the whole reconcile.
+func (s *DataStore) UpdateSettingWithRetry(sName types.SettingName, mutate func(setting *longhorn.Setting)) (*longhorn.Setting, error) {
+	return s.updateSettingWithRetry(sName, mutate, false)
+}
+
+// UpdateReadOnlySettingWithRetry is the same as UpdateSettingWithRetry but
+// allows updating read-only settings.
+func (s *DataStore) UpdateReadOnlySettingWithRetry(sName types.SettingName, mutate func(setting *longhorn.Setting)) (*longhorn.Setting, error) {
+	return s.updateSettingWithRetry(sName, mutate, true)
+}
+
+func (s *DataStore) updateSettingWithRetry(sName types.SettingName, mutate func(setting *longhorn.Setting), allowReadOnly bool) (*longhorn.Setting, error) {
+	obj, err := util.RetryOnConflictCause(func() (interface{}, error) {
+		setting, err := s.GetSetting(sName)
+		if err != nil {
+			return nil, err
+		}
+		mutate(setting)
+		return s.updateSetting(setting, allowReadOnly)
+	})
+	if err != nil {
+		return nil, err
+	}
+	setting, ok := obj.(*longhorn.Setting)
+	if !ok {
+		return nil, fmt.Errorf("BUG: cannot convert to setting %v object", sName)
+	}
+	return setting, nil
+}
+
+func (s *DataStore) updateSetting(setting *longhorn.Setting, allowReadOnly bool) (*longhorn.Setting, error) {
+	if !allowReadOnly {
+		if definition, ok := types.GetSettingDefinition(types.SettingName(setting.Name)); ok && definition.ReadOnly {
+			return nil, fmt.Errorf("setting %v is read-only", setting.Name)
+		}
+	}
+
+	if err := types.ValidateSetting(setting.Name, setting.Value); err != nil {
+		return nil, err
+	}
+
 	obj, err := s.lhClient.LonghornV1beta2().Settings(s.namespace).Update(context.TODO(), setting, metav1.UpdateOptions{})
 	if err != nil {
 		return nil, err
@@ -602,6 +691,28 @@ func (s *DataStore) UpdateVolume(v *longhorn.Volume) (*longhorn.Volume, error) {
 	return obj, nil
 }
 
+// UpdateVolumeWithRetry applies mutate to the latest cached copy of the
+// volume and updates it. On conflict, the volume is re-fetched and the
+// mutation is reapplied.
+func (s *DataStore) UpdateVolumeWithRetry(name string, mutate func(v *longhorn.Volume)) (*longhorn.Volume, error) {
+	obj, err := util.RetryOnConflictCause(func() (interface{}, error) {
+		v, err := s.GetVolume(name)
+		if err != nil {
+			return nil, err
+		}
+		mutate(v)
+		return s.UpdateVolume(v)
+	})
+	if err != nil {
+		return nil, err
+	}
+	v, ok := obj.(*longhorn.Volume)
+	if !ok {
+		return nil, fmt.Errorf("BUG: cannot convert to volume %v object", name)
+	}
+	return v, nil
+}
+
 // UpdateVolumeStatus updates Longhorn Volume status and verifies update
 func (s *DataStore) UpdateVolumeStatus(v *longhorn.Volume) (*longhorn.Volume, error) {
 	obj, err := s.lhClient.LonghornV1beta2().Volumes(s.namespace).UpdateStatus(context.TODO(), v, metav1.UpdateOptions{})
@@ -659,6 +770,29 @@ func (s *DataStore) ListVolumesRO() ([]*longhorn.Volume, error) {
 	return s.vLister.Volumes(s.namespace).List(labels.Everything())
 }
 
+// ListVolumesByNodeRO returns a list of Volumes requested to be attached to or
+// currently attached to node Name for the given namespace. The list contains direct
+// references to the internal cache objects and should not be mutated.
+func (s *DataStore) ListVolumesByNodeRO(name string) ([]*longhorn.Volume, error) {
+	objs, err := s.vIndexer.ByIndex(NodeIndex, name)
+	if err != nil {
+		return nil, err
+	}
+
+	list := []*longhorn.Volume{}
+	for _, obj := range objs {
+		v, ok := obj.(*longhorn.Volume)
+		if !ok {
+			return nil, fmt.Errorf("BUG: datastore: unexpected object %#v in volume node index", obj)
+		}
+		if v.Namespace != s.namespace {
+			continue
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
 // ListVolumesROWithBackupVolumeName returns a single object contains all volumes
 // with the given backup volume name
 func (s *DataStore) ListVolumesROWithBackupVolumeName(backupVolumeName string) ([]*longhorn.Volume, error) {
@@ -1156,6 +1290,28 @@ func (s *DataStore) UpdateReplica(r *longhorn.Replica) (*longhorn.Replica, error
 	return obj, nil
 }
 
+// UpdateReplicaWithRetry applies mutate to the latest cached copy of the
+// replica and updates it. On conflict, the replica is re-fetched and the
+// mutation is reapplied.
+func (s *DataStore) UpdateReplicaWithRetry(name string, mutate func(r *longhorn.Replica)) (*longhorn.Replica, error) {
+	obj, err := util.RetryOnConflictCause(func() (interface{}, error) {
+		r, err := s.GetReplica(name)
+		if err != nil {
+			return nil, err
+		}
+		mutate(r)
+		return s.UpdateReplica(r)
+	})
+	if err != nil {
+		return nil, err
+	}
+	r, ok := obj.(*longhorn.Replica)
+	if !ok {
+		return nil, fmt.Errorf("BUG: cannot convert to replica %v object", name)
+	}
+	return r, nil
+}
+
 // UpdateReplicaStatus updates Replica status and verifies update
 func (s *DataStore) UpdateReplicaStatus(r *longhorn.Replica) (*longhorn.Replica, error) {
 	if err := checkReplica(r); err != nil {
@@ -1247,6 +1403,29 @@ func (s *DataStore) ListVolumeReplicas(volumeName string) (map[string]*longhorn.
 	return s.listReplicas(selector)
 }
 
+// GetOtherAvailableHealthyReplicaName returns the name of an available healthy
+// replica of the volume other than the given one. The replicas requested for
+// eviction are not counted. An empty name is returned if there is none.
+func (s *DataStore) GetOtherAvailableHealthyReplicaName(volumeName, replicaName string) (string, error) {
+	rs, err := s.ListVolumeReplicas(volumeName)
+	if err != nil {
+		return "", err
+	}
+	for _, r := range rs {
+		if r.Name == replicaName {
+			continue
+		}
+		if !IsAvailableHealthyReplica(r) {
+			continue
+		}
+		if r.Status.EvictionRequested {
+			continue
+		}
+		return r.Name, nil
+	}
+	return "", nil
+}
+
 // ReplicaAddressToReplicaName will directly return the address if the format
 // is invalid or the replica is not found.
 func ReplicaAddressToReplicaName(address string, rs []*longhorn.Replica) string {
@@ -2047,9 +2226,10 @@ func (s *DataStore) CreateNode(node *longhorn.Node) (*longhorn.Node, error) {
 	return ret.DeepCopy(), nil
 }
 
-// CreateDefaultNode will create the default Disk at the value of the
-// DefaultDataPath Setting only if Create Default Disk on Labeled Nodes has
-// been disabled.
+// CreateDefaultNode will create the disks listed in the DefaultDiskConfiguration
+// Setting, or the default Disk at the value of the DefaultDataPath Setting if
+// the former is empty, only if Create Default Disk on Labeled Nodes has been
+// disabled and the Kubernetes node doesn't opt out of the default disks.
 func (s *DataStore) CreateDefaultNode(name string) (*longhorn.Node, error) {
 	requireLabel, err := s.GetSettingAsBool(types.SettingNameCreateDefaultDiskLabeledNodes)
 	if err != nil {
@@ -2075,20 +2255,85 @@ func (s *DataStore) CreateDefaultNode(name string) (*longhorn.Node, error) {
 		// this will be done only once.
 		// If user remove all the disks on the node, the default disk
 		// will not be recreated automatically
-		dataPath, err := s.GetSettingValueExisted(types.SettingNameDefaultDataPath)
+		skipDefaultDisks, err := s.isDefaultDisksSkipped(name)
 		if err != nil {
 			return nil, err
 		}
-		disks, err := types.CreateDefaultDisk(dataPath)
-		if err != nil {
-			return nil, err
+		if !skipDefaultDisks {
+			disks, err := s.createDefaultDisks()
+			if err != nil {
+				return nil, err
+			}
+			node.Spec.Disks = disks
 		}
-		node.Spec.Disks = disks
 	}
 
 	return s.CreateNode(node)
 }
 
+// isDefaultDisksSkipped checks if the Kubernetes node opts out of the default
+// disks by the annotation
+func (s *DataStore) isDefaultDisksSkipped(name string) (bool, error) {
+	kubeNode, err := s.GetKubernetesNode(name)
+	if err != nil {
+		if ErrorIsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return strings.ToLower(kubeNode.Annotations[types.KubeNodeSkipDefaultDisksAnnotationKey]) == "true", nil
+}
+
+func (s *DataStore) createDefaultDisks() (map[string]longhorn.DiskSpec, error) {
+	diskConfig, err := s.GetSetting(types.SettingNameDefaultDiskConfiguration)
+	if err != nil {
+		return nil, err
+	}
+	if diskConfig.Value != "" {
+		return s.createDisksFromConfiguration(diskConfig.Value)
+	}
+
+	dataPath, err := s.GetSettingValueExisted(types.SettingNameDefaultDataPath)
+	if err != nil {
+		return nil, err
+	}
+	storageReservedPercentage, err := s.GetSettingAsInt(types.SettingNameStorageReservedPercentageForDefaultDisk)
+	if err != nil {
+		return nil, err
+	}
+	return types.CreateDefaultDisk(dataPath, storageReservedPercentage)
+}
+
+// createDisksFromConfiguration creates the disks of the default disk
+// configuration setting. The disks whose paths don't exist on the host are
+// skipped, so that a path missing on some nodes doesn't block the node
+// initialization. Failing to check a path is returned so the caller retries.
+func (s *DataStore) createDisksFromConfiguration(config string) (map[string]longhorn.DiskSpec, error) {
+	disks, err := types.UnmarshalToDisks(config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal setting %v", types.SettingNameDefaultDiskConfiguration)
+	}
+
+	existingDisks := []types.DiskSpecWithName{}
+	for _, disk := range disks {
+		if disk.Path != "" {
+			exists, err := util.IsHostPathExisting(disk.Path)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to check default disk path %v", disk.Path)
+			}
+			if !exists {
+				logrus.Warnf("Skipped creating default disk %v since the path doesn't exist", disk.Path)
+				continue
+			}
+			if err := util.CreateDiskPathReplicaSubdirectory(disk.Path); err != nil {
+				return nil, err
+			}
+		}
+		existingDisks = append(existingDisks, disk)
+	}
+	return types.CreateDisks(existingDisks)
+}
+
 func (s *DataStore) GetNodeRO(name string) (*longhorn.Node, error) {
 	return s.nLister.Nodes(s.namespace).Get(name)
 }
@@ -2427,11 +2672,17 @@ func getNodeSelector(nodeName string) (labels.Selector, error) {
 
 // ListReplicasByNode gets a map of Replicas on the node Name for the given namespace.
 func (s *DataStore) ListReplicasByNode(name string) (map[string]*longhorn.Replica, error) {
-	nodeSelector, err := getNodeSelector(name)
+	list, err := s.ListReplicasByNodeRO(name)
 	if err != nil {
 		return nil, err
 	}
-	return s.listReplicas(nodeSelector)
+
+	itemMap := map[string]*longhorn.Replica{}
+	for _, itemRO := range list {
+		// Cannot use cached object from lister
+		itemMap[itemRO.Name] = itemRO.DeepCopy()
+	}
+	return itemMap, nil
 }
 
 // ListReplicasByDiskUUID gets a list of Replicas on a specific disk the given namespace.
@@ -2467,12 +2718,25 @@ func (s *DataStore) ListReplicasByBackingImage(backingImageName string) ([]*long
 // ListReplicasByNodeRO returns a list of all Replicas on node Name for the given namespace,
 // the list contains direct references to the internal cache objects and should not be mutated.
 // Consider using this function when you can guarantee read only access and don't want the overhead of deep copies
+// The lookup uses the node index of the informer instead of scanning all replicas.
 func (s *DataStore) ListReplicasByNodeRO(name string) ([]*longhorn.Replica, error) {
-	nodeSelector, err := getNodeSelector(name)
+	objs, err := s.rIndexer.ByIndex(NodeIndex, name)
 	if err != nil {
 		return nil, err
 	}
-	return s.rLister.Replicas(s.namespace).List(nodeSelector)
+
+	list := []*longhorn.Replica{}
+	for _, obj := range objs {
+		r, ok := obj.(*longhorn.Replica)
+		if !ok {
+			return nil, fmt.Errorf("BUG: datastore: unexpected object %#v in replica node index", obj)
+		}
+		if r.Namespace != s.namespace {
+			continue
+		}
+		list = append(list, r)
+	}
+	return list, nil
 }
 
 func labelNode(nodeID string, obj runtime.Object) error {
@@ -2629,7 +2893,8 @@ func GetOwnerReferencesForNode(node *longhorn.Node) []metav1.OwnerReference {
 	}
 }
 
-// GetSettingAsInt gets the setting for the given name, returns as integer
+// GetSettingAsInt gets the setting for the given name, returns as integer.
+// An empty value falls back to the default value in the setting definition.
 // Returns error if the definition type is not integer
 func (s *DataStore) GetSettingAsInt(settingName types.SettingName) (int64, error) {
 	definition, ok := types.GetSettingDefinition(settingName)
@@ -2641,6 +2906,9 @@ func (s *DataStore) GetSettingAsInt(settingName types.SettingName) (int64, error
 		return -1, err
 	}
 	value := settings.Value
+	if value == "" {
+		value = definition.Default
+	}
 
 	if definition.Type == types.SettingTypeInt {
 		result, err := strconv.ParseInt(value, 10, 64)
@@ -2653,7 +2921,8 @@ func (s *DataStore) GetSettingAsInt(settingName types.SettingName) (int64, error
 	return -1, fmt.Errorf("the %v setting value couldn't change to integer, value is %v ", string(settingName), value)
 }
 
-// GetSettingAsBool gets the setting for the given name, returns as boolean
+// GetSettingAsBool gets the setting for the given name, returns as boolean.
+// An empty value falls back to the default value in the setting definition.
 // Returns error if the definition type is not boolean
 func (s *DataStore) GetSettingAsBool(settingName types.SettingName) (bool, error) {
 	definition, ok := types.GetSettingDefinition(settingName)
@@ -2665,6 +2934,9 @@ func (s *DataStore) GetSettingAsBool(settingName types.SettingName) (bool, error
 		return false, err
 	}
 	value := settings.Value
+	if value == "" {
+		value = definition.Default
+	}
 
 	if definition.Type == types.SettingTypeBool {
 		result, err := strconv.ParseBool(value)
@@ -2677,6 +2949,34 @@ func (s *DataStore) GetSettingAsBool(settingName types.SettingName) (bool, error
 	return false, fmt.Errorf("the %v setting value couldn't be converted to bool, value is %v ", string(settingName), value)
 }
 
+// GetSettingAsDuration gets the setting for the given name, returns as duration.
+// An empty value falls back to the default value in the setting definition.
+// Returns error if the definition type is not duration
+func (s *DataStore) GetSettingAsDuration(settingName types.SettingName) (time.Duration, error) {
+	definition, ok := types.GetSettingDefinition(settingName)
+	if !ok {
+		return 0, fmt.Errorf("setting %v is not supported", settingName)
+	}
+	settings, err := s.GetSetting(settingName)
+	if err != nil {
+		return 0, err
+	}
+	value := settings.Value
+	if value == "" {
+		value = definition.Default
+	}
+
+	if definition.Type == types.SettingTypeDuration {
+		result, err := types.ParseSettingDuration(value)
+		if err != nil {
+			return 0, errors.Wrapf(err, "the %v setting value couldn't be converted to duration", string(settingName))
+		}
+		return result, nil
+	}
+
+	return 0, fmt.Errorf("the %v setting value couldn't be converted to duration, value is %v ", string(settingName), value)
+}
+
 // GetSettingImagePullPolicy get the setting and return one of Kubernetes ImagePullPolicy definition
 // Returns error if the ImagePullPolicy is invalid
 func (s *DataStore) GetSettingImagePullPolicy() (corev1.PullPolicy, error) {
@@ -3235,6 +3535,11 @@ func (s *DataStore) ListBackupTargets() (map[string]*longhorn.BackupTarget, erro
 	return itemMap, nil
 }
 
+// ListBackupTargetsRO returns a list of all backup targets for the given namespace
+func (s *DataStore) ListBackupTargetsRO() ([]*longhorn.BackupTarget, error) {
+	return s.btLister.BackupTargets(s.namespace).List(labels.Everything())
+}
+
 // GetDefaultBackupTargetRO returns the BackupTarget for the default backup target
 func (s *DataStore) GetDefaultBackupTargetRO() (*longhorn.BackupTarget, error) {
 	return s.GetBackupTargetRO(types.DefaultBackupTargetName)
@@ -4373,3 +4678,144 @@ func (s *DataStore) listSystemRestores(selector labels.Selector) (map[string]*lo
 func (s *DataStore) ListSystemRestores() (map[string]*longhorn.SystemRestore, error) {
 	return s.listSystemRestores(labels.Everything())
 }
+
+// CreateLHVolumeAttachment creates a Longhorn VolumeAttachment resource and verifies creation
+func (s *DataStore) CreateLHVolumeAttachment(va *longhorn.VolumeAttachment) (*longhorn.VolumeAttachment, error) {
+	ret, err := s.lhClient.LonghornV1beta2().VolumeAttachments(s.namespace).Create(context.TODO(), va, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if SkipListerCheck {
+		return ret, nil
+	}
+
+	obj, err := verifyCreation(ret.Name, "Longhorn volume attachment", func(name string) (runtime.Object, error) {
+		return s.GetLHVolumeAttachmentRO(name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	ret, ok := obj.(*longhorn.VolumeAttachment)
+	if !ok {
+		return nil, fmt.Errorf("BUG: datastore: verifyCreation returned wrong type for Longhorn VolumeAttachment")
+	}
+
+	return ret.DeepCopy(), nil
+}
+
+// UpdateLHVolumeAttachment updates Longhorn VolumeAttachment and verifies update
+func (s *DataStore) UpdateLHVolumeAttachment(va *longhorn.VolumeAttachment) (*longhorn.VolumeAttachment, error) {
+	obj, err := s.lhClient.LonghornV1beta2().VolumeAttachments(s.namespace).Update(context.TODO(), va, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	verifyUpdate(va.Name, obj, func(name string) (runtime.Object, error) {
+		return s.GetLHVolumeAttachmentRO(name)
+	})
+	return obj, nil
+}
+
+// UpdateLHVolumeAttachmentStatus updates Longhorn VolumeAttachment status and verifies update
+func (s *DataStore) UpdateLHVolumeAttachmentStatus(va *longhorn.VolumeAttachment) (*longhorn.VolumeAttachment, error) {
+	obj, err := s.lhClient.LonghornV1beta2().VolumeAttachments(s.namespace).UpdateStatus(context.TODO(), va, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	verifyUpdate(va.Name, obj, func(name string) (runtime.Object, error) {
+		return s.GetLHVolumeAttachmentRO(name)
+	})
+	return obj, nil
+}
+
+// DeleteLHVolumeAttachment deletes the Longhorn VolumeAttachment with the given name
+func (s *DataStore) DeleteLHVolumeAttachment(name string) error {
+	return s.lhClient.LonghornV1beta2().VolumeAttachments(s.namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+}
+
+// GetLHVolumeAttachment returns a copy of Longhorn VolumeAttachment with the given name
+func (s *DataStore) GetLHVolumeAttachment(name string) (*longhorn.VolumeAttachment, error) {
+	resultRO, err := s.GetLHVolumeAttachmentRO(name)
+	if err != nil {
+		return nil, err
+	}
+	// Cannot use cached object from lister
+	return resultRO.DeepCopy(), nil
+}
+
+// GetLHVolumeAttachmentRO returns the Longhorn VolumeAttachment with the given name
+func (s *DataStore) GetLHVolumeAttachmentRO(name string) (*longhorn.VolumeAttachment, error) {
+	return s.lhVALister.VolumeAttachments(s.namespace).Get(name)
+}
+
+// ListLHVolumeAttachments returns an object contains all Longhorn VolumeAttachments
+func (s *DataStore) ListLHVolumeAttachments() (map[string]*longhorn.VolumeAttachment, error) {
+	list, err := s.lhVALister.VolumeAttachments(s.namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	itemMap := map[string]*longhorn.VolumeAttachment{}
+	for _, itemRO := range list {
+		// Cannot use cached object from lister
+		itemMap[itemRO.Name] = itemRO.DeepCopy()
+	}
+	return itemMap, nil
+}
+
+// AddAttachmentTicket adds the attachment ticket to the Longhorn VolumeAttachment
+// of the volume, or replaces the existing ticket with the same ID. The
+// VolumeAttachment is created if it doesn't exist yet.
+func (s *DataStore) AddAttachmentTicket(volumeName string, ticket *longhorn.AttachmentTicket) (*longhorn.VolumeAttachment, error) {
+	va, err := s.GetLHVolumeAttachment(volumeName)
+	if err != nil {
+		if !ErrorIsNotFound(err) {
+			return nil, err
+		}
+		return s.CreateLHVolumeAttachment(&longhorn.VolumeAttachment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   volumeName,
+				Labels: types.GetVolumeLabels(volumeName),
+			},
+			Spec: longhorn.VolumeAttachmentSpec{
+				AttachmentTickets: map[string]*longhorn.AttachmentTicket{
+					ticket.ID: ticket,
+				},
+				Volume: volumeName,
+			},
+		})
+	}
+
+	if reflect.DeepEqual(va.Spec.AttachmentTickets[ticket.ID], ticket) {
+		return va, nil
+	}
+	if va.Spec.AttachmentTickets == nil {
+		va.Spec.AttachmentTickets = map[string]*longhorn.AttachmentTicket{}
+	}
+	va.Spec.AttachmentTickets[ticket.ID] = ticket
+	return s.UpdateLHVolumeAttachment(va)
+}
+
+// RemoveAttachmentTickets removes the attachment tickets matched by the given
+// function from the Longhorn VolumeAttachment of the volume, if any.
+func (s *DataStore) RemoveAttachmentTickets(volumeName string, matched func(ticket *longhorn.AttachmentTicket) bool) error {
+	va, err := s.GetLHVolumeAttachment(volumeName)
+	if err != nil {
+		if ErrorIsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	removed := false
+	for id, ticket := range va.Spec.AttachmentTickets {
+		if ticket == nil || matched(ticket) {
+			delete(va.Spec.AttachmentTickets, id)
+			removed = true
+		}
+	}
+	if !removed {
+		return nil
+	}
+	_, err = s.UpdateLHVolumeAttachment(va)
+	return err
+}
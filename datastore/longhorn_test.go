@@ -0,0 +1,336 @@
+package datastore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+
+	"github.com/longhorn/longhorn-manager/types"
+
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
+	lhfake "github.com/longhorn/longhorn-manager/k8s/pkg/client/clientset/versioned/fake"
+	lhinformers "github.com/longhorn/longhorn-manager/k8s/pkg/client/informers/externalversions"
+)
+
+const (
+	TestNamespace = "default"
+)
+
+type testDataStore struct {
+	ds                  *DataStore
+	lhClient            *lhfake.Clientset
+	kubeClient          *fake.Clientset
+	lhInformerFactory   lhinformers.SharedInformerFactory
+	kubeInformerFactory informers.SharedInformerFactory
+}
+
+func newTestDataStore(t *testing.T) *testDataStore {
+	// The fake informer caches are not updated by the fake clients
+	VerificationRetryCounts = 1
+
+	kubeClient := fake.NewSimpleClientset()
+	kubeInformerFactory := informers.NewSharedInformerFactory(kubeClient, 0)
+	lhClient := lhfake.NewSimpleClientset()
+	lhInformerFactory := lhinformers.NewSharedInformerFactory(lhClient, 0)
+	extensionsClient := apiextensionsfake.NewSimpleClientset()
+	ds, err := NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+	require.NoError(t, err)
+
+	return &testDataStore{
+		ds:                  ds,
+		lhClient:            lhClient,
+		kubeClient:          kubeClient,
+		lhInformerFactory:   lhInformerFactory,
+		kubeInformerFactory: kubeInformerFactory,
+	}
+}
+
+func newTestSetting(name types.SettingName, value, configMapResourceVersion string, appliedValue *string) *longhorn.Setting {
+	setting := &longhorn.Setting{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      string(name),
+			Namespace: TestNamespace,
+			Annotations: map[string]string{
+				types.GetLonghornLabelKey(types.ConfigMapResourceVersionKey): configMapResourceVersion,
+			},
+		},
+		Value: value,
+	}
+	if appliedValue != nil {
+		setting.Annotations[types.GetLonghornLabelKey(types.ConfigMapAppliedValueKey)] = *appliedValue
+	}
+	return setting
+}
+
+func TestUpdateCustomizedSettings(t *testing.T) {
+	assert := require.New(t)
+
+	appliedValue := func(value string) *string { return &value }
+
+	type testCase struct {
+		setting       *longhorn.Setting
+		customized    string
+		expectedValue string
+	}
+	testCases := map[string]testCase{
+		"setting unchanged by the user": {
+			setting:       newTestSetting(types.SettingNameBackupstorePollInterval, "300", "1", appliedValue("300")),
+			customized:    "500",
+			expectedValue: "500",
+		},
+		"setting changed by the user": {
+			setting:       newTestSetting(types.SettingNameBackupstorePollInterval, "200", "1", appliedValue("300")),
+			customized:    "500",
+			expectedValue: "200",
+		},
+		"setting without applied value": {
+			setting:       newTestSetting(types.SettingNameBackupstorePollInterval, "200", "1", nil),
+			customized:    "500",
+			expectedValue: "500",
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		tds := newTestDataStore(t)
+
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            types.DefaultDefaultSettingConfigMapName,
+				Namespace:       TestNamespace,
+				ResourceVersion: "2",
+			},
+			Data: map[string]string{
+				types.DefaultSettingYAMLFileName: fmt.Sprintf("%v: %v", tc.setting.Name, tc.customized),
+			},
+		}
+		err := tds.kubeInformerFactory.Core().V1().ConfigMaps().Informer().GetIndexer().Add(cm)
+		assert.NoError(err)
+
+		setting, err := tds.lhClient.LonghornV1beta2().Settings(TestNamespace).Create(context.TODO(), tc.setting, metav1.CreateOptions{})
+		assert.NoError(err)
+		err = tds.lhInformerFactory.Longhorn().V1beta2().Settings().Informer().GetIndexer().Add(setting)
+		assert.NoError(err)
+
+		err = tds.ds.UpdateCustomizedSettings(nil)
+		assert.NoError(err, "test case: %v", name)
+
+		setting, err = tds.lhClient.LonghornV1beta2().Settings(TestNamespace).Get(context.TODO(), tc.setting.Name, metav1.GetOptions{})
+		assert.NoError(err)
+		assert.Equal(tc.expectedValue, setting.Value, "test case: %v", name)
+		if tc.expectedValue == tc.customized {
+			assert.Equal(tc.customized, setting.Annotations[types.GetLonghornLabelKey(types.ConfigMapAppliedValueKey)], "test case: %v", name)
+		}
+	}
+}
+
+func TestUpdateSettingWithRetry(t *testing.T) {
+	assert := require.New(t)
+
+	type testCase struct {
+		name          types.SettingName
+		value         string
+		allowReadOnly bool
+		conflicts     int
+
+		expectedUpdates int
+		expectError     bool
+	}
+	testCases := map[string]testCase{
+		"setting updated": {
+			name:            types.SettingNameBackupstorePollInterval,
+			value:           "500",
+			expectedUpdates: 1,
+		},
+		"setting updated after conflict": {
+			name:            types.SettingNameBackupstorePollInterval,
+			value:           "500",
+			conflicts:       1,
+			expectedUpdates: 2,
+		},
+		"read-only setting rejected": {
+			name:            types.SettingNameLatestLonghornVersion,
+			value:           "v1.5.0",
+			expectedUpdates: 0,
+			expectError:     true,
+		},
+		"read-only setting updated after conflict": {
+			name:            types.SettingNameLatestLonghornVersion,
+			value:           "v1.5.0",
+			allowReadOnly:   true,
+			conflicts:       1,
+			expectedUpdates: 2,
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		tds := newTestDataStore(t)
+
+		setting, err := tds.lhClient.LonghornV1beta2().Settings(TestNamespace).Create(context.TODO(), newTestSetting(tc.name, "", "1", nil), metav1.CreateOptions{})
+		assert.NoError(err)
+		err = tds.lhInformerFactory.Longhorn().V1beta2().Settings().Informer().GetIndexer().Add(setting)
+		assert.NoError(err)
+
+		updates := 0
+		tds.lhClient.PrependReactor("update", "settings", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			updates++
+			if updates <= tc.conflicts {
+				return true, nil, apierrors.NewConflict(schema.GroupResource{Resource: "settings"}, string(tc.name), fmt.Errorf("the object has been modified"))
+			}
+			return false, nil, nil
+		})
+
+		mutate := func(setting *longhorn.Setting) {
+			setting.Value = tc.value
+		}
+		if tc.allowReadOnly {
+			_, err = tds.ds.UpdateReadOnlySettingWithRetry(tc.name, mutate)
+		} else {
+			_, err = tds.ds.UpdateSettingWithRetry(tc.name, mutate)
+		}
+		assert.Equal(tc.expectedUpdates, updates, "test case: %v", name)
+		if tc.expectError {
+			assert.Error(err, "test case: %v", name)
+			continue
+		}
+		assert.NoError(err, "test case: %v", name)
+
+		setting, err = tds.lhClient.LonghornV1beta2().Settings(TestNamespace).Get(context.TODO(), string(tc.name), metav1.GetOptions{})
+		assert.NoError(err)
+		assert.Equal(tc.value, setting.Value, "test case: %v", name)
+	}
+}
+
+func TestUpdateVolumeWithRetry(t *testing.T) {
+	assert := require.New(t)
+
+	type testCase struct {
+		conflicts int
+
+		expectedUpdates int
+	}
+	testCases := map[string]testCase{
+		"volume updated": {
+			expectedUpdates: 1,
+		},
+		"volume updated after conflict": {
+			conflicts:       1,
+			expectedUpdates: 2,
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		tds := newTestDataStore(t)
+
+		volume := &longhorn.Volume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-volume",
+				Namespace: TestNamespace,
+			},
+			Spec: longhorn.VolumeSpec{
+				NumberOfReplicas: 3,
+			},
+		}
+		volume, err := tds.lhClient.LonghornV1beta2().Volumes(TestNamespace).Create(context.TODO(), volume, metav1.CreateOptions{})
+		assert.NoError(err)
+		err = tds.lhInformerFactory.Longhorn().V1beta2().Volumes().Informer().GetIndexer().Add(volume)
+		assert.NoError(err)
+
+		updates := 0
+		tds.lhClient.PrependReactor("update", "volumes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			updates++
+			if updates <= tc.conflicts {
+				return true, nil, apierrors.NewConflict(schema.GroupResource{Resource: "volumes"}, volume.Name, fmt.Errorf("the object has been modified"))
+			}
+			return false, nil, nil
+		})
+
+		_, err = tds.ds.UpdateVolumeWithRetry(volume.Name, func(v *longhorn.Volume) {
+			v.Spec.NumberOfReplicas = 2
+		})
+		assert.NoError(err, "test case: %v", name)
+		assert.Equal(tc.expectedUpdates, updates, "test case: %v", name)
+
+		volume, err = tds.lhClient.LonghornV1beta2().Volumes(TestNamespace).Get(context.TODO(), volume.Name, metav1.GetOptions{})
+		assert.NoError(err)
+		assert.Equal(2, volume.Spec.NumberOfReplicas, "test case: %v", name)
+	}
+}
+
+func TestUpdateReplicaWithRetry(t *testing.T) {
+	assert := require.New(t)
+
+	type testCase struct {
+		conflicts int
+
+		expectedUpdates int
+	}
+	testCases := map[string]testCase{
+		"replica updated": {
+			expectedUpdates: 1,
+		},
+		"replica updated after conflict": {
+			conflicts:       1,
+			expectedUpdates: 2,
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		tds := newTestDataStore(t)
+
+		replica := &longhorn.Replica{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-volume-r-1",
+				Namespace: TestNamespace,
+			},
+			Spec: longhorn.ReplicaSpec{
+				InstanceSpec: longhorn.InstanceSpec{
+					VolumeName: "test-volume",
+				},
+			},
+		}
+		replica, err := tds.lhClient.LonghornV1beta2().Replicas(TestNamespace).Create(context.TODO(), replica, metav1.CreateOptions{})
+		assert.NoError(err)
+		err = tds.lhInformerFactory.Longhorn().V1beta2().Replicas().Informer().GetIndexer().Add(replica)
+		assert.NoError(err)
+
+		updates := 0
+		tds.lhClient.PrependReactor("update", "replicas", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			updates++
+			if updates <= tc.conflicts {
+				return true, nil, apierrors.NewConflict(schema.GroupResource{Resource: "replicas"}, replica.Name, fmt.Errorf("the object has been modified"))
+			}
+			return false, nil, nil
+		})
+
+		_, err = tds.ds.UpdateReplicaWithRetry(replica.Name, func(r *longhorn.Replica) {
+			r.Spec.FailedAt = "2023-01-01T00:00:00Z"
+		})
+		assert.NoError(err, "test case: %v", name)
+		assert.Equal(tc.expectedUpdates, updates, "test case: %v", name)
+
+		replica, err = tds.lhClient.LonghornV1beta2().Replicas(TestNamespace).Get(context.TODO(), replica.Name, metav1.GetOptions{})
+		assert.NoError(err)
+		assert.Equal("2023-01-01T00:00:00Z", replica.Spec.FailedAt, "test case: %v", name)
+	}
+}
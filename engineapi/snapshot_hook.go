@@ -0,0 +1,245 @@
+package engineapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/longhorn/longhorn-manager/datastore"
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
+	"github.com/longhorn/longhorn-manager/types"
+)
+
+const (
+	// SnapshotHookTimeout is the overall timeout of a snapshot hook command,
+	// including connecting to the Kubernetes API server.
+	SnapshotHookTimeout = 30 * time.Second
+
+	podExecProtocol      = "v4.channel.k8s.io"
+	podExecStdoutChannel = 1
+	podExecStderrChannel = 2
+	podExecErrorChannel  = 3
+)
+
+// PodCommandExecutor executes a command inside a container of a pod
+type PodCommandExecutor interface {
+	Exec(namespace, podName, containerName string, command []string) (string, error)
+}
+
+// websocketPodCommandExecutor executes the commands via the pods/exec
+// subresource of the Kubernetes API server. It authenticates with the bearer
+// token or the client certificate of the config only. The basic auth, the auth
+// provider and the exec credential plugins are not supported, which is fine
+// for the in-cluster config the Longhorn manager runs with.
+type websocketPodCommandExecutor struct {
+	config *rest.Config
+}
+
+func NewWebsocketPodCommandExecutor(config *rest.Config) PodCommandExecutor {
+	return &websocketPodCommandExecutor{config: config}
+}
+
+func (e *websocketPodCommandExecutor) Exec(namespace, podName, containerName string, command []string) (string, error) {
+	if e.config == nil {
+		return "", fmt.Errorf("cannot exec in pod %v/%v without Kubernetes client config", namespace, podName)
+	}
+
+	u, err := url.Parse(e.config.Host)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse Kubernetes API server host %v", e.config.Host)
+	}
+	switch u.Scheme {
+	case "https", "":
+		u.Scheme = "wss"
+	case "http":
+		u.Scheme = "ws"
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + fmt.Sprintf("/api/v1/namespaces/%s/pods/%s/exec", namespace, podName)
+	query := url.Values{}
+	for _, c := range command {
+		query.Add("command", c)
+	}
+	if containerName != "" {
+		query.Set("container", containerName)
+	}
+	query.Set("stdout", "true")
+	query.Set("stderr", "true")
+	u.RawQuery = query.Encode()
+
+	tlsConfig, err := rest.TLSConfigFor(e.config)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get TLS config for Kubernetes API server")
+	}
+	header := http.Header{}
+	token := e.config.BearerToken
+	if token == "" && e.config.BearerTokenFile != "" {
+		data, err := os.ReadFile(e.config.BearerTokenFile)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to read token file %v", e.config.BearerTokenFile)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+
+	// A single deadline covers both the handshake and the command execution
+	deadline := time.Now().Add(SnapshotHookTimeout)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	dialer := &websocket.Dialer{
+		TLSClientConfig: tlsConfig,
+		Subprotocols:    []string{podExecProtocol},
+	}
+	conn, _, err := dialer.DialContext(ctx, u.String(), header)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to connect to pod %v/%v", namespace, podName)
+	}
+	defer conn.Close()
+
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return "", err
+	}
+
+	var output bytes.Buffer
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				return output.String(), nil
+			}
+			return output.String(), errors.Wrapf(err, "failed to read the output of command %v in pod %v/%v", command, namespace, podName)
+		}
+		if len(data) == 0 {
+			continue
+		}
+		switch data[0] {
+		case podExecStdoutChannel, podExecStderrChannel:
+			output.Write(data[1:])
+		case podExecErrorChannel:
+			status := &metav1.Status{}
+			if err := json.Unmarshal(data[1:], status); err != nil {
+				return output.String(), errors.Wrapf(err, "failed to parse the result of command %v in pod %v/%v", command, namespace, podName)
+			}
+			if status.Status != metav1.StatusSuccess {
+				return output.String(), fmt.Errorf("command %v in pod %v/%v failed: %v", command, namespace, podName, status.Message)
+			}
+			return output.String(), nil
+		}
+	}
+}
+
+// SnapshotHookRunner runs the snapshot hook commands specified by the volume
+// annotations in the workload pod around the snapshot creation. It is shared
+// by the snapshot controller and the volume manager so that the snapshots
+// taken by Snapshot CRs, recurring jobs, CSI and the Longhorn API are handled
+// the same way.
+type SnapshotHookRunner struct {
+	ds       *datastore.DataStore
+	executor PodCommandExecutor
+	logger   logrus.FieldLogger
+}
+
+func NewSnapshotHookRunner(ds *datastore.DataStore, executor PodCommandExecutor, logger logrus.FieldLogger) *SnapshotHookRunner {
+	return &SnapshotHookRunner{
+		ds:       ds,
+		executor: executor,
+		logger:   logger,
+	}
+}
+
+// HasSnapshotHooks returns true if the volume has the snapshot hook
+// annotations and the snapshot hooks are allowed by the setting.
+func (r *SnapshotHookRunner) HasSnapshotHooks(volume *longhorn.Volume) bool {
+	if getSnapshotHook(volume, types.SnapshotPreHookAnnotationKeySuffix) == "" &&
+		getSnapshotHook(volume, types.SnapshotPostHookAnnotationKeySuffix) == "" {
+		return false
+	}
+	allowed, err := r.ds.GetSettingAsBool(types.SettingNameAllowSnapshotHooks)
+	if err != nil {
+		r.logger.WithError(err).Warnf("Failed to get setting %v, ignoring the snapshot hooks of volume %v", types.SettingNameAllowSnapshotHooks, volume.Name)
+		return false
+	}
+	if !allowed {
+		r.logger.Warnf("Ignoring the snapshot hooks of volume %v since setting %v is disabled", volume.Name, types.SettingNameAllowSnapshotHooks)
+	}
+	return allowed
+}
+
+// CreateSnapshotWithHooks calls createSnapshot between the pre hook and the
+// post hook of the volume. The snapshot is not created if the pre hook fails,
+// but the post hook is always executed once the pre hook is started so that
+// the workload can recover from a partially applied pre hook. A post hook
+// failure is logged only since the snapshot is already taken.
+func (r *SnapshotHookRunner) CreateSnapshotWithHooks(volume *longhorn.Volume, createSnapshot func() error) error {
+	if !r.HasSnapshotHooks(volume) {
+		return createSnapshot()
+	}
+
+	defer func() {
+		if err := r.runSnapshotHook(volume, types.SnapshotPostHookAnnotationKeySuffix); err != nil {
+			r.logger.WithError(err).Warnf("Failed to run snapshot post hook of volume %v", volume.Name)
+		}
+	}()
+	if err := r.runSnapshotHook(volume, types.SnapshotPreHookAnnotationKeySuffix); err != nil {
+		return errors.Wrapf(err, "aborted creating snapshot of volume %v", volume.Name)
+	}
+	return createSnapshot()
+}
+
+func getSnapshotHook(volume *longhorn.Volume, hookAnnotationKeySuffix string) string {
+	return volume.Annotations[types.GetLonghornLabelKey(hookAnnotationKeySuffix)]
+}
+
+// getSnapshotHookPod returns the namespace and the name of a running workload
+// pod of the volume, in which the snapshot hooks are executed.
+func getSnapshotHookPod(volume *longhorn.Volume) (string, string, error) {
+	ks := volume.Status.KubernetesStatus
+	for _, ws := range ks.WorkloadsStatus {
+		if ws.PodName != "" && ws.PodStatus == string(v1.PodRunning) {
+			return ks.Namespace, ws.PodName, nil
+		}
+	}
+	return "", "", fmt.Errorf("cannot find a running workload pod of volume %v", volume.Name)
+}
+
+// runSnapshotHook executes the snapshot hook command specified by the volume
+// annotation with the key suffix in the workload pod. It does nothing if the
+// annotation is not set.
+func (r *SnapshotHookRunner) runSnapshotHook(volume *longhorn.Volume, hookAnnotationKeySuffix string) error {
+	hook := getSnapshotHook(volume, hookAnnotationKeySuffix)
+	if hook == "" {
+		return nil
+	}
+	if r.executor == nil {
+		return fmt.Errorf("cannot run snapshot hook %v (%v) of volume %v without pod executor", hook, hookAnnotationKeySuffix, volume.Name)
+	}
+
+	namespace, podName, err := getSnapshotHookPod(volume)
+	if err != nil {
+		return err
+	}
+	containerName := volume.Annotations[types.GetLonghornLabelKey(types.SnapshotHookContainerAnnotationKeySuffix)]
+
+	r.logger.Infof("Running snapshot hook %v (%v) of volume %v in pod %v/%v", hook, hookAnnotationKeySuffix, volume.Name, namespace, podName)
+	output, err := r.executor.Exec(namespace, podName, containerName, []string{"/bin/sh", "-c", hook})
+	if err != nil {
+		return errors.Wrapf(err, "failed to run snapshot hook %v (%v) of volume %v, output: %v", hook, hookAnnotationKeySuffix, volume.Name, output)
+	}
+	return nil
+}
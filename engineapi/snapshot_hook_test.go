@@ -0,0 +1,168 @@
+package engineapi
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+
+	"github.com/longhorn/longhorn-manager/datastore"
+	"github.com/longhorn/longhorn-manager/types"
+
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
+	lhfake "github.com/longhorn/longhorn-manager/k8s/pkg/client/clientset/versioned/fake"
+	lhinformers "github.com/longhorn/longhorn-manager/k8s/pkg/client/informers/externalversions"
+)
+
+const (
+	testSnapshotHookNamespace = "default"
+	testSnapshotHookVolume    = "test-volume"
+	testSnapshotHookPod       = "test-workload-pod"
+)
+
+type fakePodCommandExecutor struct {
+	commands *[]string
+	err      error
+}
+
+func (e *fakePodCommandExecutor) Exec(namespace, podName, containerName string, command []string) (string, error) {
+	*e.commands = append(*e.commands, fmt.Sprintf("%v/%v/%v: %v", namespace, podName, containerName, command[len(command)-1]))
+	return "", e.err
+}
+
+func newSnapshotHookVolume(annotations map[string]string, podStatus v1.PodPhase) *longhorn.Volume {
+	return &longhorn.Volume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        testSnapshotHookVolume,
+			Annotations: annotations,
+		},
+		Status: longhorn.VolumeStatus{
+			KubernetesStatus: longhorn.KubernetesStatus{
+				Namespace: testSnapshotHookNamespace,
+				WorkloadsStatus: []longhorn.WorkloadStatus{
+					{PodName: testSnapshotHookPod, PodStatus: string(podStatus)},
+				},
+			},
+		},
+	}
+}
+
+func newTestSnapshotHookRunner(t *testing.T, allowed string, executor PodCommandExecutor) *SnapshotHookRunner {
+	kubeClient := fake.NewSimpleClientset()
+	kubeInformerFactory := informers.NewSharedInformerFactory(kubeClient, 0)
+	lhClient := lhfake.NewSimpleClientset()
+	lhInformerFactory := lhinformers.NewSharedInformerFactory(lhClient, 0)
+	extensionsClient := apiextensionsfake.NewSimpleClientset()
+	ds, err := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, testSnapshotHookNamespace)
+	require.NoError(t, err)
+
+	sIndexer := lhInformerFactory.Longhorn().V1beta2().Settings().Informer().GetIndexer()
+	err = sIndexer.Add(&longhorn.Setting{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      string(types.SettingNameAllowSnapshotHooks),
+			Namespace: testSnapshotHookNamespace,
+		},
+		Value: allowed,
+	})
+	require.NoError(t, err)
+
+	return NewSnapshotHookRunner(ds, executor, logrus.StandardLogger())
+}
+
+// TestCreateSnapshotWithHooks covers the snapshot creation shared by the
+// Snapshot CRs and VolumeManager.CreateSnapshot, which is used by the
+// recurring jobs, CSI and the Longhorn API.
+func TestCreateSnapshotWithHooks(t *testing.T) {
+	assert := require.New(t)
+
+	preHookKey := types.GetLonghornLabelKey(types.SnapshotPreHookAnnotationKeySuffix)
+	postHookKey := types.GetLonghornLabelKey(types.SnapshotPostHookAnnotationKeySuffix)
+	containerKey := types.GetLonghornLabelKey(types.SnapshotHookContainerAnnotationKeySuffix)
+	hooks := map[string]string{
+		preHookKey:   "fsfreeze -f /data",
+		postHookKey:  "fsfreeze -u /data",
+		containerKey: "app",
+	}
+	freeze := fmt.Sprintf("%v/%v/app: fsfreeze -f /data", testSnapshotHookNamespace, testSnapshotHookPod)
+	unfreeze := fmt.Sprintf("%v/%v/app: fsfreeze -u /data", testSnapshotHookNamespace, testSnapshotHookPod)
+	create := "create snapshot"
+
+	type testCase struct {
+		allowed   string
+		volume    *longhorn.Volume
+		execErr   error
+		createErr error
+
+		expectCalls []string
+		expectError bool
+	}
+	testCases := map[string]testCase{
+		"no hook": {
+			allowed:     "true",
+			volume:      newSnapshotHookVolume(nil, v1.PodRunning),
+			expectCalls: []string{create},
+		},
+		"hooks disallowed": {
+			allowed:     "false",
+			volume:      newSnapshotHookVolume(hooks, v1.PodRunning),
+			expectCalls: []string{create},
+		},
+		"hooks executed around the snapshot creation": {
+			allowed:     "true",
+			volume:      newSnapshotHookVolume(hooks, v1.PodRunning),
+			expectCalls: []string{freeze, create, unfreeze},
+		},
+		"hooks executed in the default container": {
+			allowed:     "true",
+			volume:      newSnapshotHookVolume(map[string]string{preHookKey: "sync"}, v1.PodRunning),
+			expectCalls: []string{fmt.Sprintf("%v/%v/: sync", testSnapshotHookNamespace, testSnapshotHookPod), create},
+		},
+		"snapshot not created if the pre hook failed": {
+			allowed:     "true",
+			volume:      newSnapshotHookVolume(hooks, v1.PodRunning),
+			execErr:     fmt.Errorf("command terminated with non-zero exit code"),
+			expectCalls: []string{freeze, unfreeze},
+			expectError: true,
+		},
+		"post hook executed if the snapshot creation failed": {
+			allowed:     "true",
+			volume:      newSnapshotHookVolume(hooks, v1.PodRunning),
+			createErr:   fmt.Errorf("failed to create snapshot"),
+			expectCalls: []string{freeze, create, unfreeze},
+			expectError: true,
+		},
+		"no running workload pod": {
+			allowed:     "true",
+			volume:      newSnapshotHookVolume(hooks, v1.PodPending),
+			expectCalls: nil,
+			expectError: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		var calls []string
+		executor := &fakePodCommandExecutor{commands: &calls, err: tc.execErr}
+		runner := newTestSnapshotHookRunner(t, tc.allowed, executor)
+
+		err := runner.CreateSnapshotWithHooks(tc.volume, func() error {
+			calls = append(calls, create)
+			return tc.createErr
+		})
+		if tc.expectError {
+			assert.Error(err, "test case: %v", name)
+		} else {
+			assert.NoError(err, "test case: %v", name)
+		}
+		assert.Equal(tc.expectCalls, calls, "test case: %v", name)
+	}
+}
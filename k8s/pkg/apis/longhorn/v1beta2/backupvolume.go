@@ -48,6 +48,9 @@ type BackupVolumeStatus struct {
 	// the backing image checksum.
 	// +optional
 	BackingImageChecksum string `json:"backingImageChecksum"`
+	// The number of backups of the backup volume in the backup target.
+	// +optional
+	BackupCount int `json:"backupCount"`
 	// The last time that the backup volume was synced into the cluster.
 	// +optional
 	// +nullable
@@ -62,6 +65,7 @@ type BackupVolumeStatus struct {
 // +kubebuilder:printcolumn:name="CreatedAt",type=string,JSONPath=`.status.createdAt`,description="The backup volume creation time"
 // +kubebuilder:printcolumn:name="LastBackupName",type=string,JSONPath=`.status.lastBackupName`,description="The backup volume last backup name"
 // +kubebuilder:printcolumn:name="LastBackupAt",type=string,JSONPath=`.status.lastBackupAt`,description="The backup volume last backup time"
+// +kubebuilder:printcolumn:name="BackupCount",type=integer,JSONPath=`.status.backupCount`,description="The number of backups of the backup volume in the backup target"
 // +kubebuilder:printcolumn:name="LastSyncedAt",type=string,JSONPath=`.status.lastSyncedAt`,description="The backup volume last synced time"
 
 // BackupVolume is where Longhorn stores backup volume object.
@@ -23,6 +23,7 @@ const (
 	DiskConditionTypeSchedulable = "Schedulable"
 	DiskConditionTypeReady       = "Ready"
 	DiskConditionTypeError       = "Error"
+	DiskConditionTypeEvicted     = "Evicted"
 )
 
 const (
@@ -30,6 +31,8 @@ const (
 	DiskConditionReasonDiskFilesystemChanged = "DiskFilesystemChanged"
 	DiskConditionReasonNoDiskInfo            = "NoDiskInfo"
 	DiskConditionReasonDiskNotReady          = "DiskNotReady"
+	DiskConditionReasonEvictionNotRequested  = "EvictionNotRequested"
+	DiskConditionReasonEvictionInProgress    = "EvictionInProgress"
 )
 
 const (
@@ -42,7 +45,10 @@ const (
 	ErrorReplicaScheduleNodeUnavailable                  = "nodes are unavailable"
 	ErrorReplicaScheduleEngineImageNotReady              = "none of the node candidates contains a ready engine image"
 	ErrorReplicaScheduleHardNodeAffinityNotSatisfied     = "hard affinity cannot be satisfied"
+	ErrorReplicaScheduleHardDiskAntiAffinityNotSatisfied = "hard disk anti-affinity cannot be satisfied"
 	ErrorReplicaScheduleSchedulingFailed                 = "replica scheduling failed"
+	ErrorReplicaScheduleNodeUnderPressure                = "nodes are under resource pressure"
+	ErrorReplicaSchedulePlacementPolicyNotSatisfied      = "replica placement policy cannot be satisfied"
 )
 
 type SnapshotCheckStatus struct {
@@ -74,10 +80,32 @@ type DiskStatus struct {
 	// +optional
 	StorageMaximum int64 `json:"storageMaximum"`
 	// +optional
+	StorageSchedulable int64 `json:"storageSchedulable"`
+	// +optional
 	// +nullable
 	ScheduledReplica map[string]int64 `json:"scheduledReplica"`
 	// +optional
 	DiskUUID string `json:"diskUUID"`
+	// +optional
+	// +nullable
+	Benchmark *DiskBenchmark `json:"benchmark"`
+}
+
+// DiskBenchmark is the result of the IO benchmark run on the disk when it was added
+type DiskBenchmark struct {
+	// The approximate sequential write throughput in bytes per second
+	// +optional
+	SequentialThroughput int64 `json:"sequentialThroughput"`
+	// The approximate IOPS of sequential 4 KiB direct synchronous writes
+	// +optional
+	SyncWriteIOPS int64 `json:"syncWriteIOPS"`
+	// The approximate IOPS of random 4 KiB direct synchronous writes
+	// +optional
+	RandomWriteIOPS int64 `json:"randomWriteIOPS"`
+	// +optional
+	BenchmarkedAt string `json:"benchmarkedAt"`
+	// +optional
+	Error string `json:"error"`
 }
 
 // NodeSpec defines the desired state of the Longhorn node
@@ -112,6 +140,10 @@ type NodeStatus struct {
 	Zone string `json:"zone"`
 	// +optional
 	SnapshotCheckStatus SnapshotCheckStatus `json:"snapshotCheckStatus"`
+	// AutoEvicting indicates that the replicas on the node are being evicted
+	// because the node is being drained.
+	// +optional
+	AutoEvicting bool `json:"autoEvicting"`
 }
 
 // +genclient
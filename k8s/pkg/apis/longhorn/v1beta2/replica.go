@@ -56,6 +56,9 @@ type ReplicaStatus struct {
 	InstanceStatus `json:""`
 	// +optional
 	EvictionRequested bool `json:"evictionRequested"`
+	// The snapshot of which the checksum mismatched the one agreed by the other replicas when the snapshot data integrity check last found the replica corrupted
+	// +optional
+	LastCorruptedSnapshot string `json:"lastCorruptedSnapshot"`
 }
 
 // +genclient
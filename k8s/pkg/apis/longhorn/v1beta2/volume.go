@@ -70,6 +70,15 @@ const (
 	ReplicaAutoBalanceBestEffort  = ReplicaAutoBalance("best-effort")
 )
 
+// +kubebuilder:validation:Enum=balanced;spread;node-set;""
+type ReplicaPlacementPolicy string
+
+const (
+	ReplicaPlacementPolicyBalanced = ReplicaPlacementPolicy("balanced")
+	ReplicaPlacementPolicySpread   = ReplicaPlacementPolicy("spread")
+	ReplicaPlacementPolicyNodeSet  = ReplicaPlacementPolicy("node-set")
+)
+
 // +kubebuilder:validation:Enum=ignored;disabled;enabled
 type UnmapMarkSnapChainRemoved string
 
@@ -98,9 +107,10 @@ type VolumeCloneStatus struct {
 }
 
 const (
-	VolumeConditionTypeScheduled        = "scheduled"
-	VolumeConditionTypeRestore          = "restore"
-	VolumeConditionTypeTooManySnapshots = "toomanysnapshots"
+	VolumeConditionTypeScheduled            = "scheduled"
+	VolumeConditionTypeRestore              = "restore"
+	VolumeConditionTypeTooManySnapshots     = "toomanysnapshots"
+	VolumeConditionTypeEngineUpgradeBlocked = "engineupgradeblocked"
 )
 
 const (
@@ -109,6 +119,7 @@ const (
 	VolumeConditionReasonRestoreInProgress             = "RestoreInProgress"
 	VolumeConditionReasonRestoreFailure                = "RestoreFailure"
 	VolumeConditionReasonTooManySnapshots              = "TooManySnapshots"
+	VolumeConditionReasonIncompatibleEngineImage       = "IncompatibleEngineImage"
 )
 
 type SnapshotDataIntegrity string
@@ -176,6 +187,9 @@ type WorkloadStatus struct {
 	WorkloadName string `json:"workloadName"`
 	// +optional
 	WorkloadType string `json:"workloadType"`
+	// The node the pod is scheduled to.
+	// +optional
+	NodeID string `json:"nodeID"`
 }
 
 // VolumeSpec defines the desired state of the Longhorn volume
@@ -227,9 +241,24 @@ type VolumeSpec struct {
 	NumberOfReplicas int `json:"numberOfReplicas"`
 	// +optional
 	ReplicaAutoBalance ReplicaAutoBalance `json:"replicaAutoBalance"`
+	// The policy ordering the candidate disks when scheduling a replica of the volume.
+	// "balanced" prefers the disks with the most usable storage. "spread" prefers the zones and nodes
+	// holding fewer replicas. "node-set" keeps the replicas on the nodes listed in replicaPlacementNodes.
+	// Empty means picking a random node among the candidates.
+	// +optional
+	ReplicaPlacementPolicy ReplicaPlacementPolicy `json:"replicaPlacementPolicy"`
+	// The nodes the replicas are kept on by the "node-set" replica placement policy.
+	// +optional
+	ReplicaPlacementNodes []string `json:"replicaPlacementNodes"`
 	// +kubebuilder:validation:Enum=ignored;disabled;enabled;fast-check
 	// +optional
 	SnapshotDataIntegrity SnapshotDataIntegrity `json:"snapshotDataIntegrity"`
+	// The maximum number of snapshots of the volume. 0 means following the global setting.
+	// +optional
+	SnapshotMaxCount int `json:"snapshotMaxCount"`
+	// The retention policy of the backups of the volume applied by the recurring backup jobs. The latest backup and the backups the DR or restoring volumes depend on are always retained. Only the volumes in this cluster are considered, so the backups used by the DR volumes in other clusters may be deleted.
+	// +optional
+	BackupRetention VolumeBackupRetention `json:"backupRetention"`
 	// Deprecated. Rename to BackingImage
 	// +optional
 	BaseImage string `json:"baseImage"`
@@ -238,6 +267,16 @@ type VolumeSpec struct {
 	RecurringJobs []VolumeRecurringJobSpec `json:"recurringJobs,omitempty"`
 }
 
+// VolumeBackupRetention defines the backups of the volume retained in the backup target
+type VolumeBackupRetention struct {
+	// The number of the latest backups to retain. 0 means no limit.
+	// +optional
+	Count int `json:"count"`
+	// The maximum age of the backups to retain, e.g. "720h". Empty means no limit.
+	// +optional
+	MaxAge string `json:"maxAge"`
+}
+
 // VolumeStatus defines the observed state of the Longhorn volume
 type VolumeStatus struct {
 	// +optional
@@ -279,10 +318,31 @@ type VolumeStatus struct {
 	ActualSize int64 `json:"actualSize"`
 	// +optional
 	LastDegradedAt string `json:"lastDegradedAt"`
+	// The time when the volume robustness became healthy the last time.
+	// +optional
+	LastHealthyAt string `json:"lastHealthyAt"`
 	// +optional
 	ShareEndpoint string `json:"shareEndpoint"`
 	// +optional
 	ShareState ShareManagerState `json:"shareState"`
+	// The rebuild status of the replicas being rebuilt, keyed by the replica name.
+	// +optional
+	// +nullable
+	RebuildStatus map[string]*VolumeRebuildStatus `json:"rebuildStatus"`
+}
+
+// VolumeRebuildStatus is the rebuild status of a replica of the volume
+type VolumeRebuildStatus struct {
+	// +optional
+	Progress int `json:"progress"`
+	// +optional
+	State string `json:"state"`
+	// +optional
+	Error string `json:"error"`
+	// +optional
+	FromReplica string `json:"fromReplica"`
+	// +optional
+	StartedAt string `json:"startedAt"`
 }
 
 // +genclient
@@ -0,0 +1,93 @@
+package v1beta2
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+type AttacherType string
+
+const (
+	AttacherTypeCSIAttacher  = AttacherType("csi-attacher")
+	AttacherTypeLonghornAPI  = AttacherType("longhorn-api")
+	AttacherTypeRecurringJob = AttacherType("recurring-job")
+)
+
+const (
+	AttacherPriorityLevelCSIAttacher  = 900
+	AttacherPriorityLevelLonghornAPI  = 800
+	AttacherPriorityLevelRecurringJob = 200
+)
+
+const (
+	AttachmentParameterDisableFrontend = "disableFrontend"
+)
+
+// AttachmentTicket is a request of an attacher to attach the volume to a node
+type AttachmentTicket struct {
+	// The unique ID of the ticket, which differentiates the tickets of the same volume.
+	// +optional
+	ID string `json:"id"`
+	// The attacher type, which decides the priority of the ticket.
+	// +optional
+	Type AttacherType `json:"type"`
+	// The node the volume is requested to attach to.
+	// +optional
+	NodeID string `json:"nodeID"`
+	// The additional parameters of the attachment, e.g. disableFrontend.
+	// +optional
+	// +nullable
+	Parameters map[string]string `json:"parameters"`
+}
+
+// AttachmentTicketStatus is the observed state of an attachment ticket
+type AttachmentTicketStatus struct {
+	// +optional
+	ID string `json:"id"`
+	// Whether the volume is attached as the ticket requested.
+	// +optional
+	Satisfied bool `json:"satisfied"`
+	// The reason why the ticket is not satisfied yet.
+	// +optional
+	Message string `json:"message"`
+}
+
+// VolumeAttachmentSpec defines the desired state of the Longhorn VolumeAttachment
+type VolumeAttachmentSpec struct {
+	// +optional
+	// +nullable
+	AttachmentTickets map[string]*AttachmentTicket `json:"attachmentTickets"`
+	// The name of the volume.
+	// +optional
+	Volume string `json:"volume"`
+}
+
+// VolumeAttachmentStatus defines the observed state of the Longhorn VolumeAttachment
+type VolumeAttachmentStatus struct {
+	// +optional
+	// +nullable
+	AttachmentTicketStatuses map[string]*AttachmentTicketStatus `json:"attachmentTicketStatuses"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:shortName=lhva
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Volume",type=string,JSONPath=`.spec.volume`,description="The volume of the attachment tickets"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// VolumeAttachment is where Longhorn stores the attachment tickets of a volume
+type VolumeAttachment struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VolumeAttachmentSpec   `json:"spec,omitempty"`
+	Status VolumeAttachmentStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// VolumeAttachmentList is a list of VolumeAttachments
+type VolumeAttachmentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VolumeAttachment `json:"items"`
+}
@@ -25,6 +25,45 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AttachmentTicket) DeepCopyInto(out *AttachmentTicket) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AttachmentTicket.
+func (in *AttachmentTicket) DeepCopy() *AttachmentTicket {
+	if in == nil {
+		return nil
+	}
+	out := new(AttachmentTicket)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AttachmentTicketStatus) DeepCopyInto(out *AttachmentTicketStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AttachmentTicketStatus.
+func (in *AttachmentTicketStatus) DeepCopy() *AttachmentTicketStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AttachmentTicketStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackingImage) DeepCopyInto(out *BackingImage) {
 	*out = *in
@@ -757,6 +796,22 @@ func (in *Condition) DeepCopy() *Condition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiskBenchmark) DeepCopyInto(out *DiskBenchmark) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskBenchmark.
+func (in *DiskBenchmark) DeepCopy() *DiskBenchmark {
+	if in == nil {
+		return nil
+	}
+	out := new(DiskBenchmark)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DiskSpec) DeepCopyInto(out *DiskSpec) {
 	*out = *in
@@ -793,6 +848,11 @@ func (in *DiskStatus) DeepCopyInto(out *DiskStatus) {
 			(*out)[key] = val
 		}
 	}
+	if in.Benchmark != nil {
+		in, out := &in.Benchmark, &out.Benchmark
+		*out = new(DiskBenchmark)
+		**out = **in
+	}
 	return
 }
 
@@ -2513,6 +2573,145 @@ func (in *Volume) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeAttachment) DeepCopyInto(out *VolumeAttachment) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeAttachment.
+func (in *VolumeAttachment) DeepCopy() *VolumeAttachment {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeAttachment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VolumeAttachment) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeAttachmentList) DeepCopyInto(out *VolumeAttachmentList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VolumeAttachment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeAttachmentList.
+func (in *VolumeAttachmentList) DeepCopy() *VolumeAttachmentList {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeAttachmentList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VolumeAttachmentList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeAttachmentSpec) DeepCopyInto(out *VolumeAttachmentSpec) {
+	*out = *in
+	if in.AttachmentTickets != nil {
+		in, out := &in.AttachmentTickets, &out.AttachmentTickets
+		*out = make(map[string]*AttachmentTicket, len(*in))
+		for key, val := range *in {
+			var outVal *AttachmentTicket
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(AttachmentTicket)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeAttachmentSpec.
+func (in *VolumeAttachmentSpec) DeepCopy() *VolumeAttachmentSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeAttachmentSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeAttachmentStatus) DeepCopyInto(out *VolumeAttachmentStatus) {
+	*out = *in
+	if in.AttachmentTicketStatuses != nil {
+		in, out := &in.AttachmentTicketStatuses, &out.AttachmentTicketStatuses
+		*out = make(map[string]*AttachmentTicketStatus, len(*in))
+		for key, val := range *in {
+			var outVal *AttachmentTicketStatus
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(AttachmentTicketStatus)
+				**out = **in
+			}
+			(*out)[key] = outVal
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeAttachmentStatus.
+func (in *VolumeAttachmentStatus) DeepCopy() *VolumeAttachmentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeAttachmentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeBackupRetention) DeepCopyInto(out *VolumeBackupRetention) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeBackupRetention.
+func (in *VolumeBackupRetention) DeepCopy() *VolumeBackupRetention {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeBackupRetention)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VolumeCloneStatus) DeepCopyInto(out *VolumeCloneStatus) {
 	*out = *in
@@ -2562,6 +2761,22 @@ func (in *VolumeList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeRebuildStatus) DeepCopyInto(out *VolumeRebuildStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeRebuildStatus.
+func (in *VolumeRebuildStatus) DeepCopy() *VolumeRebuildStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeRebuildStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VolumeRecurringJob) DeepCopyInto(out *VolumeRecurringJob) {
 	*out = *in
@@ -2641,6 +2856,12 @@ func (in *VolumeSpec) DeepCopyInto(out *VolumeSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ReplicaPlacementNodes != nil {
+		in, out := &in.ReplicaPlacementNodes, &out.ReplicaPlacementNodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.BackupRetention = in.BackupRetention
 	if in.RecurringJobs != nil {
 		in, out := &in.RecurringJobs, &out.RecurringJobs
 		*out = make([]VolumeRecurringJobSpec, len(*in))
@@ -2671,6 +2892,21 @@ func (in *VolumeStatus) DeepCopyInto(out *VolumeStatus) {
 		copy(*out, *in)
 	}
 	out.CloneStatus = in.CloneStatus
+	if in.RebuildStatus != nil {
+		in, out := &in.RebuildStatus, &out.RebuildStatus
+		*out = make(map[string]*VolumeRebuildStatus, len(*in))
+		for key, val := range *in {
+			var outVal *VolumeRebuildStatus
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(VolumeRebuildStatus)
+				**out = **in
+			}
+			(*out)[key] = outVal
+		}
+	}
 	return
 }
 
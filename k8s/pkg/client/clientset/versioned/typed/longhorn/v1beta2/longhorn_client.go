@@ -46,6 +46,7 @@ type LonghornV1beta2Interface interface {
 	SystemBackupsGetter
 	SystemRestoresGetter
 	VolumesGetter
+	VolumeAttachmentsGetter
 }
 
 // LonghornV1beta2Client is used to interact with features provided by the longhorn.io group.
@@ -133,6 +134,10 @@ func (c *LonghornV1beta2Client) Volumes(namespace string) VolumeInterface {
 	return newVolumes(c, namespace)
 }
 
+func (c *LonghornV1beta2Client) VolumeAttachments(namespace string) VolumeAttachmentInterface {
+	return newVolumeAttachments(c, namespace)
+}
+
 // NewForConfig creates a new LonghornV1beta2Client for the given config.
 func NewForConfig(c *rest.Config) (*LonghornV1beta2Client, error) {
 	config := *c
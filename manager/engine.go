@@ -11,6 +11,8 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	etypes "github.com/longhorn/longhorn-engine/pkg/types"
+
 	"github.com/longhorn/longhorn-manager/engineapi"
 	"github.com/longhorn/longhorn-manager/util"
 
@@ -109,7 +111,14 @@ func (m *VolumeManager) CreateSnapshot(snapshotName string, labels map[string]st
 	}
 	defer engineClientProxy.Close()
 
-	snapshotName, err = engineClientProxy.SnapshotCreate(e, snapshotName, labels)
+	v, err := m.ds.GetVolumeRO(volumeName)
+	if err != nil {
+		return nil, err
+	}
+	err = m.hookRunner.CreateSnapshotWithHooks(v, func() error {
+		snapshotName, err = engineClientProxy.SnapshotCreate(e, snapshotName, labels)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -160,6 +169,45 @@ func (m *VolumeManager) DeleteSnapshot(snapshotName, volumeName string) error {
 	return nil
 }
 
+// GetSnapshotBackupSources returns the snapshots of the volume that are used by
+// the backups in progress, mapped to the backup names
+func (m *VolumeManager) GetSnapshotBackupSources(volumeName string) (map[string]string, error) {
+	backups, err := m.ds.ListBackupsWithBackupVolumeName(volumeName)
+	if err != nil {
+		return nil, err
+	}
+
+	backupSources := map[string]string{}
+	for _, backup := range backups {
+		switch backup.Status.State {
+		case longhorn.BackupStateCompleted, longhorn.BackupStateError, longhorn.BackupStateUnknown:
+			continue
+		}
+		if backup.Spec.SnapshotName != "" {
+			backupSources[backup.Spec.SnapshotName] = backup.Name
+		}
+	}
+	return backupSources, nil
+}
+
+// CheckSnapshotDeletable returns an error explaining why the snapshot cannot be
+// deleted by users
+func CheckSnapshotDeletable(snap *longhorn.SnapshotInfo, backupSources map[string]string) error {
+	if snap.Name == etypes.VolumeHeadName {
+		return fmt.Errorf("cannot delete %v", etypes.VolumeHeadName)
+	}
+	if snap.Removed {
+		return fmt.Errorf("snapshot %v has already been removed", snap.Name)
+	}
+	if !snap.UserCreated {
+		return fmt.Errorf("snapshot %v is created by the system and will be cleaned up by snapshot purge", snap.Name)
+	}
+	if backupName, ok := backupSources[snap.Name]; ok {
+		return fmt.Errorf("snapshot %v is the source of backup %v in progress", snap.Name, backupName)
+	}
+	return nil
+}
+
 func (m *VolumeManager) RevertSnapshot(snapshotName, volumeName string) error {
 	if volumeName == "" || snapshotName == "" {
 		return fmt.Errorf("volume and snapshot name required")
@@ -239,13 +287,13 @@ func (m *VolumeManager) PurgeSnapshot(volumeName string) error {
 	return nil
 }
 
-func (m *VolumeManager) BackupSnapshot(backupName, volumeName, snapshotName string, labels map[string]string) error {
+func (m *VolumeManager) BackupSnapshot(backupName, volumeName, snapshotName string, labels map[string]string) (*longhorn.Backup, error) {
 	if volumeName == "" || snapshotName == "" {
-		return fmt.Errorf("volume and snapshot name required")
+		return nil, fmt.Errorf("volume and snapshot name required")
 	}
 
 	if err := m.checkVolumeNotInMigration(volumeName); err != nil {
-		return err
+		return nil, err
 	}
 
 	backupCR := &longhorn.Backup{
@@ -257,8 +305,7 @@ func (m *VolumeManager) BackupSnapshot(backupName, volumeName, snapshotName stri
 			Labels:       labels,
 		},
 	}
-	_, err := m.ds.CreateBackup(backupCR, volumeName)
-	return err
+	return m.ds.CreateBackup(backupCR, volumeName)
 }
 
 func (m *VolumeManager) checkVolumeNotInMigration(volumeName string) error {
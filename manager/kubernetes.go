@@ -9,6 +9,7 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/longhorn/longhorn-manager/datastore"
 	"github.com/longhorn/longhorn-manager/types"
@@ -39,6 +40,19 @@ func (m *VolumeManager) PVCreate(name, pvName, fsType, secretNamespace, secretNa
 		return nil, fmt.Errorf("failed to get longhorn static storage class name for PV %v creation: %v", pvName, err)
 	}
 
+	pv := newPVManifestForVolume(v, pvName, storageClassName, fsType, secretNamespace, secretName)
+	pv, err = m.ds.CreatePersistentVolume(pv)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Debugf("Created PV for volume %v: %+v", v.Name, v.Spec)
+	return v, nil
+}
+
+// newPVManifestForVolume returns the PV manifest of the volume with the
+// default filesystem type and, for an encrypted volume, the secret references
+func newPVManifestForVolume(v *longhorn.Volume, pvName, storageClassName, fsType, secretNamespace, secretName string) *corev1.PersistentVolume {
 	if fsType == "" {
 		fsType = "ext4"
 	}
@@ -60,14 +74,56 @@ func (m *VolumeManager) PVCreate(name, pvName, fsType, secretNamespace, secretNa
 		pv.Spec.CSI.NodeStageSecretRef = secretRef
 		pv.Spec.CSI.NodePublishSecretRef = secretRef
 	}
+	return pv
+}
 
-	pv, err = m.ds.CreatePersistentVolume(pv)
+// GetKubernetesManifest returns the PV and PVC manifests referencing the
+// volume, which can be applied to recreate the Kubernetes objects of the
+// volume, e.g. in another cluster after the volume is restored there. The
+// names default to the ones recorded in the Kubernetes status of the volume,
+// then to the volume name.
+func (m *VolumeManager) GetKubernetesManifest(name, pvName, fsType, secretNamespace, secretName, namespace, pvcName string) (*corev1.PersistentVolume, *corev1.PersistentVolumeClaim, error) {
+	v, err := m.ds.GetVolumeRO(name)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	ks := v.Status.KubernetesStatus
 
-	logrus.Debugf("Created PV for volume %v: %+v", v.Name, v.Spec)
-	return v, nil
+	if pvName == "" {
+		pvName = ks.PVName
+	}
+	if pvName == "" {
+		pvName = v.Name
+	}
+	if namespace == "" {
+		namespace = ks.Namespace
+	}
+	if namespace == "" {
+		namespace = corev1.NamespaceDefault
+	}
+	if pvcName == "" {
+		pvcName = ks.PVCName
+	}
+	if pvcName == "" {
+		pvcName = v.Name
+	}
+
+	storageClassName, err := m.ds.GetSettingValueExisted(types.SettingNameDefaultLonghornStaticStorageClass)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to get longhorn static storage class name for the manifest of volume %v", v.Name)
+	}
+
+	pv := newPVManifestForVolume(v, pvName, storageClassName, fsType, secretNamespace, secretName)
+	pv.TypeMeta = metav1.TypeMeta{
+		APIVersion: "v1",
+		Kind:       "PersistentVolume",
+	}
+	pvc := datastore.NewPVCManifestForVolume(v, pvName, namespace, pvcName, storageClassName)
+	pvc.TypeMeta = metav1.TypeMeta{
+		APIVersion: "v1",
+		Kind:       "PersistentVolumeClaim",
+	}
+	return pv, pvc, nil
 }
 
 func (m *VolumeManager) PVCCreate(name, namespace, pvcName string) (v *longhorn.Volume, err error) {
@@ -1,9 +1,25 @@
 package manager
 
 import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
 	corev1 "k8s.io/api/core/v1"
 )
 
 func (m *VolumeManager) GetLonghornEventList() (*corev1.EventList, error) {
 	return m.ds.GetLonghornEventList()
 }
+
+// CheckReadiness returns an error if the datastore caches haven't synced yet
+// or the Kubernetes API server is unreachable.
+func (m *VolumeManager) CheckReadiness() error {
+	if !m.ds.HasSynced() {
+		return fmt.Errorf("datastore caches are not synced yet")
+	}
+	if _, err := m.ds.GetKubernetesVersion(); err != nil {
+		return errors.Wrap(err, "failed to reach the Kubernetes API server")
+	}
+	return nil
+}
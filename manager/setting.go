@@ -1,6 +1,11 @@
 package manager
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -55,3 +60,106 @@ func (m *VolumeManager) CreateOrUpdateSetting(s *longhorn.Setting) (*longhorn.Se
 	logrus.Debugf("Updated setting %v to %v", s.Name, setting.Value)
 	return setting, nil
 }
+
+// SettingsValidationError is returned by ImportSettings when some of the
+// values to import are invalid, in which case none of them is applied.
+type SettingsValidationError struct {
+	Errors []string
+}
+
+func (e *SettingsValidationError) Error() string {
+	return fmt.Sprintf("failed to validate the settings to import: %v", strings.Join(e.Errors, "; "))
+}
+
+// ExportSettings returns the values of all settings, keyed by the setting name
+func (m *VolumeManager) ExportSettings() (map[string]string, error) {
+	settings, err := m.ds.ListSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	for name, setting := range settings {
+		values[string(name)] = setting.Value
+	}
+	return values, nil
+}
+
+// ImportSettings applies the setting values exported by ExportSettings. The
+// unknown and read-only settings and the unchanged values are skipped, and the
+// reasons are returned along with the applied settings. All values are
+// validated before any of them is applied, and the applied values are rolled
+// back if one of them fails to apply.
+func (m *VolumeManager) ImportSettings(values map[string]string) (applied []string, skipped map[string]string, err error) {
+	settings, err := m.ds.ListSettings()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := []string{}
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	skipped = map[string]string{}
+	toApply := []*longhorn.Setting{}
+	oldValues := map[string]string{}
+	validationErrors := []string{}
+	for _, name := range names {
+		value := strings.TrimSpace(values[name])
+
+		definition, ok := types.GetSettingDefinition(types.SettingName(name))
+		if !ok {
+			skipped[name] = "unknown setting"
+			continue
+		}
+		if definition.ReadOnly {
+			skipped[name] = "read-only setting"
+			continue
+		}
+		setting, ok := settings[types.SettingName(name)]
+		if !ok {
+			skipped[name] = "unknown setting"
+			continue
+		}
+		if setting.Value == value {
+			skipped[name] = "value unchanged"
+			continue
+		}
+
+		if err := m.ds.ValidateSetting(name, value); err != nil {
+			validationErrors = append(validationErrors, err.Error())
+			continue
+		}
+		oldValues[name] = setting.Value
+		setting.Value = value
+		toApply = append(toApply, setting)
+	}
+	if len(validationErrors) != 0 {
+		return nil, nil, &SettingsValidationError{Errors: validationErrors}
+	}
+
+	applied = []string{}
+	for _, setting := range toApply {
+		if _, err := m.CreateOrUpdateSetting(setting); err != nil {
+			m.rollbackImportedSettings(applied, oldValues)
+			return nil, nil, errors.Wrapf(err, "failed to import setting %v, rolled back the imported settings %v", setting.Name, applied)
+		}
+		applied = append(applied, setting.Name)
+	}
+
+	logrus.Infof("Imported settings %v, skipped settings %v", applied, skipped)
+	return applied, skipped, nil
+}
+
+func (m *VolumeManager) rollbackImportedSettings(names []string, oldValues map[string]string) {
+	for i := len(names) - 1; i >= 0; i-- {
+		name := names[i]
+		if _, err := m.ds.UpdateSettingWithRetry(types.SettingName(name), func(setting *longhorn.Setting) {
+			setting.Value = oldValues[name]
+		}); err != nil {
+			logrus.WithError(err).Errorf("Failed to roll back imported setting %v to %v", name, oldValues[name])
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+
+	"github.com/longhorn/longhorn-manager/datastore"
+	"github.com/longhorn/longhorn-manager/types"
+
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
+	lhfake "github.com/longhorn/longhorn-manager/k8s/pkg/client/clientset/versioned/fake"
+	lhinformers "github.com/longhorn/longhorn-manager/k8s/pkg/client/informers/externalversions"
+)
+
+const (
+	TestNamespace = "default"
+	TestNode1     = "test-node-1"
+)
+
+func TestImportSettings(t *testing.T) {
+	assert := require.New(t)
+
+	// The fake informer caches are not updated by the fake clients
+	datastore.VerificationRetryCounts = 1
+
+	existingSettings := map[types.SettingName]string{
+		types.SettingNameDefaultReplicaCount:  "3",
+		types.SettingNameUpgradeCheckInterval: "24h",
+		types.SettingNameDefaultEngineImage:   "longhornio/longhorn-engine:v1",
+	}
+
+	type testCase struct {
+		values       map[string]string
+		failedUpdate types.SettingName
+
+		expectValidationError bool
+		expectError           bool
+		expectedApplied       []string
+		expectedSkipped       map[string]string
+		expectedValues        map[types.SettingName]string
+	}
+	testCases := map[string]testCase{
+		"settings imported": {
+			values: map[string]string{
+				string(types.SettingNameDefaultReplicaCount):  "2",
+				string(types.SettingNameUpgradeCheckInterval): "1h",
+				string(types.SettingNameDefaultEngineImage):   "longhornio/longhorn-engine:v2",
+				"unknown-setting": "value",
+			},
+			expectedApplied: []string{
+				string(types.SettingNameDefaultReplicaCount),
+				string(types.SettingNameUpgradeCheckInterval),
+			},
+			expectedSkipped: map[string]string{
+				string(types.SettingNameDefaultEngineImage): "read-only setting",
+				"unknown-setting": "unknown setting",
+			},
+			expectedValues: map[types.SettingName]string{
+				types.SettingNameDefaultReplicaCount:  "2",
+				types.SettingNameUpgradeCheckInterval: "1h",
+			},
+		},
+		"invalid value rejects all settings": {
+			values: map[string]string{
+				string(types.SettingNameDefaultReplicaCount):  "2",
+				string(types.SettingNameUpgradeCheckInterval): "-1h",
+			},
+			expectValidationError: true,
+			expectError:           true,
+			expectedValues: map[types.SettingName]string{
+				types.SettingNameDefaultReplicaCount:  "3",
+				types.SettingNameUpgradeCheckInterval: "24h",
+			},
+		},
+		"applied settings rolled back on failure": {
+			values: map[string]string{
+				string(types.SettingNameDefaultReplicaCount):  "2",
+				string(types.SettingNameUpgradeCheckInterval): "1h",
+			},
+			failedUpdate: types.SettingNameUpgradeCheckInterval,
+			expectError:  true,
+			expectedValues: map[types.SettingName]string{
+				types.SettingNameDefaultReplicaCount:  "3",
+				types.SettingNameUpgradeCheckInterval: "24h",
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		kubeClient := fake.NewSimpleClientset()
+		kubeInformerFactory := informers.NewSharedInformerFactory(kubeClient, 0)
+		lhClient := lhfake.NewSimpleClientset()
+		lhInformerFactory := lhinformers.NewSharedInformerFactory(lhClient, 0)
+		extensionsClient := apiextensionsfake.NewSimpleClientset()
+		ds, err := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+		assert.NoError(err)
+
+		sIndexer := lhInformerFactory.Longhorn().V1beta2().Settings().Informer().GetIndexer()
+		for sName, value := range existingSettings {
+			setting := &longhorn.Setting{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      string(sName),
+					Namespace: TestNamespace,
+				},
+				Value: value,
+			}
+			setting, err = lhClient.LonghornV1beta2().Settings(TestNamespace).Create(context.TODO(), setting, metav1.CreateOptions{})
+			assert.NoError(err)
+			assert.NoError(sIndexer.Add(setting))
+		}
+
+		if tc.failedUpdate != "" {
+			lhClient.PrependReactor("update", "settings", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				setting := action.(clienttesting.UpdateAction).GetObject().(*longhorn.Setting)
+				if setting.Name != string(tc.failedUpdate) {
+					return false, nil, nil
+				}
+				return true, nil, fmt.Errorf("failed to update setting %v", setting.Name)
+			})
+		}
+
+		m := NewVolumeManager(TestNode1, ds, nil, nil)
+		applied, skipped, err := m.ImportSettings(tc.values)
+		if tc.expectError {
+			assert.Error(err, "test case: %v", name)
+			_, isValidationError := err.(*SettingsValidationError)
+			assert.Equal(tc.expectValidationError, isValidationError, "test case: %v", name)
+		} else {
+			assert.NoError(err, "test case: %v", name)
+			assert.Equal(tc.expectedApplied, applied, "test case: %v", name)
+			assert.Equal(tc.expectedSkipped, skipped, "test case: %v", name)
+		}
+
+		for sName, expectedValue := range tc.expectedValues {
+			setting, err := lhClient.LonghornV1beta2().Settings(TestNamespace).Get(context.TODO(), string(sName), metav1.GetOptions{})
+			assert.NoError(err, "test case: %v", name)
+			assert.Equal(expectedValue, setting.Value, "test case: %v, setting: %v", name, sName)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -12,8 +13,10 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
 
 	"github.com/longhorn/longhorn-manager/datastore"
+	"github.com/longhorn/longhorn-manager/engineapi"
 	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
 	"github.com/longhorn/longhorn-manager/scheduler"
 	"github.com/longhorn/longhorn-manager/types"
@@ -21,18 +24,20 @@ import (
 )
 
 type VolumeManager struct {
-	ds        *datastore.DataStore
-	scheduler *scheduler.ReplicaScheduler
+	ds         *datastore.DataStore
+	scheduler  *scheduler.ReplicaScheduler
+	hookRunner *engineapi.SnapshotHookRunner
 
 	currentNodeID string
 
 	proxyConnCounter util.Counter
 }
 
-func NewVolumeManager(currentNodeID string, ds *datastore.DataStore, proxyConnCounter util.Counter) *VolumeManager {
+func NewVolumeManager(currentNodeID string, ds *datastore.DataStore, kubeConfig *rest.Config, proxyConnCounter util.Counter) *VolumeManager {
 	return &VolumeManager{
-		ds:        ds,
-		scheduler: scheduler.NewReplicaScheduler(ds),
+		ds:         ds,
+		scheduler:  scheduler.NewReplicaScheduler(ds),
+		hookRunner: engineapi.NewSnapshotHookRunner(ds, engineapi.NewWebsocketPodCommandExecutor(kubeConfig), logrus.StandardLogger()),
 
 		currentNodeID: currentNodeID,
 
@@ -164,6 +169,8 @@ func (m *VolumeManager) Create(name string, spec *longhorn.VolumeSpec, recurring
 			DataSource:                spec.DataSource,
 			NumberOfReplicas:          spec.NumberOfReplicas,
 			ReplicaAutoBalance:        spec.ReplicaAutoBalance,
+			ReplicaPlacementPolicy:    spec.ReplicaPlacementPolicy,
+			ReplicaPlacementNodes:     spec.ReplicaPlacementNodes,
 			DataLocality:              spec.DataLocality,
 			StaleReplicaTimeout:       spec.StaleReplicaTimeout,
 			BackingImage:              spec.BackingImage,
@@ -173,6 +180,8 @@ func (m *VolumeManager) Create(name string, spec *longhorn.VolumeSpec, recurring
 			RevisionCounterDisabled:   spec.RevisionCounterDisabled,
 			SnapshotDataIntegrity:     spec.SnapshotDataIntegrity,
 			UnmapMarkSnapChainRemoved: spec.UnmapMarkSnapChainRemoved,
+			SnapshotMaxCount:          spec.SnapshotMaxCount,
+			BackupRetention:           spec.BackupRetention,
 		},
 	}
 
@@ -227,6 +236,10 @@ func (m *VolumeManager) Attach(name, nodeID string, disableFrontend bool, attach
 		return nil, fmt.Errorf("volume %v is pending restoring", name)
 	}
 
+	if types.IsAttachmentTicketSupported(v) {
+		return m.addAttachmentTicket(v, nodeID, disableFrontend, attachedBy)
+	}
+
 	if v.Spec.NodeID == nodeID {
 		logrus.Debugf("Volume %v is already attached to node %v", v.Name, v.Spec.NodeID)
 		return v, nil
@@ -241,7 +254,7 @@ func (m *VolumeManager) Attach(name, nodeID string, disableFrontend bool, attach
 		return nil, fmt.Errorf("invalid state %v to attach RWO volume %v", v.Status.State, name)
 	}
 
-	isVolumeShared := v.Spec.AccessMode == longhorn.AccessModeReadWriteMany && !v.Spec.Migratable
+	isVolumeShared := types.IsSharedVolume(v)
 	isVolumeDetached := v.Spec.NodeID == ""
 	if isVolumeDetached {
 		if !isVolumeShared || disableFrontend {
@@ -307,13 +320,24 @@ func (m *VolumeManager) Detach(name, nodeID string) (v *longhorn.Volume, err err
 		return nil, fmt.Errorf("cannot detach standby volume %v", v.Name)
 	}
 
+	if types.IsAttachmentTicketSupported(v) {
+		va, err := m.ds.GetLHVolumeAttachmentRO(v.Name)
+		if err != nil && !datastore.ErrorIsNotFound(err) {
+			return nil, err
+		}
+		// The volume attached without the attachment tickets is detached as before
+		if va != nil {
+			return v, m.removeAttachmentTickets(v, nodeID)
+		}
+	}
+
 	if v.Spec.NodeID == "" && v.Spec.MigrationNodeID == "" {
 		logrus.Infof("No need to detach volume %v is already detached from all nodes", v.Name)
 		return v, nil
 	}
 
 	// shared volumes only need to be detached if they are attached in maintenance mode
-	if v.Spec.AccessMode == longhorn.AccessModeReadWriteMany && !v.Spec.Migratable && !v.Spec.DisableFrontend {
+	if types.IsSharedVolume(v) && !v.Spec.DisableFrontend {
 		logrus.Infof("No need to detach volume %v since it's shared via %v", v.Name, v.Status.ShareEndpoint)
 		return v, nil
 	}
@@ -358,6 +382,78 @@ func (m *VolumeManager) Detach(name, nodeID string) (v *longhorn.Volume, err err
 	return v, nil
 }
 
+// addAttachmentTicket requests attaching the volume by the attachment ticket of
+// the CSI attacher or the Longhorn API. The volume controller attaches the
+// volume as the ticket with the highest priority requests.
+//
+// Only the tickets of the recurring jobs, which auto attach the volume with the
+// frontend disabled, are pre-empted by the new ticket. The volume attached by
+// the CSI attacher or the Longhorn API, e.g. in maintenance mode, has to be
+// detached explicitly before being attached to another node or with another
+// frontend mode. So does the volume attached without the attachment tickets.
+func (m *VolumeManager) addAttachmentTicket(v *longhorn.Volume, nodeID string, disableFrontend bool, attachedBy string) (*longhorn.Volume, error) {
+	attacherType := longhorn.AttacherTypeLonghornAPI
+	if attachedBy == string(longhorn.AttacherTypeCSIAttacher) {
+		attacherType = longhorn.AttacherTypeCSIAttacher
+	}
+	ticket := &longhorn.AttachmentTicket{
+		ID:     types.GetAttachmentTicketID(attacherType, nodeID),
+		Type:   attacherType,
+		NodeID: nodeID,
+		Parameters: map[string]string{
+			longhorn.AttachmentParameterDisableFrontend: strconv.FormatBool(disableFrontend),
+		},
+	}
+
+	va, err := m.ds.GetLHVolumeAttachmentRO(v.Name)
+	if err != nil && !datastore.ErrorIsNotFound(err) {
+		return nil, err
+	}
+	if va == nil && v.Spec.NodeID != "" {
+		if v.Spec.NodeID == nodeID {
+			logrus.Debugf("Volume %v is already attached to node %v", v.Name, v.Spec.NodeID)
+			return v, nil
+		}
+		return nil, fmt.Errorf("non migratable volume %v cannot attach to node %v is already attached to node %v", v.Name, nodeID, v.Spec.NodeID)
+	}
+	if va != nil {
+		for _, t := range va.Spec.AttachmentTickets {
+			if t == nil || t.ID == ticket.ID || t.Type == longhorn.AttacherTypeRecurringJob {
+				continue
+			}
+			if t.NodeID != nodeID {
+				return nil, fmt.Errorf("volume %v is already requested to attach to node %v by attachment ticket %v", v.Name, t.NodeID, t.ID)
+			}
+			if types.IsAttachmentTicketFrontendDisabled(t) != disableFrontend {
+				return nil, fmt.Errorf("volume %v is already requested to attach to node %v with disableFrontend %v by attachment ticket %v",
+					v.Name, t.NodeID, types.IsAttachmentTicketFrontendDisabled(t), t.ID)
+			}
+		}
+	}
+
+	if _, err := m.ds.AddAttachmentTicket(v.Name, ticket); err != nil {
+		return nil, err
+	}
+	logrus.Infof("Volume %v attachment to %v with disableFrontend %v requested by attachment ticket %v", v.Name, nodeID, disableFrontend, ticket.ID)
+	return v, nil
+}
+
+// removeAttachmentTickets removes the attachment tickets of the CSI attacher
+// and the Longhorn API for the node, or for all nodes if the node is not
+// specified. The tickets of the other attachers are removed by themselves.
+func (m *VolumeManager) removeAttachmentTickets(v *longhorn.Volume, nodeID string) error {
+	if err := m.ds.RemoveAttachmentTickets(v.Name, func(ticket *longhorn.AttachmentTicket) bool {
+		if ticket.Type != longhorn.AttacherTypeCSIAttacher && ticket.Type != longhorn.AttacherTypeLonghornAPI {
+			return false
+		}
+		return nodeID == "" || ticket.NodeID == nodeID
+	}); err != nil {
+		return err
+	}
+	logrus.Infof("Volume %v detachment from node %v requested by removing attachment tickets", v.Name, nodeID)
+	return nil
+}
+
 func (m *VolumeManager) isVolumeAvailableOnNode(volume, node string) bool {
 	es, _ := m.ds.ListVolumeEngines(volume)
 	for _, e := range es {
@@ -758,7 +854,6 @@ func (m *VolumeManager) DeleteVolumeRecurringJob(volumeName string, name string,
 }
 
 func (m *VolumeManager) DeleteReplica(volumeName, replicaName string) error {
-	healthyReplica := ""
 	rs, err := m.ds.ListVolumeReplicas(volumeName)
 	if err != nil {
 		return err
@@ -766,18 +861,9 @@ func (m *VolumeManager) DeleteReplica(volumeName, replicaName string) error {
 	if _, exists := rs[replicaName]; !exists {
 		return fmt.Errorf("cannot find replica %v of volume %v", replicaName, volumeName)
 	}
-	for _, r := range rs {
-		if r.Name == replicaName {
-			continue
-		}
-		if !datastore.IsAvailableHealthyReplica(r) {
-			continue
-		}
-		if r.Status.EvictionRequested {
-			continue
-		}
-		healthyReplica = r.Name
-		break
+	healthyReplica, err := m.ds.GetOtherAvailableHealthyReplicaName(volumeName, replicaName)
+	if err != nil {
+		return err
 	}
 	if healthyReplica == "" {
 		return fmt.Errorf("no other healthy replica available, cannot delete replica %v since it may still contain data for recovery", replicaName)
@@ -867,6 +953,20 @@ func (m *VolumeManager) EngineUpgrade(volumeName, image string) (v *longhorn.Vol
 		return nil, fmt.Errorf("cannot do live upgrade for a unhealthy volume %v", v.Name)
 	}
 
+	if v.Status.State == longhorn.VolumeStateAttached && image != v.Status.CurrentImage {
+		oldEngineImage, err := m.ds.GetEngineImage(types.GetEngineImageChecksumName(v.Status.CurrentImage))
+		if err != nil {
+			return nil, err
+		}
+		newEngineImage, err := m.ds.GetEngineImage(types.GetEngineImageChecksumName(image))
+		if err != nil {
+			return nil, err
+		}
+		if err := types.CheckEngineImageLiveUpgradeCompatibility(oldEngineImage, newEngineImage); err != nil {
+			return nil, errors.Wrapf(err, "cannot do live upgrade for volume %v", v.Name)
+		}
+	}
+
 	oldImage := v.Spec.EngineImage
 	v.Spec.EngineImage = image
 
@@ -1070,3 +1170,13 @@ func (m *VolumeManager) verifyDataSourceForVolumeCreation(dataSource longhorn.Vo
 	}
 	return nil
 }
+
+// DryRunReplicaScheduling returns the placement decisions the replica
+// scheduler would make with the given setting values, without applying them
+func (m *VolumeManager) DryRunReplicaScheduling(settings map[string]string) ([]*scheduler.ReplicaPlacement, error) {
+	settingOverrides := map[types.SettingName]string{}
+	for name, value := range settings {
+		settingOverrides[types.SettingName(name)] = strings.TrimSpace(value)
+	}
+	return m.scheduler.DryRunScheduleReplicas(settingOverrides)
+}
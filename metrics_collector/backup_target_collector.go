@@ -0,0 +1,67 @@
+package metricscollector
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/longhorn/longhorn-manager/datastore"
+)
+
+type BackupTargetCollector struct {
+	*baseCollector
+
+	availableMetric metricInfo
+}
+
+func NewBackupTargetCollector(
+	logger logrus.FieldLogger,
+	nodeID string,
+	ds *datastore.DataStore) *BackupTargetCollector {
+
+	btc := &BackupTargetCollector{
+		baseCollector: newBaseCollector(subsystemBackupTarget, logger, nodeID, ds),
+	}
+
+	btc.availableMetric = metricInfo{
+		Desc: prometheus.NewDesc(
+			prometheus.BuildFQName(longhornName, subsystemBackupTarget, "available"),
+			"Whether the backup target is available. 1 means available, 0 means unavailable",
+			[]string{backupTargetLabel, urlLabel},
+			nil,
+		),
+		Type: prometheus.GaugeValue,
+	}
+
+	return btc
+}
+
+func (btc *BackupTargetCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- btc.availableMetric.Desc
+}
+
+func (btc *BackupTargetCollector) Collect(ch chan<- prometheus.Metric) {
+	defer func() {
+		if err := recover(); err != nil {
+			btc.logger.WithField("error", err).Warn("Panic during collecting metrics")
+		}
+	}()
+
+	backupTargets, err := btc.ds.ListBackupTargetsRO()
+	if err != nil {
+		btc.logger.WithError(err).Warn("Error during scrape")
+		return
+	}
+
+	for _, bt := range backupTargets {
+		// Only the node responsible for the backup target reports it
+		if bt.Status.OwnerID != btc.currentNodeID || bt.Spec.BackupTargetURL == "" {
+			continue
+		}
+		available := float64(0)
+		if bt.Status.Available {
+			available = 1
+		}
+		ch <- prometheus.MustNewConstMetric(btc.availableMetric.Desc, btc.availableMetric.Type, available, bt.Name, bt.Spec.BackupTargetURL)
+	}
+}
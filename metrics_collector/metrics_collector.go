@@ -22,13 +22,20 @@ func InitMetricsCollectorSystem(logger logrus.FieldLogger, currentNodeID string,
 	logger.Info("Initializing metrics collector system")
 
 	vc := NewVolumeCollector(logger, currentNodeID, ds)
+	rc := NewReplicaCollector(logger, currentNodeID, ds)
 	dc := NewDiskCollector(logger, currentNodeID, ds)
 	bc := NewBackupCollector(logger, currentNodeID, ds)
+	btc := NewBackupTargetCollector(logger, currentNodeID, ds)
+	uc := NewUpgradeCollector(logger, currentNodeID, ds)
 
 	if err := registry.Register(vc); err != nil {
 		logger.WithField("collector", subsystemVolume).WithError(err).Warn("Failed to register collector")
 	}
 
+	if err := registry.Register(rc); err != nil {
+		logger.WithField("collector", subsystemReplica).WithError(err).Warn("Failed to register collector")
+	}
+
 	if err := registry.Register(dc); err != nil {
 		logger.WithField("collector", subsystemDisk).WithError(err).Warn("Failed to register collector")
 	}
@@ -37,6 +44,14 @@ func InitMetricsCollectorSystem(logger logrus.FieldLogger, currentNodeID string,
 		logger.WithField("collector", subsystemBackup).WithError(err).Warn("Failed to register collector")
 	}
 
+	if err := registry.Register(btc); err != nil {
+		logger.WithField("collector", subsystemBackupTarget).WithError(err).Warn("Failed to register collector")
+	}
+
+	if err := registry.Register(uc); err != nil {
+		logger.WithField("collector", subsystemUpgrade).WithError(err).Warn("Failed to register collector")
+	}
+
 	namespace := os.Getenv(types.EnvPodNamespace)
 	if namespace == "" {
 		logger.Warnf("Cannot detect pod namespace, environment variable %v is missing, "+
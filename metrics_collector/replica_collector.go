@@ -0,0 +1,98 @@
+package metricscollector
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/longhorn/longhorn-manager/datastore"
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
+)
+
+type ReplicaCollector struct {
+	*baseCollector
+
+	countMetric           metricInfo
+	rebuildingCountMetric metricInfo
+}
+
+func NewReplicaCollector(
+	logger logrus.FieldLogger,
+	nodeID string,
+	ds *datastore.DataStore) *ReplicaCollector {
+
+	rc := &ReplicaCollector{
+		baseCollector: newBaseCollector(subsystemReplica, logger, nodeID, ds),
+	}
+
+	rc.countMetric = metricInfo{
+		Desc: prometheus.NewDesc(
+			prometheus.BuildFQName(longhornName, subsystemReplica, "count"),
+			"Total number of replicas in each state on this node",
+			[]string{nodeLabel, stateLabel},
+			nil,
+		),
+		Type: prometheus.GaugeValue,
+	}
+
+	rc.rebuildingCountMetric = metricInfo{
+		Desc: prometheus.NewDesc(
+			prometheus.BuildFQName(longhornName, subsystemReplica, "rebuilding_count"),
+			"Total number of replicas being rebuilt on this node",
+			[]string{nodeLabel},
+			nil,
+		),
+		Type: prometheus.GaugeValue,
+	}
+
+	return rc
+}
+
+func (rc *ReplicaCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- rc.countMetric.Desc
+	ch <- rc.rebuildingCountMetric.Desc
+}
+
+func (rc *ReplicaCollector) Collect(ch chan<- prometheus.Metric) {
+	defer func() {
+		if err := recover(); err != nil {
+			rc.logger.WithField("error", err).Warn("Panic during collecting metrics")
+		}
+	}()
+
+	replicas, err := rc.ds.ListReplicasByNodeRO(rc.currentNodeID)
+	if err != nil {
+		rc.logger.WithError(err).Warn("Error during scrape")
+		return
+	}
+	volumes, err := rc.ds.ListVolumesRO()
+	if err != nil {
+		rc.logger.WithError(err).Warn("Error during scrape")
+		return
+	}
+
+	// The rebuild status is recorded in the volume, keyed by the replica name
+	rebuilding := map[string]struct{}{}
+	for _, v := range volumes {
+		for rName, status := range v.Status.RebuildStatus {
+			if status == nil || status.Error != "" {
+				continue
+			}
+			rebuilding[rName] = struct{}{}
+		}
+	}
+
+	replicaCount := map[longhorn.InstanceState]int{}
+	rebuildingCount := 0
+	for _, r := range replicas {
+		replicaCount[r.Status.CurrentState]++
+		if _, ok := rebuilding[r.Name]; ok {
+			rebuildingCount++
+		}
+	}
+
+	for state, count := range replicaCount {
+		ch <- prometheus.MustNewConstMetric(rc.countMetric.Desc, rc.countMetric.Type, float64(count), rc.currentNodeID, string(state))
+	}
+	ch <- prometheus.MustNewConstMetric(rc.rebuildingCountMetric.Desc, rc.rebuildingCountMetric.Type, float64(rebuildingCount), rc.currentNodeID)
+}
@@ -15,6 +15,9 @@ const (
 	subsystemInstanceManager = "instance_manager"
 	subsystemManager         = "manager"
 	subsystemBackup          = "backup"
+	subsystemBackupTarget    = "backup_target"
+	subsystemReplica         = "replica"
+	subsystemUpgrade         = "upgrade"
 
 	nodeLabel            = "node"
 	diskLabel            = "disk"
@@ -25,6 +28,14 @@ const (
 	instanceManagerType  = "instance_manager_type"
 	managerLabel         = "manager"
 	backupLabel          = "backup"
+	backupTargetLabel    = "backup_target"
+	urlLabel             = "url"
+	currentVersionLabel  = "current_version"
+	latestVersionLabel   = "latest_version"
+	stateLabel           = "state"
+	pvcNamespaceLabel    = "pvc_namespace"
+	pvcLabel             = "pvc"
+	workloadLabel        = "workload"
 )
 
 type metricInfo struct {
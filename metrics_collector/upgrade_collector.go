@@ -0,0 +1,63 @@
+package metricscollector
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/longhorn/longhorn-manager/controller"
+	"github.com/longhorn/longhorn-manager/datastore"
+)
+
+type UpgradeCollector struct {
+	*baseCollector
+
+	availableMetric metricInfo
+}
+
+func NewUpgradeCollector(
+	logger logrus.FieldLogger,
+	nodeID string,
+	ds *datastore.DataStore) *UpgradeCollector {
+
+	uc := &UpgradeCollector{
+		baseCollector: newBaseCollector(subsystemUpgrade, logger, nodeID, ds),
+	}
+
+	uc.availableMetric = metricInfo{
+		Desc: prometheus.NewDesc(
+			prometheus.BuildFQName(longhornName, subsystemUpgrade, "available"),
+			"Whether a newer Longhorn version is reported by the upgrade checker. 1 means available, 0 means not available",
+			[]string{currentVersionLabel, latestVersionLabel},
+			nil,
+		),
+		Type: prometheus.GaugeValue,
+	}
+
+	return uc
+}
+
+func (uc *UpgradeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- uc.availableMetric.Desc
+}
+
+func (uc *UpgradeCollector) Collect(ch chan<- prometheus.Metric) {
+	defer func() {
+		if err := recover(); err != nil {
+			uc.logger.WithField("error", err).Warn("Panic during collecting metrics")
+		}
+	}()
+
+	// Export the result of the last upgrade check of the setting controller
+	// of this manager, so the current version is the one this manager runs.
+	result := controller.GetUpgradeCheckResult()
+	if result == nil {
+		return
+	}
+
+	available := float64(0)
+	if result.Available {
+		available = 1
+	}
+	ch <- prometheus.MustNewConstMetric(uc.availableMetric.Desc, uc.availableMetric.Type, available, result.CurrentVersion, result.LatestVersion)
+}
@@ -1,6 +1,9 @@
 package metricscollector
 
 import (
+	"sort"
+	"strings"
+
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
@@ -22,6 +25,7 @@ type VolumeCollector struct {
 	sizeMetric       metricInfo
 	stateMetric      metricInfo
 	robustnessMetric metricInfo
+	countMetric      metricInfo
 
 	volumePerfMetrics
 }
@@ -80,8 +84,18 @@ func NewVolumeCollector(
 	vc.robustnessMetric = metricInfo{
 		Desc: prometheus.NewDesc(
 			prometheus.BuildFQName(longhornName, subsystemVolume, "robustness"),
-			"Robustness of this volume",
-			[]string{nodeLabel, volumeLabel},
+			"Robustness of this volume. 0 means unknown, 1 means healthy, 2 means degraded, 3 means faulted",
+			[]string{nodeLabel, volumeLabel, pvcNamespaceLabel, pvcLabel, workloadLabel},
+			nil,
+		),
+		Type: prometheus.GaugeValue,
+	}
+
+	vc.countMetric = metricInfo{
+		Desc: prometheus.NewDesc(
+			prometheus.BuildFQName(longhornName, subsystemVolume, "count"),
+			"Total number of volumes in each state owned by this node",
+			[]string{nodeLabel, stateLabel},
 			nil,
 		),
 		Type: prometheus.GaugeValue,
@@ -155,6 +169,7 @@ func (vc *VolumeCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- vc.sizeMetric.Desc
 	ch <- vc.stateMetric.Desc
 	ch <- vc.robustnessMetric.Desc
+	ch <- vc.countMetric.Desc
 }
 
 func (vc *VolumeCollector) Collect(ch chan<- prometheus.Metric) {
@@ -170,8 +185,11 @@ func (vc *VolumeCollector) Collect(ch chan<- prometheus.Metric) {
 		return
 	}
 
+	volumeCount := map[longhorn.VolumeState]int{}
 	for _, v := range volumeLists {
 		if v.Status.OwnerID == vc.currentNodeID {
+			volumeCount[v.Status.State]++
+
 			var err error
 			var e *longhorn.Engine
 			var engineClientProxy engineapi.EngineClientProxy
@@ -197,7 +215,7 @@ func (vc *VolumeCollector) Collect(ch chan<- prometheus.Metric) {
 			ch <- prometheus.MustNewConstMetric(vc.capacityMetric.Desc, vc.capacityMetric.Type, float64(v.Spec.Size), vc.currentNodeID, v.Name)
 			ch <- prometheus.MustNewConstMetric(vc.sizeMetric.Desc, vc.sizeMetric.Type, float64(v.Status.ActualSize), vc.currentNodeID, v.Name)
 			ch <- prometheus.MustNewConstMetric(vc.stateMetric.Desc, vc.stateMetric.Type, float64(getVolumeStateValue(v)), vc.currentNodeID, v.Name)
-			ch <- prometheus.MustNewConstMetric(vc.robustnessMetric.Desc, vc.robustnessMetric.Type, float64(getVolumeRobustnessValue(v)), vc.currentNodeID, v.Name)
+			ch <- prometheus.MustNewConstMetric(vc.robustnessMetric.Desc, vc.robustnessMetric.Type, float64(getVolumeRobustnessValue(v)), vc.currentNodeID, v.Name, v.Status.KubernetesStatus.Namespace, v.Status.KubernetesStatus.PVCName, getVolumeWorkloads(v))
 			ch <- prometheus.MustNewConstMetric(vc.volumePerfMetrics.throughputMetrics.read.Desc, vc.volumePerfMetrics.throughputMetrics.read.Type, float64(vc.getVolumeReadThroughput(metrics)), vc.currentNodeID, v.Name)
 			ch <- prometheus.MustNewConstMetric(vc.volumePerfMetrics.throughputMetrics.write.Desc, vc.volumePerfMetrics.throughputMetrics.write.Type, float64(vc.getVolumeWriteThroughput(metrics)), vc.currentNodeID, v.Name)
 			ch <- prometheus.MustNewConstMetric(vc.volumePerfMetrics.iopsMetrics.read.Desc, vc.volumePerfMetrics.iopsMetrics.read.Type, float64(vc.getVolumeReadIOPS(metrics)), vc.currentNodeID, v.Name)
@@ -206,6 +224,10 @@ func (vc *VolumeCollector) Collect(ch chan<- prometheus.Metric) {
 			ch <- prometheus.MustNewConstMetric(vc.volumePerfMetrics.latencyMetrics.write.Desc, vc.volumePerfMetrics.latencyMetrics.write.Type, float64(vc.getVolumeWriteLatency(metrics)), vc.currentNodeID, v.Name)
 		}
 	}
+
+	for state, count := range volumeCount {
+		ch <- prometheus.MustNewConstMetric(vc.countMetric.Desc, vc.countMetric.Type, float64(count), vc.currentNodeID, string(state))
+	}
 }
 
 func (vc *VolumeCollector) getEngineClientProxy(engine *longhorn.Engine) (c engineapi.EngineClientProxy, err error) {
@@ -251,6 +273,20 @@ func getVolumeRobustnessValue(v *longhorn.Volume) int {
 	return robustnessValue
 }
 
+// getVolumeWorkloads returns the sorted names of the workloads using the
+// volume, separated by comma.
+func getVolumeWorkloads(v *longhorn.Volume) string {
+	workloads := []string{}
+	for _, ws := range v.Status.KubernetesStatus.WorkloadsStatus {
+		if ws.WorkloadName == "" || util.Contains(workloads, ws.WorkloadName) {
+			continue
+		}
+		workloads = append(workloads, ws.WorkloadName)
+	}
+	sort.Strings(workloads)
+	return strings.Join(workloads, ",")
+}
+
 func (vc *VolumeCollector) getVolumeReadThroughput(metrics *engineapi.Metrics) int64 {
 	if metrics == nil {
 		return 0
@@ -2,12 +2,19 @@ package scheduler
 
 import (
 	"fmt"
+	"math"
+	"math/rand"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+
 	"github.com/longhorn/longhorn-manager/datastore"
 	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
 	"github.com/longhorn/longhorn-manager/types"
@@ -15,11 +22,22 @@ import (
 )
 
 const (
+	// FailedReplicaMaxRetryCount is the rebuild retry limit used when the
+	// setting cannot be retrieved
 	FailedReplicaMaxRetryCount = 5
+
+	// NonReusableReplicaRebuildRetryCount marks the replicas that must not be
+	// reused after failing, e.g. the replicas newly created for rebuilding. It
+	// exceeds any rebuild retry limit.
+	NonReusableReplicaRebuildRetryCount = math.MaxInt32
 )
 
 type ReplicaScheduler struct {
 	ds *datastore.DataStore
+
+	// settingOverrides replaces the values of the settings read by the
+	// scheduler. It is only used for the dry run.
+	settingOverrides map[types.SettingName]string
 }
 
 type Disk struct {
@@ -44,6 +62,31 @@ func NewReplicaScheduler(ds *datastore.DataStore) *ReplicaScheduler {
 	return rcScheduler
 }
 
+func (rcs *ReplicaScheduler) getSettingAsInt(name types.SettingName) (int64, error) {
+	if value, ok := rcs.settingOverrides[name]; ok {
+		return strconv.ParseInt(value, 10, 64)
+	}
+	return rcs.ds.GetSettingAsInt(name)
+}
+
+func (rcs *ReplicaScheduler) getSettingAsBool(name types.SettingName) (bool, error) {
+	if value, ok := rcs.settingOverrides[name]; ok {
+		return strconv.ParseBool(value)
+	}
+	return rcs.ds.GetSettingAsBool(name)
+}
+
+// GetReplicaRebuildRetryLimit returns the maximum number of times a failed
+// replica can be reused for rebuilding
+func (rcs *ReplicaScheduler) GetReplicaRebuildRetryLimit() int {
+	limit, err := rcs.getSettingAsInt(types.SettingNameReplicaRebuildRetryLimit)
+	if err != nil || limit < 1 {
+		logrus.Warnf("Failed to get valid setting %v, use the default value %v: %v", types.SettingNameReplicaRebuildRetryLimit, FailedReplicaMaxRetryCount, err)
+		return FailedReplicaMaxRetryCount
+	}
+	return int(limit)
+}
+
 // ScheduleReplica will return (nil, nil) for unschedulable replica
 func (rcs *ReplicaScheduler) ScheduleReplica(replica *longhorn.Replica, replicas map[string]*longhorn.Replica, volume *longhorn.Volume) (*longhorn.Replica, util.MultiError, error) {
 	// only called when replica is starting for the first time
@@ -63,12 +106,38 @@ func (rcs *ReplicaScheduler) ScheduleReplica(replica *longhorn.Replica, replicas
 		return nil, nil, err
 	}
 
+	diskCandidates, multiError, err := rcs.findDiskCandidates(replica, replicas, volume, nodesInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// there's no disk that fit for current replica
+	if len(diskCandidates) == 0 {
+		logrus.Errorf("There's no available disk for replica %v, size %v: %v", replica.ObjectMeta.Name, replica.Spec.VolumeSize, multiError.Join())
+		return nil, multiError, nil
+	}
+
+	orderedDisks := orderDiskCandidates(diskCandidates, replicas, volume, nodesInfo)
+
+	// schedule replica to disk
+	rcs.scheduleReplicaToDisk(replica, orderedDisks[0])
+
+	return replica, nil, nil
+}
+
+// findDiskCandidates returns the disks the replica can be scheduled to among
+// the given nodes
+func (rcs *ReplicaScheduler) findDiskCandidates(replica *longhorn.Replica, replicas map[string]*longhorn.Replica, volume *longhorn.Volume, nodesInfo map[string]*longhorn.Node) (map[string]*Disk, util.MultiError, error) {
 	nodeCandidates, multiError := rcs.getNodeCandidates(nodesInfo, replica)
 	if len(nodeCandidates) == 0 {
-		logrus.Errorf("There's no available node for replica %v, size %v", replica.ObjectMeta.Name, replica.Spec.VolumeSize)
 		return nil, multiError, nil
 	}
 
+	diskPressurePercentage, err := rcs.getSettingAsInt(types.SettingNameDiskPressurePercentage)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	nodeDisksMap := map[string]map[string]struct{}{}
 	for _, node := range nodeCandidates {
 		disks := map[string]struct{}{}
@@ -83,23 +152,139 @@ func (rcs *ReplicaScheduler) ScheduleReplica(replica *longhorn.Replica, replicas
 			if types.GetCondition(diskStatus.Conditions, longhorn.DiskConditionTypeSchedulable).Status != longhorn.ConditionStatusTrue {
 				continue
 			}
+			// the replicas on a disk under pressure are being evicted
+			if IsDiskUnderPressure(diskStatus, diskPressurePercentage) {
+				continue
+			}
 			disks[diskStatus.DiskUUID] = struct{}{}
 		}
 		nodeDisksMap[node.Name] = disks
 	}
 
-	diskCandidates, multiError := rcs.getDiskCandidates(nodeCandidates, nodeDisksMap, replicas, volume, true)
+	respectNodePressure, err := rcs.getSettingAsBool(types.SettingNameSchedulingRespectNodePressure)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !respectNodePressure {
+		diskCandidates, multiError := rcs.getDiskCandidates(nodeCandidates, nodeDisksMap, replicas, volume, true)
+		return diskCandidates, multiError, nil
+	}
 
-	// there's no disk that fit for current replica
-	if len(diskCandidates) == 0 {
-		logrus.Errorf("There's no available disk for replica %v, size %v", replica.ObjectMeta.Name, replica.Spec.VolumeSize)
-		return nil, multiError, nil
+	nodesUnderPressure, err := rcs.getNodesUnderPressure(nodeCandidates)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(nodesUnderPressure) == 0 {
+		diskCandidates, multiError := rcs.getDiskCandidates(nodeCandidates, nodeDisksMap, replicas, volume, true)
+		return diskCandidates, multiError, nil
 	}
 
-	// schedule replica to disk
-	rcs.scheduleReplicaToDisk(replica, diskCandidates)
+	// Try the nodes without pressure first. The nodes under pressure are kept
+	// in the node list so that the anti-affinity checks still account for
+	// the replicas on them, but none of their disks can be picked.
+	preferredNodeDisksMap := map[string]map[string]struct{}{}
+	for nodeName, disks := range nodeDisksMap {
+		if _, ok := nodesUnderPressure[nodeName]; ok {
+			preferredNodeDisksMap[nodeName] = map[string]struct{}{}
+			continue
+		}
+		preferredNodeDisksMap[nodeName] = disks
+	}
+	diskCandidates, multiError := rcs.getDiskCandidates(nodeCandidates, preferredNodeDisksMap, replicas, volume, true)
+	if len(diskCandidates) > 0 {
+		return diskCandidates, multiError, nil
+	}
 
-	return replica, nil, nil
+	logrus.Infof("Falling back to nodes under resource pressure %v for replica %v", util.GetSortedKeysFromMap(nodesUnderPressure), replica.Name)
+	diskCandidates, errors := rcs.getDiskCandidates(nodeCandidates, nodeDisksMap, replicas, volume, true)
+	if len(diskCandidates) > 0 {
+		return diskCandidates, errors, nil
+	}
+	multiError.Append(errors)
+	multiError.Append(util.NewMultiError(longhorn.ErrorReplicaScheduleNodeUnderPressure))
+	return diskCandidates, multiError, nil
+}
+
+// getNodesUnderPressure returns the nodes of which the Kubernetes node reports
+// memory pressure, or of which the CPU or memory requested by the pods exceeds
+// the percentage of the allocatable resources specified by the setting.
+func (rcs *ReplicaScheduler) getNodesUnderPressure(nodes map[string]*longhorn.Node) (map[string]*longhorn.Node, error) {
+	requestPercentage, err := rcs.getSettingAsInt(types.SettingNameSchedulingNodePressureRequestPercentage)
+	if err != nil {
+		return nil, err
+	}
+
+	nodesUnderPressure := map[string]*longhorn.Node{}
+	for nodeName, node := range nodes {
+		kubeNode, err := rcs.ds.GetKubernetesNode(nodeName)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		pods, err := rcs.ds.ListPodsByNodeRO(nodeName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list pods on node %v for checking node pressure", nodeName)
+		}
+		requests := corev1.ResourceList{}
+		for _, pod := range pods {
+			if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+				continue
+			}
+			for resourceName, request := range getPodRequests(pod) {
+				total := requests[resourceName]
+				total.Add(request)
+				requests[resourceName] = total
+			}
+		}
+		if isKubeNodeUnderPressure(kubeNode, requests, requestPercentage) {
+			logrus.Debugf("Deprioritizing node %v under resource pressure when scheduling replicas", nodeName)
+			nodesUnderPressure[nodeName] = node
+		}
+	}
+	return nodesUnderPressure, nil
+}
+
+// getPodRequests returns the CPU and memory requested by the pod. As the
+// Kubernetes scheduler does, the init containers, which run one by one before
+// the other containers, count with the largest request among them.
+func getPodRequests(pod *corev1.Pod) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		total := resource.Quantity{}
+		for _, container := range pod.Spec.Containers {
+			if request, ok := container.Resources.Requests[resourceName]; ok {
+				total.Add(request)
+			}
+		}
+		for _, container := range pod.Spec.InitContainers {
+			if request, ok := container.Resources.Requests[resourceName]; ok && request.Cmp(total) > 0 {
+				total = request.DeepCopy()
+			}
+		}
+		requests[resourceName] = total
+	}
+	return requests
+}
+
+func isKubeNodeUnderPressure(kubeNode *corev1.Node, requests corev1.ResourceList, requestPercentage int64) bool {
+	for _, condition := range kubeNode.Status.Conditions {
+		if condition.Type == corev1.NodeMemoryPressure && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		allocatable, ok := kubeNode.Status.Allocatable[resourceName]
+		if !ok || allocatable.IsZero() {
+			continue
+		}
+		request := requests[resourceName]
+		if float64(request.MilliValue()) > float64(allocatable.MilliValue())*float64(requestPercentage)/100 {
+			return true
+		}
+	}
+	return false
 }
 
 func (rcs *ReplicaScheduler) getNodeCandidates(nodesInfo map[string]*longhorn.Node, schedulingReplica *longhorn.Replica) (nodeCandidates map[string]*longhorn.Node, multiError util.MultiError) {
@@ -130,6 +315,16 @@ func (rcs *ReplicaScheduler) getNodeCandidates(nodesInfo map[string]*longhorn.No
 	return nodeCandidates, nil
 }
 
+// IsDiskUnderPressure checks if the used capacity of the disk exceeds the
+// disk pressure percentage. A percentage of 0 disables the check.
+func IsDiskUnderPressure(diskStatus *longhorn.DiskStatus, diskPressurePercentage int64) bool {
+	if diskPressurePercentage <= 0 || diskStatus.StorageMaximum <= 0 {
+		return false
+	}
+	used := diskStatus.StorageMaximum - diskStatus.StorageAvailable
+	return used > diskStatus.StorageMaximum*diskPressurePercentage/100
+}
+
 // getNodesWithEvictingReplicas returns nodes that have replicas being evicted
 func getNodesWithEvictingReplicas(replicas map[string]*longhorn.Replica, nodeInfo map[string]*longhorn.Node) map[string]*longhorn.Node {
 	nodesWithEvictingReplicas := map[string]*longhorn.Node{}
@@ -147,26 +342,43 @@ func (rcs *ReplicaScheduler) getDiskCandidates(nodeInfo map[string]*longhorn.Nod
 	multiError := util.NewMultiError()
 
 	nodeSoftAntiAffinity, err :=
-		rcs.ds.GetSettingAsBool(types.SettingNameReplicaSoftAntiAffinity)
+		rcs.getSettingAsBool(types.SettingNameReplicaSoftAntiAffinity)
 	if err != nil {
 		logrus.Errorf("error getting replica soft anti-affinity setting: %v", err)
 	}
 
 	zoneSoftAntiAffinity, err :=
-		rcs.ds.GetSettingAsBool(types.SettingNameReplicaZoneSoftAntiAffinity)
+		rcs.getSettingAsBool(types.SettingNameReplicaZoneSoftAntiAffinity)
 	if err != nil {
 		logrus.Errorf("Error getting replica zone soft anti-affinity setting: %v", err)
 	}
 
+	diskSoftAntiAffinity, err :=
+		rcs.getSettingAsBool(types.SettingNameReplicaDiskSoftAntiAffinity)
+	if err != nil {
+		logrus.Errorf("Error getting replica disk soft anti-affinity setting: %v", err)
+	}
+
+	// The disks of all the given nodes are returned, so that the order among
+	// them is decided by the replica placement policy instead of the map order.
 	getDiskCandidatesFromNodes := func(nodes map[string]*longhorn.Node) (diskCandidates map[string]*Disk, multiError util.MultiError) {
+		diskCandidates = map[string]*Disk{}
 		multiError = util.NewMultiError()
 		for _, node := range nodes {
-			diskCandidates, errors := rcs.filterNodeDisksForReplica(node, nodeDisksMap[node.Name], replicas, volume, requireSchedulingCheck)
-			if len(diskCandidates) > 0 {
-				return diskCandidates, nil
+			disks, errors := rcs.filterNodeDisksForReplica(node, nodeDisksMap[node.Name], replicas, volume, requireSchedulingCheck)
+			if len(disks) == 0 {
+				multiError.Append(errors)
+				continue
 			}
-			multiError.Append(errors)
+			for diskUUID, disk := range disks {
+				diskCandidates[diskUUID] = disk
+			}
+		}
+		diskCandidates, errors := filterDisksByDiskAntiAffinity(diskCandidates, util.NewMultiError(), replicas, diskSoftAntiAffinity)
+		if len(diskCandidates) > 0 {
+			return diskCandidates, nil
 		}
+		multiError.Append(errors)
 		return map[string]*Disk{}, multiError
 	}
 
@@ -196,16 +408,44 @@ func (rcs *ReplicaScheduler) getDiskCandidates(nodeInfo map[string]*longhorn.Nod
 		return result
 	}
 
+	// Filter Nodes. If the Nodes don't match the tags, don't bother marking them as candidates.
+	nodesMatchingSelector := map[string]*longhorn.Node{}
+	for nodeName, node := range nodeInfo {
+		if rcs.checkTagsAreFulfilled(node.Spec.Tags, volume.Spec.NodeSelector) {
+			nodesMatchingSelector[nodeName] = node
+		}
+	}
+	if len(nodesMatchingSelector) == 0 {
+		multiError.Append(util.NewMultiError(longhorn.ErrorReplicaScheduleTagsNotFulfilled))
+		return map[string]*Disk{}, multiError
+	}
+	if volume.Spec.ReplicaPlacementPolicy == longhorn.ReplicaPlacementPolicyNodeSet {
+		placementNodes := map[string]bool{}
+		for _, nodeName := range volume.Spec.ReplicaPlacementNodes {
+			placementNodes[nodeName] = true
+		}
+		for nodeName := range nodesMatchingSelector {
+			if !placementNodes[nodeName] {
+				delete(nodesMatchingSelector, nodeName)
+			}
+		}
+		if len(nodesMatchingSelector) == 0 {
+			multiError.Append(util.NewMultiError(longhorn.ErrorReplicaSchedulePlacementPolicyNotSatisfied))
+			return map[string]*Disk{}, multiError
+		}
+	}
+	for nodeName := range usedNodes {
+		if _, ok := nodesMatchingSelector[nodeName]; !ok {
+			delete(usedNodes, nodeName)
+		}
+	}
+
 	unusedNodes := map[string]*longhorn.Node{}
 	unusedNodesInNewZones := map[string]*longhorn.Node{}
 	nodesInUnusedZones := map[string]*longhorn.Node{}
-	nodesWithEvictingReplicas := getNodesWithEvictingReplicas(replicas, nodeInfo)
+	nodesWithEvictingReplicas := getNodesWithEvictingReplicas(replicas, nodesMatchingSelector)
 
-	for nodeName, node := range nodeInfo {
-		// Filter Nodes. If the Nodes don't match the tags, don't bother marking them as candidates.
-		if !rcs.checkTagsAreFulfilled(node.Spec.Tags, volume.Spec.NodeSelector) {
-			continue
-		}
+	for nodeName, node := range nodesMatchingSelector {
 		if _, ok := usedNodes[nodeName]; !ok {
 			unusedNodes[nodeName] = node
 			if _, ok := usedZones[node.Status.Zone]; !ok {
@@ -281,6 +521,38 @@ func (rcs *ReplicaScheduler) getDiskCandidates(nodeInfo map[string]*longhorn.Nod
 	return map[string]*Disk{}, multiError
 }
 
+// filterDisksByDiskAntiAffinity prefers the disks that don't hold a replica of
+// the volume yet. The disks already holding a replica are kept only if the disk
+// soft anti-affinity is enabled and there is no other choice.
+func filterDisksByDiskAntiAffinity(disks map[string]*Disk, multiError util.MultiError, replicas map[string]*longhorn.Replica, diskSoftAntiAffinity bool) (map[string]*Disk, util.MultiError) {
+	if len(disks) == 0 {
+		return disks, multiError
+	}
+
+	usedDisks := map[string]bool{}
+	for _, r := range replicas {
+		if r.Spec.DiskID != "" && r.DeletionTimestamp == nil && r.Spec.FailedAt == "" {
+			usedDisks[r.Spec.DiskID] = true
+		}
+	}
+
+	unusedDisks := map[string]*Disk{}
+	for diskUUID, disk := range disks {
+		if !usedDisks[diskUUID] {
+			unusedDisks[diskUUID] = disk
+		}
+	}
+	if len(unusedDisks) > 0 {
+		return unusedDisks, multiError
+	}
+	if diskSoftAntiAffinity {
+		return disks, multiError
+	}
+
+	multiError.Append(util.NewMultiError(longhorn.ErrorReplicaScheduleHardDiskAntiAffinityNotSatisfied))
+	return map[string]*Disk{}, multiError
+}
+
 func (rcs *ReplicaScheduler) filterNodeDisksForReplica(node *longhorn.Node, disks map[string]struct{}, replicas map[string]*longhorn.Replica, volume *longhorn.Volume, requireSchedulingCheck bool) (preferredDisks map[string]*Disk, multiError util.MultiError) {
 	multiError = util.NewMultiError()
 	preferredDisks = map[string]*Disk{}
@@ -391,8 +663,7 @@ func (rcs *ReplicaScheduler) getNodeInfo() (map[string]*longhorn.Node, error) {
 	return scheduledNode, nil
 }
 
-func (rcs *ReplicaScheduler) scheduleReplicaToDisk(replica *longhorn.Replica, diskCandidates map[string]*Disk) {
-	disk := rcs.getDiskWithMostUsableStorage(diskCandidates)
+func (rcs *ReplicaScheduler) scheduleReplicaToDisk(replica *longhorn.Replica, disk *Disk) {
 	replica.Spec.NodeID = disk.NodeID
 	replica.Spec.DiskID = disk.DiskUUID
 	replica.Spec.DiskPath = disk.Path
@@ -406,25 +677,93 @@ func (rcs *ReplicaScheduler) scheduleReplicaToDisk(replica *longhorn.Replica, di
 	}).Debugf("Schedule replica to node %v", replica.Spec.NodeID)
 }
 
-func (rcs *ReplicaScheduler) getDiskWithMostUsableStorage(disks map[string]*Disk) *Disk {
-	diskWithMostUsableStorage := &Disk{}
-	for _, disk := range disks {
-		diskWithMostUsableStorage = disk
-		break
+// orderDiskCandidates returns the disk candidates in the order preferred by the
+// replica placement policy of the volume. Ties are broken by the disk UUID so
+// that the order of a policy is deterministic. Without a policy, the node is
+// picked randomly among the candidates so that concurrently scheduled replicas
+// don't pile onto the same disk, and the disk with the most usable storage of
+// the node comes first.
+func orderDiskCandidates(diskCandidates map[string]*Disk, replicas map[string]*longhorn.Replica, volume *longhorn.Volume, nodesInfo map[string]*longhorn.Node) []*Disk {
+	disks := []*Disk{}
+	for _, disk := range diskCandidates {
+		disks = append(disks, disk)
 	}
 
-	for _, disk := range disks {
-		diskWithMostStorageSize := diskWithMostUsableStorage.StorageAvailable - diskWithMostUsableStorage.StorageReserved
-		diskSize := disk.StorageAvailable - disk.StorageReserved
-		if diskWithMostStorageSize > diskSize {
-			continue
+	usableStorage := func(disk *Disk) int64 {
+		return disk.StorageAvailable - disk.StorageReserved
+	}
+	lessByUsableStorage := func(i, j int) bool {
+		if usableStorage(disks[i]) != usableStorage(disks[j]) {
+			return usableStorage(disks[i]) > usableStorage(disks[j])
 		}
-
-		diskWithMostUsableStorage = disk
+		return disks[i].DiskUUID < disks[j].DiskUUID
+	}
+
+	switch volume.Spec.ReplicaPlacementPolicy {
+	case longhorn.ReplicaPlacementPolicyBalanced, longhorn.ReplicaPlacementPolicyNodeSet:
+		sort.Slice(disks, lessByUsableStorage)
+	case longhorn.ReplicaPlacementPolicySpread:
+		sortDisksBySpread(disks, replicas, nodesInfo, lessByUsableStorage)
+	default:
+		nodeRank := map[string]int{}
+		for _, disk := range disks {
+			nodeRank[disk.NodeID] = 0
+		}
+		nodeIDs := util.GetSortedKeysFromMap(nodeRank)
+		for rank, i := range rand.Perm(len(nodeIDs)) {
+			nodeRank[nodeIDs[i]] = rank
+		}
+		sort.Slice(disks, func(i, j int) bool {
+			if ri, rj := nodeRank[disks[i].NodeID], nodeRank[disks[j].NodeID]; ri != rj {
+				return ri < rj
+			}
+			return lessByUsableStorage(i, j)
+		})
 	}
+	return disks
+}
 
-	return diskWithMostUsableStorage
+// sortDisksBySpread sorts the disks so that the zones and then the nodes not
+// holding a replica of the volume come first, followed by the nodes holding
+// fewer replicas
+func sortDisksBySpread(disks []*Disk, replicas map[string]*longhorn.Replica, nodesInfo map[string]*longhorn.Node, less func(i, j int) bool) {
+	usedNodes := map[string]bool{}
+	usedZones := map[string]bool{}
+	for _, r := range replicas {
+		if r.Spec.NodeID == "" || r.DeletionTimestamp != nil || r.Spec.FailedAt != "" {
+			continue
+		}
+		usedNodes[r.Spec.NodeID] = true
+		if node, ok := nodesInfo[r.Spec.NodeID]; ok {
+			usedZones[node.Status.Zone] = true
+		}
+	}
+	replicaCountPerNode := map[string]int{}
+	for nodeName, node := range nodesInfo {
+		for _, diskStatus := range node.Status.DiskStatus {
+			replicaCountPerNode[nodeName] += len(diskStatus.ScheduledReplica)
+		}
+	}
+	zoneOf := func(disk *Disk) string {
+		if node, ok := nodesInfo[disk.NodeID]; ok {
+			return node.Status.Zone
+		}
+		return ""
+	}
+	sort.Slice(disks, func(i, j int) bool {
+		if zi, zj := usedZones[zoneOf(disks[i])], usedZones[zoneOf(disks[j])]; zi != zj {
+			return !zi
+		}
+		if ni, nj := usedNodes[disks[i].NodeID], usedNodes[disks[j].NodeID]; ni != nj {
+			return !ni
+		}
+		if ci, cj := replicaCountPerNode[disks[i].NodeID], replicaCountPerNode[disks[j].NodeID]; ci != cj {
+			return ci < cj
+		}
+		return less(i, j)
+	})
 }
+
 func filterActiveReplicas(replicas map[string]*longhorn.Replica) map[string]*longhorn.Replica {
 	result := map[string]*longhorn.Replica{}
 	for _, r := range replicas {
@@ -507,9 +846,10 @@ func (rcs *ReplicaScheduler) RequireNewReplica(replicas map[string]*longhorn.Rep
 		return 0
 	}
 
+	rebuildRetryLimit := rcs.GetReplicaRebuildRetryLimit()
 	hasPotentiallyReusableReplica := false
 	for _, r := range replicas {
-		if IsPotentiallyReusableReplica(r, hardNodeAffinity) {
+		if IsPotentiallyReusableReplica(r, hardNodeAffinity, rebuildRetryLimit) {
 			hasPotentiallyReusableReplica = true
 			break
 		}
@@ -519,7 +859,7 @@ func (rcs *ReplicaScheduler) RequireNewReplica(replicas map[string]*longhorn.Rep
 	}
 
 	// Otherwise Longhorn will relay the new replica creation then there is a chance to reuse failed replicas later.
-	settingValue, err := rcs.ds.GetSettingAsInt(types.SettingNameReplicaReplenishmentWaitInterval)
+	settingValue, err := rcs.getSettingAsInt(types.SettingNameReplicaReplenishmentWaitInterval)
 	if err != nil {
 		logrus.Errorf("Failed to get Setting ReplicaReplenishmentWaitInterval, will directly replenish a new replica: %v", err)
 		return 0
@@ -548,7 +888,7 @@ func (rcs *ReplicaScheduler) isFailedReplicaReusable(r *longhorn.Replica, v *lon
 	if r.Spec.NodeID == "" || r.Spec.DiskID == "" {
 		return false
 	}
-	if r.Spec.RebuildRetryCount >= FailedReplicaMaxRetryCount {
+	if r.Spec.RebuildRetryCount >= rcs.GetReplicaRebuildRetryLimit() {
 		return false
 	}
 	if r.Status.EvictionRequested {
@@ -620,14 +960,14 @@ func (rcs *ReplicaScheduler) isFailedReplicaReusable(r *longhorn.Replica, v *lon
 
 // IsPotentiallyReusableReplica is used to check if a failed replica is potentially reusable.
 // A potentially reusable replica means this failed replica may be able to reuse it later but it’s not valid now due to node/disk down issue.
-func IsPotentiallyReusableReplica(r *longhorn.Replica, hardNodeAffinity string) bool {
+func IsPotentiallyReusableReplica(r *longhorn.Replica, hardNodeAffinity string, rebuildRetryLimit int) bool {
 	if r.Spec.FailedAt == "" {
 		return false
 	}
 	if r.Spec.NodeID == "" || r.Spec.DiskID == "" {
 		return false
 	}
-	if r.Spec.RebuildRetryCount >= FailedReplicaMaxRetryCount {
+	if r.Spec.RebuildRetryCount >= rebuildRetryLimit {
 		return false
 	}
 	if r.Status.EvictionRequested {
@@ -668,6 +1008,19 @@ func (rcs *ReplicaScheduler) IsSchedulableToDisk(size int64, requiredStorage int
 		(size+info.StorageScheduled) <= int64(float64(info.StorageMaximum-info.StorageReserved)*float64(info.OverProvisioningPercentage)/100)
 }
 
+// GetDiskStorageSchedulable returns the size that can still be scheduled to
+// the disk by new replicas, following the same math as IsSchedulableToDisk.
+func (rcs *ReplicaScheduler) GetDiskStorageSchedulable(info *DiskSchedulingInfo) int64 {
+	if !rcs.IsSchedulableToDisk(0, 0, info) {
+		return 0
+	}
+	schedulable := int64(float64(info.StorageMaximum-info.StorageReserved)*float64(info.OverProvisioningPercentage)/100) - info.StorageScheduled
+	if schedulable < 0 {
+		return 0
+	}
+	return schedulable
+}
+
 func (rcs *ReplicaScheduler) isDiskNotFull(info *DiskSchedulingInfo) bool {
 	// StorageAvailable = the space can be used by 3rd party or Longhorn system.
 	return info.StorageMaximum > 0 && info.StorageAvailable > 0 &&
@@ -676,11 +1029,11 @@ func (rcs *ReplicaScheduler) isDiskNotFull(info *DiskSchedulingInfo) bool {
 
 func (rcs *ReplicaScheduler) GetDiskSchedulingInfo(disk longhorn.DiskSpec, diskStatus *longhorn.DiskStatus) (*DiskSchedulingInfo, error) {
 	// get StorageOverProvisioningPercentage and StorageMinimalAvailablePercentage settings
-	overProvisioningPercentage, err := rcs.ds.GetSettingAsInt(types.SettingNameStorageOverProvisioningPercentage)
+	overProvisioningPercentage, err := rcs.getSettingAsInt(types.SettingNameStorageOverProvisioningPercentage)
 	if err != nil {
 		return nil, err
 	}
-	minimalAvailablePercentage, err := rcs.ds.GetSettingAsInt(types.SettingNameStorageMinimalAvailablePercentage)
+	minimalAvailablePercentage, err := rcs.getSettingAsInt(types.SettingNameStorageMinimalAvailablePercentage)
 	if err != nil {
 		return nil, err
 	}
@@ -749,3 +1102,200 @@ func findDiskSpecAndDiskStatusInNode(diskUUID string, node *longhorn.Node) (long
 	}
 	return longhorn.DiskSpec{}, longhorn.DiskStatus{}, false
 }
+
+// ReplicaPlacement is the placement decision the scheduler would make for a
+// replica in a dry run
+type ReplicaPlacement struct {
+	VolumeName     string
+	ReplicaName    string
+	CurrentNodeID  string
+	CurrentDiskID  string
+	ProposedNodeID string
+	ProposedDiskID string
+	Reason         string
+}
+
+// DryRunScheduleReplicas returns the placement decisions the scheduler would
+// make for the replicas of all volumes if the given setting values were
+// applied. The replicas are evaluated one by one in name order, and every
+// proposal is taken into account when evaluating the following replicas so
+// that the proposals don't contradict each other. Neither the settings nor the
+// replicas are mutated.
+func (rcs *ReplicaScheduler) DryRunScheduleReplicas(settingOverrides map[types.SettingName]string) ([]*ReplicaPlacement, error) {
+	for name, value := range settingOverrides {
+		if err := types.ValidateSetting(string(name), value); err != nil {
+			return nil, err
+		}
+	}
+	dryRun := &ReplicaScheduler{
+		ds:               rcs.ds,
+		settingOverrides: settingOverrides,
+	}
+
+	nodesInfo, err := dryRun.getNodeInfo()
+	if err != nil {
+		return nil, err
+	}
+	volumes, err := rcs.ds.ListVolumesRO()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(volumes, func(i, j int) bool {
+		return volumes[i].Name < volumes[j].Name
+	})
+
+	placements := []*ReplicaPlacement{}
+	for _, v := range volumes {
+		replicas, err := rcs.ds.ListVolumeReplicas(v.Name)
+		if err != nil {
+			return nil, err
+		}
+		// The proposed replicas are the copies the proposals are applied to
+		proposedReplicas := map[string]*longhorn.Replica{}
+		replicaNames := []string{}
+		for name, r := range replicas {
+			proposedReplicas[name] = r.DeepCopy()
+			replicaNames = append(replicaNames, name)
+		}
+		sort.Strings(replicaNames)
+
+		for _, name := range replicaNames {
+			r := proposedReplicas[name]
+			if r.DeletionTimestamp != nil {
+				continue
+			}
+			placement, err := dryRun.dryRunScheduleReplica(r, proposedReplicas, v, nodesInfo)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to dry run the scheduling of replica %v", r.Name)
+			}
+			placements = append(placements, placement)
+
+			if placement.ProposedNodeID == "" || placement.ProposedDiskID == r.Spec.DiskID {
+				continue
+			}
+			nodesInfo = reserveReplicaOnNodes(releaseReplicaFromNodes(nodesInfo, r), r, placement.ProposedNodeID, placement.ProposedDiskID)
+			r.Spec.NodeID = placement.ProposedNodeID
+			r.Spec.DiskID = placement.ProposedDiskID
+		}
+	}
+	return placements, nil
+}
+
+func (rcs *ReplicaScheduler) dryRunScheduleReplica(r *longhorn.Replica, replicas map[string]*longhorn.Replica, v *longhorn.Volume, nodesInfo map[string]*longhorn.Node) (*ReplicaPlacement, error) {
+	placement := &ReplicaPlacement{
+		VolumeName:    v.Name,
+		ReplicaName:   r.Name,
+		CurrentNodeID: r.Spec.NodeID,
+		CurrentDiskID: r.Spec.DiskID,
+	}
+
+	if r.Spec.FailedAt != "" {
+		placement.ProposedNodeID = r.Spec.NodeID
+		placement.ProposedDiskID = r.Spec.DiskID
+		placement.Reason = "failed replica is not rescheduled"
+		return placement, nil
+	}
+
+	// Without a replica placement policy the scheduler picks a random node,
+	// so the disks are ordered as the balanced policy does to keep the
+	// proposals stable.
+	if v.Spec.ReplicaPlacementPolicy == "" {
+		v = v.DeepCopy()
+		v.Spec.ReplicaPlacementPolicy = longhorn.ReplicaPlacementPolicyBalanced
+	}
+
+	// Evaluate the replica as if it were the one being scheduled, so it
+	// doesn't count against its own node, zone and disk.
+	otherReplicas := map[string]*longhorn.Replica{}
+	for name, replica := range replicas {
+		if name != r.Name {
+			otherReplicas[name] = replica
+		}
+	}
+	schedulingReplica := r.DeepCopy()
+	schedulingReplica.Spec.NodeID = ""
+	schedulingReplica.Spec.DiskID = ""
+	schedulingReplica.Spec.DiskPath = ""
+
+	diskCandidates, multiError, err := rcs.findDiskCandidates(schedulingReplica, otherReplicas, v, releaseReplicaFromNodes(nodesInfo, r))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(diskCandidates) == 0 {
+		placement.Reason = fmt.Sprintf("no disk fulfills the scheduling requirements: %v", multiError.Join())
+		return placement, nil
+	}
+	orderedDisks := orderDiskCandidates(diskCandidates, otherReplicas, v, nodesInfo)
+	for _, disk := range orderedDisks {
+		if disk.DiskUUID == r.Spec.DiskID && r.Spec.NodeID != "" {
+			placement.ProposedNodeID = r.Spec.NodeID
+			placement.ProposedDiskID = r.Spec.DiskID
+			placement.Reason = "current placement fulfills the scheduling requirements"
+			return placement, nil
+		}
+	}
+
+	disk := orderedDisks[0]
+	placement.ProposedNodeID = disk.NodeID
+	placement.ProposedDiskID = disk.DiskUUID
+	if r.Spec.NodeID == "" {
+		placement.Reason = "replica is not scheduled yet"
+	} else {
+		placement.Reason = "current placement is not preferred by the scheduling requirements"
+	}
+	return placement, nil
+}
+
+// reserveReplicaOnNodes returns the node info with the storage scheduled for
+// the replica on the given disk
+func reserveReplicaOnNodes(nodesInfo map[string]*longhorn.Node, r *longhorn.Replica, nodeID, diskUUID string) map[string]*longhorn.Node {
+	node, ok := nodesInfo[nodeID]
+	if !ok {
+		return nodesInfo
+	}
+
+	node = node.DeepCopy()
+	for _, diskStatus := range node.Status.DiskStatus {
+		if diskStatus.DiskUUID != diskUUID {
+			continue
+		}
+		if diskStatus.ScheduledReplica == nil {
+			diskStatus.ScheduledReplica = map[string]int64{}
+		}
+		diskStatus.ScheduledReplica[r.Name] = r.Spec.VolumeSize
+		diskStatus.StorageScheduled += r.Spec.VolumeSize
+	}
+
+	result := map[string]*longhorn.Node{}
+	for name, n := range nodesInfo {
+		result[name] = n
+	}
+	result[node.Name] = node
+	return result
+}
+
+// releaseReplicaFromNodes returns the node info with the storage scheduled
+// for the replica released from its disk
+func releaseReplicaFromNodes(nodesInfo map[string]*longhorn.Node, r *longhorn.Replica) map[string]*longhorn.Node {
+	node, ok := nodesInfo[r.Spec.NodeID]
+	if !ok {
+		return nodesInfo
+	}
+
+	node = node.DeepCopy()
+	for _, diskStatus := range node.Status.DiskStatus {
+		if size, ok := diskStatus.ScheduledReplica[r.Name]; ok {
+			delete(diskStatus.ScheduledReplica, r.Name)
+			diskStatus.StorageScheduled -= size
+		}
+	}
+
+	result := map[string]*longhorn.Node{}
+	for name, n := range nodesInfo {
+		result[name] = n
+	}
+	result[node.Name] = node
+	return result
+}
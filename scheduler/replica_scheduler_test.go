@@ -3,9 +3,11 @@ package scheduler
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/client-go/informers"
@@ -32,6 +34,9 @@ const (
 	TestNode1     = "test-node-name-1"
 	TestNode2     = "test-node-name-2"
 	TestNode3     = "test-node-name-3"
+	TestNode4     = "test-node-name-4"
+	TestZone1     = "test-zone-1"
+	TestZone2     = "test-zone-2"
 
 	TestOwnerID1    = TestNode1
 	TestEngineImage = "longhorn-engine:latest"
@@ -55,11 +60,14 @@ const (
 var longhornFinalizerKey = longhorn.SchemeGroupVersion.Group
 
 func newReplicaScheduler(lhInformerFactory lhinformerfactory.SharedInformerFactory, kubeInformerFactory informers.SharedInformerFactory,
-	lhClient *lhfake.Clientset, kubeClient *fake.Clientset, extensionsClient *apiextensionsfake.Clientset) *ReplicaScheduler {
+	lhClient *lhfake.Clientset, kubeClient *fake.Clientset, extensionsClient *apiextensionsfake.Clientset) (*ReplicaScheduler, error) {
 	fmt.Printf("testing NewReplicaScheduler\n")
 
-	ds := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
-	return NewReplicaScheduler(ds)
+	ds, err := datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, TestNamespace)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplicaScheduler(ds), nil
 }
 
 func newDaemonPod(phase v1.PodPhase, name, namespace, nodeID, podIP string) *v1.Pod {
@@ -99,6 +107,33 @@ func newNode(name, namespace string, allowScheduling bool, status longhorn.Condi
 	}
 }
 
+func newNodeInZoneWithSchedulableDisk(name, zone string) *longhorn.Node {
+	node := newNode(name, TestNamespace, true, longhorn.ConditionStatusTrue)
+	node.Status.Zone = zone
+	node.Spec.Disks = map[string]longhorn.DiskSpec{
+		getDiskID(name, "1"): newDisk(TestDefaultDataPath, true, 0),
+	}
+	node.Status.DiskStatus = map[string]*longhorn.DiskStatus{
+		getDiskID(name, "1"): {
+			StorageAvailable: TestDiskAvailableSize,
+			StorageScheduled: 0,
+			StorageMaximum:   TestDiskSize,
+			Conditions: []longhorn.Condition{
+				newCondition(longhorn.DiskConditionTypeSchedulable, longhorn.ConditionStatusTrue),
+			},
+			DiskUUID: getDiskID(name, "1"),
+		},
+	}
+	return node
+}
+
+func setDiskTags(node *longhorn.Node, tags []string) {
+	for name, disk := range node.Spec.Disks {
+		disk.Tags = tags
+		node.Spec.Disks[name] = disk
+	}
+}
+
 func newEngineImage(image string, state longhorn.EngineImageState) *longhorn.EngineImage {
 	return &longhorn.EngineImage{
 		ObjectMeta: metav1.ObjectMeta{
@@ -229,6 +264,7 @@ type ReplicaSchedulerTestCase struct {
 	storageOverProvisioningPercentage string
 	storageMinimalAvailablePercentage string
 	replicaNodeSoftAntiAffinity       string
+	replicaZoneSoftAntiAffinity       string
 
 	// schedule state
 	expectedNodes map[string]*longhorn.Node
@@ -718,6 +754,143 @@ func (s *TestSuite) TestReplicaScheduler(c *C) {
 	tc.isNilReplica = false
 	testCases["schedule to disk with the most usable storage"] = tc
 
+	// Test zone anti-affinity, replicas should spread across zones even if
+	// the node soft anti-affinity allows co-location
+	tc = generateSchedulerTestCase()
+	daemon1 = newDaemonPod(v1.PodRunning, TestDaemon1, TestNamespace, TestNode1, TestIP1)
+	daemon2 = newDaemonPod(v1.PodRunning, TestDaemon2, TestNamespace, TestNode2, TestIP2)
+	tc.daemons = []*v1.Pod{
+		daemon1,
+		daemon2,
+	}
+	node1 = newNodeInZoneWithSchedulableDisk(TestNode1, TestZone1)
+	tc.engineImage.Status.NodeDeploymentMap[node1.Name] = true
+	node2 = newNodeInZoneWithSchedulableDisk(TestNode2, TestZone2)
+	tc.engineImage.Status.NodeDeploymentMap[node2.Name] = true
+	tc.nodes = map[string]*longhorn.Node{
+		TestNode1: node1,
+		TestNode2: node2,
+	}
+	tc.expectedNodes = map[string]*longhorn.Node{
+		TestNode1: node1,
+		TestNode2: node2,
+	}
+	tc.err = false
+	tc.isNilReplica = false
+	tc.replicaNodeSoftAntiAffinity = "true"
+	tc.replicaZoneSoftAntiAffinity = "false"
+	testCases["schedule replicas to different zones"] = tc
+
+	// Test zone soft anti-affinity, replicas should fall back to the same
+	// zone when there are fewer zones than replicas
+	tc = generateSchedulerTestCase()
+	daemon1 = newDaemonPod(v1.PodRunning, TestDaemon1, TestNamespace, TestNode1, TestIP1)
+	daemon2 = newDaemonPod(v1.PodRunning, TestDaemon2, TestNamespace, TestNode2, TestIP2)
+	tc.daemons = []*v1.Pod{
+		daemon1,
+		daemon2,
+	}
+	node1 = newNodeInZoneWithSchedulableDisk(TestNode1, TestZone1)
+	tc.engineImage.Status.NodeDeploymentMap[node1.Name] = true
+	node2 = newNodeInZoneWithSchedulableDisk(TestNode2, TestZone1)
+	tc.engineImage.Status.NodeDeploymentMap[node2.Name] = true
+	tc.nodes = map[string]*longhorn.Node{
+		TestNode1: node1,
+		TestNode2: node2,
+	}
+	tc.expectedNodes = map[string]*longhorn.Node{
+		TestNode1: node1,
+		TestNode2: node2,
+	}
+	tc.err = false
+	tc.isNilReplica = false
+	tc.replicaNodeSoftAntiAffinity = "false"
+	tc.replicaZoneSoftAntiAffinity = "true"
+	testCases["schedule replicas to the same zone with zone soft anti-affinity"] = tc
+
+	// Test disk selector, replicas should only be scheduled to the disks
+	// whose tags are a superset of the volume disk selector
+	tc = generateSchedulerTestCase()
+	tc.volume.Spec.DiskSelector = []string{"ssd"}
+	daemon1 = newDaemonPod(v1.PodRunning, TestDaemon1, TestNamespace, TestNode1, TestIP1)
+	daemon2 = newDaemonPod(v1.PodRunning, TestDaemon2, TestNamespace, TestNode2, TestIP2)
+	daemon3 = newDaemonPod(v1.PodRunning, TestDaemon3, TestNamespace, TestNode3, TestIP3)
+	tc.daemons = []*v1.Pod{
+		daemon1,
+		daemon2,
+		daemon3,
+	}
+	node1 = newNodeInZoneWithSchedulableDisk(TestNode1, "")
+	setDiskTags(node1, []string{"ssd"})
+	tc.engineImage.Status.NodeDeploymentMap[node1.Name] = true
+	node2 = newNodeInZoneWithSchedulableDisk(TestNode2, "")
+	setDiskTags(node2, []string{"hdd"})
+	tc.engineImage.Status.NodeDeploymentMap[node2.Name] = true
+	node3 = newNodeInZoneWithSchedulableDisk(TestNode3, "")
+	setDiskTags(node3, []string{"fast", "ssd"})
+	tc.engineImage.Status.NodeDeploymentMap[node3.Name] = true
+	tc.nodes = map[string]*longhorn.Node{
+		TestNode1: node1,
+		TestNode2: node2,
+		TestNode3: node3,
+	}
+	tc.expectedNodes = map[string]*longhorn.Node{
+		TestNode1: node1,
+		TestNode3: node3,
+	}
+	tc.err = false
+	tc.isNilReplica = false
+	tc.replicaNodeSoftAntiAffinity = "false"
+	testCases["schedule replicas to disks matching the disk selector"] = tc
+
+	// Test node selector, replicas should only be scheduled to the nodes
+	// whose tags are a superset of the volume node selector
+	tc = generateSchedulerTestCase()
+	tc.volume.Spec.NodeSelector = []string{"gold"}
+	daemon1 = newDaemonPod(v1.PodRunning, TestDaemon1, TestNamespace, TestNode1, TestIP1)
+	daemon2 = newDaemonPod(v1.PodRunning, TestDaemon2, TestNamespace, TestNode2, TestIP2)
+	tc.daemons = []*v1.Pod{
+		daemon1,
+		daemon2,
+	}
+	node1 = newNodeInZoneWithSchedulableDisk(TestNode1, "")
+	node1.Spec.Tags = []string{"gold"}
+	tc.engineImage.Status.NodeDeploymentMap[node1.Name] = true
+	node2 = newNodeInZoneWithSchedulableDisk(TestNode2, "")
+	node2.Spec.Tags = []string{"silver"}
+	tc.engineImage.Status.NodeDeploymentMap[node2.Name] = true
+	tc.nodes = map[string]*longhorn.Node{
+		TestNode1: node1,
+		TestNode2: node2,
+	}
+	tc.expectedNodes = map[string]*longhorn.Node{
+		TestNode1: node1,
+	}
+	tc.err = false
+	tc.isNilReplica = false
+	tc.replicaNodeSoftAntiAffinity = "true"
+	testCases["schedule replicas to nodes matching the node selector"] = tc
+
+	// Test node selector, replicas should not be scheduled when no node
+	// fulfills the volume node selector
+	tc = generateSchedulerTestCase()
+	tc.volume.Spec.NodeSelector = []string{"gold"}
+	daemon1 = newDaemonPod(v1.PodRunning, TestDaemon1, TestNamespace, TestNode1, TestIP1)
+	tc.daemons = []*v1.Pod{
+		daemon1,
+	}
+	node1 = newNodeInZoneWithSchedulableDisk(TestNode1, "")
+	node1.Spec.Tags = []string{"silver"}
+	tc.engineImage.Status.NodeDeploymentMap[node1.Name] = true
+	tc.nodes = map[string]*longhorn.Node{
+		TestNode1: node1,
+	}
+	tc.expectedNodes = map[string]*longhorn.Node{}
+	tc.err = false
+	tc.isNilReplica = true
+	tc.replicaNodeSoftAntiAffinity = "true"
+	testCases["there's no node matching the node selector"] = tc
+
 	for name, tc := range testCases {
 		fmt.Printf("testing %v\n", name)
 
@@ -736,7 +909,8 @@ func (s *TestSuite) TestReplicaScheduler(c *C) {
 		sIndexer := lhInformerFactory.Longhorn().V1beta2().Settings().Informer().GetIndexer()
 		pIndexer := kubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
 
-		s := newReplicaScheduler(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient, extensionsClient)
+		s, err := newReplicaScheduler(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient, extensionsClient)
+		c.Assert(err, IsNil)
 		// create daemon pod
 		for _, daemon := range tc.daemons {
 			p, err := kubeClient.CoreV1().Pods(TestNamespace).Create(context.TODO(), daemon, metav1.CreateOptions{})
@@ -789,6 +963,17 @@ func (s *TestSuite) TestReplicaScheduler(c *C) {
 			err = sIndexer.Add(setting)
 			c.Assert(err, IsNil)
 		}
+		// Set replica zone soft anti-affinity setting
+		if tc.replicaZoneSoftAntiAffinity != "" {
+			s := initSettings(
+				string(types.SettingNameReplicaZoneSoftAntiAffinity),
+				tc.replicaZoneSoftAntiAffinity)
+			setting, err :=
+				lhClient.LonghornV1beta2().Settings(TestNamespace).Create(context.TODO(), s, metav1.CreateOptions{})
+			c.Assert(err, IsNil)
+			err = sIndexer.Add(setting)
+			c.Assert(err, IsNil)
+		}
 		// validate scheduler
 		for _, replica := range tc.replicas {
 			r, err := lhClient.LonghornV1beta2().Replicas(TestNamespace).Create(context.TODO(), replica, metav1.CreateOptions{})
@@ -824,3 +1009,664 @@ func (s *TestSuite) TestReplicaScheduler(c *C) {
 		c.Assert(len(tc.expectedNodes), Equals, 0)
 	}
 }
+
+func (s *TestSuite) TestIsDiskUnderPressure(c *C) {
+	type testCase struct {
+		storageMaximum         int64
+		storageAvailable       int64
+		diskPressurePercentage int64
+
+		expectedUnderPressure bool
+	}
+	testCases := map[string]testCase{
+		"disabled": {
+			storageMaximum:         100,
+			storageAvailable:       0,
+			diskPressurePercentage: 0,
+			expectedUnderPressure:  false,
+		},
+		"usage below the threshold": {
+			storageMaximum:         100,
+			storageAvailable:       30,
+			diskPressurePercentage: 80,
+			expectedUnderPressure:  false,
+		},
+		"usage equal to the threshold": {
+			storageMaximum:         100,
+			storageAvailable:       20,
+			diskPressurePercentage: 80,
+			expectedUnderPressure:  false,
+		},
+		"usage above the threshold": {
+			storageMaximum:         100,
+			storageAvailable:       10,
+			diskPressurePercentage: 80,
+			expectedUnderPressure:  true,
+		},
+		"unknown disk capacity": {
+			storageMaximum:         0,
+			storageAvailable:       0,
+			diskPressurePercentage: 80,
+			expectedUnderPressure:  false,
+		},
+	}
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+		diskStatus := &longhorn.DiskStatus{
+			StorageMaximum:   tc.storageMaximum,
+			StorageAvailable: tc.storageAvailable,
+		}
+		c.Assert(IsDiskUnderPressure(diskStatus, tc.diskPressurePercentage), Equals, tc.expectedUnderPressure, Commentf("test case: %v", name))
+	}
+}
+
+func (s *TestSuite) TestDryRunScheduleReplicas(c *C) {
+	kept := "current placement fulfills the scheduling requirements"
+	moved := "current placement is not preferred by the scheduling requirements"
+	unschedulable := "no disk fulfills the scheduling requirements: "
+
+	type testCase struct {
+		nodes            []string
+		replicaNodes     []string
+		settingOverrides map[types.SettingName]string
+
+		// The expectations are in the replica order
+		expectedNodes   []string
+		expectedReasons []string
+		err             bool
+	}
+	testCases := map[string]testCase{
+		"replicas spread across nodes are kept": {
+			nodes:           []string{TestNode1, TestNode2, TestNode3},
+			replicaNodes:    []string{TestNode1, TestNode2, TestNode3},
+			expectedNodes:   []string{TestNode1, TestNode2, TestNode3},
+			expectedReasons: []string{kept, kept, kept},
+		},
+		"replicas on the same node are moved": {
+			nodes:           []string{TestNode1, TestNode2, TestNode3},
+			replicaNodes:    []string{TestNode1, TestNode1},
+			expectedNodes:   []string{TestNode2, TestNode1},
+			expectedReasons: []string{moved, kept},
+		},
+		"replicas on the same node are moved to different nodes": {
+			nodes:           []string{TestNode1, TestNode2, TestNode3},
+			replicaNodes:    []string{TestNode1, TestNode1, TestNode1},
+			expectedNodes:   []string{TestNode2, TestNode3, TestNode1},
+			expectedReasons: []string{moved, moved, kept},
+		},
+		"replicas on the same node violate the hard node anti-affinity": {
+			nodes:           []string{TestNode1},
+			replicaNodes:    []string{TestNode1, TestNode1},
+			expectedNodes:   []string{"", ""},
+			expectedReasons: []string{unschedulable, unschedulable},
+		},
+		"replicas on the same node are kept with the soft node anti-affinity": {
+			nodes:        []string{TestNode1},
+			replicaNodes: []string{TestNode1, TestNode1},
+			settingOverrides: map[types.SettingName]string{
+				types.SettingNameReplicaSoftAntiAffinity: "true",
+			},
+			expectedNodes:   []string{TestNode1, TestNode1},
+			expectedReasons: []string{kept, kept},
+		},
+		"invalid setting value": {
+			nodes:        []string{TestNode1},
+			replicaNodes: []string{TestNode1, TestNode1},
+			settingOverrides: map[types.SettingName]string{
+				types.SettingNameReplicaSoftAntiAffinity: "invalid",
+			},
+			err: true,
+		},
+	}
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		kubeClient := fake.NewSimpleClientset()
+		kubeInformerFactory := informers.NewSharedInformerFactory(kubeClient, controller.NoResyncPeriodFunc())
+
+		lhClient := lhfake.NewSimpleClientset()
+		lhInformerFactory := lhinformerfactory.NewSharedInformerFactory(lhClient, controller.NoResyncPeriodFunc())
+
+		extensionsClient := apiextensionsfake.NewSimpleClientset()
+
+		vIndexer := lhInformerFactory.Longhorn().V1beta2().Volumes().Informer().GetIndexer()
+		rIndexer := lhInformerFactory.Longhorn().V1beta2().Replicas().Informer().GetIndexer()
+		nIndexer := lhInformerFactory.Longhorn().V1beta2().Nodes().Informer().GetIndexer()
+		eiIndexer := lhInformerFactory.Longhorn().V1beta2().EngineImages().Informer().GetIndexer()
+
+		rcs, err := newReplicaScheduler(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient, extensionsClient)
+		c.Assert(err, IsNil)
+
+		engineImage := newEngineImage(TestEngineImage, longhorn.EngineImageStateDeployed)
+		engineImage.Namespace = TestNamespace
+		for _, nodeName := range tc.nodes {
+			node := newNodeInZoneWithSchedulableDisk(nodeName, TestZone1)
+			node.Namespace = TestNamespace
+			c.Assert(nIndexer.Add(node), IsNil)
+			engineImage.Status.NodeDeploymentMap[nodeName] = true
+		}
+		c.Assert(eiIndexer.Add(engineImage), IsNil)
+
+		v := newVolume(TestVolumeName, len(tc.replicaNodes))
+		v.Namespace = TestNamespace
+		c.Assert(vIndexer.Add(v), IsNil)
+		currentNodes := map[string]string{}
+		for i, nodeName := range tc.replicaNodes {
+			r := newReplicaForVolume(v)
+			r.Name = fmt.Sprintf("%v-r-%d", v.Name, i)
+			r.Namespace = TestNamespace
+			r.Spec.NodeID = nodeName
+			r.Spec.DiskID = getDiskID(nodeName, "1")
+			r.Spec.DiskPath = TestDefaultDataPath
+			c.Assert(rIndexer.Add(r), IsNil)
+			currentNodes[r.Name] = nodeName
+		}
+
+		placements, err := rcs.DryRunScheduleReplicas(tc.settingOverrides)
+		if tc.err {
+			c.Assert(err, NotNil, Commentf("test case: %v", name))
+			continue
+		}
+		c.Assert(err, IsNil, Commentf("test case: %v", name))
+		c.Assert(placements, HasLen, len(tc.replicaNodes), Commentf("test case: %v", name))
+		for i, p := range placements {
+			c.Assert(p.VolumeName, Equals, TestVolumeName)
+			c.Assert(p.ReplicaName, Equals, fmt.Sprintf("%v-r-%d", TestVolumeName, i))
+			c.Assert(p.CurrentNodeID, Equals, currentNodes[p.ReplicaName])
+			c.Assert(p.ProposedNodeID, Equals, tc.expectedNodes[i], Commentf("test case: %v, replica: %v", name, p.ReplicaName))
+			c.Assert(strings.HasPrefix(p.Reason, tc.expectedReasons[i]), Equals, true, Commentf("test case: %v, reason: %v", name, p.Reason))
+		}
+
+		// the result doesn't depend on the iteration order of the maps
+		for i := 0; i < 10; i++ {
+			again, err := rcs.DryRunScheduleReplicas(tc.settingOverrides)
+			c.Assert(err, IsNil, Commentf("test case: %v", name))
+			c.Assert(again, DeepEquals, placements, Commentf("test case: %v", name))
+		}
+
+		// the replicas are left untouched
+		replicas, err := rcs.ds.ListVolumeReplicas(TestVolumeName)
+		c.Assert(err, IsNil)
+		for _, r := range replicas {
+			c.Assert(r.Spec.NodeID, Equals, currentNodes[r.Name])
+		}
+	}
+}
+
+func newKubeNode(name string, memoryPressure bool) *v1.Node {
+	status := v1.ConditionFalse
+	if memoryPressure {
+		status = v1.ConditionTrue
+	}
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Status: v1.NodeStatus{
+			Allocatable: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("2"),
+				v1.ResourceMemory: resource.MustParse("4Gi"),
+			},
+			Conditions: []v1.NodeCondition{
+				{
+					Type:   v1.NodeMemoryPressure,
+					Status: status,
+				},
+			},
+		},
+	}
+}
+
+func newPodWithRequests(name, nodeID, cpu, memory string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: TestNamespace,
+		},
+		Spec: v1.PodSpec{
+			NodeName: nodeID,
+			Containers: []v1.Container{
+				{
+					Name: name,
+					Resources: v1.ResourceRequirements{
+						Requests: v1.ResourceList{
+							v1.ResourceCPU:    resource.MustParse(cpu),
+							v1.ResourceMemory: resource.MustParse(memory),
+						},
+					},
+				},
+			},
+		},
+		Status: v1.PodStatus{
+			Phase: v1.PodRunning,
+		},
+	}
+}
+
+func (s *TestSuite) TestScheduleReplicaRespectNodePressure(c *C) {
+	podWithInitContainer := newPodWithRequests("pod-1", TestNode1, "100m", "100Mi")
+	podWithInitContainer.Spec.InitContainers = []v1.Container{
+		{
+			Name: "init",
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("1900m"),
+					v1.ResourceMemory: resource.MustParse("100Mi"),
+				},
+			},
+		},
+	}
+
+	type testCase struct {
+		respectNodePressure string
+		requestPercentage   string
+		nodes               []string
+		memoryPressureNodes []string
+		pods                []*v1.Pod
+		fullNodes           []string
+
+		expectedNode   string
+		expectedReason string
+	}
+	testCases := map[string]testCase{
+		"node pressure is ignored if the setting is disabled": {
+			respectNodePressure: "false",
+			nodes:               []string{TestNode1, TestNode2},
+			memoryPressureNodes: []string{TestNode1},
+			fullNodes:           []string{TestNode1, TestNode2},
+			expectedReason:      longhorn.ErrorReplicaScheduleInsufficientStorage,
+		},
+		"node under memory pressure is deprioritized": {
+			respectNodePressure: "true",
+			nodes:               []string{TestNode1, TestNode2},
+			memoryPressureNodes: []string{TestNode1},
+			expectedNode:        TestNode2,
+		},
+		"node with pod requests exceeding allocatable is deprioritized": {
+			respectNodePressure: "true",
+			nodes:               []string{TestNode1, TestNode2},
+			pods: []*v1.Pod{
+				newPodWithRequests("pod-1", TestNode1, "1", "3Gi"),
+				newPodWithRequests("pod-2", TestNode1, "500m", "2Gi"),
+				newPodWithRequests("pod-3", TestNode2, "1", "3Gi"),
+			},
+			expectedNode: TestNode2,
+		},
+		"node with pod requests above the request percentage is deprioritized": {
+			respectNodePressure: "true",
+			nodes:               []string{TestNode1, TestNode2},
+			pods: []*v1.Pod{
+				newPodWithRequests("pod-1", TestNode1, "1900m", "1Gi"),
+			},
+			expectedNode: TestNode2,
+		},
+		"node with pod requests below the customized request percentage is used": {
+			respectNodePressure: "true",
+			requestPercentage:   "100",
+			nodes:               []string{TestNode1, TestNode2},
+			pods: []*v1.Pod{
+				newPodWithRequests("pod-1", TestNode1, "1900m", "1Gi"),
+				newPodWithRequests("pod-2", TestNode2, "1", "3Gi"),
+				newPodWithRequests("pod-3", TestNode2, "500m", "2Gi"),
+			},
+			expectedNode: TestNode1,
+		},
+		"node with init container requests above the request percentage is deprioritized": {
+			respectNodePressure: "true",
+			nodes:               []string{TestNode1, TestNode2},
+			pods:                []*v1.Pod{podWithInitContainer},
+			expectedNode:        TestNode2,
+		},
+		"node under memory pressure is used if no other node is available": {
+			respectNodePressure: "true",
+			nodes:               []string{TestNode1},
+			memoryPressureNodes: []string{TestNode1},
+			expectedNode:        TestNode1,
+		},
+		"scheduling reason mentions the node pressure": {
+			respectNodePressure: "true",
+			nodes:               []string{TestNode1, TestNode2},
+			memoryPressureNodes: []string{TestNode1},
+			fullNodes:           []string{TestNode1, TestNode2},
+			expectedReason:      longhorn.ErrorReplicaScheduleNodeUnderPressure,
+		},
+	}
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		kubeClient := fake.NewSimpleClientset()
+		kubeInformerFactory := informers.NewSharedInformerFactory(kubeClient, controller.NoResyncPeriodFunc())
+
+		lhClient := lhfake.NewSimpleClientset()
+		lhInformerFactory := lhinformerfactory.NewSharedInformerFactory(lhClient, controller.NoResyncPeriodFunc())
+
+		extensionsClient := apiextensionsfake.NewSimpleClientset()
+
+		nIndexer := lhInformerFactory.Longhorn().V1beta2().Nodes().Informer().GetIndexer()
+		eiIndexer := lhInformerFactory.Longhorn().V1beta2().EngineImages().Informer().GetIndexer()
+		sIndexer := lhInformerFactory.Longhorn().V1beta2().Settings().Informer().GetIndexer()
+		knIndexer := kubeInformerFactory.Core().V1().Nodes().Informer().GetIndexer()
+		pIndexer := kubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+
+		rcs, err := newReplicaScheduler(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient, extensionsClient)
+		c.Assert(err, IsNil)
+
+		setting := initSettings(string(types.SettingNameSchedulingRespectNodePressure), tc.respectNodePressure)
+		setting.Namespace = TestNamespace
+		c.Assert(sIndexer.Add(setting), IsNil)
+		if tc.requestPercentage != "" {
+			setting := initSettings(string(types.SettingNameSchedulingNodePressureRequestPercentage), tc.requestPercentage)
+			setting.Namespace = TestNamespace
+			c.Assert(sIndexer.Add(setting), IsNil)
+		}
+
+		engineImage := newEngineImage(TestEngineImage, longhorn.EngineImageStateDeployed)
+		engineImage.Namespace = TestNamespace
+		for _, nodeName := range tc.nodes {
+			node := newNodeInZoneWithSchedulableDisk(nodeName, TestZone1)
+			node.Namespace = TestNamespace
+			if util.Contains(tc.fullNodes, nodeName) {
+				node.Status.DiskStatus[getDiskID(nodeName, "1")].StorageAvailable = 0
+			}
+			c.Assert(nIndexer.Add(node), IsNil)
+			c.Assert(knIndexer.Add(newKubeNode(nodeName, util.Contains(tc.memoryPressureNodes, nodeName))), IsNil)
+			engineImage.Status.NodeDeploymentMap[nodeName] = true
+		}
+		c.Assert(eiIndexer.Add(engineImage), IsNil)
+		for _, pod := range tc.pods {
+			c.Assert(pIndexer.Add(pod), IsNil)
+		}
+
+		v := newVolume(TestVolumeName, 1)
+		r := newReplicaForVolume(v)
+		replica, multiError, err := rcs.ScheduleReplica(r, map[string]*longhorn.Replica{r.Name: r}, v)
+		c.Assert(err, IsNil, Commentf("test case: %v", name))
+		if tc.expectedNode == "" {
+			c.Assert(replica, IsNil, Commentf("test case: %v", name))
+			c.Assert(strings.Contains(multiError.Join(), tc.expectedReason), Equals, true, Commentf("test case: %v, reason: %v", name, multiError.Join()))
+			c.Assert(strings.Contains(multiError.Join(), longhorn.ErrorReplicaScheduleNodeUnderPressure), Equals, tc.respectNodePressure == "true", Commentf("test case: %v, reason: %v", name, multiError.Join()))
+			continue
+		}
+		c.Assert(replica, NotNil, Commentf("test case: %v", name))
+		c.Assert(replica.Spec.NodeID, Equals, tc.expectedNode, Commentf("test case: %v", name))
+	}
+}
+
+func (s *TestSuite) TestIsPotentiallyReusableReplicaRebuildRetryLimit(c *C) {
+	type testCase struct {
+		rebuildRetryLimit string
+		rebuildRetryCount int
+
+		expectedLimit    int
+		expectedReusable bool
+	}
+	testCases := map[string]testCase{
+		"retry count below the default limit": {
+			rebuildRetryCount: FailedReplicaMaxRetryCount - 1,
+			expectedLimit:     FailedReplicaMaxRetryCount,
+			expectedReusable:  true,
+		},
+		"retry count reaches the default limit": {
+			rebuildRetryCount: FailedReplicaMaxRetryCount,
+			expectedLimit:     FailedReplicaMaxRetryCount,
+			expectedReusable:  false,
+		},
+		"retry count reaches the customized limit": {
+			rebuildRetryLimit: "2",
+			rebuildRetryCount: 2,
+			expectedLimit:     2,
+			expectedReusable:  false,
+		},
+		"retry count below the customized limit": {
+			rebuildRetryLimit: "10",
+			rebuildRetryCount: FailedReplicaMaxRetryCount,
+			expectedLimit:     10,
+			expectedReusable:  true,
+		},
+		"non-reusable replica with the raised limit": {
+			rebuildRetryLimit: "100",
+			rebuildRetryCount: NonReusableReplicaRebuildRetryCount,
+			expectedLimit:     100,
+			expectedReusable:  false,
+		},
+	}
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		kubeClient := fake.NewSimpleClientset()
+		kubeInformerFactory := informers.NewSharedInformerFactory(kubeClient, controller.NoResyncPeriodFunc())
+
+		lhClient := lhfake.NewSimpleClientset()
+		lhInformerFactory := lhinformerfactory.NewSharedInformerFactory(lhClient, controller.NoResyncPeriodFunc())
+
+		extensionsClient := apiextensionsfake.NewSimpleClientset()
+
+		sIndexer := lhInformerFactory.Longhorn().V1beta2().Settings().Informer().GetIndexer()
+
+		rcs, err := newReplicaScheduler(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient, extensionsClient)
+		c.Assert(err, IsNil)
+
+		if tc.rebuildRetryLimit != "" {
+			setting := initSettings(string(types.SettingNameReplicaRebuildRetryLimit), tc.rebuildRetryLimit)
+			setting.Namespace = TestNamespace
+			c.Assert(sIndexer.Add(setting), IsNil)
+		}
+
+		r := newReplicaForVolume(newVolume(TestVolumeName, 1))
+		r.Spec.NodeID = TestNode1
+		r.Spec.DiskID = getDiskID(TestNode1, "1")
+		r.Spec.FailedAt = util.Now()
+		r.Spec.RebuildRetryCount = tc.rebuildRetryCount
+
+		limit := rcs.GetReplicaRebuildRetryLimit()
+		c.Assert(limit, Equals, tc.expectedLimit, Commentf("test case: %v", name))
+		c.Assert(IsPotentiallyReusableReplica(r, "", limit), Equals, tc.expectedReusable, Commentf("test case: %v", name))
+	}
+}
+
+func (s *TestSuite) TestOrderDiskCandidates(c *C) {
+	type testCase struct {
+		policy       longhorn.ReplicaPlacementPolicy
+		replicaNodes []string
+
+		expectedNodes []string
+	}
+	testCases := map[string]testCase{
+		"balanced prefers the disks with more usable storage": {
+			policy:        longhorn.ReplicaPlacementPolicyBalanced,
+			replicaNodes:  []string{TestNode1},
+			expectedNodes: []string{TestNode1, TestNode2, TestNode3},
+		},
+		"spread prefers the unused zones and nodes": {
+			policy:        longhorn.ReplicaPlacementPolicySpread,
+			replicaNodes:  []string{TestNode1},
+			expectedNodes: []string{TestNode3, TestNode2, TestNode1},
+		},
+		"spread prefers the nodes with fewer replicas": {
+			policy:        longhorn.ReplicaPlacementPolicySpread,
+			expectedNodes: []string{TestNode3, TestNode2, TestNode1},
+		},
+		"node-set prefers the disks with more usable storage": {
+			policy:        longhorn.ReplicaPlacementPolicyNodeSet,
+			replicaNodes:  []string{TestNode1},
+			expectedNodes: []string{TestNode1, TestNode2, TestNode3},
+		},
+	}
+
+	node1 := newNodeInZoneWithSchedulableDisk(TestNode1, TestZone1)
+	node1.Status.DiskStatus[getDiskID(TestNode1, "1")].ScheduledReplica = map[string]int64{
+		"other-r-1": TestVolumeSize,
+		"other-r-2": TestVolumeSize,
+	}
+	node2 := newNodeInZoneWithSchedulableDisk(TestNode2, TestZone1)
+	node2.Status.DiskStatus[getDiskID(TestNode2, "1")].StorageAvailable = TestDiskAvailableSize - TestVolumeSize
+	node2.Status.DiskStatus[getDiskID(TestNode2, "1")].ScheduledReplica = map[string]int64{
+		"other-r-3": TestVolumeSize,
+	}
+	node3 := newNodeInZoneWithSchedulableDisk(TestNode3, TestZone2)
+	node3.Status.DiskStatus[getDiskID(TestNode3, "1")].StorageAvailable = TestDiskAvailableSize - 2*TestVolumeSize
+	nodesInfo := map[string]*longhorn.Node{
+		TestNode1: node1,
+		TestNode2: node2,
+		TestNode3: node3,
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		diskCandidates := map[string]*Disk{}
+		for nodeName, node := range nodesInfo {
+			for diskName, diskStatus := range node.Status.DiskStatus {
+				diskCandidates[diskStatus.DiskUUID] = &Disk{
+					DiskSpec:   node.Spec.Disks[diskName],
+					DiskStatus: diskStatus,
+					NodeID:     nodeName,
+				}
+			}
+		}
+
+		v := newVolume(TestVolumeName, 3)
+		v.Spec.ReplicaPlacementPolicy = tc.policy
+		replicas := map[string]*longhorn.Replica{}
+		for _, nodeName := range tc.replicaNodes {
+			r := newReplicaForVolume(v)
+			r.Spec.NodeID = nodeName
+			r.Spec.DiskID = getDiskID(nodeName, "1")
+			replicas[r.Name] = r
+		}
+
+		nodes := []string{}
+		for _, disk := range orderDiskCandidates(diskCandidates, replicas, v, nodesInfo) {
+			nodes = append(nodes, disk.NodeID)
+		}
+		c.Assert(nodes, DeepEquals, tc.expectedNodes, Commentf("test case: %v", name))
+	}
+}
+
+func (s *TestSuite) TestScheduleReplicaPlacementPolicy(c *C) {
+	type testCase struct {
+		policy         longhorn.ReplicaPlacementPolicy
+		placementNodes []string
+
+		// The nodes the replicas are scheduled to one after another. An
+		// empty node means the replica cannot be scheduled.
+		expectedNodes []string
+	}
+	testCases := map[string]testCase{
+		"balanced picks the disk with the most usable storage among all nodes": {
+			policy:        longhorn.ReplicaPlacementPolicyBalanced,
+			expectedNodes: []string{TestNode1, TestNode2, TestNode3},
+		},
+		"spread picks the node with the fewest replicas among all nodes": {
+			policy:        longhorn.ReplicaPlacementPolicySpread,
+			expectedNodes: []string{TestNode4, TestNode3, TestNode2},
+		},
+		"node-set picks the disk with the most usable storage among the node set": {
+			policy:         longhorn.ReplicaPlacementPolicyNodeSet,
+			placementNodes: []string{TestNode4, TestNode2, TestNode3},
+			expectedNodes:  []string{TestNode2, TestNode3, TestNode4},
+		},
+		"node-set keeps the replicas beyond the node set unscheduled": {
+			policy:         longhorn.ReplicaPlacementPolicyNodeSet,
+			placementNodes: []string{TestNode3, TestNode4},
+			expectedNodes:  []string{TestNode3, TestNode4, ""},
+		},
+	}
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		kubeClient := fake.NewSimpleClientset()
+		kubeInformerFactory := informers.NewSharedInformerFactory(kubeClient, controller.NoResyncPeriodFunc())
+
+		lhClient := lhfake.NewSimpleClientset()
+		lhInformerFactory := lhinformerfactory.NewSharedInformerFactory(lhClient, controller.NoResyncPeriodFunc())
+
+		extensionsClient := apiextensionsfake.NewSimpleClientset()
+
+		nIndexer := lhInformerFactory.Longhorn().V1beta2().Nodes().Informer().GetIndexer()
+		eiIndexer := lhInformerFactory.Longhorn().V1beta2().EngineImages().Informer().GetIndexer()
+
+		rcs, err := newReplicaScheduler(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient, extensionsClient)
+		c.Assert(err, IsNil)
+
+		engineImage := newEngineImage(TestEngineImage, longhorn.EngineImageStateDeployed)
+		engineImage.Namespace = TestNamespace
+		for i, nodeName := range []string{TestNode1, TestNode2, TestNode3, TestNode4} {
+			node := newNodeInZoneWithSchedulableDisk(nodeName, TestZone1)
+			node.Namespace = TestNamespace
+			// Node 1 has the most usable storage, node 4 has the fewest replicas
+			diskStatus := node.Status.DiskStatus[getDiskID(nodeName, "1")]
+			diskStatus.StorageAvailable = TestDiskAvailableSize - int64(i)*100000000
+			diskStatus.ScheduledReplica = map[string]int64{}
+			for j := 0; j < 3-i; j++ {
+				diskStatus.ScheduledReplica[fmt.Sprintf("other-r-%d", j)] = 0
+			}
+			c.Assert(nIndexer.Add(node), IsNil)
+			engineImage.Status.NodeDeploymentMap[nodeName] = true
+		}
+		c.Assert(eiIndexer.Add(engineImage), IsNil)
+
+		v := newVolume(TestVolumeName, len(tc.expectedNodes))
+		v.Spec.ReplicaPlacementPolicy = tc.policy
+		v.Spec.ReplicaPlacementNodes = tc.placementNodes
+
+		// The result must not depend on the iteration order of the node maps
+		for i := 0; i < 20; i++ {
+			replicas := map[string]*longhorn.Replica{}
+			for _, expectedNode := range tc.expectedNodes {
+				r := newReplicaForVolume(v)
+				replicas[r.Name] = r
+				replica, multiError, err := rcs.ScheduleReplica(r.DeepCopy(), replicas, v)
+				c.Assert(err, IsNil, Commentf("test case: %v", name))
+				if expectedNode == "" {
+					c.Assert(replica, IsNil, Commentf("test case: %v", name))
+					continue
+				}
+				c.Assert(replica, NotNil, Commentf("test case: %v, reason: %v", name, multiError.Join()))
+				c.Assert(replica.Spec.NodeID, Equals, expectedNode, Commentf("test case: %v", name))
+				replicas[r.Name] = replica
+			}
+		}
+	}
+}
+
+func (s *TestSuite) TestScheduleReplicaWithoutPlacementPolicy(c *C) {
+	kubeClient := fake.NewSimpleClientset()
+	kubeInformerFactory := informers.NewSharedInformerFactory(kubeClient, controller.NoResyncPeriodFunc())
+
+	lhClient := lhfake.NewSimpleClientset()
+	lhInformerFactory := lhinformerfactory.NewSharedInformerFactory(lhClient, controller.NoResyncPeriodFunc())
+
+	extensionsClient := apiextensionsfake.NewSimpleClientset()
+
+	nIndexer := lhInformerFactory.Longhorn().V1beta2().Nodes().Informer().GetIndexer()
+	eiIndexer := lhInformerFactory.Longhorn().V1beta2().EngineImages().Informer().GetIndexer()
+
+	rcs, err := newReplicaScheduler(lhInformerFactory, kubeInformerFactory, lhClient, kubeClient, extensionsClient)
+	c.Assert(err, IsNil)
+
+	engineImage := newEngineImage(TestEngineImage, longhorn.EngineImageStateDeployed)
+	engineImage.Namespace = TestNamespace
+	for i, nodeName := range []string{TestNode1, TestNode2, TestNode3} {
+		node := newNodeInZoneWithSchedulableDisk(nodeName, TestZone1)
+		node.Namespace = TestNamespace
+		node.Status.DiskStatus[getDiskID(nodeName, "1")].StorageAvailable = TestDiskAvailableSize - int64(i)*100000000
+		c.Assert(nIndexer.Add(node), IsNil)
+		engineImage.Status.NodeDeploymentMap[nodeName] = true
+	}
+	c.Assert(eiIndexer.Add(engineImage), IsNil)
+
+	v := newVolume(TestVolumeName, 3)
+	r := newReplicaForVolume(v)
+
+	// The node is picked randomly instead of always taking the disk with the
+	// most usable storage
+	scheduledNodes := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		replica, multiError, err := rcs.ScheduleReplica(r.DeepCopy(), map[string]*longhorn.Replica{r.Name: r}, v)
+		c.Assert(err, IsNil)
+		c.Assert(replica, NotNil, Commentf("reason: %v", multiError.Join()))
+		scheduledNodes[replica.Spec.NodeID] = true
+	}
+	c.Assert(len(scheduledNodes) > 1, Equals, true, Commentf("scheduled nodes: %v", scheduledNodes))
+}
@@ -2,6 +2,7 @@ package types
 
 import (
 	"fmt"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
@@ -14,6 +15,7 @@ import (
 	"gopkg.in/yaml.v2"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 
 	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
 	"github.com/longhorn/longhorn-manager/meta"
@@ -30,6 +32,7 @@ const (
 	SettingTypeString     = SettingType("string")
 	SettingTypeInt        = SettingType("int")
 	SettingTypeBool       = SettingType("bool")
+	SettingTypeDuration   = SettingType("duration")
 	SettingTypeDeprecated = SettingType("deprecated")
 )
 
@@ -101,6 +104,29 @@ const (
 	SettingNameRemoveSnapshotsDuringFilesystemTrim                      = SettingName("remove-snapshots-during-filesystem-trim")
 	SettingNameFastReplicaRebuildEnabled                                = SettingName("fast-replica-rebuild-enabled")
 	SettingNameReplicaFileSyncHTTPClientTimeout                         = SettingName("replica-file-sync-http-client-timeout")
+	SettingNameUpgradeCheckInterval                                     = SettingName("upgrade-check-interval")
+	SettingNameUpgradeResponderURL                                      = SettingName("upgrade-responder-url")
+	SettingNameUpgradeCheckerHTTPProxy                                  = SettingName("upgrade-checker-http-proxy")
+	SettingNameLastUpgradeCheckTimestamp                                = SettingName("last-upgrade-check-timestamp")
+	SettingNameUpgradeCheckerChannel                                    = SettingName("upgrade-checker-channel")
+	SettingNameReplicaDiskSoftAntiAffinity                              = SettingName("replica-disk-soft-anti-affinity")
+	SettingNameAutoDetachTimeout                                        = SettingName("auto-detach-timeout")
+	SettingNameSnapshotMaxCount                                         = SettingName("snapshot-max-count")
+	SettingNameDiskPressurePercentage                                   = SettingName("disk-pressure-percentage")
+	SettingNameGuaranteedEngineManagerMemory                            = SettingName("guaranteed-engine-manager-memory")
+	SettingNameGuaranteedReplicaManagerMemory                           = SettingName("guaranteed-replica-manager-memory")
+	SettingNameLogLevel                                                 = SettingName("log-level")
+	SettingNameOrphanAutoDeletionGracePeriod                            = SettingName("orphan-auto-deletion-grace-period")
+	SettingNameConcurrentBackupLimit                                    = SettingName("concurrent-backup-limit")
+	SettingNameSchedulingRespectNodePressure                            = SettingName("scheduling-respect-node-pressure")
+	SettingNameReplicaRebuildRetryLimit                                 = SettingName("replica-rebuild-retry-limit")
+	SettingNameAutoAttachPreferReplicaNode                              = SettingName("auto-attach-prefer-replica-node")
+	SettingNameDefaultDiskConfiguration                                 = SettingName("default-disk-configuration")
+	SettingNameStorageReservedPercentageForDefaultDisk                  = SettingName("storage-reserved-percentage-for-default-disk")
+	SettingNameMaintenanceMode                                          = SettingName("maintenance-mode")
+	SettingNameDiskBenchmarkOnDiskAddition                              = SettingName("disk-benchmark-on-disk-addition")
+	SettingNameAllowSnapshotHooks                                       = SettingName("allow-snapshot-hooks")
+	SettingNameSchedulingNodePressureRequestPercentage                  = SettingName("scheduling-node-pressure-request-percentage")
 )
 
 var (
@@ -170,6 +196,29 @@ var (
 		SettingNameRemoveSnapshotsDuringFilesystemTrim,
 		SettingNameFastReplicaRebuildEnabled,
 		SettingNameReplicaFileSyncHTTPClientTimeout,
+		SettingNameUpgradeCheckInterval,
+		SettingNameUpgradeResponderURL,
+		SettingNameUpgradeCheckerHTTPProxy,
+		SettingNameLastUpgradeCheckTimestamp,
+		SettingNameUpgradeCheckerChannel,
+		SettingNameReplicaDiskSoftAntiAffinity,
+		SettingNameAutoDetachTimeout,
+		SettingNameSnapshotMaxCount,
+		SettingNameDiskPressurePercentage,
+		SettingNameGuaranteedEngineManagerMemory,
+		SettingNameGuaranteedReplicaManagerMemory,
+		SettingNameLogLevel,
+		SettingNameOrphanAutoDeletionGracePeriod,
+		SettingNameConcurrentBackupLimit,
+		SettingNameSchedulingRespectNodePressure,
+		SettingNameReplicaRebuildRetryLimit,
+		SettingNameAutoAttachPreferReplicaNode,
+		SettingNameDefaultDiskConfiguration,
+		SettingNameStorageReservedPercentageForDefaultDisk,
+		SettingNameMaintenanceMode,
+		SettingNameDiskBenchmarkOnDiskAddition,
+		SettingNameAllowSnapshotHooks,
+		SettingNameSchedulingNodePressureRequestPercentage,
 	}
 )
 
@@ -264,6 +313,29 @@ var (
 		SettingNameRemoveSnapshotsDuringFilesystemTrim:                      SettingDefinitionRemoveSnapshotsDuringFilesystemTrim,
 		SettingNameFastReplicaRebuildEnabled:                                SettingDefinitionFastReplicaRebuildEnabled,
 		SettingNameReplicaFileSyncHTTPClientTimeout:                         SettingDefinitionReplicaFileSyncHTTPClientTimeout,
+		SettingNameUpgradeCheckInterval:                                     SettingDefinitionUpgradeCheckInterval,
+		SettingNameUpgradeResponderURL:                                      SettingDefinitionUpgradeResponderURL,
+		SettingNameUpgradeCheckerHTTPProxy:                                  SettingDefinitionUpgradeCheckerHTTPProxy,
+		SettingNameLastUpgradeCheckTimestamp:                                SettingDefinitionLastUpgradeCheckTimestamp,
+		SettingNameUpgradeCheckerChannel:                                    SettingDefinitionUpgradeCheckerChannel,
+		SettingNameReplicaDiskSoftAntiAffinity:                              SettingDefinitionReplicaDiskSoftAntiAffinity,
+		SettingNameAutoDetachTimeout:                                        SettingDefinitionAutoDetachTimeout,
+		SettingNameSnapshotMaxCount:                                         SettingDefinitionSnapshotMaxCount,
+		SettingNameDiskPressurePercentage:                                   SettingDefinitionDiskPressurePercentage,
+		SettingNameGuaranteedEngineManagerMemory:                            SettingDefinitionGuaranteedEngineManagerMemory,
+		SettingNameGuaranteedReplicaManagerMemory:                           SettingDefinitionGuaranteedReplicaManagerMemory,
+		SettingNameLogLevel:                                                 SettingDefinitionLogLevel,
+		SettingNameOrphanAutoDeletionGracePeriod:                            SettingDefinitionOrphanAutoDeletionGracePeriod,
+		SettingNameConcurrentBackupLimit:                                    SettingDefinitionConcurrentBackupLimit,
+		SettingNameSchedulingRespectNodePressure:                            SettingDefinitionSchedulingRespectNodePressure,
+		SettingNameReplicaRebuildRetryLimit:                                 SettingDefinitionReplicaRebuildRetryLimit,
+		SettingNameAutoAttachPreferReplicaNode:                              SettingDefinitionAutoAttachPreferReplicaNode,
+		SettingNameDefaultDiskConfiguration:                                 SettingDefinitionDefaultDiskConfiguration,
+		SettingNameStorageReservedPercentageForDefaultDisk:                  SettingDefinitionStorageReservedPercentageForDefaultDisk,
+		SettingNameMaintenanceMode:                                          SettingDefinitionMaintenanceMode,
+		SettingNameDiskBenchmarkOnDiskAddition:                              SettingDefinitionDiskBenchmarkOnDiskAddition,
+		SettingNameAllowSnapshotHooks:                                       SettingDefinitionAllowSnapshotHooks,
+		SettingNameSchedulingNodePressureRequestPercentage:                  SettingDefinitionSchedulingNodePressureRequestPercentage,
 	}
 
 	SettingDefinitionBackupTarget = SettingDefinition{
@@ -458,12 +530,13 @@ var (
 
 	SettingDefinitionUpgradeChecker = SettingDefinition{
 		DisplayName: "Enable Upgrade Checker",
-		Description: "Upgrade Checker will check for new Longhorn version periodically. When there is a new version available, a notification will appear in the UI",
-		Category:    SettingCategoryGeneral,
-		Type:        SettingTypeBool,
-		Required:    true,
-		ReadOnly:    false,
-		Default:     "true",
+		Description: "Upgrade Checker will check for new Longhorn version periodically. When there is a new version available, a notification will appear in the UI\n\n" +
+			"To check immediately, annotate this setting with `longhorn.io/trigger-upgrade-check`. The annotation is removed once the check is done.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeBool,
+		Required: true,
+		ReadOnly: false,
+		Default:  "true",
 	}
 
 	SettingDefinitionCurrentLonghornVersion = SettingDefinition{
@@ -496,12 +569,13 @@ var (
 
 	SettingDefinitionDefaultReplicaCount = SettingDefinition{
 		DisplayName: "Default Replica Count",
-		Description: "The default number of replicas when a volume is created from the Longhorn UI. For Kubernetes configuration, update the `numberOfReplicas` in the StorageClass",
-		Category:    SettingCategoryGeneral,
-		Type:        SettingTypeInt,
-		Required:    true,
-		ReadOnly:    false,
-		Default:     "3",
+		Description: "The default number of replicas when a volume is created without specifying the number of replicas, e.g. from the Longhorn UI or by a StorageClass without `numberOfReplicas`. " +
+			"Changing this setting only applies to the volumes created afterwards, the number of replicas of the existing volumes is not changed",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeInt,
+		Required: true,
+		ReadOnly: false,
+		Default:  "3",
 	}
 
 	SettingDefinitionDefaultDataLocality = SettingDefinition{
@@ -679,7 +753,8 @@ var (
 		Description: "Define the policy to use when a node with the last healthy replica of a volume is drained. \n" +
 			"- **block-if-contains-last-replica** Longhorn will block the drain when the node contains the last healthy replica of a volume.\n" +
 			"- **allow-if-replica-is-stopped** Longhorn will allow the drain when the node contains the last healthy replica of a volume but the replica is stopped. WARNING: possible data loss if the node is removed after draining. Select this option if you want to drain the node and do in-place upgrade/maintenance.\n" +
-			"- **always-allow** Longhorn will allow the drain even though the node contains the last healthy replica of a volume. WARNING: possible data loss if the node is removed after draining. Also possible data corruption if the last replica was running during the draining.\n",
+			"- **always-allow** Longhorn will allow the drain even though the node contains the last healthy replica of a volume. WARNING: possible data loss if the node is removed after draining. Also possible data corruption if the last replica was running during the draining.\n" +
+			"- **block-for-eviction** Longhorn will evict all replicas from a cordoned node and block the drain until the eviction completes. Select this option if the node is going to be removed or its disks are going to be replaced.\n",
 		Category: SettingCategoryGeneral,
 		Type:     SettingTypeString,
 		Required: true,
@@ -689,6 +764,7 @@ var (
 			string(NodeDrainPolicyBlockIfContainsLastReplica),
 			string(NodeDrainPolicyAllowIfReplicaIsStopped),
 			string(NodeDrainPolicyAlwaysAllow),
+			string(NodeDrainPolicyBlockForEviction),
 		},
 	}
 
@@ -968,12 +1044,14 @@ var (
 
 	SettingDefinitionEngineReplicaTimeout = SettingDefinition{
 		DisplayName: "Timeout between Engine and Replica",
-		Description: "In seconds. The setting specifies the timeout between the engine and replica(s), and the value should be between 8 to 30 seconds. The default value is 8 seconds.",
-		Category:    SettingCategoryGeneral,
-		Type:        SettingTypeInt,
-		Required:    true,
-		ReadOnly:    false,
-		Default:     "8",
+		Description: "In seconds. The setting specifies the timeout between the engine and replica(s), and the value should be between 8 to 30 seconds. The default value is 8 seconds. \n\n" +
+			"The timeout is passed to the engine when it starts, so the change applies to the engines started afterwards, including the ones started by a live engine upgrade. " +
+			"The running engines keep using the timeout they were started with until the volumes are detached and attached again.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeInt,
+		Required: true,
+		ReadOnly: false,
+		Default:  "8",
 	}
 
 	SettingDefinitionSnapshotDataIntegrity = SettingDefinition{
@@ -1047,6 +1125,278 @@ var (
 		ReadOnly:    false,
 		Default:     "30",
 	}
+
+	SettingDefinitionUpgradeCheckInterval = SettingDefinition{
+		DisplayName: "Upgrade Check Interval",
+		Description: "The interval between two checks for a new Longhorn version, e.g. `1h` or `30m`. Only takes effect when **Enable Upgrade Checker** is enabled.\n\n" +
+			"Set to 0 to disable the periodic check. A manually triggered check still works in this case.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeDuration,
+		Required: true,
+		ReadOnly: false,
+		Default:  "24h",
+	}
+
+	SettingDefinitionUpgradeResponderURL = SettingDefinition{
+		DisplayName: "Upgrade Responder URL",
+		Description: "The URL of the upgrade responder queried by Upgrade Checker. Leave this blank to use the default Longhorn upgrade responder.",
+		Category:    SettingCategoryGeneral,
+		Type:        SettingTypeString,
+		Required:    false,
+		ReadOnly:    false,
+	}
+
+	SettingDefinitionUpgradeCheckerHTTPProxy = SettingDefinition{
+		DisplayName: "Upgrade Checker HTTP Proxy",
+		Description: "The HTTP proxy used by Upgrade Checker to reach the upgrade responder, e.g. `http://proxy.example.com:3128`. " +
+			"Leave this blank to use the proxy configured by the environment variables HTTP_PROXY, HTTPS_PROXY and NO_PROXY of the Longhorn manager.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeString,
+		Required: false,
+		ReadOnly: false,
+	}
+
+	SettingDefinitionLastUpgradeCheckTimestamp = SettingDefinition{
+		DisplayName: "Last Upgrade Check Timestamp",
+		Description: "The time of the last successful check for a new Longhorn version, in RFC 3339 format. Updated by Upgrade Checker automatically",
+		Category:    SettingCategoryGeneral,
+		Type:        SettingTypeString,
+		Required:    false,
+		ReadOnly:    true,
+	}
+
+	SettingDefinitionUpgradeCheckerChannel = SettingDefinition{
+		DisplayName: "Upgrade Checker Channel",
+		Description: "The release channel tracked by Upgrade Checker, e.g. `latest`, `stable` or `beta`. " +
+			"The newest version tagged with this channel by the upgrade responder is reported as **Latest Longhorn Version**. Leave this blank to track the `latest` channel.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeString,
+		Required: false,
+		ReadOnly: false,
+		Default:  "latest",
+	}
+
+	SettingDefinitionReplicaDiskSoftAntiAffinity = SettingDefinition{
+		DisplayName: "Replica Disk Level Soft Anti-Affinity",
+		Description: "Allow scheduling new Replicas of Volume to the same Disk as existing healthy Replicas within a Node. Longhorn always prefers the Disks that don't hold a Replica of the Volume yet. If disabled, the scheduling fails when only Disks already holding a Replica of the Volume are available on the Node.",
+		Category:    SettingCategoryScheduling,
+		Type:        SettingTypeBool,
+		Required:    true,
+		ReadOnly:    false,
+		Default:     "true",
+	}
+
+	SettingDefinitionAutoDetachTimeout = SettingDefinition{
+		DisplayName: "Auto Detach Timeout",
+		Description: "The time, e.g. `5m` or `1h`, a Volume can stay attached to a Node that Kubernetes reports as down or deleted before Longhorn detaches it automatically, so that it can be reattached to another Node. " +
+			"Volumes attached to the Nodes that are up are never detached. Set to 0 to disable the automatic detachment.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeDuration,
+		Required: true,
+		ReadOnly: false,
+		Default:  "0",
+	}
+
+	SettingDefinitionSnapshotMaxCount = SettingDefinition{
+		DisplayName: "Snapshot Maximum Count",
+		Description: "The maximum number of snapshots kept for a Volume. When the count exceeds it, Longhorn deletes the oldest user created snapshots automatically. The snapshots created by the system or used by backups and volume cloning are never deleted. " +
+			"The value should be between 2 and 250, or 0 to disable the automatic deletion. It can be overridden by the Volume field `snapshotMaxCount`.",
+		Category: SettingCategorySnapshot,
+		Type:     SettingTypeInt,
+		Required: true,
+		ReadOnly: false,
+		Default:  "250",
+	}
+
+	SettingDefinitionDiskPressurePercentage = SettingDefinition{
+		DisplayName: "Disk Pressure Percentage",
+		Description: "If the used capacity of a disk exceeds this percentage of the disk's capacity, Longhorn evicts replicas from the disk until the usage drops below it. The evicted replicas are rebuilt on other disks with enough space, and the last healthy replica of a volume is never evicted.\n\n" +
+			"Set the value to 0 to disable the disk pressure eviction.",
+		Category: SettingCategoryScheduling,
+		Type:     SettingTypeInt,
+		Required: true,
+		ReadOnly: false,
+		Default:  "0",
+	}
+
+	SettingDefinitionGuaranteedEngineManagerMemory = SettingDefinition{
+		DisplayName: "Guaranteed Engine Manager Memory",
+		Description: "The amount of memory that will be requested for each engine manager Pod, in the Kubernetes quantity format. For example, 256Mi means 256 MiB of memory will be reserved for each engine manager pod. \n\n" +
+			"WARNING: \n\n" +
+			"  - Empty or 0 means unsetting memory requests for engine manager pods. \n\n" +
+			"  - After this setting is changed, all engine manager pods on all the nodes will be automatically restarted. In other words, DO NOT CHANGE THIS SETTING WITH ATTACHED VOLUMES. \n\n",
+		Category: SettingCategoryDangerZone,
+		Type:     SettingTypeString,
+		Required: false,
+		ReadOnly: false,
+		Default:  "",
+	}
+
+	SettingDefinitionGuaranteedReplicaManagerMemory = SettingDefinition{
+		DisplayName: "Guaranteed Replica Manager Memory",
+		Description: "The amount of memory that will be requested for each replica manager Pod, in the Kubernetes quantity format. For example, 256Mi means 256 MiB of memory will be reserved for each replica manager pod. \n\n" +
+			"WARNING: \n\n" +
+			"  - Empty or 0 means unsetting memory requests for replica manager pods. \n\n" +
+			"  - After this setting is changed, all replica manager pods on all the nodes will be automatically restarted. In other words, DO NOT CHANGE THIS SETTING WITH ATTACHED VOLUMES. \n\n",
+		Category: SettingCategoryDangerZone,
+		Type:     SettingTypeString,
+		Required: false,
+		ReadOnly: false,
+		Default:  "",
+	}
+
+	SettingDefinitionLogLevel = SettingDefinition{
+		DisplayName: "Log Level",
+		Description: "The log level of the Longhorn manager. The change takes effect immediately without restarting the manager pods, and it overrides the `--debug` flag of the manager. \n\n" +
+			"Leave it empty to follow the `--debug` flag, which selects the debug level if set and the info level otherwise. \n\n" +
+			"The log format is set by the `--log-json` flag of the manager.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeString,
+		Required: false,
+		ReadOnly: false,
+		Default:  "",
+		Choices: []string{
+			logrus.PanicLevel.String(),
+			logrus.FatalLevel.String(),
+			logrus.ErrorLevel.String(),
+			logrus.WarnLevel.String(),
+			logrus.InfoLevel.String(),
+			logrus.DebugLevel.String(),
+			logrus.TraceLevel.String(),
+		},
+	}
+
+	SettingDefinitionOrphanAutoDeletionGracePeriod = SettingDefinition{
+		DisplayName: "Orphan Auto-Deletion Grace Period",
+		Description: "In seconds. The minimum age of an orphan before Longhorn deletes it and its corresponding orphaned data automatically when the setting 'Orphan Auto-Deletion' is enabled. \n\n" +
+			"This gives the time to recover the data when the orphan is detected unexpectedly.",
+		Category: SettingCategoryOrphan,
+		Type:     SettingTypeInt,
+		Required: true,
+		ReadOnly: false,
+		Default:  "300",
+	}
+
+	SettingDefinitionConcurrentBackupLimit = SettingDefinition{
+		DisplayName: "Concurrent Backup Limit",
+		Description: "This setting controls how many backups can be taken to the backup target concurrently. \n\n" +
+			"Backups beyond the limit stay in the Pending state until a running backup finishes. \n\n" +
+			"Set the value to **0** to disable the limit.",
+		Category: SettingCategoryBackup,
+		Type:     SettingTypeInt,
+		Required: true,
+		ReadOnly: false,
+		Default:  "0",
+	}
+
+	SettingDefinitionSchedulingRespectNodePressure = SettingDefinition{
+		DisplayName: "Scheduling Respect Node Pressure",
+		Description: "Deprioritize the nodes under resource pressure when scheduling new Replicas. " +
+			"A node is under pressure if Kubernetes reports the MemoryPressure condition on it, or if the CPU or memory requests of the pods running on it exceed the percentage of its allocatable resources specified by the setting `scheduling-node-pressure-request-percentage`. " +
+			"Longhorn schedules Replicas to these nodes only if no other node is available.",
+		Category: SettingCategoryScheduling,
+		Type:     SettingTypeBool,
+		Required: true,
+		ReadOnly: false,
+		Default:  "false",
+	}
+
+	SettingDefinitionReplicaRebuildRetryLimit = SettingDefinition{
+		DisplayName: "Replica Rebuild Retry Limit",
+		Description: "The maximum number of times Longhorn retries rebuilding a failed Replica on its disk. \n\n" +
+			"Once the limit is reached, Longhorn stops reusing the failed Replica, cleans it up and replenishes a new Replica instead. " +
+			"The retry count is reset after the Replica is rebuilt successfully.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeInt,
+		Required: true,
+		ReadOnly: false,
+		Default:  "5",
+	}
+
+	SettingDefinitionAutoAttachPreferReplicaNode = SettingDefinition{
+		DisplayName: "Auto Attachment Prefers Replica Node",
+		Description: "When Longhorn attaches a volume automatically, for example for restoring, expansion or replica eviction, prefer a node hosting a healthy replica of the volume so that the engine does not rely on remote replicas only. \n\n" +
+			"This is a best-effort preference. It does not apply to the volumes attached to a node explicitly.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeBool,
+		Required: true,
+		ReadOnly: false,
+		Default:  "false",
+	}
+
+	SettingDefinitionDefaultDiskConfiguration = SettingDefinition{
+		DisplayName: "Default Disk Configuration",
+		Description: "The disks created on a node when it registers with Longhorn for the first time and the setting 'Create Default Disk on Labeled Nodes' is disabled. " +
+			"The value is a JSON list of disks, for example `[{\"path\":\"/mnt/disk1\",\"allowScheduling\":true},{\"path\":\"/mnt/disk2\",\"allowScheduling\":true,\"storageReserved\":1024,\"tags\":[\"ssd\"]}]`. \n\n" +
+			"If the value is empty, a single disk is created at the 'Default Data Path'. " +
+			"The nodes annotated with `node.longhorn.io/skip-default-disks: true` are left without disks so that they can be configured manually.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeString,
+		Required: false,
+		ReadOnly: false,
+		Default:  "",
+	}
+
+	SettingDefinitionStorageReservedPercentageForDefaultDisk = SettingDefinition{
+		DisplayName: "Storage Reserved Percentage For Default Disk",
+		Description: "The reserved percentage specifies the percentage of disk space that will not be allocated to the default disk on each new Longhorn node. " +
+			"The reserved space of the default disk scales with the disk capacity. It doesn't apply to the disks with the reserved space explicitly specified, e.g. by the setting 'Default Disk Configuration' or the node annotation 'node.longhorn.io/default-disks-config'. \n\n" +
+			"This setting only affects the default disk of a new adding node or nodes when installing Longhorn.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeInt,
+		Required: true,
+		ReadOnly: false,
+		Default:  "30",
+	}
+
+	SettingDefinitionMaintenanceMode = SettingDefinition{
+		DisplayName: "Maintenance Mode",
+		Description: "Pause the automatic actions of Longhorn, e.g. before bulk operations on the cluster. When enabled: \n\n" +
+			"- Failed or missing replicas are not rebuilt. The replicas of newly created volumes are still created. \n" +
+			"- Replicas are not rebalanced, i.e. no replica is added or removed for replica auto-balance or data locality. \n" +
+			"- Volumes whose replicas all failed are not salvaged automatically. \n" +
+			"- Replicas are not evicted from the disks or nodes requested for eviction, the cordoned nodes, or the disks under pressure. \n\n" +
+			"The other operations are still allowed, e.g. attaching or detaching volumes, creating snapshots and backups, deleting resources, and reading from the API.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeBool,
+		Required: true,
+		ReadOnly: false,
+		Default:  "false",
+	}
+
+	SettingDefinitionDiskBenchmarkOnDiskAddition = SettingDefinition{
+		DisplayName: "Disk Benchmark on Disk Addition",
+		Description: "Run a short IO benchmark on a newly added disk and record the approximate sequential throughput and synchronous write IOPS in the disk status, which helps to decide the disk tags for tiering. " +
+			"The benchmark writes a temporary file of 64 MiB to the disk and briefly loads it, hence it is disabled by default. The disks already holding replicas are never benchmarked.",
+		Category: SettingCategoryScheduling,
+		Type:     SettingTypeBool,
+		Required: true,
+		ReadOnly: false,
+		Default:  "false",
+	}
+
+	SettingDefinitionAllowSnapshotHooks = SettingDefinition{
+		DisplayName: "Allow Snapshot Hooks",
+		Description: "Allow running the commands specified by the volume annotations `longhorn.io/snapshot-pre-hook` and `longhorn.io/snapshot-post-hook` in the workload pod before and after a snapshot is taken, e.g. to freeze the filesystem for an application consistent snapshot. " +
+			"The commands are executed with the permissions of the Longhorn manager, so anyone who can annotate the Longhorn volumes can run arbitrary commands in the workload pods. Enable it only if every such user is trusted. " +
+			"When disabled, the hook annotations are ignored and the snapshots are taken without running the hooks.",
+		Category: SettingCategorySnapshot,
+		Type:     SettingTypeBool,
+		Required: true,
+		ReadOnly: false,
+		Default:  "false",
+	}
+
+	SettingDefinitionSchedulingNodePressureRequestPercentage = SettingDefinition{
+		DisplayName: "Scheduling Node Pressure Request Percentage",
+		Description: "The percentage of the allocatable CPU or memory of a node, above which the node is considered under resource pressure once requested by the pods running on it. " +
+			"It takes effect only if the setting `scheduling-respect-node-pressure` is enabled.",
+		Category: SettingCategoryScheduling,
+		Type:     SettingTypeInt,
+		Required: true,
+		ReadOnly: false,
+		Default:  "90",
+	}
 )
 
 type NodeDownPodDeletionPolicy string
@@ -1064,6 +1414,7 @@ const (
 	NodeDrainPolicyBlockIfContainsLastReplica = NodeWithLastHealthyReplicaDrainPolicy("block-if-contains-last-replica")
 	NodeDrainPolicyAllowIfReplicaIsStopped    = NodeWithLastHealthyReplicaDrainPolicy("allow-if-replica-is-stopped")
 	NodeDrainPolicyAlwaysAllow                = NodeWithLastHealthyReplicaDrainPolicy("always-allow")
+	NodeDrainPolicyBlockForEviction           = NodeWithLastHealthyReplicaDrainPolicy("block-for-eviction")
 )
 
 type SystemManagedPodsImagePullPolicy string
@@ -1094,6 +1445,9 @@ func ValidateSetting(name, value string) (err error) {
 	if definition.Required && value == "" {
 		return fmt.Errorf("required setting %v shouldn't be empty", sName)
 	}
+	if err := validateSettingValueByDefinition(sName, definition, value); err != nil {
+		return err
+	}
 
 	switch sName {
 	case SettingNameBackupTarget:
@@ -1105,42 +1459,6 @@ func ValidateSetting(name, value string) (err error) {
 			return fmt.Errorf("value %s, contains %v", value, strings.Join(findStr, " or "))
 		}
 
-	// boolean
-	case SettingNameCreateDefaultDiskLabeledNodes:
-		fallthrough
-	case SettingNameAllowRecurringJobWhileVolumeDetached:
-		fallthrough
-	case SettingNameReplicaSoftAntiAffinity:
-		fallthrough
-	case SettingNameDisableSchedulingOnCordonedNode:
-		fallthrough
-	case SettingNameReplicaZoneSoftAntiAffinity:
-		fallthrough
-	case SettingNameAllowNodeDrainWithLastHealthyReplica:
-		fallthrough
-	case SettingNameAllowVolumeCreationWithDegradedAvailability:
-		fallthrough
-	case SettingNameAutoCleanupSystemGeneratedSnapshot:
-		fallthrough
-	case SettingNameAutoDeletePodWhenVolumeDetachedUnexpectedly:
-		fallthrough
-	case SettingNameKubernetesClusterAutoscalerEnabled:
-		fallthrough
-	case SettingNameOrphanAutoDeletion:
-		fallthrough
-	case SettingNameDeletingConfirmationFlag:
-		fallthrough
-	case SettingNameRestoreVolumeRecurringJobs:
-		fallthrough
-	case SettingNameRemoveSnapshotsDuringFilesystemTrim:
-		fallthrough
-	case SettingNameFastReplicaRebuildEnabled:
-		fallthrough
-	case SettingNameUpgradeChecker:
-		if value != "true" && value != "false" {
-			return fmt.Errorf("value %v of setting %v should be true or false", value, sName)
-		}
-
 	case SettingNameStorageOverProvisioningPercentage:
 		if _, err := strconv.Atoi(value); err != nil {
 			return errors.Wrapf(err, "value %v is not a number", value)
@@ -1153,7 +1471,7 @@ func ValidateSetting(name, value string) (err error) {
 		if value < 0 {
 			return fmt.Errorf("value %v should be positive", value)
 		}
-	case SettingNameStorageMinimalAvailablePercentage:
+	case SettingNameStorageMinimalAvailablePercentage, SettingNameDiskPressurePercentage, SettingNameSchedulingNodePressureRequestPercentage:
 		if _, err := strconv.Atoi(value); err != nil {
 			return errors.Wrapf(err, "value %v is not a number", value)
 		}
@@ -1195,6 +1513,10 @@ func ValidateSetting(name, value string) (err error) {
 		fallthrough
 	case SettingNameSupportBundleFailedHistoryLimit:
 		fallthrough
+	case SettingNameOrphanAutoDeletionGracePeriod:
+		fallthrough
+	case SettingNameConcurrentBackupLimit:
+		fallthrough
 	case SettingNameBackupstorePollInterval:
 		value, err := strconv.Atoi(value)
 		if err != nil {
@@ -1203,6 +1525,22 @@ func ValidateSetting(name, value string) (err error) {
 		if value < 0 {
 			return fmt.Errorf("the value %v shouldn't be less than 0", value)
 		}
+	case SettingNameReplicaRebuildRetryLimit:
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.Wrapf(err, "value %v is not a number", value)
+		}
+		if limit < 1 {
+			return fmt.Errorf("the value %v shouldn't be less than 1", limit)
+		}
+	case SettingNameStorageReservedPercentageForDefaultDisk:
+		percentage, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.Wrapf(err, "value %v is not a number", value)
+		}
+		if percentage < 0 || percentage > 100 {
+			return fmt.Errorf("the value %v should be between 0 and 100", percentage)
+		}
 	case SettingNameFailedBackupTTL:
 		interval, err := strconv.Atoi(value)
 		if err != nil {
@@ -1219,6 +1557,10 @@ func ValidateSetting(name, value string) (err error) {
 		if _, err = UnmarshalNodeSelector(value); err != nil {
 			return errors.Wrapf(err, "the value of %v is invalid", sName)
 		}
+	case SettingNameDefaultDiskConfiguration:
+		if err = ValidateDefaultDiskConfiguration(value); err != nil {
+			return errors.Wrapf(err, "the value of %v is invalid", sName)
+		}
 	case SettingNameStorageNetwork:
 		if err = ValidateStorageNetwork(value); err != nil {
 			return errors.Wrapf(err, "the value of %v is invalid", sName)
@@ -1257,6 +1599,30 @@ func ValidateSetting(name, value string) (err error) {
 		if timeout < 8 || timeout > 30 {
 			return fmt.Errorf("the value %v should be between 8 and 30", value)
 		}
+	case SettingNameSnapshotMaxCount:
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.Wrapf(err, "value %v is not a number", value)
+		}
+		if err := ValidateSnapshotMaxCount(count); err != nil {
+			return err
+		}
+	case SettingNameUpgradeResponderURL:
+		fallthrough
+	case SettingNameUpgradeCheckerHTTPProxy:
+		if value == "" {
+			break
+		}
+		if err := validateAbsoluteURL(value); err != nil {
+			return err
+		}
+	case SettingNameLastUpgradeCheckTimestamp:
+		if value == "" {
+			break
+		}
+		if _, err := util.ParseTimeZ(value); err != nil {
+			return errors.Wrapf(err, "value %v is not a valid RFC 3339 timestamp", value)
+		}
 	case SettingNameSnapshotDataIntegrity:
 		if err = ValidateSnapshotDataIntegrity(value); err != nil {
 			return errors.Wrapf(err, "the value of %v is invalid", sName)
@@ -1272,19 +1638,6 @@ func ValidateSetting(name, value string) (err error) {
 
 		logrus.Debugf("The interval between two data integrity checks is %v seconds", nextRunAt.Sub(runAt).Seconds())
 
-	// multi-choices
-	case SettingNameNodeDownPodDeletionPolicy:
-		fallthrough
-	case SettingNameDefaultDataLocality:
-		fallthrough
-	case SettingNameNodeDrainPolicy:
-		fallthrough
-	case SettingNameSystemManagedPodsImagePullPolicy:
-		definition, _ := GetSettingDefinition(sName)
-		choices := definition.Choices
-		if !isValidChoice(choices, value) {
-			return fmt.Errorf("value %v is not a valid choice, available choices %v", value, choices)
-		}
 	case SettingNameGuaranteedEngineManagerCPU:
 		fallthrough
 	case SettingNameGuaranteedReplicaManagerCPU:
@@ -1295,6 +1648,72 @@ func ValidateSetting(name, value string) (err error) {
 		if i < 0 || i > 40 {
 			return fmt.Errorf("guaranteed engine/replica cpu value %v should be between 0 to 40", value)
 		}
+	case SettingNameGuaranteedEngineManagerMemory, SettingNameGuaranteedReplicaManagerMemory:
+		if value == "" {
+			return nil
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return errors.Wrapf(err, "guaranteed engine/replica memory value %v is not a valid quantity", value)
+		}
+		if quantity.Sign() < 0 {
+			return fmt.Errorf("guaranteed engine/replica memory value %v should not be negative", value)
+		}
+	}
+	return nil
+}
+
+// validateSettingValueByDefinition checks the value against the type and the
+// choices of the setting definition. Setting specific conditions are checked
+// by ValidateSetting.
+func validateSettingValueByDefinition(sName SettingName, definition SettingDefinition, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	switch definition.Type {
+	case SettingTypeBool:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("value %v of setting %v should be true or false", value, sName)
+		}
+	case SettingTypeInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("value %v of setting %v should be an integer", value, sName)
+		}
+	case SettingTypeDuration:
+		if _, err := ParseSettingDuration(value); err != nil {
+			return errors.Wrapf(err, "value %v of setting %v should be a duration", value, sName)
+		}
+	}
+
+	if !isValidChoice(definition.Choices, value) {
+		return fmt.Errorf("value %v of setting %v is not a valid choice, available choices %v", value, sName, definition.Choices)
+	}
+
+	return nil
+}
+
+// ParseSettingDuration parses the value of a duration setting, which
+// shouldn't be less than 0
+func ParseSettingDuration(value string) (time.Duration, error) {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, errors.Wrapf(err, "value %v is not a valid duration", value)
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("the value %v shouldn't be less than 0", value)
+	}
+	return duration, nil
+}
+
+// validateAbsoluteURL checks if the passed value is a URL with both scheme and host
+func validateAbsoluteURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil {
+		return errors.Wrapf(err, "value %v is not a valid URL", value)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("value %v should be an absolute URL with scheme and host", value)
 	}
 	return nil
 }
@@ -9,6 +9,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -114,10 +115,19 @@ const (
 	NodeCreateDefaultDiskLabelValueConfig     = "config"
 	KubeNodeDefaultDiskConfigAnnotationKey    = "node.longhorn.io/default-disks-config"
 	KubeNodeDefaultNodeTagConfigAnnotationKey = "node.longhorn.io/default-node-tags"
+	KubeNodeSkipDefaultDisksAnnotationKey     = "node.longhorn.io/skip-default-disks"
 
 	LastAppliedTolerationAnnotationKeySuffix = "last-applied-tolerations"
+	UpgradeCheckTriggerAnnotationKeySuffix   = "trigger-upgrade-check"
+	DiskPressureEvictionAnnotationKeySuffix  = "disk-pressure-eviction"
+	ReplicaForceDeletionAnnotationKeySuffix  = "force-deletion"
+
+	SnapshotPreHookAnnotationKeySuffix       = "snapshot-pre-hook"
+	SnapshotPostHookAnnotationKeySuffix      = "snapshot-post-hook"
+	SnapshotHookContainerAnnotationKeySuffix = "snapshot-hook-container"
 
 	ConfigMapResourceVersionKey = "configmap-resource-version"
+	ConfigMapAppliedValueKey    = "configmap-applied-value"
 
 	KubernetesStatusLabel = "KubernetesStatus"
 	KubernetesReplicaSet  = "ReplicaSet"
@@ -200,6 +210,11 @@ const (
 	KubernetesMinVersion = "v1.18.0"
 )
 
+const (
+	MinSnapshotMaxCount = 2
+	MaxSnapshotMaxCount = 250
+)
+
 const (
 	EnvNodeName       = "NODE_NAME"
 	EnvPodNamespace   = "POD_NAMESPACE"
@@ -655,6 +670,75 @@ func ValidateDataLocalityAndReplicaCount(mode longhorn.DataLocality, count int)
 	return nil
 }
 
+// IsSharedVolume returns true if the volume is a RWX volume exported by a share
+// manager. A migratable RWX volume is attached to the nodes directly instead.
+func IsSharedVolume(v *longhorn.Volume) bool {
+	return v.Spec.AccessMode == longhorn.AccessModeReadWriteMany && !v.Spec.Migratable
+}
+
+// IsAttachmentTicketSupported returns true if the attachment of the volume is
+// arbitrated by the attachment tickets. The migratable and shared volumes are
+// attached to multiple nodes hence are not supported.
+func IsAttachmentTicketSupported(v *longhorn.Volume) bool {
+	return v.Spec.AccessMode != longhorn.AccessModeReadWriteMany && !v.Spec.Migratable
+}
+
+func GetAttachmentTicketID(attacherType longhorn.AttacherType, id string) string {
+	return fmt.Sprintf("%s-%s", attacherType, id)
+}
+
+// GetAttacherPriorityLevel returns the priority of the attacher type. The
+// ticket of the attacher with the higher priority takes precedence.
+func GetAttacherPriorityLevel(attacherType longhorn.AttacherType) int {
+	switch attacherType {
+	case longhorn.AttacherTypeCSIAttacher:
+		return longhorn.AttacherPriorityLevelCSIAttacher
+	case longhorn.AttacherTypeLonghornAPI:
+		return longhorn.AttacherPriorityLevelLonghornAPI
+	case longhorn.AttacherTypeRecurringJob:
+		return longhorn.AttacherPriorityLevelRecurringJob
+	default:
+		return 0
+	}
+}
+
+// GetWinningAttachmentTicket returns the ticket with the highest priority, or
+// nil if there is no ticket. The ties are broken by the ticket ID so that the
+// result is deterministic.
+func GetWinningAttachmentTicket(tickets map[string]*longhorn.AttachmentTicket) *longhorn.AttachmentTicket {
+	var winner *longhorn.AttachmentTicket
+	for _, ticket := range tickets {
+		if ticket == nil {
+			continue
+		}
+		if winner == nil {
+			winner = ticket
+			continue
+		}
+		priority, winnerPriority := GetAttacherPriorityLevel(ticket.Type), GetAttacherPriorityLevel(winner.Type)
+		if priority > winnerPriority || (priority == winnerPriority && ticket.ID < winner.ID) {
+			winner = ticket
+		}
+	}
+	return winner
+}
+
+func IsAttachmentTicketFrontendDisabled(ticket *longhorn.AttachmentTicket) bool {
+	return ticket.Parameters[longhorn.AttachmentParameterDisableFrontend] == strconv.FormatBool(true)
+}
+
+// CheckEngineImageLiveUpgradeCompatibility returns an error if the engine of
+// the old engine image cannot be live upgraded to the new engine image
+func CheckEngineImageLiveUpgradeCompatibility(oldImage, newImage *longhorn.EngineImage) error {
+	if oldImage.Status.ControllerAPIVersion > newImage.Status.ControllerAPIVersion ||
+		oldImage.Status.ControllerAPIVersion < newImage.Status.ControllerAPIMinVersion {
+		return fmt.Errorf("the controller API version %v of engine image %v is not within the range [%v, %v] supported by engine image %v",
+			oldImage.Status.ControllerAPIVersion, oldImage.Spec.Image,
+			newImage.Status.ControllerAPIMinVersion, newImage.Status.ControllerAPIVersion, newImage.Spec.Image)
+	}
+	return nil
+}
+
 func ValidateReplicaAutoBalance(option longhorn.ReplicaAutoBalance) error {
 	switch option {
 	case longhorn.ReplicaAutoBalanceIgnored,
@@ -667,6 +751,23 @@ func ValidateReplicaAutoBalance(option longhorn.ReplicaAutoBalance) error {
 	}
 }
 
+func ValidateReplicaPlacementPolicy(policy longhorn.ReplicaPlacementPolicy, nodes []string) error {
+	switch policy {
+	case "", longhorn.ReplicaPlacementPolicyBalanced, longhorn.ReplicaPlacementPolicySpread:
+		if len(nodes) > 0 {
+			return fmt.Errorf("replica placement nodes are only used by the replica placement policy %v", longhorn.ReplicaPlacementPolicyNodeSet)
+		}
+		return nil
+	case longhorn.ReplicaPlacementPolicyNodeSet:
+		if len(nodes) == 0 {
+			return fmt.Errorf("replica placement nodes are required by the replica placement policy %v", policy)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid replica placement policy: %v", policy)
+	}
+}
+
 func ValidateDataLocality(mode longhorn.DataLocality) error {
 	if mode != longhorn.DataLocalityDisabled && mode != longhorn.DataLocalityBestEffort && mode != longhorn.DataLocalityStrictLocal {
 		return fmt.Errorf("invalid data locality mode: %v", mode)
@@ -709,6 +810,40 @@ func ValidateUnmapMarkSnapChainRemoved(unmapValue longhorn.UnmapMarkSnapChainRem
 	return nil
 }
 
+// ValidateSnapshotMaxCount accepts 0, which disables the limit, or a count
+// within the range supported by the engine
+func ValidateSnapshotMaxCount(count int) error {
+	if count != 0 && (count < MinSnapshotMaxCount || count > MaxSnapshotMaxCount) {
+		return fmt.Errorf("snapshot max count %v should be 0 or between %v and %v", count, MinSnapshotMaxCount, MaxSnapshotMaxCount)
+	}
+	return nil
+}
+
+// ValidateBackupRetention accepts a non-negative count and an empty or positive max age
+func ValidateBackupRetention(retention longhorn.VolumeBackupRetention) error {
+	if retention.Count < 0 {
+		return fmt.Errorf("backup retention count %v should not be negative", retention.Count)
+	}
+	if retention.MaxAge != "" {
+		maxAge, err := time.ParseDuration(retention.MaxAge)
+		if err != nil {
+			return errors.Wrapf(err, "invalid backup retention max age %v", retention.MaxAge)
+		}
+		if maxAge <= 0 {
+			return fmt.Errorf("backup retention max age %v should be positive", retention.MaxAge)
+		}
+	}
+	return nil
+}
+
+// ValidateStaleReplicaTimeout accepts a positive timeout in minutes
+func ValidateStaleReplicaTimeout(timeout int) error {
+	if timeout <= 0 {
+		return fmt.Errorf("stale replica timeout %v should be a positive number of minutes", timeout)
+	}
+	return nil
+}
+
 func GetDaemonSetNameFromEngineImageName(engineImageName string) string {
 	return "engine-image-" + engineImageName
 }
@@ -731,13 +866,19 @@ func LabelsToString(labels map[string]string) string {
 }
 
 func CreateDisksFromAnnotation(annotation string) (map[string]longhorn.DiskSpec, error) {
-	validDisks := map[string]longhorn.DiskSpec{}
-	existFsid := map[string]string{}
-
 	disks, err := UnmarshalToDisks(annotation)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to unmarshal the default disks annotation")
 	}
+	return CreateDisks(disks)
+}
+
+// CreateDisks validates the disks and returns the disk specs by the disk
+// names. The disk paths should exist on the host.
+func CreateDisks(disks []DiskSpecWithName) (map[string]longhorn.DiskSpec, error) {
+	validDisks := map[string]longhorn.DiskSpec{}
+	existFsid := map[string]string{}
+
 	for _, disk := range disks {
 		if disk.Path == "" {
 			return nil, fmt.Errorf("invalid disk %+v", disk)
@@ -785,6 +926,36 @@ func CreateDisksFromAnnotation(annotation string) (map[string]longhorn.DiskSpec,
 	return validDisks, nil
 }
 
+// ValidateDefaultDiskConfiguration checks the disk list of the setting
+// default-disk-configuration. The disk paths are checked on the node when the
+// disks are created.
+func ValidateDefaultDiskConfiguration(value string) error {
+	if value == "" {
+		return nil
+	}
+	disks, err := UnmarshalToDisks(value)
+	if err != nil {
+		return err
+	}
+	paths := map[string]struct{}{}
+	for _, disk := range disks {
+		if disk.Path == "" {
+			return fmt.Errorf("invalid disk %+v", disk)
+		}
+		if _, exists := paths[disk.Path]; exists {
+			return fmt.Errorf("duplicate disk path %v", disk.Path)
+		}
+		paths[disk.Path] = struct{}{}
+		if disk.StorageReserved < 0 {
+			return fmt.Errorf("the storageReserved setting of disk %v should not be negative", disk.Path)
+		}
+		if _, err := util.ValidateTags(disk.Tags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func GetNodeTagsFromAnnotation(annotation string) ([]string, error) {
 	nodeTags, err := UnmarshalToNodeTags(annotation)
 	if err != nil {
@@ -823,7 +994,9 @@ func UnmarshalToNodeTags(s string) ([]string, error) {
 	return res, nil
 }
 
-func CreateDefaultDisk(dataPath string) (map[string]longhorn.DiskSpec, error) {
+// CreateDefaultDisk creates the default disk at dataPath, reserving
+// storageReservedPercentage of the disk capacity.
+func CreateDefaultDisk(dataPath string, storageReservedPercentage int64) (map[string]longhorn.DiskSpec, error) {
 	if err := util.CreateDiskPathReplicaSubdirectory(dataPath); err != nil {
 		return nil, err
 	}
@@ -836,7 +1009,7 @@ func CreateDefaultDisk(dataPath string) (map[string]longhorn.DiskSpec, error) {
 			Path:              diskStat.Path,
 			AllowScheduling:   true,
 			EvictionRequested: false,
-			StorageReserved:   diskStat.StorageMaximum * 30 / 100,
+			StorageReserved:   diskStat.StorageMaximum * storageReservedPercentage / 100,
 			Tags:              []string{},
 		},
 	}, nil
@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
+
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
 )
 
 func TestParseToleration(t *testing.T) {
@@ -100,3 +102,266 @@ func TestParseToleration(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateSetting(t *testing.T) {
+	type testCase struct {
+		name  SettingName
+		value string
+
+		expectError bool
+	}
+	testCases := map[string]testCase{
+		"valid boolean": {
+			name:        SettingNameAutoSalvage,
+			value:       "true",
+			expectError: false,
+		},
+		"invalid boolean": {
+			name:        SettingNameAutoSalvage,
+			value:       "ture",
+			expectError: true,
+		},
+		"valid integer": {
+			name:        SettingNameBackupstorePollInterval,
+			value:       "300",
+			expectError: false,
+		},
+		"invalid integer": {
+			name:        SettingNameBackupstorePollInterval,
+			value:       "5m",
+			expectError: true,
+		},
+		"engine replica timeout within range": {
+			name:        SettingNameEngineReplicaTimeout,
+			value:       "30",
+			expectError: false,
+		},
+		"engine replica timeout below range": {
+			name:        SettingNameEngineReplicaTimeout,
+			value:       "7",
+			expectError: true,
+		},
+		"engine replica timeout above range": {
+			name:        SettingNameEngineReplicaTimeout,
+			value:       "31",
+			expectError: true,
+		},
+		"valid choice": {
+			name:        SettingNameNodeDrainPolicy,
+			value:       string(NodeDrainPolicyAlwaysAllow),
+			expectError: false,
+		},
+		"invalid choice": {
+			name:        SettingNameNodeDrainPolicy,
+			value:       "never-allow",
+			expectError: true,
+		},
+		"empty required setting": {
+			name:        SettingNameDefaultReplicaCount,
+			value:       "",
+			expectError: true,
+		},
+		"default replica count within range": {
+			name:        SettingNameDefaultReplicaCount,
+			value:       "2",
+			expectError: false,
+		},
+		"default replica count below range": {
+			name:        SettingNameDefaultReplicaCount,
+			value:       "0",
+			expectError: true,
+		},
+		"default replica count above range": {
+			name:        SettingNameDefaultReplicaCount,
+			value:       "21",
+			expectError: true,
+		},
+		"valid duration": {
+			name:        SettingNameUpgradeCheckInterval,
+			value:       "30m",
+			expectError: false,
+		},
+		"invalid duration": {
+			name:        SettingNameUpgradeCheckInterval,
+			value:       "30",
+			expectError: true,
+		},
+		"negative duration": {
+			name:        SettingNameUpgradeCheckInterval,
+			value:       "-1h",
+			expectError: true,
+		},
+		"disabled auto detach timeout": {
+			name:        SettingNameAutoDetachTimeout,
+			value:       "0",
+			expectError: false,
+		},
+		"invalid auto detach timeout": {
+			name:        SettingNameAutoDetachTimeout,
+			value:       "5",
+			expectError: true,
+		},
+		"valid default disk configuration": {
+			name:        SettingNameDefaultDiskConfiguration,
+			value:       `[{"path":"/mnt/disk1","allowScheduling":true},{"path":"/mnt/disk2","storageReserved":1024,"tags":["ssd"]}]`,
+			expectError: false,
+		},
+		"empty default disk configuration": {
+			name:        SettingNameDefaultDiskConfiguration,
+			value:       "",
+			expectError: false,
+		},
+		"invalid default disk configuration": {
+			name:        SettingNameDefaultDiskConfiguration,
+			value:       `[{"path":"/mnt/disk1"},{"path":"/mnt/disk1"}]`,
+			expectError: true,
+		},
+		"valid storage reserved percentage": {
+			name:        SettingNameStorageReservedPercentageForDefaultDisk,
+			value:       "25",
+			expectError: false,
+		},
+		"invalid storage reserved percentage": {
+			name:        SettingNameStorageReservedPercentageForDefaultDisk,
+			value:       "101",
+			expectError: true,
+		},
+		"invalid scheduling node pressure request percentage": {
+			name:        SettingNameSchedulingNodePressureRequestPercentage,
+			value:       "101",
+			expectError: true,
+		},
+		"valid log level": {
+			name:        SettingNameLogLevel,
+			value:       "debug",
+			expectError: false,
+		},
+		"empty log level following the flags": {
+			name:        SettingNameLogLevel,
+			value:       "",
+			expectError: false,
+		},
+		"invalid log level": {
+			name:        SettingNameLogLevel,
+			value:       "verbose",
+			expectError: true,
+		},
+		"unsupported setting": {
+			name:        SettingName("unknown-setting"),
+			value:       "true",
+			expectError: true,
+		},
+	}
+
+	for name, test := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		err := ValidateSetting(string(test.name), test.value)
+		if test.expectError && err == nil {
+			t.Errorf("expected error for setting %v with value %v", test.name, test.value)
+		}
+		if !test.expectError && err != nil {
+			t.Errorf("unexpected error for setting %v with value %v: %v", test.name, test.value, err)
+		}
+	}
+}
+
+func TestCheckEngineImageLiveUpgradeCompatibility(t *testing.T) {
+	newEngineImage := func(controllerAPIVersion, controllerAPIMinVersion int) *longhorn.EngineImage {
+		return &longhorn.EngineImage{
+			Status: longhorn.EngineImageStatus{
+				EngineVersionDetails: longhorn.EngineVersionDetails{
+					ControllerAPIVersion:    controllerAPIVersion,
+					ControllerAPIMinVersion: controllerAPIMinVersion,
+				},
+			},
+		}
+	}
+
+	type testCase struct {
+		oldImage *longhorn.EngineImage
+		newImage *longhorn.EngineImage
+
+		expectError bool
+	}
+	testCases := map[string]testCase{
+		"same controller API version": {
+			oldImage:    newEngineImage(4, 3),
+			newImage:    newEngineImage(4, 3),
+			expectError: false,
+		},
+		"newer controller API version": {
+			oldImage:    newEngineImage(3, 3),
+			newImage:    newEngineImage(4, 3),
+			expectError: false,
+		},
+		"older controller API version": {
+			oldImage:    newEngineImage(4, 3),
+			newImage:    newEngineImage(3, 3),
+			expectError: true,
+		},
+		"controller API version below the minimal version": {
+			oldImage:    newEngineImage(2, 2),
+			newImage:    newEngineImage(4, 3),
+			expectError: true,
+		},
+	}
+
+	for name, test := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		err := CheckEngineImageLiveUpgradeCompatibility(test.oldImage, test.newImage)
+		if test.expectError && err == nil {
+			t.Errorf("expected error for test case %v", name)
+		}
+		if !test.expectError && err != nil {
+			t.Errorf("unexpected error for test case %v: %v", name, err)
+		}
+	}
+}
+
+func TestValidateBackupRetention(t *testing.T) {
+	type testCase struct {
+		retention longhorn.VolumeBackupRetention
+
+		expectError bool
+	}
+	testCases := map[string]testCase{
+		"no retention": {
+			retention:   longhorn.VolumeBackupRetention{},
+			expectError: false,
+		},
+		"valid count and max age": {
+			retention:   longhorn.VolumeBackupRetention{Count: 5, MaxAge: "720h"},
+			expectError: false,
+		},
+		"negative count": {
+			retention:   longhorn.VolumeBackupRetention{Count: -1},
+			expectError: true,
+		},
+		"invalid max age": {
+			retention:   longhorn.VolumeBackupRetention{MaxAge: "30d"},
+			expectError: true,
+		},
+		"zero max age": {
+			retention:   longhorn.VolumeBackupRetention{MaxAge: "0s"},
+			expectError: true,
+		},
+		"negative max age": {
+			retention:   longhorn.VolumeBackupRetention{MaxAge: "-1h"},
+			expectError: true,
+		},
+	}
+
+	for name, test := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		err := ValidateBackupRetention(test.retention)
+		if test.expectError && err == nil {
+			t.Errorf("expected error for test case %v", name)
+		}
+		if !test.expectError && err != nil {
+			t.Errorf("unexpected error for test case %v: %v", name, err)
+		}
+	}
+}
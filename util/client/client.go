@@ -56,7 +56,10 @@ func NewClient(ctx context.Context, config *rest.Config, namespace string, needD
 		kubeInformerFactory := informers.NewSharedInformerFactory(kubeClient, time.Second*30)
 		lhInformerFactory := lhinformers.NewSharedInformerFactory(lhClient, time.Second*30)
 
-		ds = datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, namespace)
+		ds, err = datastore.NewDataStore(lhInformerFactory, lhClient, kubeInformerFactory, kubeClient, extensionsClient, namespace)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to create datastore")
+		}
 
 		go kubeInformerFactory.Start(ctx.Done())
 		go lhInformerFactory.Start(ctx.Done())
@@ -25,6 +25,7 @@ import (
 	"sync"
 	"syscall"
 	"time"
+	"unsafe"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/handlers"
@@ -58,7 +59,13 @@ const (
 
 	DefaultKubernetesTolerationKey = "kubernetes.io"
 
-	DiskConfigFile = "longhorn-disk.cfg"
+	DiskConfigFile    = "longhorn-disk.cfg"
+	DiskBenchmarkFile = "longhorn-disk-benchmark.tmp"
+
+	diskBenchmarkSequentialBlockSize  = 1024 * 1024
+	diskBenchmarkSequentialBlockCount = 64
+	diskBenchmarkSmallBlockSize       = 4 * 1024
+	diskBenchmarkSmallBlockCount      = 256
 
 	SizeAlignment     = 2 * 1024 * 1024
 	MinimalVolumeSize = 10 * 1024 * 1024
@@ -93,6 +100,12 @@ type DiskStat struct {
 	StorageAvailable int64
 }
 
+type DiskBenchmark struct {
+	SequentialThroughput int64
+	SyncWriteIOPS        int64
+	RandomWriteIOPS      int64
+}
+
 func ConvertSize(size interface{}) (int64, error) {
 	switch size := size.(type) {
 	case int64:
@@ -635,6 +648,22 @@ func CreateDiskPathReplicaSubdirectory(path string) error {
 	return nil
 }
 
+// IsHostPathExisting returns true if the path exists on the host. The check
+// prints the result instead of exiting with it, so that a failure to execute
+// in the host namespace isn't mistaken for a missing path.
+func IsHostPathExisting(path string) (bool, error) {
+	nsPath := iscsi_util.GetHostNamespacePath(HostProcPath)
+	nsExec, err := iscsi_util.NewNamespaceExecutor(nsPath)
+	if err != nil {
+		return false, err
+	}
+	output, err := nsExec.Execute("bash", []string{"-c", `if [ -e "$1" ]; then echo true; else echo false; fi`, "bash", path})
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to check path %v on host", path)
+	}
+	return strings.TrimSpace(output) == "true", nil
+}
+
 func DeleteDiskPathReplicaSubdirectoryAndDiskCfgFile(
 	nsExec *iscsi_util.NamespaceExecutor, path string) error {
 
@@ -790,6 +819,90 @@ func GenerateDiskConfig(path string) (*DiskConfig, error) {
 	return cfg, nil
 }
 
+// BenchmarkDisk runs a short IO benchmark on the disk of the path on the host.
+// The sequential throughput is measured by writing 1 MiB blocks with direct IO,
+// the random write IOPS by writing 4 KiB blocks at random offsets of the
+// written file with direct synchronous IO, and the synchronous write IOPS by
+// sequentially writing 4 KiB blocks with direct synchronous IO, where every
+// write waits for the device.
+func BenchmarkDisk(path string) (benchmark *DiskBenchmark, err error) {
+	defer func() {
+		err = errors.Wrapf(err, "cannot benchmark disk %v", path)
+	}()
+
+	nsPath := iscsi_util.GetHostNamespacePath(HostProcPath)
+	nsExec, err := iscsi_util.NewNamespaceExecutor(nsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	filePath := filepath.Join(path, DiskBenchmarkFile)
+	defer func() {
+		if _, rmErr := nsExec.Execute("rm", []string{"-f", filePath}); rmErr != nil {
+			logrus.WithError(rmErr).Warnf("Failed to clean up disk benchmark file %v", filePath)
+		}
+	}()
+
+	sequentialElapsed, err := timeDiskWrite(nsExec, filePath, diskBenchmarkSequentialBlockSize, diskBenchmarkSequentialBlockCount, "direct")
+	if err != nil {
+		return nil, err
+	}
+	// The random writes go to the file written by the sequential writes,
+	// which is truncated by the following synchronous writes
+	randomElapsed, err := timeDiskRandomWrite(filePath, diskBenchmarkSequentialBlockSize*diskBenchmarkSequentialBlockCount, diskBenchmarkSmallBlockSize, diskBenchmarkSmallBlockCount)
+	if err != nil {
+		return nil, err
+	}
+	smallBlockElapsed, err := timeDiskWrite(nsExec, filePath, diskBenchmarkSmallBlockSize, diskBenchmarkSmallBlockCount, "direct,dsync")
+	if err != nil {
+		return nil, err
+	}
+
+	return &DiskBenchmark{
+		SequentialThroughput: int64(float64(diskBenchmarkSequentialBlockSize*diskBenchmarkSequentialBlockCount) / sequentialElapsed.Seconds()),
+		SyncWriteIOPS:        int64(float64(diskBenchmarkSmallBlockCount) / smallBlockElapsed.Seconds()),
+		RandomWriteIOPS:      int64(float64(diskBenchmarkSmallBlockCount) / randomElapsed.Seconds()),
+	}, nil
+}
+
+func timeDiskWrite(nsExec *iscsi_util.NamespaceExecutor, filePath string, blockSize, blockCount int, outputFlags string) (time.Duration, error) {
+	startedAt := time.Now()
+	if _, err := nsExec.Execute("dd", []string{"if=/dev/zero", "of=" + filePath,
+		fmt.Sprintf("bs=%d", blockSize), fmt.Sprintf("count=%d", blockCount), "oflag=" + outputFlags, "conv=fsync"}); err != nil {
+		return 0, errors.Wrapf(err, "failed to write %v blocks of %v bytes to %v", blockCount, blockSize, filePath)
+	}
+	return time.Since(startedAt), nil
+}
+
+// timeDiskRandomWrite writes the blocks at random block aligned offsets of the
+// existing file with direct synchronous IO. The file is opened through the
+// root of the host init process rather than written by a command in the host
+// namespace, since starting a command per write would be measured instead of
+// the disk.
+func timeDiskRandomWrite(filePath string, fileSize, blockSize, blockCount int) (time.Duration, error) {
+	hostFilePath := filepath.Join(HostProcPath, "1", "root", filePath)
+	f, err := os.OpenFile(hostFilePath, os.O_WRONLY|syscall.O_DIRECT|syscall.O_DSYNC, 0)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to open %v for random writes", filePath)
+	}
+	defer f.Close()
+
+	// The buffer of direct IO has to be aligned to the block size
+	raw := make([]byte, 2*blockSize)
+	start := (blockSize - int(uintptr(unsafe.Pointer(&raw[0]))%uintptr(blockSize))) % blockSize
+	block := raw[start : start+blockSize]
+
+	blocks := fileSize / blockSize
+	startedAt := time.Now()
+	for i := 0; i < blockCount; i++ {
+		offset := int64(rand.Intn(blocks)) * int64(blockSize)
+		if _, err := f.WriteAt(block, offset); err != nil {
+			return 0, errors.Wrapf(err, "failed to write a block of %v bytes to %v at offset %v", blockSize, filePath, offset)
+		}
+	}
+	return time.Since(startedAt), nil
+}
+
 func MinInt(a, b int) int {
 	if a <= b {
 		return a
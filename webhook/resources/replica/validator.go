@@ -0,0 +1,71 @@
+package replica
+
+import (
+	"fmt"
+
+	admissionregv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/longhorn/longhorn-manager/datastore"
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
+	"github.com/longhorn/longhorn-manager/types"
+	"github.com/longhorn/longhorn-manager/webhook/admission"
+	werror "github.com/longhorn/longhorn-manager/webhook/error"
+)
+
+type replicaValidator struct {
+	admission.DefaultValidator
+	ds *datastore.DataStore
+}
+
+func NewValidator(ds *datastore.DataStore) admission.Validator {
+	return &replicaValidator{ds: ds}
+}
+
+func (r *replicaValidator) Resource() admission.Resource {
+	return admission.Resource{
+		Name:       "replicas",
+		Scope:      admissionregv1.NamespacedScope,
+		APIGroup:   longhorn.SchemeGroupVersion.Group,
+		APIVersion: longhorn.SchemeGroupVersion.Version,
+		ObjectType: &longhorn.Replica{},
+		OperationTypes: []admissionregv1.OperationType{
+			admissionregv1.Delete,
+		},
+	}
+}
+
+// Delete rejects the deletion of the only healthy replica of an attached
+// volume unless the replica is annotated with longhorn.io/force-deletion.
+func (r *replicaValidator) Delete(request *admission.Request, oldObj runtime.Object) error {
+	replica := oldObj.(*longhorn.Replica)
+
+	if _, ok := replica.Annotations[types.GetLonghornLabelKey(types.ReplicaForceDeletionAnnotationKeySuffix)]; ok {
+		return nil
+	}
+	if !datastore.IsAvailableHealthyReplica(replica) {
+		return nil
+	}
+
+	volume, err := r.ds.GetVolumeRO(replica.Spec.VolumeName)
+	if err != nil {
+		if datastore.ErrorIsNotFound(err) {
+			return nil
+		}
+		return werror.NewInvalidError(fmt.Sprintf("cannot delete replica %v since failed to get volume %v: %v", replica.Name, replica.Spec.VolumeName, err), "")
+	}
+	if volume.DeletionTimestamp != nil || volume.Status.State != longhorn.VolumeStateAttached {
+		return nil
+	}
+
+	healthyReplica, err := r.ds.GetOtherAvailableHealthyReplicaName(volume.Name, replica.Name)
+	if err != nil {
+		return werror.NewInvalidError(fmt.Sprintf("cannot delete replica %v since failed to list replicas of volume %v: %v", replica.Name, volume.Name, err), "")
+	}
+	if healthyReplica == "" {
+		return werror.NewInvalidError(fmt.Sprintf("cannot delete replica %v since it is the only healthy replica of attached volume %v, please add annotation %v to force the deletion",
+			replica.Name, volume.Name, types.GetLonghornLabelKey(types.ReplicaForceDeletionAnnotationKeySuffix)), "")
+	}
+
+	return nil
+}
@@ -66,6 +66,10 @@ func (v *volumeMutator) Create(request *admission.Request, newObj runtime.Object
 		patchOps = append(patchOps, `{"op": "replace", "path": "/spec/nodeSelector", "value": []}`)
 	}
 
+	if volume.Spec.ReplicaPlacementNodes == nil {
+		patchOps = append(patchOps, `{"op": "replace", "path": "/spec/replicaPlacementNodes", "value": []}`)
+	}
+
 	if volume.Spec.RecurringJobs == nil {
 		patchOps = append(patchOps, `{"op": "replace", "path": "/spec/recurringJobs", "value": []}`)
 	}
@@ -89,6 +93,14 @@ func (v *volumeMutator) Create(request *admission.Request, newObj runtime.Object
 		patchOps = append(patchOps, fmt.Sprintf(`{"op": "replace", "path": "/spec/numberOfReplicas", "value": %v}`, numberOfReplicas))
 	}
 
+	if volume.Spec.StaleReplicaTimeout == 0 {
+		patchOps = append(patchOps, fmt.Sprintf(`{"op": "replace", "path": "/spec/staleReplicaTimeout", "value": %s}`, types.DefaultStaleReplicaTimeout))
+	}
+
+	if volume.Spec.Frontend == "" && !volume.Spec.Standby {
+		patchOps = append(patchOps, fmt.Sprintf(`{"op": "replace", "path": "/spec/frontend", "value": "%s"}`, longhorn.VolumeFrontendBlockDev))
+	}
+
 	if string(volume.Spec.DataLocality) == "" {
 		defaultDataLocality, err := v.ds.GetSettingValueExisted(types.SettingNameDefaultDataLocality)
 		if err != nil {
@@ -235,6 +247,9 @@ func (v *volumeMutator) Update(request *admission.Request, oldObj runtime.Object
 	if volume.Spec.NodeSelector == nil {
 		patchOps = append(patchOps, `{"op": "replace", "path": "/spec/nodeSelector", "value": []}`)
 	}
+	if volume.Spec.ReplicaPlacementNodes == nil {
+		patchOps = append(patchOps, `{"op": "replace", "path": "/spec/replicaPlacementNodes", "value": []}`)
+	}
 	if volume.Spec.RecurringJobs == nil {
 		patchOps = append(patchOps, `{"op": "replace", "path": "/spec/recurringJobs", "value": []}`)
 	}
@@ -287,5 +302,8 @@ func (v *volumeMutator) getDefaultReplicaCount() (int, error) {
 	if err != nil {
 		return 0, err
 	}
+	if err := types.ValidateReplicaCount(int(c)); err != nil {
+		return 0, errors.Wrapf(err, "invalid value %v for setting %v", c, types.SettingNameDefaultReplicaCount)
+	}
 	return int(c), nil
 }
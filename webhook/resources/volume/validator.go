@@ -70,10 +70,26 @@ func (v *volumeValidator) Create(request *admission.Request, newObj runtime.Obje
 		return werror.NewInvalidError(err.Error(), "")
 	}
 
+	if err := types.ValidateReplicaPlacementPolicy(volume.Spec.ReplicaPlacementPolicy, volume.Spec.ReplicaPlacementNodes); err != nil {
+		return werror.NewInvalidError(err.Error(), "")
+	}
+
 	if err := types.ValidateUnmapMarkSnapChainRemoved(volume.Spec.UnmapMarkSnapChainRemoved); err != nil {
 		return werror.NewInvalidError(err.Error(), "")
 	}
 
+	if err := types.ValidateSnapshotMaxCount(volume.Spec.SnapshotMaxCount); err != nil {
+		return werror.NewInvalidError(err.Error(), "")
+	}
+
+	if err := types.ValidateBackupRetention(volume.Spec.BackupRetention); err != nil {
+		return werror.NewInvalidError(err.Error(), "")
+	}
+
+	if err := types.ValidateStaleReplicaTimeout(volume.Spec.StaleReplicaTimeout); err != nil {
+		return werror.NewInvalidError(err.Error(), "")
+	}
+
 	if volume.Spec.BackingImage != "" {
 		if _, err := v.ds.GetBackingImage(volume.Spec.BackingImage); err != nil {
 			return werror.NewInvalidError(err.Error(), "")
@@ -84,6 +100,10 @@ func (v *volumeValidator) Create(request *admission.Request, newObj runtime.Obje
 		return werror.NewInvalidError("BUG: Invalid empty Setting.EngineImage", "")
 	}
 
+	if err := v.validateEngineImage(volume.Spec.EngineImage); err != nil {
+		return err
+	}
+
 	if !volume.Spec.Standby {
 		if volume.Spec.Frontend != longhorn.VolumeFrontendBlockDev && volume.Spec.Frontend != longhorn.VolumeFrontendISCSI {
 			return werror.NewInvalidError(fmt.Sprintf("invalid volume frontend specified: %v", volume.Spec.Frontend), "")
@@ -133,10 +153,34 @@ func (v *volumeValidator) Update(request *admission.Request, oldObj runtime.Obje
 		return werror.NewInvalidError(err.Error(), "")
 	}
 
+	if err := types.ValidateReplicaPlacementPolicy(newVolume.Spec.ReplicaPlacementPolicy, newVolume.Spec.ReplicaPlacementNodes); err != nil {
+		return werror.NewInvalidError(err.Error(), "")
+	}
+
 	if err := types.ValidateUnmapMarkSnapChainRemoved(newVolume.Spec.UnmapMarkSnapChainRemoved); err != nil {
 		return werror.NewInvalidError(err.Error(), "")
 	}
 
+	if err := types.ValidateSnapshotMaxCount(newVolume.Spec.SnapshotMaxCount); err != nil {
+		return werror.NewInvalidError(err.Error(), "")
+	}
+
+	if err := types.ValidateBackupRetention(newVolume.Spec.BackupRetention); err != nil {
+		return werror.NewInvalidError(err.Error(), "")
+	}
+
+	if oldVolume.Spec.StaleReplicaTimeout != newVolume.Spec.StaleReplicaTimeout {
+		if err := types.ValidateStaleReplicaTimeout(newVolume.Spec.StaleReplicaTimeout); err != nil {
+			return werror.NewInvalidError(err.Error(), "")
+		}
+	}
+
+	if oldVolume.Spec.EngineImage != newVolume.Spec.EngineImage {
+		if err := v.validateEngineImage(newVolume.Spec.EngineImage); err != nil {
+			return err
+		}
+	}
+
 	if newVolume.Spec.DataLocality == longhorn.DataLocalityStrictLocal {
 		// Check if the strict-local volume can attach to newVolume.Spec.NodeID
 		if oldVolume.Spec.NodeID != newVolume.Spec.NodeID && newVolume.Spec.NodeID != "" {
@@ -150,7 +194,7 @@ func (v *volumeValidator) Update(request *admission.Request, oldObj runtime.Obje
 	}
 
 	if err := datastore.CheckVolume(newVolume); err != nil {
-		return err
+		return werror.NewInvalidError(err.Error(), "")
 	}
 
 	return nil
@@ -200,6 +244,18 @@ func (v *volumeValidator) validateExpansionSize(oldVolume *longhorn.Volume, newV
 	return nil
 }
 
+// validateEngineImage rejects the volume if the engine image is not deployed.
+// Other failures of getting the engine image are internal errors.
+func (v *volumeValidator) validateEngineImage(image string) error {
+	if _, err := v.ds.GetEngineImage(types.GetEngineImageChecksumName(image)); err != nil {
+		if datastore.ErrorIsNotFound(err) {
+			return werror.NewInvalidError(fmt.Sprintf("engine image %v is not deployed", image), "")
+		}
+		return werror.NewInternalError(errors.Wrapf(err, "failed to get engine image %v", image).Error())
+	}
+	return nil
+}
+
 func (v *volumeValidator) hasLocalReplicaOnSameNodeAsStrictLocalVolume(volume *longhorn.Volume) (bool, error) {
 	replicas, err := v.ds.ListVolumeReplicas(volume.Name)
 	if err != nil {